@@ -14,6 +14,7 @@ const (
 	ChallengeTypeHttp01       ChallengeType = "http-01"
 	ChallengeTypeDns01        ChallengeType = "dns-01"
 	ChallengeTypeDnsPersist01 ChallengeType = "dns-persist-01"
+	ChallengeTypeTlsAlpn01    ChallengeType = "tls-alpn-01" // RFC 8737
 )
 
 // ACME challenge object
@@ -12,6 +12,14 @@ var (
 	ErrChallengeTypeDoesntSupportCNAME = errors.New("challenge type doesnt support CNAME use")
 )
 
+// ChallengeResource is the information a challenge provider needs to provision (or
+// deprovision) the resource for a single challenge
+type ChallengeResource struct {
+	Domain  string
+	Token   string
+	KeyAuth KeyAuth
+}
+
 // ValidationResourceDns01 returns the dnsRecord name and value to provision
 // in response to a Dns01 challenge for a given domain and keyAuth
 func ValidationResourceDns01(domain string, keyAuth KeyAuth) (dnsRecordName string, dnsRecordValue string) {
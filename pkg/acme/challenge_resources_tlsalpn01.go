@@ -0,0 +1,90 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net"
+	"time"
+)
+
+// ALPNProtocolTlsAlpn01 is the ALPN protocol name that must be negotiated when responding
+// to a tls-alpn-01 challenge (RFC 8737 s 6.2)
+const ALPNProtocolTlsAlpn01 = "acme-tls/1"
+
+// OIDAcmeIdentifier is the id-pe-acmeIdentifier certificate extension (RFC 8737 s 6.1)
+var OIDAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// tlsAlpn01CertValidity is how long validation certificates are valid for
+const tlsAlpn01CertValidity = 7 * 24 * time.Hour
+
+// ValidationResourceTlsAlpn01 returns the self-signed certificate to serve in response
+// to a TlsAlpn01 challenge for a given domain (or ip identifier value) and keyAuth. The
+// certificate contains the domain (or ip) as its only SAN and the critical acmeIdentifier
+// extension, which contains the sha256 digest of keyAuth (RFC 8737 s 3, RFC 8738 s 6).
+func ValidationResourceTlsAlpn01(domain string, keyAuth KeyAuth) (*tls.Certificate, error) {
+	// extension value is the DER encoded OCTET STRING of the key authorization digest
+	keyAuthDigest := sha256.Sum256([]byte(keyAuth))
+	extValue, err := asn1.Marshal(keyAuthDigest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// key for the certificate (not important, it is discarded after validation)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Cert Warden tls-alpn-01 challenge"},
+		NotBefore:             now.Add(-1 * time.Hour),
+		NotAfter:              now.Add(tlsAlpn01CertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{
+				Id:       OIDAcmeIdentifier,
+				Critical: true,
+				Value:    extValue,
+			},
+		},
+	}
+
+	// SAN
+	if ip := net.ParseIP(domain); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{domain}
+	}
+
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(certDer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{certDer},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
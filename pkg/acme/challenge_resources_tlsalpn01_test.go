@@ -0,0 +1,51 @@
+package acme_test
+
+import (
+	"bytes"
+	"certwarden-backend/pkg/acme"
+	"crypto/sha256"
+	"encoding/asn1"
+	"testing"
+)
+
+// TestValidationResourceTlsAlpn01 confirms the validation certificate meets the requirements
+// of RFC 8737 s 3
+func TestValidationResourceTlsAlpn01(t *testing.T) {
+	domain := "www.example.com"
+	keyAuth := acme.KeyAuth("evaGxfADs6pSRb2LAv9IZf17Dt3juxGJ-PCt92wr-oA.9jg46WB3rR_AHD-EBXdN7cBkH1WOu0tA3M9fm21mqTI")
+
+	cert, err := acme.ValidationResourceTlsAlpn01(domain, keyAuth)
+	if err != nil {
+		t.Fatalf("failed to make validation certificate (%s)", err)
+	}
+
+	leaf := cert.Leaf
+	if len(leaf.DNSNames) != 1 || leaf.DNSNames[0] != domain {
+		t.Errorf("expected single SAN %s but got %v", domain, leaf.DNSNames)
+	}
+
+	expectedDigest := sha256.Sum256([]byte(keyAuth))
+	found := false
+	for _, ext := range leaf.Extensions {
+		if !ext.Id.Equal(acme.OIDAcmeIdentifier) {
+			continue
+		}
+		found = true
+
+		if !ext.Critical {
+			t.Error("acmeIdentifier extension must be critical")
+		}
+
+		var digest []byte
+		rest, err := asn1.Unmarshal(ext.Value, &digest)
+		if err != nil || len(rest) != 0 {
+			t.Fatalf("acmeIdentifier extension value is not a single octet string (%v)", err)
+		}
+		if !bytes.Equal(digest, expectedDigest[:]) {
+			t.Errorf("acmeIdentifier extension value %x does not match key auth digest %x", digest, expectedDigest)
+		}
+	}
+	if !found {
+		t.Error("acmeIdentifier extension missing from validation certificate")
+	}
+}
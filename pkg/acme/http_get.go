@@ -7,7 +7,9 @@ import (
 )
 
 // get does an unauthenticated GET request to an ACME endpoint
-func (service *Service) get(url string) (bodyBytes []byte, _ http.Header, _ error) {
+func (service *Service) get(url string) (bodyBytes []byte, _ http.Header, err error) {
+	defer func() { service.metrics.RecordAcmeRequest(service.dirUri, http.MethodGet, err) }()
+
 	// do GET
 	resp, err := service.httpClient.Get(url)
 	if err != nil {
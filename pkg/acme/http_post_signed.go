@@ -13,6 +13,8 @@ import (
 // postToUrlSigned posts the payload to the specified url, using the specified AccountKeyInfo
 // and returns the response body (data / bytes) and headers from ACME
 func (service *Service) postToUrlSigned(payload any, url string, accountKey AccountKey) (bodyBytes []byte, headers http.Header, err error) {
+	defer func() { service.metrics.RecordAcmeRequest(service.dirUri, http.MethodPost, err) }()
+
 	nonce, err := service.nonceManager.Nonce()
 	if err != nil {
 		return nil, nil, err
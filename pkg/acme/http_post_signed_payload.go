@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/asn1"
 	"encoding/json"
 	"errors"
+	"math/big"
 	"strings"
 )
 
@@ -118,6 +121,10 @@ func (asm *acmeSignedMessage) setNonceAndSign(nonce string, accountKey AccountKe
 			hashed384 := sha512.Sum384(dataToSign)
 			hashed = hashed384[:]
 
+		case 521:
+			hashed512 := sha512.Sum512(dataToSign)
+			hashed = hashed512[:]
+
 		default:
 			return errors.New("acme: failed to sign (unsupported ec bit size)")
 		}
@@ -135,6 +142,18 @@ func (asm *acmeSignedMessage) setNonceAndSign(nonce string, accountKey AccountKe
 		// combine the buffers and encode
 		asm.Signature = encodeString(append(rPadded, sPadded...))
 
+	case ed25519.PrivateKey:
+		// EdDSA signs the message itself (no pre-hash)
+		asm.Signature = encodeString(ed25519.Sign(key, dataToSign))
+
+	case crypto.Signer:
+		// other signers (e.g. keys that reside on a hardware token)
+		signature, err := signWithSigner(key, dataToSign)
+		if err != nil {
+			return err
+		}
+		asm.Signature = encodeString(signature)
+
 	case []byte:
 		// mac signature (for EAB)
 		mac := hmac.New(sha256.New, key)
@@ -189,10 +208,23 @@ func makeAcmeSignedMessage(payload any, nonce string, url string, accountKey Acc
 			msg.ProtectedHeader.Algorithm = "ES256"
 		case "P-384":
 			msg.ProtectedHeader.Algorithm = "ES384"
+		case "P-521":
+			msg.ProtectedHeader.Algorithm = "ES512"
 		default:
 			return nil, errors.New("acme: signature algorithm: unsupported ecdsa curve")
 		}
 
+	case ed25519.PrivateKey:
+		// rfc 8037 s3.1
+		msg.ProtectedHeader.Algorithm = "EdDSA"
+
+	case crypto.Signer:
+		// other signers (e.g. keys that reside on a hardware token)
+		msg.ProtectedHeader.Algorithm, err = signerAlgorithm(privateKey)
+		if err != nil {
+			return nil, err
+		}
+
 	case []byte:
 		// Assume []byte is an External Account Binding key, which always uses HS256
 		msg.ProtectedHeader.Algorithm = "HS256"
@@ -231,6 +263,79 @@ func makeAcmeSignedMessage(payload any, nonce string, url string, accountKey Acc
 	return msg, nil
 }
 
+// signerAlgorithm returns the JWS alg for a generic crypto.Signer, based on its public key
+func signerAlgorithm(signer crypto.Signer) (string, error) {
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		return "RS256", nil
+
+	case *ecdsa.PublicKey:
+		switch pub.Curve.Params().Name {
+		case "P-256":
+			return "ES256", nil
+		case "P-384":
+			return "ES384", nil
+		case "P-521":
+			return "ES512", nil
+		}
+		return "", errors.New("acme: signature algorithm: unsupported ecdsa curve")
+
+	case ed25519.PublicKey:
+		return "EdDSA", nil
+	}
+
+	return "", errors.New("acme: signature algorithm: unsupported signer public key type")
+}
+
+// signWithSigner signs dataToSign with a generic crypto.Signer and returns the signature
+// in the format ACME expects
+func signWithSigner(signer crypto.Signer, dataToSign []byte) ([]byte, error) {
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		// all rsa use RS256
+		hashed := sha256.Sum256(dataToSign)
+		return signer.Sign(rand.Reader, hashed[:], crypto.SHA256)
+
+	case *ecdsa.PublicKey:
+		var hash crypto.Hash
+		bitSize := pub.Params().BitSize
+		switch bitSize {
+		case 256:
+			hash = crypto.SHA256
+		case 384:
+			hash = crypto.SHA384
+		case 521:
+			hash = crypto.SHA512
+		default:
+			return nil, errors.New("acme: failed to sign (unsupported ec bit size)")
+		}
+
+		hasher := hash.New()
+		_, _ = hasher.Write(dataToSign)
+		derSignature, err := signer.Sign(rand.Reader, hasher.Sum(nil), hash)
+		if err != nil {
+			return nil, err
+		}
+
+		// crypto.Signer returns an asn.1 signature, ACME expects zero padded r || s
+		var rs struct {
+			R, S *big.Int
+		}
+		rest, err := asn1.Unmarshal(derSignature, &rs)
+		if err != nil || len(rest) > 0 {
+			return nil, errors.New("acme: failed to sign (malformed ecdsa signature)")
+		}
+
+		return append(padBytes(rs.R.Bytes(), bitSize), padBytes(rs.S.Bytes(), bitSize)...), nil
+
+	case ed25519.PublicKey:
+		// EdDSA signs the message itself (no pre-hash)
+		return signer.Sign(rand.Reader, dataToSign, crypto.Hash(0))
+	}
+
+	return nil, errors.New("acme: sign: unsupported signer public key type")
+}
+
 func (asm *acmeSignedMessage) SignedHTTPBody() *bytes.Buffer {
 	return bytes.NewBuffer(asm.marshalled)
 }
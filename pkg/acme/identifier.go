@@ -1,5 +1,11 @@
 package acme
 
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
 // Identifier is the ACME Identifier object
 type Identifier struct {
 	Type  IdentifierType `json:"type"`
@@ -13,8 +19,21 @@ const (
 	UnknownIdentifierType IdentifierType = ""
 
 	IdentifierTypeDns = "dns"
+	IdentifierTypeIp  = "ip" // RFC 8738
 )
 
+// NewIdentifier returns the Identifier for a certificate name. If the name is an IP address
+// it is an ip identifier (with the address in its canonical text form, per RFC 8738 s 3),
+// otherwise it is a dns identifier.
+func NewIdentifier(name string) Identifier {
+	addr, err := netip.ParseAddr(name)
+	if err == nil && addr.Zone() == "" {
+		return Identifier{Type: IdentifierTypeIp, Value: addr.Unmap().String()}
+	}
+
+	return Identifier{Type: IdentifierTypeDns, Value: name}
+}
+
 // IdentifierSlice is a slice of Identifier
 type IdentifierSlice []Identifier
 
@@ -30,3 +49,43 @@ func (ids *IdentifierSlice) DnsIdentifiers() []string {
 
 	return s
 }
+
+// IpIdentifiers returns a slice of the value strings of the ip Identifiers
+func (ids *IdentifierSlice) IpIdentifiers() []string {
+	var s []string
+
+	for _, id := range *ids {
+		if id.Type == IdentifierTypeIp {
+			s = append(s, id.Value)
+		}
+	}
+
+	return s
+}
+
+// ReverseDNSName returns the reverse mapping domain name of an ip identifier value (e.g.
+// 4.3.2.1.in-addr.arpa) which is used as the tls-alpn-01 SNI value for ip identifiers
+// (RFC 8738 s 6). If value is not an ip address, it is returned unchanged.
+func ReverseDNSName(value string) string {
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return value
+	}
+	addr = addr.Unmap()
+
+	labels := []string{}
+	if addr.Is4() {
+		b := addr.As4()
+		for i := len(b) - 1; i >= 0; i-- {
+			labels = append(labels, strconv.Itoa(int(b[i])))
+		}
+		return strings.Join(labels, ".") + ".in-addr.arpa"
+	}
+
+	const hexDigits = "0123456789abcdef"
+	b := addr.As16()
+	for i := len(b) - 1; i >= 0; i-- {
+		labels = append(labels, string(hexDigits[b[i]&0x0f]), string(hexDigits[b[i]>>4]))
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa"
+}
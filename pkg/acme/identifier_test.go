@@ -0,0 +1,44 @@
+package acme_test
+
+import (
+	"certwarden-backend/pkg/acme"
+	"testing"
+)
+
+// TestNewIdentifier confirms names are mapped to the correct identifier type and value
+func TestNewIdentifier(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantType  acme.IdentifierType
+		wantValue string
+	}{
+		{"www.example.com", acme.IdentifierTypeDns, "www.example.com"},
+		{"*.example.com", acme.IdentifierTypeDns, "*.example.com"},
+		{"192.0.2.1", acme.IdentifierTypeIp, "192.0.2.1"},
+		{"2001:DB8:0:0::1", acme.IdentifierTypeIp, "2001:db8::1"},
+		{"::ffff:192.0.2.1", acme.IdentifierTypeIp, "192.0.2.1"},
+	}
+
+	for _, test := range tests {
+		id := acme.NewIdentifier(test.name)
+		if id.Type != test.wantType || id.Value != test.wantValue {
+			t.Errorf("name %s: expected %s/%s but got %s/%s", test.name, test.wantType, test.wantValue, id.Type, id.Value)
+		}
+	}
+}
+
+// TestReverseDNSName confirms ip identifiers map to their RFC 8738 s 6 reverse names
+func TestReverseDNSName(t *testing.T) {
+	tests := map[string]string{
+		"192.0.2.1":       "1.2.0.192.in-addr.arpa",
+		"2001:db8::1":     "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		"www.example.com": "www.example.com",
+	}
+
+	for value, want := range tests {
+		got := acme.ReverseDNSName(value)
+		if got != want {
+			t.Errorf("value %s: expected %s but got %s", value, want, got)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package acme
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/sha256"
 	"errors"
@@ -14,8 +16,8 @@ type jsonWebKey struct {
 	KeyType        string `json:"kty,omitempty"`
 	PublicExponent string `json:"e,omitempty"`   // RSA
 	Modulus        string `json:"n,omitempty"`   // RSA
-	CurveName      string `json:"crv,omitempty"` // EC
-	CurvePointX    string `json:"x,omitempty"`   // EC
+	CurveName      string `json:"crv,omitempty"` // EC, OKP
+	CurvePointX    string `json:"x,omitempty"`   // EC, OKP (public key)
 	CurvePointY    string `json:"y,omitempty"`   // EC
 }
 
@@ -47,6 +49,47 @@ func (accountKey *AccountKey) jwk() (jwk *jsonWebKey, err error) {
 
 		return jwk, nil
 
+	case ed25519.PrivateKey:
+		// rfc 8037 s2
+		jwk.KeyType = "OKP"
+		jwk.CurveName = "Ed25519"
+		jwk.CurvePointX = encodeString(privateKey.Public().(ed25519.PublicKey))
+
+		return jwk, nil
+
+	case crypto.Signer:
+		// other signers (e.g. keys that reside on a hardware token)
+		switch publicKey := privateKey.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+
+			jwk.PublicExponent, err = encodeInt(publicKey.E)
+			if err != nil {
+				return nil, err
+			}
+			jwk.Modulus = encodeBigInt(publicKey.N, publicKey.N.BitLen())
+
+			return jwk, nil
+
+		case *ecdsa.PublicKey:
+			jwk.KeyType = "EC"
+
+			jwk.CurveName = publicKey.Curve.Params().Name
+
+			keyBitSize := publicKey.Curve.Params().BitSize
+			jwk.CurvePointX = encodeBigInt(publicKey.X, keyBitSize)
+			jwk.CurvePointY = encodeBigInt(publicKey.Y, keyBitSize)
+
+			return jwk, nil
+
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.CurveName = "Ed25519"
+			jwk.CurvePointX = encodeString(publicKey)
+
+			return jwk, nil
+		}
+
 	default:
 		// break to final error return
 	}
@@ -78,6 +121,13 @@ func (jwk *jsonWebKey) encodedSHA256PublicThumbprint() (thumbprint string, err e
 		_, _ = buf.WriteString(`","y":"`)
 		_, _ = buf.WriteString(jwk.CurvePointY)
 		_, _ = buf.WriteString(`"}`)
+	case "OKP":
+		// rfc 8037 s2
+		_, _ = buf.WriteString(`{"crv":"`)
+		_, _ = buf.WriteString(jwk.CurveName)
+		_, _ = buf.WriteString(`","kty":"OKP","x":"`)
+		_, _ = buf.WriteString(jwk.CurvePointX)
+		_, _ = buf.WriteString(`"}`)
 	default:
 		return "", errors.New("acme: jwk thumbprint: unsupported private key type")
 	}
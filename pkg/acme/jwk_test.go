@@ -0,0 +1,76 @@
+package acme_test
+
+import (
+	"certwarden-backend/pkg/acme"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"testing"
+)
+
+// TestKeyAuthorizationEd25519 checks the Ed25519 JWK thumbprint against the example in
+// rfc 8037 appendix A.3
+func TestKeyAuthorizationEd25519(t *testing.T) {
+	seed, err := base64.RawURLEncoding.DecodeString("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accountKey := acme.AccountKey{
+		Key: ed25519.NewKeyFromSeed(seed),
+	}
+
+	keyAuth, err := accountKey.KeyAuthorization("token")
+	if err != nil {
+		t.Fatalf("failed to make key authorization (%s)", err)
+	}
+
+	expected := acme.KeyAuth("token.kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k")
+	if keyAuth != expected {
+		t.Errorf("key authorization is '%s' but expected '%s'", keyAuth, expected)
+	}
+}
+
+// opaqueSigner hides the concrete private key type, like a key that resides on a token
+type opaqueSigner struct {
+	crypto.Signer
+}
+
+// TestKeyAuthorizationSigner checks that a generic crypto.Signer produces the same JWK
+// thumbprint as the underlying private key
+func TestKeyAuthorizationSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []crypto.Signer{rsaKey, ecKey, edKey} {
+		accountKey := acme.AccountKey{Key: key}
+		expected, err := accountKey.KeyAuthorization("token")
+		if err != nil {
+			t.Fatalf("failed to make key authorization (%s)", err)
+		}
+
+		signerAccountKey := acme.AccountKey{Key: opaqueSigner{key}}
+		keyAuth, err := signerAccountKey.KeyAuthorization("token")
+		if err != nil {
+			t.Fatalf("failed to make signer key authorization (%s)", err)
+		}
+
+		if keyAuth != expected {
+			t.Errorf("signer key authorization is '%s' but expected '%s'", keyAuth, expected)
+		}
+	}
+}
@@ -108,8 +108,8 @@ func (service *Service) FinalizeOrder(finalizeUrl string, derCsr []byte, account
 	if service.logger.Level() == zapcore.DebugLevel {
 		csr, prettyErr := x509.ParseCertificateRequest(derCsr)
 		if prettyErr == nil {
-			// log CN and DNS names
-			service.logger.Debugf("attempting finalize using csr with common name: %s ; and dns name(s): %s", csr.Subject.CommonName, csr.DNSNames)
+			// log CN, DNS names, and IP addresses
+			service.logger.Debugf("attempting finalize using csr with common name: %s ; dns name(s): %s ; and ip address(es): %s", csr.Subject.CommonName, csr.DNSNames, csr.IPAddresses)
 
 			// Log full CSR
 			// prettyBytes, prettyErr := json.MarshalIndent(csr, "", "\t")
@@ -2,6 +2,7 @@ package acme
 
 import (
 	"certwarden-backend/pkg/acme/nonces"
+	"certwarden-backend/pkg/metrics"
 	"context"
 	"errors"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 // App interface is for connecting to the main app
 type App interface {
 	GetLogger() *zap.SugaredLogger
+	GetMetrics() *metrics.Service
 	GetHttpClient() *http.Client
 	GetShutdownContext() context.Context
 	GetShutdownWaitGroup() *sync.WaitGroup
@@ -21,6 +23,7 @@ type App interface {
 // Acme service struct
 type Service struct {
 	logger       *zap.SugaredLogger
+	metrics      *metrics.Service
 	httpClient   *http.Client
 	dirUri       string
 	dir          *directory
@@ -37,6 +40,9 @@ func NewService(app App, dirUri string) (*Service, error) {
 		return nil, errors.New("acme: newservice requires valid logger")
 	}
 
+	// metrics (may be nil)
+	service.metrics = app.GetMetrics()
+
 	// http client
 	service.httpClient = app.GetHttpClient()
 
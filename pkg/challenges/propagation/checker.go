@@ -0,0 +1,311 @@
+package propagation
+
+import (
+	"certwarden-backend/pkg/challenges/providers/dns01goacme"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+const (
+	// timeout for each individual dns query
+	queryTimeout = 5 * time.Second
+	// maximum length of a CNAME chain that will be followed
+	maxCNAMEHops = 10
+)
+
+// Checker checks whether a dns-01 TXT record is visible on the relevant nameservers
+type Checker struct {
+	logger             *zap.SugaredLogger
+	resolvers          []string
+	checkAuthoritative bool
+	checkResolvers     bool
+	interval           time.Duration
+	// port used to query authoritative nameservers
+	authPort string
+}
+
+// NewChecker creates a Checker from cfg
+func NewChecker(logger *zap.SugaredLogger, cfg Config) (*Checker, error) {
+	err := validateConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	checker := &Checker{
+		logger:             logger,
+		checkAuthoritative: *cfg.CheckAuthoritative,
+		checkResolvers:     *cfg.CheckResolvers,
+		interval:           time.Duration(*cfg.IntervalSeconds) * time.Second,
+		authPort:           defaultDNSPort,
+	}
+
+	// resolvers (system resolvers if none configured)
+	checker.resolvers, err = normalizeServers(cfg.Resolvers)
+	if err != nil {
+		return nil, err
+	}
+	if len(checker.resolvers) == 0 {
+		for _, addrPort := range dns01goacme.GetDNSServers() {
+			checker.resolvers = append(checker.resolvers, addrPort.String())
+		}
+	}
+
+	return checker, nil
+}
+
+// WaitFor polls until the TXT record fqdn (following any CNAMEs) with value is visible
+// on all of the nameservers being checked, or until ctx is done. If ctx is done first,
+// the returned error wraps ctx's error.
+func (c *Checker) WaitFor(ctx context.Context, fqdn string, value string) error {
+	for {
+		err := c.Check(ctx, fqdn, value)
+		if err == nil {
+			return nil
+		}
+		c.logger.Debugf("propagation: %s not yet propagated (%s), will check again in %s", fqdn, err, c.interval)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last check: %s)", ctx.Err(), err)
+		case <-time.After(c.interval):
+			// check again
+		}
+	}
+}
+
+// nameserver is a server to check for the record
+type nameserver struct {
+	address   string
+	recursive bool
+}
+
+// Check returns nil if the TXT record fqdn (following any CNAMEs) with value is
+// visible on all of the nameservers being checked, otherwise it returns an error
+// describing why the record is not (yet) visible.
+func (c *Checker) Check(ctx context.Context, fqdn string, value string) error {
+	name, err := c.followCNAMEs(ctx, dns.CanonicalName(fqdn))
+	if err != nil {
+		return err
+	}
+
+	servers := []nameserver{}
+	if c.checkResolvers {
+		for _, resolver := range c.resolvers {
+			servers = append(servers, nameserver{address: resolver, recursive: true})
+		}
+	}
+	if c.checkAuthoritative {
+		authServers, err := c.authoritativeServers(ctx, name)
+		if err != nil {
+			return err
+		}
+		for _, authServer := range authServers {
+			servers = append(servers, nameserver{address: authServer, recursive: false})
+		}
+	}
+
+	for _, server := range servers {
+		err = c.txtVisible(ctx, server, name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// followCNAMEs returns the final name after following the CNAME chain (if any) that
+// starts at name
+func (c *Checker) followCNAMEs(ctx context.Context, name string) (string, error) {
+	resp, err := c.resolve(ctx, name, dns.TypeTXT)
+	if err != nil {
+		return "", err
+	}
+
+	for range maxCNAMEHops {
+		next := ""
+		for _, rr := range resp.Answer {
+			if cname, ok := rr.(*dns.CNAME); ok && strings.EqualFold(cname.Hdr.Name, name) {
+				next = dns.CanonicalName(cname.Target)
+				break
+			}
+		}
+		if next == "" {
+			return name, nil
+		}
+		name = next
+	}
+
+	return "", fmt.Errorf("cname chain for %s is too long", name)
+}
+
+// authoritativeServers returns the addresses of the authoritative nameservers of the
+// zone containing name
+func (c *Checker) authoritativeServers(ctx context.Context, name string) ([]string, error) {
+	zone, err := c.findZone(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.resolve(ctx, zone, dns.TypeNS)
+	if err != nil {
+		return nil, err
+	}
+
+	// glue
+	glue := map[string][]string{}
+	for _, rr := range resp.Extra {
+		switch rec := rr.(type) {
+		case *dns.A:
+			glue[dns.CanonicalName(rec.Hdr.Name)] = append(glue[dns.CanonicalName(rec.Hdr.Name)], rec.A.String())
+		case *dns.AAAA:
+			glue[dns.CanonicalName(rec.Hdr.Name)] = append(glue[dns.CanonicalName(rec.Hdr.Name)], rec.AAAA.String())
+		}
+	}
+
+	addresses := []string{}
+	for _, rr := range resp.Answer {
+		ns, ok := rr.(*dns.NS)
+		if !ok {
+			continue
+		}
+		host := dns.CanonicalName(ns.Ns)
+
+		ips := glue[host]
+		if len(ips) == 0 {
+			ips, err = c.resolveAddresses(ctx, host)
+			if err != nil {
+				c.logger.Debugf("propagation: failed to resolve nameserver %s (%s)", host, err)
+				continue
+			}
+		}
+
+		for _, ip := range ips {
+			addresses = append(addresses, net.JoinHostPort(ip, c.authPort))
+		}
+	}
+
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no authoritative nameservers found for zone %s", zone)
+	}
+
+	return addresses, nil
+}
+
+// findZone returns the zone containing name, found by querying for the SOA of name and
+// each of its parents
+func (c *Checker) findZone(ctx context.Context, name string) (string, error) {
+	for offset, end := 0, false; !end; offset, end = dns.NextLabel(name, offset) {
+		candidate := name[offset:]
+		if candidate == "." {
+			break
+		}
+
+		resp, err := c.resolve(ctx, candidate, dns.TypeSOA)
+		if err != nil {
+			return "", err
+		}
+
+		for _, rrs := range [][]dns.RR{resp.Answer, resp.Ns} {
+			for _, rr := range rrs {
+				if soa, ok := rr.(*dns.SOA); ok && dns.IsSubDomain(soa.Hdr.Name, name) {
+					return dns.CanonicalName(soa.Hdr.Name), nil
+				}
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no soa found for %s", name)
+}
+
+// resolveAddresses returns the A and AAAA addresses of host
+func (c *Checker) resolveAddresses(ctx context.Context, host string) ([]string, error) {
+	ips := []string{}
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		resp, err := c.resolve(ctx, host, qtype)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rr := range resp.Answer {
+			switch rec := rr.(type) {
+			case *dns.A:
+				ips = append(ips, rec.A.String())
+			case *dns.AAAA:
+				ips = append(ips, rec.AAAA.String())
+			}
+		}
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses for %s", host)
+	}
+
+	return ips, nil
+}
+
+// txtVisible returns nil if server returns a TXT record for name that has value
+func (c *Checker) txtVisible(ctx context.Context, server nameserver, name string, value string) error {
+	resp, err := exchange(ctx, server.address, name, dns.TypeTXT, server.recursive)
+	if err != nil {
+		return fmt.Errorf("failed to query %s for %s (%s)", server.address, name, err)
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("%s returned %s for %s", server.address, dns.RcodeToString[resp.Rcode], name)
+	}
+
+	for _, rr := range resp.Answer {
+		if txt, ok := rr.(*dns.TXT); ok && strings.EqualFold(txt.Hdr.Name, name) && strings.Join(txt.Txt, "") == value {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s does not have the expected value for %s", server.address, name)
+}
+
+// resolve queries the resolvers (in order) until one of them answers
+func (c *Checker) resolve(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	errs := []error{}
+	for _, resolver := range c.resolvers {
+		resp, err := exchange(ctx, resolver, name, qtype, true)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", resolver, err))
+			continue
+		}
+		if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+			errs = append(errs, fmt.Errorf("%s: %s", resolver, dns.RcodeToString[resp.Rcode]))
+			continue
+		}
+
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("failed to resolve %s %s (%w)", name, dns.TypeToString[qtype], errors.Join(errs...))
+}
+
+// exchange sends a single query to server, retrying over tcp if the udp response was
+// truncated
+func exchange(ctx context.Context, server string, name string, qtype uint16, recursive bool) (*dns.Msg, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(name, qtype)
+	msg.RecursionDesired = recursive
+
+	client := &dns.Client{Timeout: queryTimeout}
+	resp, _, err := client.ExchangeContext(ctx, msg, server)
+	if err == nil && resp.Truncated {
+		client.Net = "tcp"
+		resp, _, err = client.ExchangeContext(ctx, msg, server)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,199 @@
+package propagation
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+const testZone = "example.com."
+
+// testServer is a minimal in-process dns server for testZone that acts as both the
+// recursive resolver and the zone's authoritative nameserver
+type testServer struct {
+	mu    sync.Mutex
+	txt   map[string][]string // owner -> values
+	cname map[string]string   // owner -> target
+}
+
+func (s *testServer) setTXT(owner string, values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.txt[owner] = values
+}
+
+func (s *testServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+
+	name := r.Question[0].Name
+	qtype := r.Question[0].Qtype
+	hdr := func(owner string, rrtype uint16) dns.RR_Header {
+		return dns.RR_Header{Name: owner, Rrtype: rrtype, Class: dns.ClassINET, Ttl: 60}
+	}
+	soa := &dns.SOA{Hdr: hdr(testZone, dns.TypeSOA), Ns: "ns1." + testZone, Mbox: "hostmaster." + testZone, Serial: 1}
+
+	if !dns.IsSubDomain(testZone, name) {
+		m.Rcode = dns.RcodeRefused
+		_ = w.WriteMsg(m)
+		return
+	}
+
+	// follow cname (only recursive queries get the target's records)
+	if target, ok := s.cname[name]; ok && qtype != dns.TypeCNAME {
+		m.Answer = append(m.Answer, &dns.CNAME{Hdr: hdr(name, dns.TypeCNAME), Target: target})
+		if !r.RecursionDesired {
+			_ = w.WriteMsg(m)
+			return
+		}
+		name = target
+	}
+
+	switch {
+	case qtype == dns.TypeSOA && name == testZone:
+		m.Answer = append(m.Answer, soa)
+	case qtype == dns.TypeNS && name == testZone:
+		m.Answer = append(m.Answer, &dns.NS{Hdr: hdr(testZone, dns.TypeNS), Ns: "ns1." + testZone})
+		m.Extra = append(m.Extra, &dns.A{Hdr: hdr("ns1."+testZone, dns.TypeA), A: net.ParseIP("127.0.0.1")})
+	case qtype == dns.TypeTXT && len(s.txt[name]) > 0:
+		for _, value := range s.txt[name] {
+			m.Answer = append(m.Answer, &dns.TXT{Hdr: hdr(name, dns.TypeTXT), Txt: []string{value}})
+		}
+	default:
+		m.Ns = append(m.Ns, soa)
+	}
+
+	_ = w.WriteMsg(m)
+}
+
+// startTestServer starts a testServer on a random local udp port and returns a
+// Checker that uses it as both its resolver and the authoritative nameserver
+func startTestServer(t *testing.T, checkAuthoritative, checkResolvers bool) (*testServer, *Checker) {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testServ := &testServer{
+		txt:   make(map[string][]string),
+		cname: map[string]string{"_acme-challenge.www.example.com.": "_acme-challenge.alias.example.com."},
+	}
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        pc,
+		Handler:           testServ,
+		NotifyStartedFunc: func() { close(started) },
+	}
+
+	go func() { _ = server.ActivateAndServe() }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	<-started
+
+	addr := pc.LocalAddr().String()
+	_, port, _ := net.SplitHostPort(addr)
+
+	checker, err := NewChecker(zap.NewNop().Sugar(), Config{
+		Enabled:            new(true),
+		CheckAuthoritative: new(checkAuthoritative),
+		Resolvers:          []string{addr},
+		CheckResolvers:     new(checkResolvers),
+		IntervalSeconds:    new(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker.authPort = port
+	checker.interval = 10 * time.Millisecond
+
+	return testServ, checker
+}
+
+func TestCheckFollowsCNAME(t *testing.T) {
+	testServ, checker := startTestServer(t, true, true)
+
+	// record not yet provisioned
+	err := checker.Check(context.Background(), "_acme-challenge.www.example.com", "value1")
+	if err == nil {
+		t.Fatal("expected error before record exists")
+	}
+
+	// wrong value
+	testServ.setTXT("_acme-challenge.alias.example.com.", "other")
+	err = checker.Check(context.Background(), "_acme-challenge.www.example.com", "value1")
+	if err == nil {
+		t.Fatal("expected error for wrong value")
+	}
+
+	// one of several values
+	testServ.setTXT("_acme-challenge.alias.example.com.", "other", "value1")
+	err = checker.Check(context.Background(), "_acme-challenge.www.example.com", "value1")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	testServ, checker := startTestServer(t, true, false)
+
+	// times out
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := checker.WaitFor(ctx, "_acme-challenge.test.example.com", "value1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	// record appears while waiting
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		testServ.setTXT("_acme-challenge.test.example.com.", "value1")
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = checker.WaitFor(ctx, "_acme-challenge.test.example.com", "value1")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewCheckerConfig(t *testing.T) {
+	bad := []Config{
+		{CheckAuthoritative: new(false), CheckResolvers: new(false), IntervalSeconds: new(10)},
+		{CheckAuthoritative: new(false), CheckResolvers: new(true), IntervalSeconds: new(10)},
+		{CheckAuthoritative: new(true), CheckResolvers: new(false), IntervalSeconds: new(0)},
+		{CheckAuthoritative: new(true), CheckResolvers: new(false), IntervalSeconds: new(10), Resolvers: []string{"dns.example.com"}},
+		{CheckAuthoritative: new(true), IntervalSeconds: new(10)},
+	}
+	for i := range bad {
+		_, err := NewChecker(zap.NewNop().Sugar(), bad[i])
+		if err == nil {
+			t.Errorf("expected error for config %d", i)
+		}
+	}
+
+	checker, err := NewChecker(zap.NewNop().Sugar(), Config{
+		CheckAuthoritative: new(true),
+		CheckResolvers:     new(true),
+		IntervalSeconds:    new(10),
+		Resolvers:          []string{"192.0.2.53", "[2001:db8::53]:5353"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checker.resolvers) != 2 || checker.resolvers[0] != "192.0.2.53:53" || checker.resolvers[1] != "[2001:db8::53]:5353" {
+		t.Errorf("unexpected resolvers %v", checker.resolvers)
+	}
+}
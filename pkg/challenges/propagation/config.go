@@ -0,0 +1,82 @@
+package propagation
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// default config values
+const (
+	DefaultEnabled            = false
+	DefaultCheckAuthoritative = true
+	DefaultCheckResolvers     = false
+	DefaultIntervalSeconds    = 10
+)
+
+const defaultDNSPort = "53"
+
+// Config is the configuration for active dns-01 propagation checks
+type Config struct {
+	Enabled *bool `yaml:"enabled"`
+	// check that the record is visible on each of the zone's authoritative nameservers
+	CheckAuthoritative *bool `yaml:"check_authoritative"`
+	// recursive resolvers used to follow CNAMEs and find the zone's nameservers. If none
+	// are specified, the system's resolvers are used.
+	Resolvers []string `yaml:"resolvers"`
+	// also check that the record is visible on the resolvers
+	CheckResolvers  *bool `yaml:"check_resolvers"`
+	IntervalSeconds *int  `yaml:"interval_seconds"`
+}
+
+// normalizeServers validates each server and adds the default dns port to any server
+// that does not have a port
+func normalizeServers(servers []string) ([]string, error) {
+	normalized := []string{}
+	invalid := []string{}
+
+	for _, server := range servers {
+		host, port, err := net.SplitHostPort(server)
+		if err != nil {
+			host = server
+			port = defaultDNSPort
+		}
+
+		portNumb, err := strconv.Atoi(port)
+		if net.ParseIP(host) == nil || err != nil || portNumb < 1 || portNumb > 65535 {
+			invalid = append(invalid, server)
+			continue
+		}
+
+		normalized = append(normalized, net.JoinHostPort(host, port))
+	}
+
+	if len(invalid) != 0 {
+		return nil, fmt.Errorf("propagation: resolvers (%s) must be ip addresses with an optional port", strings.Join(invalid, ", "))
+	}
+
+	return normalized, nil
+}
+
+// validateConfig returns an error if cfg is not usable
+func validateConfig(cfg Config) error {
+	if cfg.CheckAuthoritative == nil || cfg.CheckResolvers == nil || cfg.IntervalSeconds == nil {
+		return errors.New("propagation: config is missing a value")
+	}
+
+	if !*cfg.CheckAuthoritative && !*cfg.CheckResolvers {
+		return errors.New("propagation: at least one of check_authoritative or check_resolvers must be enabled")
+	}
+
+	if *cfg.CheckResolvers && len(cfg.Resolvers) == 0 {
+		return errors.New("propagation: check_resolvers requires at least one resolver")
+	}
+
+	if *cfg.IntervalSeconds < 1 || *cfg.IntervalSeconds > 300 {
+		return fmt.Errorf("propagation: interval_seconds (%d) must be between 1 and 300", *cfg.IntervalSeconds)
+	}
+
+	return nil
+}
@@ -6,14 +6,18 @@ import (
 	"certwarden-backend/pkg/challenges/providers/dns01cloudflare"
 	"certwarden-backend/pkg/challenges/providers/dns01goacme"
 	"certwarden-backend/pkg/challenges/providers/dns01manual"
+	"certwarden-backend/pkg/challenges/providers/dns01rfc2136"
 	"certwarden-backend/pkg/challenges/providers/dnspersist01manual"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
 )
 
-// internal base config
+// internal base config; Priority orders the providers of a domain that is configured
+// on more than one provider (lowest first), later providers are fallbacks
 type InternalConfig struct {
 	Domains                  []string `yaml:"domains"`
 	PostProvisionWaitSeconds int      `yaml:"post_resource_provision_wait"`
+	Priority                 int      `yaml:"priority,omitempty"`
 }
 
 // provider manager configs
@@ -22,6 +26,11 @@ type ConfigManagerHttp01Internal struct {
 	*http01internal.Config `yaml:",inline"`
 }
 
+type ConfigManagerTlsAlpn01Internal struct {
+	InternalConfig            `yaml:",inline"`
+	*tlsalpn01internal.Config `yaml:",inline"`
+}
+
 type ConfigManagerDns01Manual struct {
 	InternalConfig      `yaml:",inline"`
 	*dns01manual.Config `yaml:",inline"`
@@ -47,6 +56,11 @@ type ConfigManagerDns01GoAcme struct {
 	*dns01goacme.Config `yaml:",inline"`
 }
 
+type ConfigManagerDns01Rfc2136 struct {
+	InternalConfig       `yaml:",inline"`
+	*dns01rfc2136.Config `yaml:",inline"`
+}
+
 type ConfigManagerDnsPersist01Manual struct {
 	InternalConfig             `yaml:",inline"`
 	*dnspersist01manual.Config `yaml:",inline"`
@@ -60,7 +74,9 @@ type Config struct {
 	Dns01AcmeShConfigs        []ConfigManagerDns01AcmeSh        `yaml:"dns_01_acme_sh,omitempty"`
 	Dns01CloudflareConfigs    []ConfigManagerDns01Cloudflare    `yaml:"dns_01_cloudflare,omitempty"`
 	Dns01GoAcmeConfigs        []ConfigManagerDns01GoAcme        `yaml:"dns_01_go_acme,omitempty"`
+	Dns01Rfc2136Configs       []ConfigManagerDns01Rfc2136       `yaml:"dns_01_rfc2136,omitempty"`
 	DnsPersist01ManualConfigs []ConfigManagerDnsPersist01Manual `yaml:"dns_persist_01_manual,omitempty"`
+	TlsAlpn01InternalConfigs  []ConfigManagerTlsAlpn01Internal  `yaml:"tls_alpn_01_internal,omitempty"`
 }
 
 // Len returns the total number of Provider Configs, regardless of type.
@@ -71,7 +87,9 @@ func (cfg Config) Len() int {
 		len(cfg.Dns01AcmeShConfigs) +
 		len(cfg.Dns01CloudflareConfigs) +
 		len(cfg.Dns01GoAcmeConfigs) +
-		len(cfg.DnsPersist01ManualConfigs)
+		len(cfg.Dns01Rfc2136Configs) +
+		len(cfg.DnsPersist01ManualConfigs) +
+		len(cfg.TlsAlpn01InternalConfigs)
 }
 
 // managerProviderConfig is a provider config and additional config for
@@ -120,12 +138,24 @@ func (cfg Config) All() []managerProviderConfig {
 			providerCfg: mgrCfg.Config,
 		})
 	}
+	for _, mgrCfg := range cfg.Dns01Rfc2136Configs {
+		all = append(all, managerProviderConfig{
+			internalCfg: mgrCfg.InternalConfig,
+			providerCfg: mgrCfg.Config,
+		})
+	}
 	for _, mgrCfg := range cfg.DnsPersist01ManualConfigs {
 		all = append(all, managerProviderConfig{
 			internalCfg: mgrCfg.InternalConfig,
 			providerCfg: mgrCfg.Config,
 		})
 	}
+	for _, mgrCfg := range cfg.TlsAlpn01InternalConfigs {
+		all = append(all, managerProviderConfig{
+			internalCfg: mgrCfg.InternalConfig,
+			providerCfg: mgrCfg.Config,
+		})
+	}
 
 	return all
 }
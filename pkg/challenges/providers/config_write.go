@@ -6,8 +6,10 @@ import (
 	"certwarden-backend/pkg/challenges/providers/dns01cloudflare"
 	"certwarden-backend/pkg/challenges/providers/dns01goacme"
 	"certwarden-backend/pkg/challenges/providers/dns01manual"
+	"certwarden-backend/pkg/challenges/providers/dns01rfc2136"
 	"certwarden-backend/pkg/challenges/providers/dnspersist01manual"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
 	"errors"
 	"io/fs"
 	"os"
@@ -31,6 +33,7 @@ func (mgr *Manager) unsafeWriteProvidersConfig() error {
 					InternalConfig: InternalConfig{
 						Domains:                  p.Domains,
 						PostProvisionWaitSeconds: p.PostProvisionWaitSeconds,
+						Priority:                 p.Priority,
 					},
 					Config: realCfg,
 				},
@@ -42,6 +45,7 @@ func (mgr *Manager) unsafeWriteProvidersConfig() error {
 					InternalConfig: InternalConfig{
 						Domains:                  p.Domains,
 						PostProvisionWaitSeconds: p.PostProvisionWaitSeconds,
+						Priority:                 p.Priority,
 					},
 					Config: realCfg,
 				},
@@ -53,6 +57,7 @@ func (mgr *Manager) unsafeWriteProvidersConfig() error {
 					InternalConfig: InternalConfig{
 						Domains:                  p.Domains,
 						PostProvisionWaitSeconds: p.PostProvisionWaitSeconds,
+						Priority:                 p.Priority,
 					},
 					Config: realCfg,
 				},
@@ -64,6 +69,7 @@ func (mgr *Manager) unsafeWriteProvidersConfig() error {
 					InternalConfig: InternalConfig{
 						Domains:                  p.Domains,
 						PostProvisionWaitSeconds: p.PostProvisionWaitSeconds,
+						Priority:                 p.Priority,
 					},
 					Config: realCfg,
 				},
@@ -75,6 +81,7 @@ func (mgr *Manager) unsafeWriteProvidersConfig() error {
 					InternalConfig: InternalConfig{
 						Domains:                  p.Domains,
 						PostProvisionWaitSeconds: p.PostProvisionWaitSeconds,
+						Priority:                 p.Priority,
 					},
 					Config: realCfg,
 				},
@@ -86,6 +93,19 @@ func (mgr *Manager) unsafeWriteProvidersConfig() error {
 					InternalConfig: InternalConfig{
 						Domains:                  p.Domains,
 						PostProvisionWaitSeconds: p.PostProvisionWaitSeconds,
+						Priority:                 p.Priority,
+					},
+					Config: realCfg,
+				},
+			)
+
+		case *dns01rfc2136.Config:
+			mgrCfg.Dns01Rfc2136Configs = append(mgrCfg.Dns01Rfc2136Configs,
+				ConfigManagerDns01Rfc2136{
+					InternalConfig: InternalConfig{
+						Domains:                  p.Domains,
+						PostProvisionWaitSeconds: p.PostProvisionWaitSeconds,
+						Priority:                 p.Priority,
 					},
 					Config: realCfg,
 				},
@@ -97,6 +117,19 @@ func (mgr *Manager) unsafeWriteProvidersConfig() error {
 					InternalConfig: InternalConfig{
 						Domains:                  p.Domains,
 						PostProvisionWaitSeconds: p.PostProvisionWaitSeconds,
+						Priority:                 p.Priority,
+					},
+					Config: realCfg,
+				},
+			)
+
+		case *tlsalpn01internal.Config:
+			mgrCfg.TlsAlpn01InternalConfigs = append(mgrCfg.TlsAlpn01InternalConfigs,
+				ConfigManagerTlsAlpn01Internal{
+					InternalConfig: InternalConfig{
+						Domains:                  p.Domains,
+						PostProvisionWaitSeconds: p.PostProvisionWaitSeconds,
+						Priority:                 p.Priority,
 					},
 					Config: realCfg,
 				},
@@ -0,0 +1,146 @@
+package dns01rfc2136
+
+import (
+	"certwarden-backend/pkg/validation"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// default values for optional config options
+const (
+	defaultNameserverPort = "53"
+	defaultTsigAlgorithm  = dns.HmacSHA256
+	defaultTTL            = 60
+)
+
+// tsigAlgorithms are the TSIG algorithms that can be used
+var tsigAlgorithms = []string{
+	dns.HmacSHA1,
+	dns.HmacSHA224,
+	dns.HmacSHA256,
+	dns.HmacSHA384,
+	dns.HmacSHA512,
+}
+
+// Configuration options
+type Config struct {
+	// primary nameserver that will receive the updates (host or host:port)
+	Nameserver string `yaml:"nameserver" json:"nameserver"`
+	// TSIG key
+	TsigKeyName   string `yaml:"tsig_key_name" json:"tsig_key_name"`
+	TsigAlgorithm string `yaml:"tsig_algorithm,omitempty" json:"tsig_algorithm,omitempty"`
+	TsigSecret    string `yaml:"tsig_secret" json:"tsig_secret"`
+	// optional; if blank, the zone is discovered with an SOA lookup
+	Zone string `yaml:"zone,omitempty" json:"zone,omitempty"`
+	// optional
+	TTL    int  `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	UseTCP bool `yaml:"use_tcp,omitempty" json:"use_tcp,omitempty"`
+}
+
+// serviceConfig is the validated and normalized form of Config that the service
+// actually uses
+type serviceConfig struct {
+	nameserver    string
+	tsigKeyName   string
+	tsigAlgorithm string
+	tsigSecret    string
+	zone          string
+	ttl           uint32
+	network       string
+}
+
+// validateConfig verifies the config meets requirements and returns the normalized
+// service config. If the config is not valid, an error is returned.
+func validateConfig(cfg *Config) (*serviceConfig, error) {
+	// must receive a config
+	if cfg == nil {
+		return nil, errServiceComponent
+	}
+
+	// collect all validation errors (to return as a list)
+	errStrings := []string{}
+
+	servCfg := &serviceConfig{
+		tsigAlgorithm: defaultTsigAlgorithm,
+		ttl:           defaultTTL,
+		network:       "udp",
+	}
+
+	// nameserver (add default port if none)
+	host, port, err := net.SplitHostPort(cfg.Nameserver)
+	if err != nil {
+		host = cfg.Nameserver
+		port = defaultNameserverPort
+	}
+	portNumb, portErr := strconv.Atoi(port)
+	if host == "" || !(validation.DomainValid(host, false) || net.ParseIP(host) != nil) || portErr != nil || portNumb < 1 || portNumb > 65535 {
+		errStrings = append(errStrings, fmt.Sprintf("nameserver (%s) must be a hostname or ip address with an optional port", cfg.Nameserver))
+	} else {
+		servCfg.nameserver = net.JoinHostPort(host, port)
+	}
+
+	// tsig key name (single label names are permitted)
+	if _, ok := dns.IsDomainName(cfg.TsigKeyName); cfg.TsigKeyName == "" || !ok {
+		errStrings = append(errStrings, fmt.Sprintf("tsig key name (%s) is not valid", cfg.TsigKeyName))
+	} else {
+		servCfg.tsigKeyName = dns.CanonicalName(cfg.TsigKeyName)
+	}
+
+	// tsig algorithm (blank is default)
+	if cfg.TsigAlgorithm != "" {
+		alg := dns.CanonicalName(cfg.TsigAlgorithm)
+		found := false
+		for _, validAlg := range tsigAlgorithms {
+			if alg == validAlg {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errStrings = append(errStrings, fmt.Sprintf("tsig algorithm (%s) is not supported", cfg.TsigAlgorithm))
+		} else {
+			servCfg.tsigAlgorithm = alg
+		}
+	}
+
+	// tsig secret must be base64
+	_, err = base64.StdEncoding.DecodeString(cfg.TsigSecret)
+	if cfg.TsigSecret == "" || err != nil {
+		errStrings = append(errStrings, "tsig secret must be a base64 encoded value")
+	} else {
+		servCfg.tsigSecret = cfg.TsigSecret
+	}
+
+	// zone (optional)
+	if cfg.Zone != "" {
+		if !validation.DomainValid(strings.TrimSuffix(cfg.Zone, "."), false) {
+			errStrings = append(errStrings, fmt.Sprintf("zone (%s) is not valid", cfg.Zone))
+		} else {
+			servCfg.zone = dns.CanonicalName(cfg.Zone)
+		}
+	}
+
+	// ttl (optional)
+	if cfg.TTL < 0 {
+		errStrings = append(errStrings, fmt.Sprintf("ttl (%d) must not be negative", cfg.TTL))
+	} else if cfg.TTL > 0 {
+		servCfg.ttl = uint32(cfg.TTL)
+	}
+
+	// transport
+	if cfg.UseTCP {
+		servCfg.network = "tcp"
+	}
+
+	// combine any errors and return
+	if len(errStrings) != 0 {
+		return nil, fmt.Errorf("dns01rfc2136: invalid config (%s)", strings.Join(errStrings, ", "))
+	}
+
+	return servCfg, nil
+}
@@ -0,0 +1,201 @@
+package dns01rfc2136
+
+import (
+	"certwarden-backend/pkg/acme"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// timeout for each dns exchange
+	exchangeTimeout = 10 * time.Second
+	// fudge value (allowed clock skew) for TSIG signatures
+	tsigFudgeSeconds = 300
+)
+
+// Provision adds the corresponding TXT record with a dynamic update to the primary
+// nameserver.
+func (service *Service) Provision(domain string, token string, keyAuth acme.KeyAuth) error {
+	return service.ProvisionBatch([]acme.ChallengeResource{{Domain: domain, Token: token, KeyAuth: keyAuth}})
+}
+
+// Deprovision deletes the corresponding TXT record with a dynamic update to the primary
+// nameserver. Only the record with the exact value is removed, any other TXT records
+// with the same name (e.g. for another pending challenge) are left in place.
+func (service *Service) Deprovision(domain string, token string, keyAuth acme.KeyAuth) error {
+	return service.DeprovisionBatch([]acme.ChallengeResource{{Domain: domain, Token: token, KeyAuth: keyAuth}})
+}
+
+// ProvisionBatch adds the TXT records for all of the resources, using one dynamic update
+// per zone.
+func (service *Service) ProvisionBatch(resources []acme.ChallengeResource) error {
+	return service.updateZones(resources, false)
+}
+
+// DeprovisionBatch deletes the exact TXT records for all of the resources, using one
+// dynamic update per zone.
+func (service *Service) DeprovisionBatch(resources []acme.ChallengeResource) error {
+	return service.updateZones(resources, true)
+}
+
+// updateZones groups the TXT records for resources by zone and then sends one update to
+// each zone that either inserts or removes (if remove) the records. All zones are
+// updated even if one of them fails.
+func (service *Service) updateZones(resources []acme.ChallengeResource, remove bool) error {
+	action := "add"
+	if remove {
+		action = "delete"
+	}
+
+	var errs error
+
+	// group by zone (keeping order)
+	zones := []string{}
+	zoneRRs := make(map[string][]dns.RR)
+	for _, resource := range resources {
+		dnsRecordName, dnsRecordValue := acme.ValidationResourceDns01(resource.Domain, resource.KeyAuth)
+
+		rr, zone, err := service.txtRecordAndZone(dnsRecordName, dnsRecordValue)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("dns01rfc2136: failed to %s dns record %s: %s (%s)", action, dnsRecordName, dnsRecordValue, err))
+			continue
+		}
+
+		if _, exists := zoneRRs[zone]; !exists {
+			zones = append(zones, zone)
+		}
+		zoneRRs[zone] = append(zoneRRs[zone], rr)
+	}
+
+	// one update per zone
+	for _, zone := range zones {
+		msg := new(dns.Msg)
+		msg.SetUpdate(zone)
+		if remove {
+			msg.Remove(zoneRRs[zone])
+		} else {
+			msg.Insert(zoneRRs[zone])
+		}
+
+		err := service.sendUpdate(msg)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("dns01rfc2136: failed to %s %d dns record(s) in zone %s (%s)", action, len(zoneRRs[zone]), zone, err))
+			continue
+		}
+
+		service.logger.Debugf("dns01rfc2136: %s %d dns record(s) in zone %s", action, len(zoneRRs[zone]), zone)
+	}
+
+	return errs
+}
+
+// txtRecordAndZone returns the TXT record for the specified name and value and the
+// zone that contains it
+func (service *Service) txtRecordAndZone(dnsRecordName, dnsRecordValue string) (*dns.TXT, string, error) {
+	fqdn := dns.CanonicalName(dnsRecordName)
+
+	zone, err := service.findZone(fqdn)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to find zone (%s)", err)
+	}
+
+	rr := &dns.TXT{
+		Hdr: dns.RR_Header{
+			Name:   fqdn,
+			Rrtype: dns.TypeTXT,
+			Class:  dns.ClassINET,
+			Ttl:    service.cfg.ttl,
+		},
+		Txt: []string{dnsRecordValue},
+	}
+
+	return rr, zone, nil
+}
+
+// findZone returns the configured zone or, if none is configured, the zone containing
+// fqdn. The zone is discovered by querying the primary for the SOA of fqdn and each of
+// its parents until an SOA is returned.
+func (service *Service) findZone(fqdn string) (string, error) {
+	// configured zone
+	if service.cfg.zone != "" {
+		if !dns.IsSubDomain(service.cfg.zone, fqdn) {
+			return "", fmt.Errorf("%s is not in configured zone %s", fqdn, service.cfg.zone)
+		}
+		return service.cfg.zone, nil
+	}
+
+	// walk up from fqdn
+	for offset, end := 0, false; !end; offset, end = dns.NextLabel(fqdn, offset) {
+		candidate := fqdn[offset:]
+		if candidate == "." {
+			break
+		}
+
+		msg := new(dns.Msg)
+		msg.SetQuestion(candidate, dns.TypeSOA)
+
+		resp, err := service.exchange(msg)
+		if err != nil {
+			return "", err
+		}
+		if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+			return "", fmt.Errorf("soa query for %s returned %s", candidate, dns.RcodeToString[resp.Rcode])
+		}
+
+		// authoritative answer, or the zone's SOA in the authority section
+		for _, rrs := range [][]dns.RR{resp.Answer, resp.Ns} {
+			for _, rr := range rrs {
+				if soa, ok := rr.(*dns.SOA); ok && dns.IsSubDomain(soa.Hdr.Name, fqdn) {
+					return dns.CanonicalName(soa.Hdr.Name), nil
+				}
+			}
+		}
+	}
+
+	return "", errors.New("no soa found")
+}
+
+// sendUpdate sends the update msg to the primary and returns an error if it was not
+// successful
+func (service *Service) sendUpdate(msg *dns.Msg) error {
+	resp, err := service.exchange(msg)
+	if err != nil {
+		return err
+	}
+
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("update returned %s", dns.RcodeToString[resp.Rcode])
+	}
+
+	return nil
+}
+
+// exchange TSIG signs msg and sends it to the primary, returning the (verified) response
+func (service *Service) exchange(msg *dns.Msg) (*dns.Msg, error) {
+	msg.SetTsig(service.cfg.tsigKeyName, service.cfg.tsigAlgorithm, tsigFudgeSeconds, time.Now().Unix())
+
+	client := &dns.Client{
+		Net:        service.cfg.network,
+		Timeout:    exchangeTimeout,
+		TsigSecret: map[string]string{service.cfg.tsigKeyName: service.cfg.tsigSecret},
+	}
+
+	// large (batch) updates don't fit in a udp message
+	if msg.Len() > dns.MinMsgSize {
+		client.Net = "tcp"
+	}
+
+	ctx, cancel := context.WithTimeout(service.shutdownContext, exchangeTimeout)
+	defer cancel()
+
+	resp, _, err := client.ExchangeContext(ctx, msg, service.cfg.nameserver)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
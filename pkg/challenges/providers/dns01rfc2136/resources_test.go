@@ -0,0 +1,331 @@
+package dns01rfc2136
+
+import (
+	"certwarden-backend/pkg/acme"
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+const (
+	testKeyName = "certwarden."
+	testSecret  = "c2VjcmV0LXRzaWcta2V5LWZvci10ZXN0aW5nLW9ubHk="
+	testZone    = "example.com."
+)
+
+// testApp implements App
+type testApp struct{}
+
+func (testApp) GetLogger() *zap.SugaredLogger       { return zap.NewNop().Sugar() }
+func (testApp) GetShutdownContext() context.Context { return context.Background() }
+
+// testPrimary is a minimal in-process authoritative server for testZone that applies
+// TSIG signed dynamic updates to its TXT records
+type testPrimary struct {
+	mu      sync.Mutex
+	txt     map[string][]string // owner -> values
+	updates int
+}
+
+func (p *testPrimary) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+
+	if r.IsTsig() != nil {
+		if w.TsigStatus() != nil {
+			m.Rcode = dns.RcodeNotAuth
+			_ = w.WriteMsg(m)
+			return
+		}
+		m.SetTsig(r.IsTsig().Hdr.Name, r.IsTsig().Algorithm, 300, time.Now().Unix())
+	}
+
+	soa := &dns.SOA{
+		Hdr:    dns.RR_Header{Name: testZone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 60},
+		Ns:     "ns1." + testZone,
+		Mbox:   "hostmaster." + testZone,
+		Serial: 1,
+	}
+
+	switch r.Opcode {
+	case dns.OpcodeQuery:
+		name := r.Question[0].Name
+		if !dns.IsSubDomain(testZone, name) {
+			m.Rcode = dns.RcodeRefused
+		} else if name == testZone {
+			m.Answer = []dns.RR{soa}
+		} else {
+			m.Ns = []dns.RR{soa}
+		}
+
+	case dns.OpcodeUpdate:
+		// updates must be signed
+		if r.IsTsig() == nil {
+			m.Rcode = dns.RcodeRefused
+			break
+		}
+		if r.Question[0].Name != testZone {
+			m.Rcode = dns.RcodeNotZone
+			break
+		}
+
+		p.mu.Lock()
+		p.updates++
+		for _, rr := range r.Ns {
+			txt, ok := rr.(*dns.TXT)
+			if !ok {
+				continue
+			}
+			value := strings.Join(txt.Txt, "")
+
+			switch txt.Hdr.Class {
+			case dns.ClassINET:
+				p.txt[txt.Hdr.Name] = append(p.txt[txt.Hdr.Name], value)
+			case dns.ClassNONE:
+				p.txt[txt.Hdr.Name] = slices.DeleteFunc(p.txt[txt.Hdr.Name], func(v string) bool { return v == value })
+			case dns.ClassANY:
+				delete(p.txt, txt.Hdr.Name)
+			}
+		}
+		p.mu.Unlock()
+
+	default:
+		m.Rcode = dns.RcodeNotImplemented
+	}
+
+	_ = w.WriteMsg(m)
+}
+
+// updateCount returns the number of updates that have been received
+func (p *testPrimary) updateCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.updates
+}
+
+// values returns the TXT values for owner
+func (p *testPrimary) values(owner string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.txt[owner])
+}
+
+// startTestPrimary starts a testPrimary listening on a random local port (udp and tcp)
+// and returns it along with its address
+func startTestPrimary(t *testing.T) (*testPrimary, string) {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	primary := &testPrimary{txt: make(map[string][]string)}
+	for _, server := range []*dns.Server{{PacketConn: pc}, {Listener: l}} {
+		started := make(chan struct{})
+		server.Handler = primary
+		server.TsigSecret = map[string]string{testKeyName: testSecret}
+		server.NotifyStartedFunc = func() { close(started) }
+		// default func rejects UPDATE
+		server.MsgAcceptFunc = func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept }
+
+		go func() { _ = server.ActivateAndServe() }()
+		t.Cleanup(func() { _ = server.Shutdown() })
+		<-started
+	}
+
+	return primary, pc.LocalAddr().String()
+}
+
+func TestProvisionDeprovision(t *testing.T) {
+	primary, addr := startTestPrimary(t)
+
+	service, err := NewService(testApp{}, &Config{
+		Nameserver:  addr,
+		TsigKeyName: "certwarden",
+		TsigSecret:  testSecret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wildcard and base domain share the same record name
+	owner := "_acme-challenge.www.example.com."
+	keyAuths := []acme.KeyAuth{"token1.thumbprint", "token2.thumbprint"}
+	_, value1 := acme.ValidationResourceDns01("www.example.com", keyAuths[0])
+	_, value2 := acme.ValidationResourceDns01("www.example.com", keyAuths[1])
+
+	for _, keyAuth := range keyAuths {
+		err = service.Provision("www.example.com", "", keyAuth)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := primary.values(owner); !slices.Equal(got, []string{value1, value2}) {
+		t.Fatalf("expected both values after provision, got %v", got)
+	}
+
+	// only the exact record is removed
+	err = service.Deprovision("www.example.com", "", keyAuths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := primary.values(owner); !slices.Equal(got, []string{value2}) {
+		t.Errorf("expected only second value after deprovision, got %v", got)
+	}
+}
+
+func TestProvisionBatch(t *testing.T) {
+	primary, addr := startTestPrimary(t)
+
+	service, err := NewService(testApp{}, &Config{
+		Nameserver:  addr,
+		TsigKeyName: testKeyName,
+		TsigSecret:  testSecret,
+		Zone:        testZone,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// enough records that the update is sent over tcp
+	resources := []acme.ChallengeResource{}
+	for i := range 20 {
+		resources = append(resources, acme.ChallengeResource{
+			Domain:  fmt.Sprintf("host%d.example.com", i),
+			KeyAuth: acme.KeyAuth(fmt.Sprintf("token%d.thumbprint", i)),
+		})
+	}
+
+	// one update for the zone
+	err = service.ProvisionBatch(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primary.updateCount() != 1 {
+		t.Errorf("expected 1 update, got %d", primary.updateCount())
+	}
+	for _, resource := range resources {
+		_, value := acme.ValidationResourceDns01(resource.Domain, resource.KeyAuth)
+		if got := primary.values("_acme-challenge." + resource.Domain + "."); !slices.Equal(got, []string{value}) {
+			t.Errorf("unexpected values for %s: %v", resource.Domain, got)
+		}
+	}
+
+	err = service.DeprovisionBatch(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primary.updateCount() != 2 {
+		t.Errorf("expected 2 updates, got %d", primary.updateCount())
+	}
+	for _, resource := range resources {
+		if got := primary.values("_acme-challenge." + resource.Domain + "."); len(got) != 0 {
+			t.Errorf("expected no values for %s, got %v", resource.Domain, got)
+		}
+	}
+}
+
+func TestProvisionBadKey(t *testing.T) {
+	primary, addr := startTestPrimary(t)
+
+	service, err := NewService(testApp{}, &Config{
+		Nameserver:  addr,
+		TsigKeyName: testKeyName,
+		TsigSecret:  "d3Jvbmctc2VjcmV0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.Provision("www.example.com", "", "token.thumbprint")
+	if err == nil {
+		t.Error("expected error provisioning with the wrong tsig secret")
+	}
+
+	if got := primary.values("_acme-challenge.www.example.com."); len(got) != 0 {
+		t.Errorf("expected no records, got %v", got)
+	}
+}
+
+func TestFindZone(t *testing.T) {
+	_, addr := startTestPrimary(t)
+
+	service, err := NewService(testApp{}, &Config{
+		Nameserver:  addr,
+		TsigKeyName: testKeyName,
+		TsigSecret:  testSecret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zone, err := service.findZone("_acme-challenge.a.b.example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zone != testZone {
+		t.Errorf("expected zone %s, got %s", testZone, zone)
+	}
+
+	_, err = service.findZone("_acme-challenge.example.net.")
+	if err == nil {
+		t.Error("expected error finding zone outside of the primary's zones")
+	}
+
+	// configured zone must contain the name
+	service.cfg.zone = "other.example.com."
+	_, err = service.findZone("_acme-challenge.www.example.com.")
+	if err == nil {
+		t.Error("expected error for name outside of the configured zone")
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	servCfg, err := validateConfig(&Config{
+		Nameserver:    "ns1.example.com",
+		TsigKeyName:   "certwarden",
+		TsigAlgorithm: "HMAC-SHA512",
+		TsigSecret:    testSecret,
+		Zone:          "Example.com",
+		UseTCP:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if servCfg.nameserver != "ns1.example.com:53" || servCfg.tsigKeyName != testKeyName || servCfg.tsigAlgorithm != dns.HmacSHA512 ||
+		servCfg.zone != testZone || servCfg.ttl != defaultTTL || servCfg.network != "tcp" {
+		t.Errorf("unexpected service config %+v", servCfg)
+	}
+
+	bad := []Config{
+		{Nameserver: "", TsigKeyName: testKeyName, TsigSecret: testSecret},
+		{Nameserver: "127.0.0.1:99999", TsigKeyName: testKeyName, TsigSecret: testSecret},
+		{Nameserver: "127.0.0.1", TsigKeyName: "", TsigSecret: testSecret},
+		{Nameserver: "127.0.0.1", TsigKeyName: testKeyName, TsigSecret: "not base64!"},
+		{Nameserver: "127.0.0.1", TsigKeyName: testKeyName, TsigSecret: testSecret, TsigAlgorithm: "hmac-md5"},
+		{Nameserver: "127.0.0.1", TsigKeyName: testKeyName, TsigSecret: testSecret, TTL: -1},
+	}
+	for i := range bad {
+		_, err = validateConfig(&bad[i])
+		if err == nil {
+			t.Errorf("expected error for config %+v", bad[i])
+		}
+	}
+}
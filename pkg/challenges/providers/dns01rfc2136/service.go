@@ -0,0 +1,84 @@
+package dns01rfc2136
+
+import (
+	"certwarden-backend/pkg/acme"
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errServiceComponent = errors.New("necessary dns-01 rfc2136 component is missing")
+)
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetShutdownContext() context.Context
+}
+
+// provider Service struct
+type Service struct {
+	logger          *zap.SugaredLogger
+	shutdownContext context.Context
+	cfg             *serviceConfig
+}
+
+// ChallengeType returns the ACME Challenge Type this provider uses, which is dns-01
+func (service *Service) AcmeChallengeType() acme.ChallengeType {
+	return acme.ChallengeTypeDns01
+}
+
+// Stop is used for any actions needed prior to deleting this provider. If no actions
+// are needed, it is just a no-op.
+func (service *Service) Stop() error { return nil }
+
+// NewService creates a new instance of the RFC 2136 provider service. Service sends
+// TSIG signed dynamic updates to one primary nameserver.
+func NewService(app App, cfg *Config) (*Service, error) {
+	// check config
+	servCfg, err := validateConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// shutdown ctx
+	service.shutdownContext = app.GetShutdownContext()
+	if service.shutdownContext == nil {
+		return nil, errServiceComponent
+	}
+
+	// nameserver, key, etc.
+	service.cfg = servCfg
+
+	return service, nil
+}
+
+// Update Service updates the Service to use the new config
+func (service *Service) UpdateService(app App, cfg *Config) error {
+	// if no config, error
+	if cfg == nil {
+		return errServiceComponent
+	}
+
+	// don't need to do anything with "old" Service, just set a new one
+	newServ, err := NewService(app, cfg)
+	if err != nil {
+		return err
+	}
+
+	// set content of old pointer so anything with the pointer calls the
+	// updated service
+	*service = *newServ
+
+	return nil
+}
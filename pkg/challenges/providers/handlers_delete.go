@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"fmt"
@@ -85,6 +86,7 @@ func (mgr *Manager) DeleteProvider(w http.ResponseWriter, r *http.Request) *outp
 		mgr.logger.Errorf("failed to save config file after providers update (%s)", err)
 		return output.JsonErrInternal(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetDNSProvider, p.ID, p, nil)
 
 	// write response
 	response := &output.JsonResponse{
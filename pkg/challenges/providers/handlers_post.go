@@ -6,8 +6,11 @@ import (
 	"certwarden-backend/pkg/challenges/providers/dns01cloudflare"
 	"certwarden-backend/pkg/challenges/providers/dns01goacme"
 	"certwarden-backend/pkg/challenges/providers/dns01manual"
+	"certwarden-backend/pkg/challenges/providers/dns01rfc2136"
 	"certwarden-backend/pkg/challenges/providers/dnspersist01manual"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"fmt"
@@ -21,6 +24,7 @@ type newPayload struct {
 
 	// optional
 	PostProvisionWaitSeconds *int `json:"post_resource_provision_wait"`
+	Priority                 *int `json:"priority"`
 
 	// + mandatory, only one of these
 	Http01InternalConfig  *http01internal.Config     `json:"http_01_internal,omitempty"`
@@ -29,7 +33,9 @@ type newPayload struct {
 	Dns01AcmeShConfig     *dns01acmesh.Config        `json:"dns_01_acme_sh,omitempty"`
 	Dns01CloudflareConfig *dns01cloudflare.Config    `json:"dns_01_cloudflare,omitempty"`
 	Dns01GoAcmeConfig     *dns01goacme.Config        `json:"dns_01_go_acme,omitempty"`
+	Dns01Rfc2136Config    *dns01rfc2136.Config       `json:"dns_01_rfc2136,omitempty"`
 	DnsPersist01Manual    *dnspersist01manual.Config `json:"dns_persist_01_manual,omitempty"`
+	TlsAlpn01Internal     *tlsalpn01internal.Config  `json:"tls_alpn_01_internal,omitempty"`
 }
 
 // CreateProvider creates a new provider using the specified configuration.
@@ -65,9 +71,15 @@ func (mgr *Manager) CreateProvider(w http.ResponseWriter, r *http.Request) *outp
 	if payload.Dns01GoAcmeConfig != nil {
 		configCount++
 	}
+	if payload.Dns01Rfc2136Config != nil {
+		configCount++
+	}
 	if payload.DnsPersist01Manual != nil {
 		configCount++
 	}
+	if payload.TlsAlpn01Internal != nil {
+		configCount++
+	}
 	if configCount != 1 {
 		err = fmt.Errorf("new provider expects 1 config, received %d", configCount)
 		mgr.logger.Debug(err)
@@ -83,6 +95,9 @@ func (mgr *Manager) CreateProvider(w http.ResponseWriter, r *http.Request) *outp
 	} else {
 		internalCfg.PostProvisionWaitSeconds = 5 * 60
 	}
+	if payload.Priority != nil {
+		internalCfg.Priority = *payload.Priority
+	}
 
 	// try to add the specified provider (actual action)
 	var p *provider
@@ -104,9 +119,15 @@ func (mgr *Manager) CreateProvider(w http.ResponseWriter, r *http.Request) *outp
 	} else if payload.Dns01GoAcmeConfig != nil {
 		p, err = mgr.unsafeAddProvider(internalCfg, payload.Dns01GoAcmeConfig)
 
+	} else if payload.Dns01Rfc2136Config != nil {
+		p, err = mgr.unsafeAddProvider(internalCfg, payload.Dns01Rfc2136Config)
+
 	} else if payload.DnsPersist01Manual != nil {
 		p, err = mgr.unsafeAddProvider(internalCfg, payload.DnsPersist01Manual)
 
+	} else if payload.TlsAlpn01Internal != nil {
+		p, err = mgr.unsafeAddProvider(internalCfg, payload.TlsAlpn01Internal)
+
 	} else {
 		mgr.logger.Error("new provider cfg missing, this error should never trigger though, report bug to developer")
 	}
@@ -125,6 +146,7 @@ func (mgr *Manager) CreateProvider(w http.ResponseWriter, r *http.Request) *outp
 		mgr.logger.Error(err)
 		return output.JsonErrInternal(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetDNSProvider, p.ID, nil, p)
 
 	// write response
 	response := &providerResponse{}
@@ -6,8 +6,11 @@ import (
 	"certwarden-backend/pkg/challenges/providers/dns01cloudflare"
 	"certwarden-backend/pkg/challenges/providers/dns01goacme"
 	"certwarden-backend/pkg/challenges/providers/dns01manual"
+	"certwarden-backend/pkg/challenges/providers/dns01rfc2136"
 	"certwarden-backend/pkg/challenges/providers/dnspersist01manual"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"errors"
@@ -29,6 +32,7 @@ type modifyPayload struct {
 	// optional
 	Domains                  []string `json:"domains,omitempty"`
 	PostProvisionWaitSeconds *int     `json:"post_resource_provision_wait"`
+	Priority                 *int     `json:"priority"`
 
 	// plus only one of these
 	Http01InternalConfig     *http01internal.Config     `json:"http_01_internal,omitempty"`
@@ -37,7 +41,9 @@ type modifyPayload struct {
 	Dns01AcmeShConfig        *dns01acmesh.Config        `json:"dns_01_acme_sh,omitempty"`
 	Dns01CloudflareConfig    *dns01cloudflare.Config    `json:"dns_01_cloudflare,omitempty"`
 	Dns01GoAcmeConfig        *dns01goacme.Config        `json:"dns_01_go_acme,omitempty"`
+	Dns01Rfc2136Config       *dns01rfc2136.Config       `json:"dns_01_rfc2136,omitempty"`
 	DnsPersist01ManualConfig *dnspersist01manual.Config `json:"dns_persist_01_manual,omitempty"`
+	TlsAlpn01InternalConfig  *tlsalpn01internal.Config  `json:"tls_alpn_01_internal,omitempty"`
 }
 
 // ModifyProvider modifies the provider specified by the ID in manager with the specified
@@ -86,9 +92,20 @@ func (mgr *Manager) ModifyProvider(w http.ResponseWriter, r *http.Request) *outp
 		return output.JsonErrValidationFailed(err)
 	}
 
-	// if domains included, validate domains
-	if len(payload.Domains) > 0 {
-		err = mgr.unsafeValidateDomains(payload.Domains, p)
+	before := audit.Snapshot(p)
+
+	// if domains or priority included, validate domains (at the new priority)
+	newPriority := p.Priority
+	if payload.Priority != nil {
+		newPriority = *payload.Priority
+	}
+	if len(payload.Domains) > 0 || payload.Priority != nil {
+		newDomains := p.Domains
+		if len(payload.Domains) > 0 {
+			newDomains = payload.Domains
+		}
+
+		err = mgr.unsafeValidateDomains(newDomains, newPriority, p)
 		if err != nil {
 			err = fmt.Errorf("failed to validate domains (%s)", err)
 			mgr.logger.Debug(err)
@@ -123,10 +140,18 @@ func (mgr *Manager) ModifyProvider(w http.ResponseWriter, r *http.Request) *outp
 		configCount++
 		pCfg = payload.Dns01GoAcmeConfig
 	}
+	if payload.Dns01Rfc2136Config != nil {
+		configCount++
+		pCfg = payload.Dns01Rfc2136Config
+	}
 	if payload.DnsPersist01ManualConfig != nil {
 		configCount++
 		pCfg = payload.DnsPersist01ManualConfig
 	}
+	if payload.TlsAlpn01InternalConfig != nil {
+		configCount++
+		pCfg = payload.TlsAlpn01InternalConfig
+	}
 
 	// check config count, also error on wrong config type
 	if configCount > 1 {
@@ -184,6 +209,14 @@ func (mgr *Manager) ModifyProvider(w http.ResponseWriter, r *http.Request) *outp
 			}
 			err = pServ.UpdateService(mgr.childApp, payload.Dns01GoAcmeConfig)
 
+		case *dns01rfc2136.Service:
+			if payload.Dns01Rfc2136Config == nil {
+				err = errInvalidProviderConfig
+				mgr.logger.Debug(err)
+				return output.JsonErrValidationFailed(err)
+			}
+			err = pServ.UpdateService(mgr.childApp, payload.Dns01Rfc2136Config)
+
 		case *dnspersist01manual.Service:
 			if payload.DnsPersist01ManualConfig == nil {
 				err = errInvalidProviderConfig
@@ -192,6 +225,14 @@ func (mgr *Manager) ModifyProvider(w http.ResponseWriter, r *http.Request) *outp
 			}
 			err = pServ.UpdateService(mgr.childApp, payload.DnsPersist01ManualConfig)
 
+		case *tlsalpn01internal.Service:
+			if payload.TlsAlpn01InternalConfig == nil {
+				err = errInvalidProviderConfig
+				mgr.logger.Debug(err)
+				return output.JsonErrValidationFailed(err)
+			}
+			err = pServ.UpdateService(mgr.childApp, payload.TlsAlpn01InternalConfig)
+
 		default:
 			// default fail
 			err = errors.New("provider service is unsupported, please report this as a bug to developer")
@@ -211,7 +252,7 @@ func (mgr *Manager) ModifyProvider(w http.ResponseWriter, r *http.Request) *outp
 	}
 
 	// update any internal config changes
-	mgr.unsafeUpdateProviderDomains(p, payload.Domains)
+	mgr.unsafeUpdateProviderDomains(p, payload.Domains, newPriority)
 
 	if payload.PostProvisionWaitSeconds != nil {
 		p.PostProvisionWaitSeconds = *payload.PostProvisionWaitSeconds
@@ -224,6 +265,7 @@ func (mgr *Manager) ModifyProvider(w http.ResponseWriter, r *http.Request) *outp
 		mgr.logger.Error(err)
 		return output.JsonErrInternal(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetDNSProvider, p.ID, before, p)
 
 	// write response
 	response := &providerResponse{}
@@ -8,10 +8,8 @@ import (
 	"github.com/julienschmidt/httprouter"
 )
 
-// challengeHandler responds to the ACME http-01 challenge path. If the requested
-// token exists in this service's resources, the keyAuth bytes are sent back to
-// the client. If the token is not in the service's resources, a 404 reply is sent.
-func (service *Service) challengeHandler(w http.ResponseWriter, r *http.Request) {
+// SetNoCacheHeaders sets headers on w to prevent caching and sniffing of challenge responses
+func SetNoCacheHeaders(w http.ResponseWriter) {
 	// direct no caching, but include some backup options to try and cover all bases to ensure
 	// the freshest response is always used
 	w.Header().Set("Cache-Control", "no-store, no-cache, max-age=0, must-revalidate, proxy-revalidate")
@@ -21,27 +19,22 @@ func (service *Service) challengeHandler(w http.ResponseWriter, r *http.Request)
 
 	// do not allow sniffing
 	w.Header().Set("X-Content-Type-Options", "nosniff")
+}
 
-	// token from the client request
-	token := httprouter.ParamsFromContext(r.Context()).ByName("token")
-
+// writeResource writes the keyAuth bytes for token to the client, if token exists in this
+// service's resources. If the token does not exist, nothing is written and false is returned.
+func (service *Service) writeResource(w http.ResponseWriter, r *http.Request, token string) bool {
 	// try to read resource
 	keyAuth, exists := service.provisionedResources.Read(token)
-
-	// resource not available, 404
 	if !exists {
-		service.logger.Debugf("http-01 challenge resource %s not found", token)
-
-		// write status 404
-		w.WriteHeader(http.StatusNotFound)
-
-		// done / exit
-		return
+		return false
 	}
 
 	// token was found, write it
 	service.logger.Debugf("writing resource (name: %s) to http-01 client", token)
 
+	SetNoCacheHeaders(w)
+
 	// convert value to content reader for output
 	contentReader := bytes.NewReader([]byte(keyAuth))
 
@@ -50,4 +43,33 @@ func (service *Service) challengeHandler(w http.ResponseWriter, r *http.Request)
 
 	// ServeContent (filename is not needed here since Content-Type is set explicitly above)
 	http.ServeContent(w, r, "", time.Time{}, contentReader)
+
+	return true
+}
+
+// challengeHandler responds to the ACME http-01 challenge path. If the requested
+// token exists in this service's resources, the keyAuth bytes are sent back to
+// the client. If the token is not in the service's resources, a 404 reply is sent.
+func (service *Service) challengeHandler(w http.ResponseWriter, r *http.Request) {
+	// token from the client request
+	token := httprouter.ParamsFromContext(r.Context()).ByName("token")
+
+	// resource not available, 404
+	if !service.writeResource(w, r, token) {
+		service.logger.Debugf("http-01 challenge resource %s not found", token)
+
+		SetNoCacheHeaders(w)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ServeAppListenerChallenge writes the resource for token to the client if this service
+// is configured to serve on the app's listener and the token exists. If nothing was
+// written, false is returned.
+func (service *Service) ServeAppListenerChallenge(w http.ResponseWriter, r *http.Request, token string) bool {
+	if !service.appListener {
+		return false
+	}
+
+	return service.writeResource(w, r, token)
 }
@@ -2,9 +2,24 @@ package http01internal
 
 import (
 	"certwarden-backend/pkg/acme"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
+// sharedResourcePath returns the path of token's resource file in the shared directory
+func (service *Service) sharedResourcePath(token string) (string, error) {
+	// tokens are base64url per rfc8555, but ensure a token can't escape the directory
+	if token == "" || token != filepath.Base(token) || strings.HasPrefix(token, ".") {
+		return "", fmt.Errorf("http-01 token %s is not valid for a shared directory file", token)
+	}
+
+	return filepath.Join(service.sharedDirectory, token), nil
+}
+
 // Provision adds a resource to host
 func (service *Service) Provision(_ string, token string, keyAuth acme.KeyAuth) error {
 	// add new entry
@@ -18,6 +33,20 @@ func (service *Service) Provision(_ string, token string, keyAuth acme.KeyAuth)
 		return err
 	}
 
+	// shared directory (for another web server to serve)
+	if service.sharedDirectory != "" {
+		path, err := service.sharedResourcePath(token)
+		if err == nil {
+			err = os.WriteFile(path, []byte(keyAuth), 0644)
+		}
+		if err != nil {
+			err = fmt.Errorf("http-01 resource %s failed to write to shared directory (%s)", token, err)
+			service.logger.Error(err)
+			_, _ = service.provisionedResources.Pop(token)
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -33,5 +62,16 @@ func (service *Service) Deprovision(_ string, token string, _ acme.KeyAuth) erro
 		return fmt.Errorf("http-01 resource %s failed to delete", token)
 	}
 
+	// shared directory
+	if service.sharedDirectory != "" {
+		path, err := service.sharedResourcePath(token)
+		if err == nil {
+			err = os.Remove(path)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("http-01 resource %s failed to delete from shared directory (%s)", token, err)
+		}
+	}
+
 	return nil
 }
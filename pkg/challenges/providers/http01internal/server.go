@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -14,58 +15,77 @@ const httpServerReadTimeout = 5 * time.Second
 const httpServerWriteTimeout = 10 * time.Second
 const httpServerIdleTimeout = 1 * time.Minute
 
+// startServer starts a challenge server on each of the service's bind addresses (or on all
+// interfaces if none are specified). If any address cannot be bound, no servers are started.
 func (service *Service) startServer() (err error) {
-	// make child context for stopping server
-	ctx, stopServer := context.WithCancel(service.shutdownContext)
-	service.stopServerFunc = stopServer
+	// if no addresses are specified, listen on all interfaces
+	hostNames := service.bindAddresses
+	if len(hostNames) == 0 {
+		hostNames = []string{""}
+	}
 
-	// err chan for stop
-	service.stopErrChan = make(chan error)
+	// create listener for each web server
+	listeners := []net.Listener{}
+	for _, hostName := range hostNames {
+		servAddr := net.JoinHostPort(hostName, strconv.Itoa(service.port))
 
-	// configure webserver
+		service.logger.Infof("attempting to start http-01 challenge server on %s.", servAddr)
 
-	// TODO: modify to allow specifying specific interface addresses
-	hostName := ""
+		ln, err := net.Listen("tcp", servAddr)
+		if err != nil {
+			service.logger.Error(fmt.Errorf("failed to start http-01 challenge server, cannot bind to %s (%s)", servAddr, err))
 
-	servAddr := fmt.Sprintf("%s:%d", hostName, service.port)
-	srv := &http.Server{
-		Addr:         servAddr,
-		Handler:      service.routes(),
-		ReadTimeout:  httpServerReadTimeout,
-		WriteTimeout: httpServerWriteTimeout,
-		IdleTimeout:  httpServerIdleTimeout,
-	}
+			// close any listeners that were already created
+			for i := range listeners {
+				_ = listeners[i].Close()
+			}
 
-	// no need to keep these connections alive
-	srv.SetKeepAlivesEnabled(false)
+			return err
+		}
+		listeners = append(listeners, ln)
+	}
 
-	// launch webserver
-	service.logger.Infof("attempting to start http-01 challenge server on %s.", servAddr)
 	if service.port != 80 {
 		service.logger.Warnf("http-01 challenge server is not configured on port 80; internet "+
 			"facing port 80 must be proxied to port %d to function.", service.port)
 	}
 
-	// create listener for web server
-	ln, err := net.Listen("tcp", servAddr)
-	if err != nil {
-		service.logger.Error(fmt.Errorf("failed to start http-01 challenge server, cannot bind to %s (%s)", servAddr, err))
-		return err
-	}
-
-	// start server
-	service.shutdownWaitgroup.Add(1)
-	go func() {
-		defer service.shutdownWaitgroup.Done()
-		defer func() { _ = ln.Close }()
+	// make child context for stopping server(s)
+	ctx, stopServer := context.WithCancel(service.shutdownContext)
+	service.stopServerFunc = stopServer
 
-		err := srv.Serve(ln)
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			service.logger.Errorf("http01internal server returned error (%s)", err)
+	// err chan for stop (buffered so the result isn't blocked if Stop is never called)
+	service.stopErrChan = make(chan error, 1)
+
+	// configure and launch webservers
+	servers := []*http.Server{}
+	for _, ln := range listeners {
+		srv := &http.Server{
+			Addr:         ln.Addr().String(),
+			Handler:      service.routes(),
+			ReadTimeout:  httpServerReadTimeout,
+			WriteTimeout: httpServerWriteTimeout,
+			IdleTimeout:  httpServerIdleTimeout,
 		}
-		service.logger.Infof("http-01 challenge server (%s) shutdown complete", servAddr)
 
-	}()
+		// no need to keep these connections alive
+		srv.SetKeepAlivesEnabled(false)
+
+		servers = append(servers, srv)
+
+		// start server
+		service.shutdownWaitgroup.Add(1)
+		go func(srv *http.Server, ln net.Listener) {
+			defer service.shutdownWaitgroup.Done()
+			defer func() { _ = ln.Close }()
+
+			err := srv.Serve(ln)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				service.logger.Errorf("http01internal server returned error (%s)", err)
+			}
+			service.logger.Infof("http-01 challenge server (%s) shutdown complete", srv.Addr)
+		}(srv, ln)
+	}
 
 	// monitor shutdown context
 	go func() {
@@ -75,13 +95,17 @@ func (service *Service) startServer() (err error) {
 		ctx, cancel := context.WithTimeout(context.Background(), maxShutdownTime)
 		defer cancel()
 
-		err = srv.Shutdown(ctx)
-		if err != nil {
-			service.logger.Errorf("error shutting down http-01 challenge server %s (%s)", servAddr, err)
+		var shutdownErrs []error
+		for _, srv := range servers {
+			err := srv.Shutdown(ctx)
+			if err != nil {
+				service.logger.Errorf("error shutting down http-01 challenge server %s (%s)", srv.Addr, err)
+				shutdownErrs = append(shutdownErrs, err)
+			}
 		}
 
 		// send shutdown result to err chan
-		service.stopErrChan <- err
+		service.stopErrChan <- errors.Join(shutdownErrs...)
 	}()
 
 	return nil
@@ -6,6 +6,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 var (
 	errServiceComponent = errors.New("necessary http-01 internal challenge service component is missing")
 	errConfigComponent  = errors.New("necessary http-01 config option missing")
+	errNoServeMethod    = errors.New("http-01 config must specify at least one of port, serve_on_app_listener, or shared_directory")
 )
 
 // App interface is for connecting to the main app
@@ -32,6 +35,9 @@ type Service struct {
 	stopServerFunc    context.CancelFunc
 	stopErrChan       chan error
 	port              int
+	bindAddresses     []string
+	appListener       bool
+	sharedDirectory   string
 	// map[token]keyAuth - token is the http resource and keyAuth is the data served
 	provisionedResources *safemap.SafeMap[acme.KeyAuth]
 }
@@ -42,8 +48,13 @@ func (service *Service) AcmeChallengeType() acme.ChallengeType {
 }
 
 // Stop is used for any actions needed prior to deleting this provider. For http-01
-// internal, the http server must be shutdown.
+// internal, the http server(s) must be shutdown.
 func (service *Service) Stop() (err error) {
+	// no server running (e.g. app listener or shared directory only)
+	if service.stopServerFunc == nil {
+		return nil
+	}
+
 	// stop server
 	service.stopServerFunc()
 
@@ -77,7 +88,18 @@ func (service *Service) Stop() (err error) {
 
 // Configuration options
 type Config struct {
+	// Port is the port of this provider's challenge server; if nil or 0, a challenge server
+	// is not started (the app listener and/or shared directory must be used instead)
 	Port *int `yaml:"port" json:"port"`
+	// BindAddresses are the interface addresses the challenge server listens on; if none
+	// are specified, the server listens on all interfaces
+	BindAddresses []string `yaml:"bind_addresses,omitempty" json:"bind_addresses,omitempty"`
+	// ServeOnAppListener serves challenges on the app's own http(s) listener(s) under
+	// /.well-known/acme-challenge/
+	ServeOnAppListener *bool `yaml:"serve_on_app_listener,omitempty" json:"serve_on_app_listener,omitempty"`
+	// SharedDirectory is a directory that challenge resources are also written to, so an
+	// existing web server can serve them (it should be served as /.well-known/acme-challenge/)
+	SharedDirectory *string `yaml:"shared_directory,omitempty" json:"shared_directory,omitempty"`
 }
 
 // NewService creates a new service
@@ -98,11 +120,40 @@ func NewService(app App, cfg *Config) (*Service, error) {
 	// allocate resources map
 	service.provisionedResources = safemap.NewSafeMap[acme.KeyAuth]()
 
-	// set port
-	if cfg.Port == nil {
-		return nil, errConfigComponent
+	// set port & addresses
+	if cfg.Port != nil {
+		if *cfg.Port < 0 || *cfg.Port > 65535 {
+			return nil, fmt.Errorf("http-01 port %d is invalid", *cfg.Port)
+		}
+		service.port = *cfg.Port
+	}
+	service.bindAddresses = slices.Clone(cfg.BindAddresses)
+	if len(service.bindAddresses) > 0 && service.port == 0 {
+		return nil, errors.New("http-01 bind addresses require a port")
+	}
+
+	// app listener
+	service.appListener = cfg.ServeOnAppListener != nil && *cfg.ServeOnAppListener
+
+	// shared directory
+	if cfg.SharedDirectory != nil && *cfg.SharedDirectory != "" {
+		info, err := os.Stat(*cfg.SharedDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("http-01 shared directory is not accessible (%s)", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("http-01 shared directory %s is not a directory", *cfg.SharedDirectory)
+		}
+		service.sharedDirectory = *cfg.SharedDirectory
+	}
+
+	// must serve challenges somehow
+	if service.port == 0 && !service.appListener && service.sharedDirectory == "" {
+		if cfg.Port == nil {
+			return nil, errConfigComponent
+		}
+		return nil, errNoServeMethod
 	}
-	service.port = *cfg.Port
 
 	// parent shutdown context
 	service.shutdownContext = app.GetShutdownContext()
@@ -110,15 +161,34 @@ func NewService(app App, cfg *Config) (*Service, error) {
 	// parent shutdown wg
 	service.shutdownWaitgroup = app.GetShutdownWaitGroup()
 
-	// start web server for http01 challenges
-	err := service.startServer()
-	if err != nil {
-		return nil, err
+	// start web server(s) for http01 challenges
+	if service.port != 0 {
+		err := service.startServer()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return service, nil
 }
 
+// serverConfigChanged returns true if cfg is different from the service's current config
+func (service *Service) serverConfigChanged(cfg *Config) bool {
+	port := 0
+	if cfg.Port != nil {
+		port = *cfg.Port
+	}
+	sharedDirectory := ""
+	if cfg.SharedDirectory != nil {
+		sharedDirectory = *cfg.SharedDirectory
+	}
+
+	return port != service.port ||
+		!slices.Equal(cfg.BindAddresses, service.bindAddresses) ||
+		(cfg.ServeOnAppListener != nil && *cfg.ServeOnAppListener) != service.appListener ||
+		sharedDirectory != service.sharedDirectory
+}
+
 // Update Service updates the Service to use the new config
 func (service *Service) UpdateService(app App, cfg *Config) (err error) {
 	// if no config, error
@@ -126,8 +196,8 @@ func (service *Service) UpdateService(app App, cfg *Config) (err error) {
 		return errServiceComponent
 	}
 
-	// if port changed, stop server and remake service
-	if cfg.Port != nil && *cfg.Port != service.port {
+	// if config changed, stop server and remake service
+	if service.serverConfigChanged(cfg) {
 		// stop old server
 		err = service.Stop()
 		if err != nil {
@@ -138,10 +208,12 @@ func (service *Service) UpdateService(app App, cfg *Config) (err error) {
 		newServ, err := NewService(app, cfg)
 		if err != nil {
 			// if failed to make, restart old server
-			errRestart := service.startServer()
-			if errRestart != nil {
-				service.logger.Panicf("failed to restart http 01 server leaving http 01 internal provider in an unstable state")
-				return errRestart
+			if service.port != 0 {
+				errRestart := service.startServer()
+				if errRestart != nil {
+					service.logger.Panicf("failed to restart http 01 server leaving http 01 internal provider in an unstable state")
+					return errRestart
+				}
 			}
 			return err
 		}
@@ -29,7 +29,8 @@ type Manager struct {
 	configFile string
 	nextId     int
 	providers  []*provider
-	dP         map[string]*provider // domain -> provider
+	dP         map[string][]*provider // domain rule key -> providers (in priority order)
+	rules      map[string]*domainRule // domain rule key -> rule
 	mu         sync.RWMutex
 }
 
@@ -42,7 +43,8 @@ func MakeManager(app application, cfg Config) (mgr *Manager, err error) {
 		configFile: app.GetConfigFilenameWithPath(),
 		nextId:     0,
 		// []*providers
-		dP: make(map[string]*provider), // domain -> provider
+		dP:    make(map[string][]*provider), // domain rule key -> providers
+		rules: make(map[string]*domainRule), // domain rule key -> rule
 	}
 
 	// get all provider cfgs as array
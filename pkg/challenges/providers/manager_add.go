@@ -6,8 +6,10 @@ import (
 	"certwarden-backend/pkg/challenges/providers/dns01cloudflare"
 	"certwarden-backend/pkg/challenges/providers/dns01goacme"
 	"certwarden-backend/pkg/challenges/providers/dns01manual"
+	"certwarden-backend/pkg/challenges/providers/dns01rfc2136"
 	"certwarden-backend/pkg/challenges/providers/dnspersist01manual"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
 	"certwarden-backend/pkg/randomness"
 	"errors"
 	"reflect"
@@ -20,7 +22,7 @@ import (
 func (mgr *Manager) unsafeAddProvider(internalCfg InternalConfig, cfg providerConfig) (*provider, error) {
 	// verify every domain ir properly formatted, or verify this is wildcard cfg (* only)
 	// and also verify all domains are available in manager
-	err := mgr.unsafeValidateDomains(internalCfg.Domains, nil)
+	err := mgr.unsafeValidateDomains(internalCfg.Domains, internalCfg.Priority, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -47,12 +49,18 @@ func (mgr *Manager) unsafeAddProvider(internalCfg InternalConfig, cfg providerCo
 	case *dns01goacme.Config:
 		serv, err = dns01goacme.NewService(mgr.childApp, realCfg)
 
+	case *dns01rfc2136.Config:
+		serv, err = dns01rfc2136.NewService(mgr.childApp, realCfg)
+
 	case *dnspersist01manual.Config:
 		// todo: remove this if this Config ever has any values added to it
 		cfg = new(dnspersist01manual.Config)
 
 		serv, err = dnspersist01manual.NewService(mgr.childApp, realCfg)
 
+	case *tlsalpn01internal.Config:
+		serv, err = tlsalpn01internal.NewService(mgr.childApp, realCfg)
+
 	default:
 		// default fail
 		return nil, errors.New("cannot create provider service, unsupported provider cfg")
@@ -74,6 +82,7 @@ func (mgr *Manager) unsafeAddProvider(internalCfg InternalConfig, cfg providerCo
 		Tag:                      randomness.GenerateInsecureString(10),
 		Domains:                  internalCfg.Domains,
 		PostProvisionWaitSeconds: internalCfg.PostProvisionWaitSeconds,
+		Priority:                 internalCfg.Priority,
 		Type:                     typeOf,
 		Config:                   cfg,
 		Service:                  serv,
@@ -86,10 +95,7 @@ func (mgr *Manager) unsafeAddProvider(internalCfg InternalConfig, cfg providerCo
 	mgr.providers = append(mgr.providers, p)
 
 	// add each domain to domain map
-	for _, domain := range internalCfg.Domains {
-		// add domain to domains map
-		mgr.dP[domain] = p
-	}
+	mgr.unsafeAddProviderDomains(p)
 
 	return p, nil
 }
@@ -4,9 +4,7 @@ package providers
 // and deletes its domains. It MUST be called from a Locked thread.
 func (mgr *Manager) unsafeDeleteProvider(p *provider) {
 	// delete each domain that used provider
-	for _, domain := range p.Domains {
-		delete(mgr.dP, domain)
-	}
+	mgr.unsafeRemoveProviderDomains(p)
 
 	// delete provider from provider slice
 	for i, oneP := range mgr.providers {
@@ -0,0 +1,23 @@
+package providers
+
+import (
+	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"net/http"
+)
+
+// ServeHttp01AppListenerChallenge writes the http-01 resource for token to the client if any
+// http01internal provider that serves on the app's listener has the resource. If no provider
+// wrote the resource, false is returned.
+func (mgr *Manager) ServeHttp01AppListenerChallenge(w http.ResponseWriter, r *http.Request, token string) bool {
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
+
+	for _, p := range mgr.providers {
+		http01Serv, ok := p.Service.(*http01internal.Service)
+		if ok && http01Serv.ServeAppListenerChallenge(w, r, token) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,377 @@
+package providers
+
+import (
+	"certwarden-backend/pkg/validation"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// prefix of a domain that is a regex rule (e.g. regex:host[0-9]+\.example\.com)
+const regexRulePrefix = "regex:"
+
+// domainRuleKind is the kind of a provider domain, which determines what it matches
+type domainRuleKind int
+
+const (
+	// domain or ip address; a domain also matches all of its subdomains
+	domainRuleExact domainRuleKind = iota
+	// *.example.com or .example.com; matches all subdomains of example.com (but not
+	// example.com itself)
+	domainRuleSuffix
+	// regex:pattern; matches any fqdn the pattern fully matches
+	domainRuleRegex
+	// * (catch-all); matches anything
+	domainRuleWildcard
+)
+
+// domainRule is the parsed form of one of a provider's domains
+type domainRule struct {
+	kind domainRuleKind
+	// canonical form of the rule, domains with the same key always match the same fqdns
+	key string
+	// for domainRuleSuffix, the domain the rule matches subdomains of; for domainRuleRegex,
+	// the domain at the (literal) end of the pattern, if any (e.g. example.com for
+	// host[0-9]+\.example\.com), which is used as the rule's suffix for precedence
+	suffix string
+	// for domainRuleRegex, the compiled (and fully anchored) pattern
+	regex *regexp.Regexp
+}
+
+// parseDomainRule parses domain into a domainRule. An error is returned if domain is not
+// a valid domain, ip address, suffix rule, regex rule, or the catch-all.
+func parseDomainRule(domain string) (*domainRule, error) {
+	switch {
+	// catch-all
+	case domain == "*":
+		return &domainRule{kind: domainRuleWildcard, key: domain}, nil
+
+	// regex
+	case strings.HasPrefix(domain, regexRulePrefix):
+		pattern := strings.TrimPrefix(domain, regexRulePrefix)
+		if pattern == "" {
+			return nil, fmt.Errorf("regex rule %s has an empty pattern", domain)
+		}
+
+		// always match the full fqdn
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("regex rule %s is not a valid regular expression (%s)", domain, err)
+		}
+
+		return &domainRule{kind: domainRuleRegex, key: domain, suffix: regexSuffix(re), regex: re}, nil
+
+	// suffix (*.example.com and .example.com are the same rule)
+	case strings.HasPrefix(domain, "*.") || strings.HasPrefix(domain, "."):
+		suffix := strings.TrimPrefix(strings.TrimPrefix(domain, "*"), ".")
+		if !validation.DomainValid(suffix, false) {
+			return nil, fmt.Errorf("suffix rule %s is not a validly formatted domain with a *. or . prefix", domain)
+		}
+
+		return &domainRule{kind: domainRuleSuffix, key: "*." + suffix, suffix: suffix}, nil
+	}
+
+	// domain or ip address
+	if !validation.DomainValid(domain, false) && !validation.IPAddressValid(domain) {
+		return nil, fmt.Errorf("domain %s is not a validly formatted domain, ip address, suffix rule (e.g. *.example.com or .example.com), or regex rule (e.g. %shost[0-9]+\\.example\\.com)", domain, regexRulePrefix)
+	}
+
+	return &domainRule{kind: domainRuleExact, key: domain}, nil
+}
+
+// unsafeMatchRule returns the key of the rule that selects the providers for fqdn, the
+// most specific rule is used:
+//  1. a domain or ip address that exactly matches
+//  2. the rule with the longest matching suffix; domains match their subdomains, suffix
+//     rules match the subdomains of their suffix, and regex rules match any fqdn the
+//     pattern fully matches (their suffix is the domain at the literal end of the pattern,
+//     or none). If two suffixes are the same length, a regex rule is used over a suffix
+//     rule, which is used over a domain.
+//  3. the catch-all
+//
+// An error is returned if no rule matches or if more than one regex rule is equally
+// specific (validation rejects regex rules that overlap, so this only happens if rules
+// were added without validation).
+func (mgr *Manager) unsafeMatchRule(fqdn string) (string, error) {
+	// exact
+	rule, exists := mgr.rules[fqdn]
+	if exists && rule.kind == domainRuleExact {
+		return rule.key, nil
+	}
+
+	// ip addresses only ever match exactly (or the catch-all)
+	if !validation.IPAddressValid(fqdn) {
+		// most specific
+		matchKeys := []string{}
+		matchLen := -1
+		matchRank := -1
+		for _, rule := range mgr.rules {
+			if !rule.matchesSubdomain(fqdn) {
+				continue
+			}
+
+			suffixLen, rank := rule.specificity()
+			if suffixLen > matchLen || (suffixLen == matchLen && rank > matchRank) {
+				matchKeys = []string{rule.key}
+				matchLen = suffixLen
+				matchRank = rank
+			} else if suffixLen == matchLen && rank == matchRank {
+				matchKeys = append(matchKeys, rule.key)
+			}
+		}
+
+		if len(matchKeys) > 1 {
+			slices.Sort(matchKeys)
+			return "", fmt.Errorf("the specified fqdn (%s) is ambiguous, it matches more than one regex rule (%s)", fqdn, strings.Join(matchKeys, ", "))
+		} else if len(matchKeys) == 1 {
+			return matchKeys[0], nil
+		}
+	}
+
+	// catch-all
+	_, exists = mgr.rules["*"]
+	if exists {
+		return "*", nil
+	}
+
+	return "", fmt.Errorf("could not find a challenge provider for the specified fqdn (%s)", fqdn)
+}
+
+// matchesSubdomain returns true if rule matches fqdn other than as an exact match (i.e.,
+// as a subdomain of a domain or suffix rule, or by regex)
+func (rule *domainRule) matchesSubdomain(fqdn string) bool {
+	switch rule.kind {
+	case domainRuleExact:
+		// include period to avoid matching something like hellodomain.com to domain.com 's provider
+		return strings.HasSuffix(fqdn, "."+rule.key)
+	case domainRuleSuffix:
+		return strings.HasSuffix(fqdn, "."+rule.suffix)
+	case domainRuleRegex:
+		return rule.regex.MatchString(fqdn)
+	}
+
+	return false
+}
+
+// specificity returns the length of rule's suffix and the rank of rule's kind (higher is
+// more specific), which determine precedence when more than one rule matches
+func (rule *domainRule) specificity() (suffixLen int, rank int) {
+	switch rule.kind {
+	case domainRuleExact:
+		return len(rule.key), 0
+	case domainRuleSuffix:
+		return len(rule.suffix), 1
+	case domainRuleRegex:
+		return len(rule.suffix), 2
+	}
+
+	return -1, -1
+}
+
+// regexSuffix returns the domain at the literal end of re (e.g. example.com for
+// ^(?:host[0-9]+\.example\.com)$), or an empty string if there isn't one
+func regexSuffix(re *regexp.Regexp) string {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return ""
+	}
+
+	lit, whole := trailingLiteral(parsed.Simplify())
+	// the domain starts after the first period (a literal that isn't the whole pattern
+	// may start part way through a label)
+	if !whole || strings.HasPrefix(lit, ".") {
+		_, lit, _ = strings.Cut(lit, ".")
+	}
+	if !validation.DomainValid(lit, false) {
+		return ""
+	}
+
+	return lit
+}
+
+// trailingLiteral returns the literal text re ends with and whether that is all of re
+func trailingLiteral(re *syntax.Regexp) (lit string, whole bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return strings.ToLower(string(re.Rune)), true
+
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return "", true
+
+	case syntax.OpCapture:
+		return trailingLiteral(re.Sub[0])
+
+	case syntax.OpConcat:
+		for i := len(re.Sub) - 1; i >= 0; i-- {
+			subLit, subWhole := trailingLiteral(re.Sub[i])
+			lit = subLit + lit
+			if !subWhole {
+				return lit, false
+			}
+		}
+		return lit, true
+	}
+
+	return "", false
+}
+
+// prog returns the compiled program of a (fully anchored) pattern that matches the same
+// names as rule. The catch-all has no pattern.
+func (rule *domainRule) prog() (*syntax.Prog, error) {
+	var pattern string
+	switch rule.kind {
+	case domainRuleExact:
+		// the domain and its subdomains
+		pattern = `^(?:(?:.+\.)?` + regexp.QuoteMeta(rule.key) + `)$`
+	case domainRuleSuffix:
+		pattern = `^(?:.+\.` + regexp.QuoteMeta(rule.suffix) + `)$`
+	case domainRuleRegex:
+		pattern = rule.regex.String()
+	default:
+		return nil, fmt.Errorf("rule %s has no pattern", rule.key)
+	}
+
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	return syntax.Compile(parsed.Simplify())
+}
+
+// rulesOverlap returns true if there is a name that both rule and other match. This is
+// decided by searching the product of the rules' automatons for a string of runes that
+// takes both of them to a match.
+func rulesOverlap(rule *domainRule, other *domainRule) bool {
+	progA, err := rule.prog()
+	if err != nil {
+		return false
+	}
+	progB, err := other.prog()
+	if err != nil {
+		return false
+	}
+
+	type statePair struct {
+		a uint32
+		b uint32
+	}
+	seen := make(map[statePair]struct{})
+	queue := []statePair{}
+	enqueue := func(pcsA []uint32, pcsB []uint32) {
+		for _, a := range pcsA {
+			for _, b := range pcsB {
+				pair := statePair{a: a, b: b}
+				if _, exists := seen[pair]; !exists {
+					seen[pair] = struct{}{}
+					queue = append(queue, pair)
+				}
+			}
+		}
+	}
+
+	pcsA, matchA := progClosure(progA, uint32(progA.Start))
+	pcsB, matchB := progClosure(progB, uint32(progB.Start))
+	if matchA && matchB {
+		return true
+	}
+	enqueue(pcsA, pcsB)
+
+	for len(queue) > 0 {
+		pair := queue[0]
+		queue = queue[1:]
+
+		instA := &progA.Inst[pair.a]
+		instB := &progB.Inst[pair.b]
+		if !runeRangesIntersect(instRuneRanges(instA), instRuneRanges(instB)) {
+			continue
+		}
+
+		// both consume a rune they have in common
+		pcsA, matchA = progClosure(progA, instA.Out)
+		pcsB, matchB = progClosure(progB, instB.Out)
+		if matchA && matchB {
+			return true
+		}
+		enqueue(pcsA, pcsB)
+	}
+
+	return false
+}
+
+// progClosure returns the rune consuming instructions of prog that are reachable from pc
+// without consuming any input, and whether a match is reachable that way. Empty width
+// assertions (e.g. ^ and $) are assumed to pass, which at worst finds an overlap that
+// doesn't exist.
+func progClosure(prog *syntax.Prog, pc uint32) (runePCs []uint32, match bool) {
+	seen := make(map[uint32]struct{})
+	stack := []uint32{pc}
+	for len(stack) > 0 {
+		pc = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, exists := seen[pc]; exists {
+			continue
+		}
+		seen[pc] = struct{}{}
+
+		inst := &prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			stack = append(stack, inst.Out, inst.Arg)
+		case syntax.InstCapture, syntax.InstNop, syntax.InstEmptyWidth:
+			stack = append(stack, inst.Out)
+		case syntax.InstMatch:
+			match = true
+		case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			runePCs = append(runePCs, pc)
+		}
+		// InstFail: dead end
+	}
+
+	return runePCs, match
+}
+
+// instRuneRanges returns the ranges of runes that inst (a rune consuming instruction)
+// consumes
+func instRuneRanges(inst *syntax.Inst) [][2]rune {
+	switch inst.Op {
+	case syntax.InstRuneAny:
+		return [][2]rune{{0, unicode.MaxRune}}
+	case syntax.InstRuneAnyNotNL:
+		return [][2]rune{{0, '\n' - 1}, {'\n' + 1, unicode.MaxRune}}
+	}
+
+	// InstRune, InstRune1
+	if len(inst.Rune) == 1 {
+		r := inst.Rune[0]
+		ranges := [][2]rune{{r, r}}
+		if syntax.Flags(inst.Arg)&syntax.FoldCase != 0 {
+			for fold := unicode.SimpleFold(r); fold != r; fold = unicode.SimpleFold(fold) {
+				ranges = append(ranges, [2]rune{fold, fold})
+			}
+		}
+		return ranges
+	}
+
+	ranges := [][2]rune{}
+	for i := 0; i+1 < len(inst.Rune); i += 2 {
+		ranges = append(ranges, [2]rune{inst.Rune[i], inst.Rune[i+1]})
+	}
+	return ranges
+}
+
+// runeRangesIntersect returns true if any rune is in both a and b
+func runeRangesIntersect(a [][2]rune, b [][2]rune) bool {
+	for _, rangeA := range a {
+		for _, rangeB := range b {
+			if rangeA[0] <= rangeB[1] && rangeB[0] <= rangeA[1] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,257 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newTestManager returns a Manager with the specified providers (without services)
+func newTestManager(t *testing.T, ps ...*provider) *Manager {
+	t.Helper()
+
+	mgr := &Manager{
+		logger: zap.NewNop().Sugar(),
+		dP:     make(map[string][]*provider),
+		rules:  make(map[string]*domainRule),
+	}
+	for _, p := range ps {
+		err := mgr.unsafeValidateDomains(p.Domains, p.Priority, nil)
+		if err != nil {
+			t.Fatalf("failed to add provider %d (%s)", p.ID, err)
+		}
+		mgr.providers = append(mgr.providers, p)
+		mgr.unsafeAddProviderDomains(p)
+	}
+
+	return mgr
+}
+
+func TestProvidersForPriority(t *testing.T) {
+	primary := &provider{ID: 0, Domains: []string{"example.com"}}
+	fallback := &provider{ID: 1, Domains: []string{"example.com", "other.com"}, Priority: 10}
+	secondary := &provider{ID: 2, Domains: []string{"example.com"}, Priority: 5}
+	mgr := newTestManager(t, fallback, primary, secondary)
+
+	ps, err := mgr.ProvidersFor("www.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 3 || ps[0] != primary || ps[1] != secondary || ps[2] != fallback {
+		t.Errorf("providers are not in priority order")
+	}
+
+	// same priority as an existing provider of the domain is rejected
+	err = mgr.unsafeValidateDomains([]string{"other.com"}, 10, nil)
+	if err == nil {
+		t.Errorf("expected duplicate priority to be rejected")
+	}
+	// unless it is the same provider
+	err = mgr.unsafeValidateDomains([]string{"other.com"}, 10, fallback)
+	if err != nil {
+		t.Errorf("expected provider's own priority to be valid (%s)", err)
+	}
+
+	// changing priority reorders
+	mgr.unsafeUpdateProviderDomains(fallback, nil, -1)
+	p, err := mgr.ProviderFor("example.com")
+	if err != nil || p != fallback {
+		t.Errorf("expected updated priority to make provider first")
+	}
+
+	// deleting removes the provider from all of its domains
+	mgr.unsafeDeleteProvider(fallback)
+	if _, err = mgr.ProvidersFor("other.com"); err == nil {
+		t.Errorf("expected no provider for deleted provider's domain")
+	}
+	ps, _ = mgr.ProvidersFor("example.com")
+	if len(ps) != 2 || ps[0] != primary {
+		t.Errorf("unexpected providers after delete")
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	apex := &provider{ID: 0, Domains: []string{"example.com"}}
+	corp := &provider{ID: 1, Domains: []string{"*.corp.example.com"}}
+	org := &provider{ID: 2, Domains: []string{".example.org", "192.0.2.1"}}
+	// regex rules that match names of a domain at the same priority aren't valid
+	hosts := &provider{ID: 3, Domains: []string{`regex:host[0-9]+\.example\.com`}, Priority: 1}
+	lab := &provider{ID: 4, Domains: []string{`regex:.*\.lab\.example\.com`}, Priority: 1}
+	catchAll := &provider{ID: 5, Domains: []string{"*"}}
+	mgr := newTestManager(t, apex, corp, org, hosts, lab, catchAll)
+
+	tests := map[string]*provider{
+		"example.com":             apex,
+		"www.example.com":         apex,
+		"corp.example.com":        apex, // suffix rules don't match the suffix itself
+		"a.b.corp.example.com":    corp,
+		"example.org":             catchAll,
+		"www.example.org":         org,
+		"host12.example.com":      hosts, // regex rule wins over a domain of the same length
+		"hostx.example.com":       apex,
+		"a.host1.example.com":     apex, // regex rules match the full fqdn
+		"192.0.2.1":               org,
+		"192.0.2.2":               catchAll,
+		"unrelated.example.net":   catchAll,
+		"www.lab.example.com":     lab,
+		"www.corp.example.com.au": catchAll,
+	}
+	for fqdn, expected := range tests {
+		p, err := mgr.ProviderFor(fqdn)
+		if err != nil {
+			t.Errorf("%s: unexpected error (%s)", fqdn, err)
+		} else if p != expected {
+			t.Errorf("%s: matched provider %d, expected %d", fqdn, p.ID, expected.ID)
+		}
+	}
+
+	// a suffix rule wins over a domain of the same length
+	sub := &provider{ID: 6, Domains: []string{"*.example.com"}}
+	mgr = newTestManager(t, apex, sub)
+	if p, _ := mgr.ProviderFor("www.example.com"); p != sub {
+		t.Errorf("expected suffix rule to be used over domain of the same length")
+	}
+	if p, _ := mgr.ProviderFor("example.com"); p != apex {
+		t.Errorf("expected domain to be used for exact match")
+	}
+
+	// a longer suffix wins over a regex rule
+	sub = &provider{ID: 7, Domains: []string{"a.b.example.com"}}
+	anyHost := &provider{ID: 8, Domains: []string{`regex:.*\.example\.com`}, Priority: 1}
+	mgr = newTestManager(t, sub, anyHost)
+	if p, _ := mgr.ProviderFor("x.a.b.example.com"); p != sub {
+		t.Errorf("expected longer domain to be used over regex rule")
+	}
+	if p, _ := mgr.ProviderFor("x.b.example.com"); p != anyHost {
+		t.Errorf("expected regex rule to be used")
+	}
+
+	// more than one equally specific regex rule is ambiguous (added without validation,
+	// which rejects this)
+	overlap := &provider{ID: 9, Domains: []string{`regex:host1[0-9]*\.example\.com`}}
+	mgr = newTestManager(t, hosts)
+	mgr.providers = append(mgr.providers, overlap)
+	mgr.unsafeAddProviderDomains(overlap)
+	if _, err := mgr.ProviderFor("host12.example.com"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected ambiguous regex match error, got %v", err)
+	}
+}
+
+func TestValidateDomainRules(t *testing.T) {
+	mgr := newTestManager(t, &provider{ID: 0, Domains: []string{".example.org"}})
+
+	invalid := [][]string{
+		{"*.example.org"},                 // same rule as .example.org, same priority
+		{"*.a.com", ".a.com"},             // same rule twice
+		{"regex:("},                       // bad regex
+		{"regex:"},                        // empty regex
+		{"*.bad domain.com"},              // bad suffix
+		{"**.example.com"},                // bad suffix
+		{"*", "example.com"},              // catch-all must be alone
+		{"example.com", "example.com..."}, // bad domain
+	}
+	for _, domains := range invalid {
+		if err := mgr.unsafeValidateDomains(domains, 0, nil); err == nil {
+			t.Errorf("expected %v to be invalid", domains)
+		}
+	}
+
+	valid := [][]string{
+		{"*.example.org"}, // at another priority (below)
+		{"example.org", "*.corp.example.com", `regex:host[0-9]+\.example\.com`},
+	}
+	for _, domains := range valid {
+		if err := mgr.unsafeValidateDomains(domains, 1, nil); err != nil {
+			t.Errorf("expected %v to be valid (%s)", domains, err)
+		}
+	}
+}
+
+func TestValidateRegexOverlap(t *testing.T) {
+	hosts := &provider{ID: 0, Domains: []string{`regex:host[0-9]+\.example\.com`}, Priority: 1}
+	mgr := newTestManager(t,
+		&provider{ID: 1, Domains: []string{"example.com", "www.example.org"}},
+		&provider{ID: 2, Domains: []string{"*.corp.example.net"}},
+		hosts,
+	)
+
+	tests := []struct {
+		domains  []string
+		priority int
+		valid    bool
+	}{
+		// regex rules that are equally specific and match the same names, at any priority
+		{[]string{`regex:host1[0-9]*\.example\.com`}, 2, false},
+		{[]string{`regex:(host|web)[0-9]\.example\.com`}, 2, false},
+		// matches a domain at another priority
+		{[]string{`regex:.*\.example\.org`}, 2, true},
+		// both match a configured domain
+		{[]string{`regex:w+\.example\.org`, `regex:.*w\.example\.org`}, 2, false},
+		// both match a name that isn't configured (x5.example.com)
+		{[]string{`regex:x[0-9]+\.example\.com`, `regex:[a-z]5\.example\.com`}, 2, false},
+		// not equally specific (the longer suffix always wins)
+		{[]string{`regex:host[0-9]+\.lab\.example\.com`}, 2, true},
+		// don't overlap
+		{[]string{`regex:web[0-9]+\.example\.com`}, 2, true},
+		// regex rule that matches a domain or suffix rule's names at the same priority
+		{[]string{`regex:web[0-9]+\.example\.com`}, 0, false},
+		{[]string{`regex:www\.example\.org`}, 0, false},
+		{[]string{`regex:[a-z]+\.corp\.example\.net`}, 0, false},
+		{[]string{`regex:[a-z]+\.corp\.example\.net`}, 1, true},
+		{[]string{"host1.example.com"}, 1, false},
+		{[]string{"host1.example.com"}, 0, true},
+		{[]string{"example.net", `regex:[a-z]+\.example\.net`}, 0, false},
+		{[]string{`regex:web[0-9]+\.example\.com`}, 3, true},
+	}
+	for _, test := range tests {
+		err := mgr.unsafeValidateDomains(test.domains, test.priority, nil)
+		if test.valid && err != nil {
+			t.Errorf("expected %v at priority %d to be valid (%s)", test.domains, test.priority, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("expected %v at priority %d to be invalid", test.domains, test.priority)
+		}
+	}
+
+	// a provider's own regex rule doesn't overlap itself
+	if err := mgr.unsafeValidateDomains([]string{`regex:host1[0-9]*\.example\.com`}, 1, hosts); err != nil {
+		t.Errorf("expected provider's own rules to be ignored (%s)", err)
+	}
+}
+
+func TestRulesOverlap(t *testing.T) {
+	tests := []struct {
+		rule    string
+		other   string
+		overlap bool
+	}{
+		{`regex:x[0-9]+\.example\.com`, `regex:[a-z]5\.example\.com`, true},
+		{`regex:x[0-9]+\.example\.com`, `regex:[a-w]5\.example\.com`, false},
+		{`regex:(ab)+\.example\.com`, `regex:a(ba)*b\.example\.com`, true},
+		{`regex:(ab)+\.example\.com`, `regex:(aab)+\.example\.com`, false},
+		{`regex:[a-z]{3}\.example\.com`, `regex:[a-z]{4,}\.example\.com`, false},
+		{`regex:(?i)WWW\.example\.com`, `regex:www\.example\.com`, true},
+		{`regex:.*\.example\.com`, "a.b.example.com", true},
+		{`regex:.*\.example\.com`, "example.com", true},
+		{`regex:host[0-9]\.example\.com`, "example.org", false},
+		{`regex:[a-z]+\.corp\.example\.net`, "*.corp.example.net", true},
+		{`regex:corp\.example\.net`, "*.corp.example.net", false},
+	}
+	for _, test := range tests {
+		rule, err := parseDomainRule(test.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := parseDomainRule(test.other)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if overlap := rulesOverlap(rule, other); overlap != test.overlap {
+			t.Errorf("expected overlap of %s and %s to be %t", test.rule, test.other, test.overlap)
+		}
+		if overlap := rulesOverlap(other, rule); overlap != test.overlap {
+			t.Errorf("expected overlap of %s and %s to be %t", test.other, test.rule, test.overlap)
+		}
+	}
+}
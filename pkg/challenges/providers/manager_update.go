@@ -1,23 +1,64 @@
 package providers
 
-// unsafeUpdateProviderDomains updates the domains serviced by a provider, if no domains
-// are specified, no modification is performed
-func (mgr *Manager) unsafeUpdateProviderDomains(p *provider, newDomains []string) {
-	// no domains == no-op
-	if len(newDomains) <= 0 {
-		return
-	}
+import (
+	"cmp"
+	"slices"
+)
 
+// unsafeUpdateProviderDomains updates the domains serviced by a provider and the provider's
+// priority for those domains, if no domains are specified, the existing domains are kept
+func (mgr *Manager) unsafeUpdateProviderDomains(p *provider, newDomains []string, newPriority int) {
 	// remove existing domain -> p mappings
-	for _, oldDomain := range p.Domains {
-		delete(mgr.dP, oldDomain)
+	mgr.unsafeRemoveProviderDomains(p)
+
+	// update p's domains and priority
+	if len(newDomains) > 0 {
+		p.Domains = newDomains
 	}
+	p.Priority = newPriority
+
+	// add each domain back to map
+	mgr.unsafeAddProviderDomains(p)
+}
+
+// unsafeAddProviderDomains adds p to the providers of each of its domains' rules, keeping
+// each rule's providers sorted by priority
+func (mgr *Manager) unsafeAddProviderDomains(p *provider) {
+	for _, domain := range p.Domains {
+		rule, err := parseDomainRule(domain)
+		if err != nil {
+			// should never happen, domains are validated before being added
+			mgr.logger.Errorf("provider mgr couldn't add domain %s for provider id %d (%s), report as bug to developer", domain, p.ID, err)
+			continue
+		}
+
+		ruleProviders := append(mgr.dP[rule.key], p)
+		slices.SortStableFunc(ruleProviders, func(a, b *provider) int {
+			return cmp.Compare(a.Priority, b.Priority)
+		})
+		mgr.dP[rule.key] = ruleProviders
+		mgr.rules[rule.key] = rule
+	}
+}
+
+// unsafeRemoveProviderDomains removes p from the providers of each of its domains' rules,
+// a rule with no remaining providers is removed entirely
+func (mgr *Manager) unsafeRemoveProviderDomains(p *provider) {
+	for _, domain := range p.Domains {
+		rule, err := parseDomainRule(domain)
+		if err != nil {
+			continue
+		}
 
-	// update p's domains
-	p.Domains = newDomains
+		ruleProviders := slices.DeleteFunc(mgr.dP[rule.key], func(oneP *provider) bool {
+			return oneP == p
+		})
 
-	// add each new domain to map
-	for _, newDomain := range newDomains {
-		mgr.dP[newDomain] = p
+		if len(ruleProviders) <= 0 {
+			delete(mgr.dP, rule.key)
+			delete(mgr.rules, rule.key)
+		} else {
+			mgr.dP[rule.key] = ruleProviders
+		}
 	}
 }
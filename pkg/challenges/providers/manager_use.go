@@ -1,44 +1,43 @@
 package providers
 
 import (
-	"fmt"
-	"strings"
+	"slices"
 )
 
 // ProviderFor returns the provider Service for the given acme Identifier. If
-// there is no provider for the Identifier, an error is returned instead.
+// there is no provider for the Identifier, an error is returned instead. When
+// more than one provider is configured for the Identifier, the first one (by
+// priority) is returned.
 func (mgr *Manager) ProviderFor(fqdn string) (*provider, error) {
-	mgr.mu.RLock()
-	defer mgr.mu.RUnlock()
-
-	// if exact domain is in the list, return its provider
-	p, exists := mgr.dP[fqdn]
-	if exists {
-		return p, nil
+	providers, err := mgr.ProvidersFor(fqdn)
+	if err != nil {
+		return nil, err
 	}
 
-	// find best match from options (if there is a provider for a more specific subdomain, choose that one)
-	providerDomain := ""
-	for domain := range mgr.dP {
-		// include period to avoid matching something like hellodomain.com to domain.com 's provider
-		if strings.HasSuffix(fqdn, "."+domain) {
-			// for a provider with the proper suffix, check length of existing match and update
-			// match if the new match is longer
-			if len(domain) > len(providerDomain) {
-				providerDomain = domain
-			}
-		}
-	}
-	// if a match was found, return its provider
-	if providerDomain != "" {
-		return mgr.dP[providerDomain], nil
-	}
+	return providers[0], nil
+}
+
+// ProvidersFor returns all of the providers for the given acme Identifier, in
+// the order they should be tried (i.e., the first provider is the primary and the
+// rest are fallbacks). If there is no provider for the Identifier, an error is
+// returned instead.
+func (mgr *Manager) ProvidersFor(fqdn string) ([]*provider, error) {
+	_, providers, err := mgr.MatchProviders(fqdn)
+	return providers, err
+}
+
+// MatchProviders returns the domain rule that matches the given acme Identifier
+// (see unsafeMatchRule for precedence) and the rule's providers, in the order they
+// should be tried. If there is no provider for the Identifier, an error is returned
+// instead.
+func (mgr *Manager) MatchProviders(fqdn string) (rule string, providers []*provider, err error) {
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
 
-	// if domain was not found, return wild provider if it exists
-	p, exists = mgr.dP["*"]
-	if exists {
-		return p, nil
+	rule, err = mgr.unsafeMatchRule(fqdn)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return nil, fmt.Errorf("could not find a challenge provider for the specified fqdn (%s)", fqdn)
+	return rule, slices.Clone(mgr.dP[rule]), nil
 }
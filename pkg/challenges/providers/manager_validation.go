@@ -1,17 +1,23 @@
 package providers
 
 import (
-	"certwarden-backend/pkg/validation"
 	"errors"
 	"fmt"
 )
 
 // unsafeValidateDomains verifies that the domains are all valid
-// and also that they're available in manager. p is optional and if specified
-// domains will also be condidered valid if they're not available but are
-// currently assigned to p.  If validation succeeds, nil is returned, if it
-// fails, an error is returned.
-func (mgr *Manager) unsafeValidateDomains(domains []string, p *provider) error {
+// and also that they're available in manager at the specified priority. Each
+// domain is a domain, ip address, suffix rule (*.example.com or .example.com),
+// regex rule (regex:pattern), or the catch-all (*). A domain may be configured
+// on more than one provider, but never on two providers with the same priority.
+// Regex rules are also checked for overlap with other rules: two regex rules that
+// are equally specific (see unsafeMatchRule) can't both match the same name, and a
+// regex rule can't match the same names as a domain or suffix rule with the same
+// priority.
+// p is optional and if specified domains will also be condidered valid if
+// they're not available but are currently assigned to p.  If validation
+// succeeds, nil is returned, if it fails, an error is returned.
+func (mgr *Manager) unsafeValidateDomains(domains []string, priority int, p *provider) error {
 	// verify every domain is properly formatted, or verify this is wildcard cfg (* only)
 	// and also verify all domains are available in manager
 
@@ -21,20 +27,92 @@ func (mgr *Manager) unsafeValidateDomains(domains []string, p *provider) error {
 	}
 
 	// validate domain names
+	ruleDomains := make(map[string]string) // rule key -> domain
+	rules := []*domainRule{}
 	for _, domain := range domains {
-		// check validity -or- wildcard
-		if !validation.DomainValid(domain, false) && !(len(domains) == 1 && domains[0] == "*") {
-			if domain == "*" {
-				return errors.New("when using wildcard domain * it must be the only specified domain on the provider")
-			}
-			return fmt.Errorf("domain %s is not a validly formatted domain", domain)
+		// check validity (domain, ip address, or rule) -or- wildcard
+		rule, err := parseDomainRule(domain)
+		if err != nil {
+			return err
+		}
+		if rule.kind == domainRuleWildcard && len(domains) != 1 {
+			return errors.New("when using wildcard domain * it must be the only specified domain on the provider")
+		}
+
+		// check for domains that are the same rule (e.g. *.example.com and .example.com),
+		// which would be ambiguous
+		otherDomain, exists := ruleDomains[rule.key]
+		if exists {
+			return fmt.Errorf("domains %s and %s overlap (they match exactly the same names)", otherDomain, domain)
 		}
+		ruleDomains[rule.key] = domain
+		rules = append(rules, rule)
 
 		// check manager availability
-		currentP, exists := mgr.dP[domain]
-		if exists && (p == nil || p != currentP) {
-			return fmt.Errorf("failed to configure domain %s, each domain can only be configured once", domain)
+		for _, currentP := range mgr.dP[rule.key] {
+			if currentP != p && currentP.Priority == priority {
+				return fmt.Errorf("failed to configure domain %s, each domain can only be configured once per priority (provider %d already uses priority %d for %s)", domain, currentP.ID, priority, rule.key)
+			}
+		}
+	}
+
+	// check for regex rules that overlap other rules
+	for i, rule := range rules {
+		// other domains of this provider
+		for _, other := range rules[i+1:] {
+			err := validateRulesOverlap(rule, priority, other, priority)
+			if err != nil {
+				return err
+			}
+		}
+
+		// domains of other providers
+		for key, other := range mgr.rules {
+			if key == rule.key {
+				continue
+			}
+			for _, currentP := range mgr.dP[key] {
+				if currentP == p {
+					continue
+				}
+				err := validateRulesOverlap(rule, priority, other, currentP.Priority)
+				if err != nil {
+					return fmt.Errorf("%w (provider %d)", err, currentP.ID)
+				}
+			}
 		}
 	}
+
+	return nil
+}
+
+// validateRulesOverlap returns an error if one of the rules is a regex rule and they overlap
+// in a way that would make matching ambiguous: both are regex rules that are equally specific
+// and match the same name, or one is a domain or suffix rule at the same priority as a regex
+// rule that matches the same name.
+func validateRulesOverlap(rule *domainRule, priority int, other *domainRule, otherPriority int) error {
+	// order so that rule is a regex rule (if either is)
+	if rule.kind != domainRuleRegex {
+		rule, other = other, rule
+		priority, otherPriority = otherPriority, priority
+	}
+	if rule.kind != domainRuleRegex {
+		return nil
+	}
+
+	switch other.kind {
+	case domainRuleRegex:
+		ruleLen, _ := rule.specificity()
+		otherLen, _ := other.specificity()
+		if ruleLen == otherLen && rulesOverlap(rule, other) {
+			return fmt.Errorf("regex rules %s and %s overlap (they can match the same names and are equally specific)", rule.key, other.key)
+		}
+
+	case domainRuleExact, domainRuleSuffix:
+		if priority == otherPriority && rulesOverlap(rule, other) {
+			return fmt.Errorf("regex rule %s overlaps %s at priority %d (they can match the same names, use a different priority)", rule.key, other.key, priority)
+		}
+	}
+
 	return nil
 }
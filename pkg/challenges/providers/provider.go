@@ -16,6 +16,14 @@ type Service interface {
 	Stop() error
 }
 
+// BatchService is optionally implemented by a provider Service that can provision (and
+// deprovision) many resources at once, e.g. with a single update for all of the records
+// in the same DNS zone
+type BatchService interface {
+	ProvisionBatch(resources []acme.ChallengeResource) (err error)
+	DeprovisionBatch(resources []acme.ChallengeResource) (err error)
+}
+
 // provider is the structure of a provider that is being managed
 type provider struct {
 	ID                       int      `json:"id"`
@@ -23,6 +31,7 @@ type provider struct {
 	Type                     string   `json:"type"`
 	Domains                  []string `json:"domains"`
 	PostProvisionWaitSeconds int      `json:"post_resource_provision_wait"`
+	Priority                 int      `json:"priority"`
 	Config                   any      `json:"config"`
 	Service                  `json:"-"`
 }
@@ -0,0 +1,52 @@
+package tlsalpn01internal
+
+import (
+	"certwarden-backend/pkg/acme"
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// serverName returns the SNI value the ACME server will send when validating domain (ip
+// identifiers use their reverse mapping name)
+func serverName(domain string) string {
+	return strings.ToLower(acme.ReverseDNSName(domain))
+}
+
+// Provision adds a validation certificate to serve for domain
+func (service *Service) Provision(domain string, _ string, keyAuth acme.KeyAuth) error {
+	cert, err := acme.ValidationResourceTlsAlpn01(domain, keyAuth)
+	if err != nil {
+		err = fmt.Errorf("tls-alpn-01 failed to make validation certificate for %s (%s)", domain, err)
+		service.logger.Error(err)
+		return err
+	}
+
+	// add new entry
+	exists, _ := service.provisionedResources.Add(serverName(domain), cert)
+
+	// if it already exists, log an error and fail (only one validation certificate can be
+	// served for a domain at a time)
+	if exists {
+		err := fmt.Errorf("tls-alpn-01 resource for %s already in use (is another order for this domain in progress?)", domain)
+		service.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// Deprovision removes a domain's validation certificate from those being served
+func (service *Service) Deprovision(domain string, _ string, _ acme.KeyAuth) error {
+	// delete entry
+	delFunc := func(domainKey string, _ *tls.Certificate) bool {
+		return domainKey == serverName(domain)
+	}
+
+	deleteOk := service.provisionedResources.DeleteFunc(delFunc)
+	if !deleteOk {
+		return fmt.Errorf("tls-alpn-01 resource for %s failed to delete", domain)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package tlsalpn01internal
+
+import (
+	"certwarden-backend/pkg/acme"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// handshakeTimeout is the max time a client has to complete the tls handshake
+const handshakeTimeout = 10 * time.Second
+
+// tlsConfig returns the tls config for the challenge server. Only the acme-tls/1 protocol
+// is supported and the certificate is selected using the SNI value (RFC 8737 s 3).
+func (service *Service) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{acme.ALPNProtocolTlsAlpn01},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if !slices.Contains(hello.SupportedProtos, acme.ALPNProtocolTlsAlpn01) {
+				return nil, fmt.Errorf("client did not offer alpn protocol %s", acme.ALPNProtocolTlsAlpn01)
+			}
+
+			cert, exists := service.provisionedResources.Read(strings.ToLower(hello.ServerName))
+			if !exists {
+				service.logger.Debugf("tls-alpn-01 challenge resource for %s not found", hello.ServerName)
+				return nil, fmt.Errorf("no tls-alpn-01 resource for %s", hello.ServerName)
+			}
+
+			service.logger.Debugf("serving tls-alpn-01 resource for %s", hello.ServerName)
+			return cert, nil
+		},
+	}
+}
+
+// startServer starts a challenge server on each of the service's bind addresses (or on all
+// interfaces if none are specified). If any address cannot be bound, no servers are started.
+func (service *Service) startServer() (err error) {
+	// if no addresses are specified, listen on all interfaces
+	hostNames := service.bindAddresses
+	if len(hostNames) == 0 {
+		hostNames = []string{""}
+	}
+
+	// create listener for each server
+	listeners := []net.Listener{}
+	for _, hostName := range hostNames {
+		servAddr := net.JoinHostPort(hostName, strconv.Itoa(service.port))
+
+		service.logger.Infof("attempting to start tls-alpn-01 challenge server on %s.", servAddr)
+
+		ln, err := net.Listen("tcp", servAddr)
+		if err != nil {
+			service.logger.Error(fmt.Errorf("failed to start tls-alpn-01 challenge server, cannot bind to %s (%s)", servAddr, err))
+
+			// close any listeners that were already created
+			for i := range listeners {
+				_ = listeners[i].Close()
+			}
+
+			return err
+		}
+		listeners = append(listeners, ln)
+	}
+
+	if service.port != 443 {
+		service.logger.Warnf("tls-alpn-01 challenge server is not configured on port 443; internet "+
+			"facing port 443 must be proxied (without tls termination) to port %d to function.", service.port)
+	}
+
+	// make child context for stopping server(s)
+	ctx, stopServer := context.WithCancel(service.shutdownContext)
+	service.stopServerFunc = stopServer
+
+	// err chan for stop (buffered so the result isn't blocked if Stop is never called)
+	service.stopErrChan = make(chan error, 1)
+
+	tlsConf := service.tlsConfig()
+
+	// wg for connections being handled, so shutdown can wait for them
+	connWg := &sync.WaitGroup{}
+
+	// launch servers
+	for _, ln := range listeners {
+		service.shutdownWaitgroup.Add(1)
+		go func(ln net.Listener) {
+			defer service.shutdownWaitgroup.Done()
+
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					if !errors.Is(err, net.ErrClosed) {
+						service.logger.Errorf("tlsalpn01internal server returned error (%s)", err)
+					}
+					break
+				}
+
+				connWg.Add(1)
+				go func() {
+					defer connWg.Done()
+					service.handleConn(conn, tlsConf)
+				}()
+			}
+
+			service.logger.Infof("tls-alpn-01 challenge server (%s) shutdown complete", ln.Addr())
+		}(ln)
+	}
+
+	// monitor shutdown context
+	go func() {
+		<-ctx.Done()
+
+		var shutdownErrs []error
+		for _, ln := range listeners {
+			err := ln.Close()
+			if err != nil {
+				service.logger.Errorf("error shutting down tls-alpn-01 challenge server %s (%s)", ln.Addr(), err)
+				shutdownErrs = append(shutdownErrs, err)
+			}
+		}
+
+		// wait for open connections (which are limited by the handshake timeout)
+		connWg.Wait()
+
+		// send shutdown result to err chan
+		service.stopErrChan <- errors.Join(shutdownErrs...)
+	}()
+
+	return nil
+}
+
+// handleConn completes the tls handshake with the client and then closes the connection; the
+// ACME server only needs to complete the handshake to validate the challenge.
+func (service *Service) handleConn(conn net.Conn, tlsConf *tls.Config) {
+	defer func() { _ = conn.Close() }()
+
+	tlsConn := tls.Server(conn, tlsConf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+
+	err := tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		service.logger.Debugf("tls-alpn-01 handshake with %s failed (%s)", conn.RemoteAddr(), err)
+		return
+	}
+
+	_ = tlsConn.Close()
+}
@@ -0,0 +1,81 @@
+package challenges
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/validation"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// matchedProviderJson is one of the providers that would be used for an identifier
+type matchedProviderJson struct {
+	ID       int    `json:"id"`
+	Tag      string `json:"tag"`
+	Type     string `json:"type"`
+	Priority int    `json:"priority"`
+}
+
+type providersForResponse struct {
+	output.JsonResponse
+	Identifier      string                `json:"identifier"`
+	ProvisionDomain string                `json:"provision_domain"`
+	Rule            string                `json:"rule"`
+	Providers       []matchedProviderJson `json:"providers"`
+}
+
+// GetProvidersFor returns the provider that would be chosen to solve the challenge for
+// the identifier specified in the query (as well as the fallback providers, in the order
+// they would be tried) and the domain rule that selected them
+func (service *Service) GetProvidersFor(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// validation
+	identifier := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("identifier")))
+	if identifier == "" {
+		err := errors.New("identifier must be specified")
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	if !validation.DomainValid(identifier, false) && !validation.IPAddressValid(identifier) {
+		err := fmt.Errorf("identifier %s is not a valid domain or ip address", identifier)
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// same translation as solving (ip identifiers are never aliased)
+	provisionDomain := identifier
+	if !validation.IPAddressValid(identifier) {
+		provisionDomain = service.dnsIDValuetoDomain(identifier)
+	}
+
+	rule, ps, err := service.DNSIdentifierProviders.MatchProviders(provisionDomain)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrNotFound(err)
+	}
+
+	// write response
+	response := &providersForResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Identifier = identifier
+	response.ProvisionDomain = provisionDomain
+	response.Rule = rule
+	response.Providers = []matchedProviderJson{}
+	for _, p := range ps {
+		response.Providers = append(response.Providers, matchedProviderJson{
+			ID:       p.ID,
+			Tag:      p.Tag,
+			Type:     p.Type,
+			Priority: p.Priority,
+		})
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 func errShutdown(domain string) error {
@@ -60,3 +62,75 @@ func (service *Service) deprovision(domain string, token string, keyAuth acme.Ke
 	service.logger.Debugf("challenges: domain %s previously used token '%s' (key auth: '%s')", domain, token, keyAuth)
 	return nil
 }
+
+// provisionBatch is the same as provision except all of the resources are provisioned at once
+// by a provider that supports batches. The batch only counts once against the rate limit.
+func (service *Service) provisionBatch(resources []acme.ChallengeResource, provider providers.BatchService) (err error) {
+	domains := make([]string, len(resources))
+	for i := range resources {
+		domains[i] = resources[i].Domain
+	}
+	domainsList := strings.Join(domains, ", ")
+
+	// impose rate limit
+	err = service.apiRateLimiter.Wait(service.shutdownContext)
+	if err != nil {
+		// if shutdown, return that err
+		if errors.Is(err, context.Canceled) {
+			return errShutdown(domainsList)
+		}
+		// otherwise return error as-is (this shouldn't happen though)
+		service.logger.Errorf("challenges: unexpected context error (%v) for domains %s", err, domainsList)
+		return err
+	}
+
+	// Provision with the appropriate provider
+	err = provider.ProvisionBatch(resources)
+	if err != nil {
+		return err
+	}
+
+	service.logger.Infof("challenges: success on any needed batch provision action for domains %s", domainsList)
+	return nil
+}
+
+// deprovisionBatch is the same as deprovision except all of the resources are deprovisioned
+// at once by a provider that supports batches. The batch only counts once against the rate
+// limit.
+func (service *Service) deprovisionBatch(resources []acme.ChallengeResource, provider providers.BatchService) (err error) {
+	domains := make([]string, len(resources))
+	for i := range resources {
+		domains[i] = resources[i].Domain
+	}
+	domainsList := strings.Join(domains, ", ")
+
+	// impose rate limit, but use background context
+	// background context ensures we try to deprovision all records
+	err = service.apiRateLimiter.Wait(context.Background())
+	if err != nil {
+		// return error as-is (this should, in theory, never error)
+		service.logger.Errorf("challenges: unexpected context error (%v) for domains %s", err, domainsList)
+		return err
+	}
+
+	// Deprovision with the appropriate provider
+	err = provider.DeprovisionBatch(resources)
+	if err != nil {
+		return err
+	}
+
+	service.logger.Infof("challenges: success on any needed batch deprovision action for domains %s", domainsList)
+	return nil
+}
+
+// waitForDns01Propagation polls until the dns-01 resource for the identifier value is
+// visible (following the CNAME if the identifier uses a domain alias) or until wait has
+// passed. nil is returned if the resource was seen, otherwise the reason it was not.
+func (service *Service) waitForDns01Propagation(identifierValue string, keyAuth acme.KeyAuth, wait time.Duration) error {
+	dnsRecordName, dnsRecordValue := acme.ValidationResourceDns01(identifierValue, keyAuth)
+
+	ctx, cancel := context.WithTimeout(service.shutdownContext, wait)
+	defer cancel()
+
+	return service.propagationChecker.WaitFor(ctx, dnsRecordName, dnsRecordValue)
+}
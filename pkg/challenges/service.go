@@ -1,8 +1,10 @@
 package challenges
 
 import (
+	"certwarden-backend/pkg/challenges/propagation"
 	"certwarden-backend/pkg/challenges/providers"
 	"certwarden-backend/pkg/datatypes/safemap"
+	"certwarden-backend/pkg/metrics"
 	"certwarden-backend/pkg/output"
 	"context"
 	"errors"
@@ -31,6 +33,7 @@ type application interface {
 	GetShutdownContext() context.Context
 	GetShutdownWaitGroup() *sync.WaitGroup
 	GetOutputter() *output.Service
+	GetMetrics() *metrics.Service
 
 	// for providers
 	GetHttpClient() *http.Client
@@ -38,8 +41,9 @@ type application interface {
 
 // Config holds all of the challenge config
 type Config struct {
-	ProviderConfigs providers.Config  `yaml:"providers"`
-	DNSIDtoDomain   map[string]string `yaml:"domain_aliases"`
+	ProviderConfigs  providers.Config   `yaml:"providers"`
+	DNSIDtoDomain    map[string]string  `yaml:"domain_aliases"`
+	PropagationCheck propagation.Config `yaml:"dns_propagation_check"`
 }
 
 // service struct
@@ -49,10 +53,13 @@ type Service struct {
 	shutdownContext        context.Context
 	shutdownWaitgroup      *sync.WaitGroup
 	output                 *output.Service
+	metrics                *metrics.Service
 	configFile             string
 	DNSIdentifierProviders *providers.Manager
 	dnsIDtoDomain          *safemap.SafeMap[string] // DNSIdentifierValue[Domain]
 	apiRateLimiter         *rate.Limiter
+	propagationChecker     *propagation.Checker  // nil if disabled
+	failedProviders        *safemap.SafeMap[int] // provision domain[ID of provider whose challenge was last invalid]
 }
 
 // NewService creates a new service
@@ -71,6 +78,9 @@ func NewService(app application, cfg *Config) (service *Service, err error) {
 	// output
 	service.output = app.GetOutputter()
 
+	// metrics (may be nil)
+	service.metrics = app.GetMetrics()
+
 	// config file path (for writing)
 	service.configFile = app.GetConfigFilenameWithPath()
 
@@ -88,8 +98,20 @@ func NewService(app application, cfg *Config) (service *Service, err error) {
 	// api management rate limiter
 	service.apiRateLimiter = rate.NewLimiter(rate.Every(time.Second/limitEventsPerSecond), limitBurstAtMost)
 
+	// dns-01 propagation checker (optional)
+	if cfg.PropagationCheck.Enabled != nil && *cfg.PropagationCheck.Enabled {
+		service.propagationChecker, err = propagation.NewChecker(service.logger, cfg.PropagationCheck)
+		if err != nil {
+			service.logger.Errorf("challenges: failed to configure dns propagation checker (%s)", err)
+			return nil, err
+		}
+	}
+
 	// make DNS Identifier -> domain map (from config value)
 	service.dnsIDtoDomain = safemap.NewSafeMapFrom(cfg.DNSIDtoDomain)
 
+	// providers that most recently failed validation (to start the next attempt with a fallback)
+	service.failedProviders = safemap.NewSafeMap[int]()
+
 	return service, nil
 }
@@ -0,0 +1,389 @@
+package challenges
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/challenges/providers"
+	"certwarden-backend/pkg/datatypes/history"
+	"certwarden-backend/pkg/metrics"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxConcurrentPerProvider is the maximum number of resources one provider is asked to
+// provision (or deprovision) at the same time for a single Solve
+const maxConcurrentPerProvider = 5
+
+// jobProvider is one of the providers that can be used to solve a job
+type jobProvider struct {
+	id      int
+	typeOf  string
+	tag     string
+	service providers.Service
+	wait    time.Duration
+}
+
+// solveJob is the state of solving the challenge of one authorization
+type solveJob struct {
+	identifier      acme.Identifier
+	challenges      []acme.Challenge
+	provisionDomain string
+
+	providerID      int
+	providerType    string
+	providerTag     string
+	providerService providers.Service
+	wait            time.Duration
+
+	// providers not yet tried, in the order to try them
+	fallbacks []jobProvider
+
+	challengeType acme.ChallengeType
+	challenge     acme.Challenge
+	keyAuth       acme.KeyAuth
+
+	// provisioning was attempted, so deprovisioning is needed
+	provisioned bool
+	err         error
+}
+
+// resource returns the provider resource for job
+func (job *solveJob) resource() acme.ChallengeResource {
+	return acme.ChallengeResource{
+		Domain:  job.provisionDomain,
+		Token:   job.challenge.Token,
+		KeyAuth: job.keyAuth,
+	}
+}
+
+// newSolveJob selects the provider and challenge for identifier and returns the job to
+// solve it. If the job can't be solved, the job's err is set.
+func (service *Service) newSolveJob(identifier acme.Identifier, challenges []acme.Challenge, key acme.AccountKey) *solveJob {
+	job := &solveJob{
+		identifier: identifier,
+		challenges: challenges,
+	}
+
+	// identifier value -> provision fqdn
+	var err error
+	job.provisionDomain, err = service.provisionDomain(identifier)
+	if err != nil {
+		job.err = err
+		return job
+	}
+
+	// get providers for provision fqdn
+	ps, err := service.DNSIdentifierProviders.ProvidersFor(job.provisionDomain)
+	if err != nil {
+		job.err = err
+		return job
+	}
+	for _, p := range ps {
+		job.fallbacks = append(job.fallbacks, jobProvider{
+			id:      p.ID,
+			typeOf:  p.Type,
+			tag:     p.Tag,
+			service: p.Service,
+			wait:    p.PostProvisionResourceWait(),
+		})
+	}
+
+	// if the provider this domain's last challenge was invalid with has a fallback, try it last
+	failedID, exists := service.failedProviders.Read(job.provisionDomain)
+	if exists && len(job.fallbacks) > 1 && job.fallbacks[0].id == failedID {
+		job.fallbacks = append(job.fallbacks[1:], job.fallbacks[0])
+		service.logger.Infof("challenges: last challenge for %s was invalid using provider %s (%s), trying its fallback first",
+			job.provisionDomain, job.fallbacks[len(job.fallbacks)-1].typeOf, job.fallbacks[len(job.fallbacks)-1].tag)
+	}
+
+	_ = service.nextProvider(job, key)
+	return job
+}
+
+// provisionDomain returns the fqdn that the resource to solve identifier's challenge is
+// provisioned for
+func (service *Service) provisionDomain(identifier acme.Identifier) (string, error) {
+	switch identifier.Type {
+	case acme.IdentifierTypeDns:
+		return service.dnsIDValuetoDomain(identifier.Value), nil
+
+	case acme.IdentifierTypeIp:
+		// ip identifiers (RFC 8738) are never aliased
+		return identifier.Value, nil
+	}
+
+	return "", fmt.Errorf("challenges: acme identifier is type (%s); only 'dns' and 'ip' are supported", string(identifier.Type))
+}
+
+// FallbackPending returns true if the last challenge for identifier was invalid using its
+// primary provider and it has a fallback (i.e., the next Solve for identifier will start
+// with a different provider). Which provider failed is only kept in memory, so after a
+// restart the primary provider is always tried first again.
+func (service *Service) FallbackPending(identifier acme.Identifier) bool {
+	provisionDomain, err := service.provisionDomain(identifier)
+	if err != nil {
+		return false
+	}
+
+	failedID, exists := service.failedProviders.Read(provisionDomain)
+	if !exists {
+		return false
+	}
+
+	ps, err := service.DNSIdentifierProviders.ProvidersFor(provisionDomain)
+	if err != nil {
+		return false
+	}
+
+	return len(ps) > 1 && ps[0].ID == failedID
+}
+
+// nextProvider switches job to the first of its untried providers that can be used for
+// its identifier and returns true. If none of them can be used, job's err is set and
+// false is returned.
+func (service *Service) nextProvider(job *solveJob, key acme.AccountKey) bool {
+	var errs error
+	for len(job.fallbacks) > 0 {
+		p := job.fallbacks[0]
+		job.fallbacks = job.fallbacks[1:]
+
+		err := service.useProvider(job, p, key)
+		if err == nil {
+			job.err = nil
+			return true
+		}
+
+		errs = errors.Join(errs, err)
+		if len(job.fallbacks) > 0 {
+			service.logger.Warnf("challenges: can't use provider %s (%s) for %s, trying next provider (%s)", p.typeOf, p.tag, job.identifier.Value, err)
+		}
+	}
+
+	job.err = errors.Join(job.err, errs)
+	return false
+}
+
+// useProvider selects the challenge for job's identifier that p solves and sets job to
+// use p. If p can't be used, an error is returned.
+func (service *Service) useProvider(job *solveJob, p jobProvider, key acme.AccountKey) error {
+	job.providerID = p.id
+	job.providerType = p.typeOf
+	job.providerTag = p.tag
+	job.providerService = p.service
+	job.wait = p.wait
+
+	job.challengeType = p.service.AcmeChallengeType()
+
+	// ip identifiers can't be validated with dns-01 (RFC 8738 s 7)
+	if job.identifier.Type == acme.IdentifierTypeIp && job.challengeType != acme.ChallengeTypeHttp01 && job.challengeType != acme.ChallengeTypeTlsAlpn01 {
+		return fmt.Errorf("challenges: provider for ip identifier '%s' uses challenge type '%s'; only '%s' and '%s' are supported for ip identifiers",
+			job.identifier.Value, job.challengeType, acme.ChallengeTypeHttp01, acme.ChallengeTypeTlsAlpn01)
+	}
+
+	var err error
+	job.challenge, err = acme.SelectChallenge(job.challengeType, job.challenges)
+	if err != nil {
+		return fmt.Errorf("challenges: error selecting challenge (%w)", err)
+	}
+
+	// vars for provision/deprovision
+	job.keyAuth, err = key.KeyAuthorization(job.challenge.Token)
+	if err != nil {
+		return fmt.Errorf("challenges: failed to make key auth (%s)", err)
+	}
+
+	// if using an alias, emit debug log message about required CNAME record (or error if challenge
+	// type doesn't support a CNAME record)
+	cnamePointsFrom, cnamePointsTo, err := acme.DNSChallengeCNAMEInfo(job.identifier.Value, job.provisionDomain, job.challengeType)
+	if err != nil {
+		return fmt.Errorf("challenges: cname error for identifier '%s', provision domain '%s', and challenge type '%s' (%w)",
+			job.identifier.Value, job.provisionDomain, job.challengeType, err)
+	}
+	if cnamePointsFrom != "" {
+		service.logger.Debugf("challenges: alias exists for acme identifier '%s' and manually created cname record pointing from '%s' to '%s' is required",
+			job.identifier.Value, cnamePointsFrom, cnamePointsTo)
+	}
+
+	return nil
+}
+
+// groupJobsByProvider returns the jobs that match include, grouped by provider (in the
+// order each provider first appears)
+func groupJobsByProvider(jobs []*solveJob, include func(*solveJob) bool) [][]*solveJob {
+	groups := [][]*solveJob{}
+	groupIndex := make(map[int]int) // provider id -> index in groups
+
+	for _, job := range jobs {
+		if !include(job) {
+			continue
+		}
+
+		i, exists := groupIndex[job.providerID]
+		if !exists {
+			i = len(groups)
+			groupIndex[job.providerID] = i
+			groups = append(groups, []*solveJob{})
+		}
+		groups[i] = append(groups[i], job)
+	}
+
+	return groups
+}
+
+// provisionJobs provisions the resources of all of the jobs that don't already have an
+// error. Each provider's jobs are done in one batch if the provider supports it, otherwise
+// they are done concurrently (bounded by maxConcurrentPerProvider).
+func (service *Service) provisionJobs(jobs []*solveJob, rec *history.Recorder) {
+	groups := groupJobsByProvider(jobs, func(job *solveJob) bool { return job.err == nil })
+
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		// add to wg to ensure deprovision completes during shutdown (done after deprovision)
+		service.shutdownWaitgroup.Add(len(group))
+		for _, job := range group {
+			job.provisioned = true
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// batch
+			batchService, ok := group[0].providerService.(providers.BatchService)
+			if ok && len(group) > 1 {
+				resources := make([]acme.ChallengeResource, len(group))
+				for i := range group {
+					resources[i] = group[i].resource()
+				}
+
+				provisionStart := time.Now()
+				err := service.provisionBatch(resources, batchService)
+				for _, job := range group {
+					job.err = err
+					service.observeProvisioning(job, metrics.ChallengeActionProvision, time.Since(provisionStart), err, rec)
+				}
+				return
+			}
+
+			// individually
+			forEachJobBounded(group, func(job *solveJob) {
+				provisionStart := time.Now()
+				job.err = service.provision(job.provisionDomain, job.challenge.Token, job.keyAuth, job.providerService)
+				service.observeProvisioning(job, metrics.ChallengeActionProvision, time.Since(provisionStart), job.err, rec)
+			})
+		}()
+	}
+
+	wg.Wait()
+}
+
+// provisionJobsWithFallback provisions the jobs (see provisionJobs) and then, for each job
+// that failed to provision, falls back to the job's next provider until every job is
+// provisioned or has run out of providers. The failed attempts are returned as they also
+// need to be deprovisioned.
+func (service *Service) provisionJobsWithFallback(jobs []*solveJob, key acme.AccountKey, rec *history.Recorder) (retiredJobs []*solveJob) {
+	service.provisionJobs(jobs, rec)
+
+	// fall back to the next provider of each job that failed to provision, until every job is
+	// provisioned or has run out of providers
+	for {
+		fallbackJobs := []*solveJob{}
+		for _, job := range jobs {
+			if job.err == nil || !job.provisioned || len(job.fallbacks) == 0 {
+				continue
+			}
+
+			service.logger.Warnf("challenges: provider %s (%s) failed to provision resource for %s, falling back to next provider (%s)",
+				job.providerType, job.providerTag, job.identifier.Value, job.err)
+			rec.Add(history.Step{
+				Action:      history.ActionProviderFallback,
+				Identifier:  job.identifier.Value,
+				Provider:    job.providerType,
+				ProviderTag: job.providerTag,
+			}, job.err)
+
+			// keep the failed attempt so it is still deprovisioned (by its provider)
+			retiredJob := *job
+			retiredJobs = append(retiredJobs, &retiredJob)
+			job.provisioned = false
+
+			if service.nextProvider(job, key) {
+				fallbackJobs = append(fallbackJobs, job)
+			}
+		}
+
+		if len(fallbackJobs) == 0 {
+			break
+		}
+		service.provisionJobs(fallbackJobs, rec)
+	}
+
+	return retiredJobs
+}
+
+// deprovisionJobs deprovisions the resources of all of the jobs that were provisioned,
+// in the same manner as provisionJobs. It doesn't wait for deprovisioning to finish as it
+// isn't necessary for solving to be considered concluded.
+func (service *Service) deprovisionJobs(jobs []*solveJob, rec *history.Recorder) {
+	groups := groupJobsByProvider(jobs, func(job *solveJob) bool { return job.provisioned })
+
+	for _, group := range groups {
+		go func() {
+			// batch
+			batchService, ok := group[0].providerService.(providers.BatchService)
+			if ok && len(group) > 1 {
+				resources := make([]acme.ChallengeResource, len(group))
+				for i := range group {
+					resources[i] = group[i].resource()
+				}
+
+				deprovisionStart := time.Now()
+				err := service.deprovisionBatch(resources, batchService)
+				for _, job := range group {
+					service.observeProvisioning(job, metrics.ChallengeActionDeprovision, time.Since(deprovisionStart), err, rec)
+					// wg done so shutdown can proceed after deprovision
+					service.shutdownWaitgroup.Done()
+				}
+				if err != nil {
+					service.logger.Errorf("challenges: deprovision failed (%s)", err)
+				}
+				return
+			}
+
+			// individually
+			forEachJobBounded(group, func(job *solveJob) {
+				// wg done so shutdown can proceed after deprovision
+				defer service.shutdownWaitgroup.Done()
+
+				deprovisionStart := time.Now()
+				err := service.deprovision(job.provisionDomain, job.challenge.Token, job.keyAuth, job.providerService)
+				service.observeProvisioning(job, metrics.ChallengeActionDeprovision, time.Since(deprovisionStart), err, rec)
+				if err != nil {
+					service.logger.Errorf("challenges: deprovision failed (%s)", err)
+				}
+			})
+		}()
+	}
+}
+
+// forEachJobBounded calls do for each job concurrently, with at most
+// maxConcurrentPerProvider running at once, and returns once all calls return
+func forEachJobBounded(jobs []*solveJob, do func(job *solveJob)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentPerProvider)
+
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			do(job)
+		}()
+	}
+
+	wg.Wait()
+}
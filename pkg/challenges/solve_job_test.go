@@ -0,0 +1,221 @@
+package challenges
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/datatypes/history"
+	"certwarden-backend/pkg/datatypes/safemap"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// testProvider is a provider Service that counts calls and tracks the peak number of
+// concurrent provisions
+type testProvider struct {
+	provisionErr error
+
+	mu            sync.Mutex
+	active        int
+	peakActive    int
+	provisioned   []string
+	deprovisioned []string
+}
+
+func (p *testProvider) AcmeChallengeType() acme.ChallengeType { return acme.ChallengeTypeDns01 }
+func (p *testProvider) Stop() error                           { return nil }
+
+func (p *testProvider) Provision(domain string, _ string, _ acme.KeyAuth) error {
+	p.mu.Lock()
+	p.active++
+	p.peakActive = max(p.peakActive, p.active)
+	p.provisioned = append(p.provisioned, domain)
+	p.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+
+	return p.provisionErr
+}
+
+func (p *testProvider) Deprovision(domain string, _ string, _ acme.KeyAuth) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.deprovisioned = append(p.deprovisioned, domain)
+	return nil
+}
+
+// testBatchProvider additionally supports batches
+type testBatchProvider struct {
+	testProvider
+	batches atomic.Int32
+}
+
+func (p *testBatchProvider) ProvisionBatch(resources []acme.ChallengeResource) error {
+	p.batches.Add(1)
+	for _, resource := range resources {
+		_ = p.testProvider.Provision(resource.Domain, resource.Token, resource.KeyAuth)
+	}
+	return p.provisionErr
+}
+
+func (p *testBatchProvider) DeprovisionBatch(resources []acme.ChallengeResource) error {
+	p.batches.Add(1)
+	for _, resource := range resources {
+		_ = p.testProvider.Deprovision(resource.Domain, resource.Token, resource.KeyAuth)
+	}
+	return nil
+}
+
+func newTestService() *Service {
+	return &Service{
+		logger:            zap.NewNop().Sugar(),
+		shutdownContext:   context.Background(),
+		shutdownWaitgroup: new(sync.WaitGroup),
+		apiRateLimiter:    rate.NewLimiter(rate.Inf, 1),
+		failedProviders:   safemap.NewSafeMap[int](),
+	}
+}
+
+// waitTimeout waits for wg and fails the test if it takes too long
+func waitTimeout(t *testing.T, wg *sync.WaitGroup) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown wait group did not complete")
+	}
+}
+
+func TestProvisionJobs(t *testing.T) {
+	service := newTestService()
+
+	individual := &testProvider{}
+	batch := &testBatchProvider{}
+	failing := &testProvider{provisionErr: errors.New("api down")}
+
+	jobs := []*solveJob{}
+	for _, domain := range []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com", "e.example.com", "f.example.com", "g.example.com"} {
+		jobs = append(jobs,
+			&solveJob{identifier: acme.Identifier{Value: domain}, provisionDomain: domain, providerID: 1, providerService: individual},
+			&solveJob{identifier: acme.Identifier{Value: domain}, provisionDomain: domain, providerID: 2, providerService: batch},
+		)
+	}
+	jobs = append(jobs,
+		&solveJob{identifier: acme.Identifier{Value: "fail.example.com"}, provisionDomain: "fail.example.com", providerID: 3, providerService: failing},
+		&solveJob{identifier: acme.Identifier{Value: "bad.example.com"}, err: errors.New("no provider")},
+	)
+
+	service.provisionJobs(jobs, nil)
+
+	if len(individual.provisioned) != 7 || individual.peakActive > maxConcurrentPerProvider {
+		t.Errorf("expected 7 individual provisions with at most %d at once, got %d (peak %d)", maxConcurrentPerProvider, len(individual.provisioned), individual.peakActive)
+	}
+	if batch.batches.Load() != 1 || len(batch.provisioned) != 7 {
+		t.Errorf("expected 1 batch of 7, got %d batches of %d", batch.batches.Load(), len(batch.provisioned))
+	}
+	for _, job := range jobs {
+		switch job.identifier.Value {
+		case "fail.example.com":
+			if job.err == nil || !job.provisioned {
+				t.Errorf("expected failed provision to be marked provisioned with error")
+			}
+		case "bad.example.com":
+			if job.provisioned {
+				t.Errorf("job that already had an error should not be provisioned")
+			}
+		default:
+			if job.err != nil || !job.provisioned {
+				t.Errorf("unexpected job state for %s (%v)", job.identifier.Value, job.err)
+			}
+		}
+	}
+
+	// everything that tried to provision is deprovisioned, even on failure
+	service.deprovisionJobs(jobs, nil)
+	waitTimeout(t, service.shutdownWaitgroup)
+
+	if len(individual.deprovisioned) != 7 || len(batch.deprovisioned) != 7 || len(failing.deprovisioned) != 1 {
+		t.Errorf("unexpected deprovision counts: %d, %d, %d", len(individual.deprovisioned), len(batch.deprovisioned), len(failing.deprovisioned))
+	}
+	if batch.batches.Load() != 2 {
+		t.Errorf("expected deprovision to be 1 batch, got %d total batches", batch.batches.Load())
+	}
+}
+
+func TestProvisionJobsWithFallback(t *testing.T) {
+	service := newTestService()
+	key := acme.AccountKey{Key: ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))}
+	challenges := []acme.Challenge{{Type: acme.ChallengeTypeDns01, Token: "token"}}
+
+	down := &testProvider{provisionErr: errors.New("api down")}
+	alsoDown := &testProvider{provisionErr: errors.New("api also down")}
+	fallback := &testProvider{}
+
+	newJob := func(domain string, ps ...*testProvider) *solveJob {
+		job := &solveJob{identifier: acme.Identifier{Type: acme.IdentifierTypeDns, Value: domain}, challenges: challenges, provisionDomain: domain}
+		for i, p := range ps {
+			job.fallbacks = append(job.fallbacks, jobProvider{id: i, typeOf: "test", service: p})
+		}
+		if !service.nextProvider(job, key) {
+			t.Fatalf("failed to select first provider for %s (%s)", domain, job.err)
+		}
+		return job
+	}
+
+	fallsBack := newJob("a.example.com", down, alsoDown, fallback)
+	noFallback := newJob("b.example.com", down)
+	exhausted := newJob("c.example.com", down, alsoDown)
+	jobs := []*solveJob{fallsBack, noFallback, exhausted}
+
+	rec := history.NewRecorder()
+	retired := service.provisionJobsWithFallback(jobs, key, rec)
+
+	if fallsBack.err != nil || fallsBack.providerService != fallback || !fallsBack.provisioned {
+		t.Errorf("expected job to be provisioned by its last provider (%v)", fallsBack.err)
+	}
+	if noFallback.err == nil || !noFallback.provisioned {
+		t.Errorf("expected job without fallback to keep its provision error")
+	}
+	if exhausted.err == nil || exhausted.providerService != alsoDown {
+		t.Errorf("expected job with exhausted providers to keep the error of its last provider")
+	}
+	if len(retired) != 3 {
+		t.Errorf("expected 3 retired attempts, got %d", len(retired))
+	}
+
+	fallbackSteps := 0
+	for _, step := range rec.Steps() {
+		if step.Action == history.ActionProviderFallback {
+			fallbackSteps++
+		}
+	}
+	if fallbackSteps != 3 {
+		t.Errorf("expected 3 fallback steps, got %d", fallbackSteps)
+	}
+
+	// every attempt is deprovisioned exactly once, by the provider that made it
+	service.deprovisionJobs(append(jobs, retired...), nil)
+	waitTimeout(t, service.shutdownWaitgroup)
+
+	if len(down.deprovisioned) != 3 || len(alsoDown.deprovisioned) != 2 || len(fallback.deprovisioned) != 1 {
+		t.Errorf("unexpected deprovision counts: %d, %d, %d", len(down.deprovisioned), len(alsoDown.deprovisioned), len(fallback.deprovisioned))
+	}
+}
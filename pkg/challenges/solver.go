@@ -2,9 +2,12 @@ package challenges
 
 import (
 	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/datatypes/history"
+	"certwarden-backend/pkg/metrics"
 	"certwarden-backend/pkg/randomness"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -12,101 +15,167 @@ import (
 
 var errChallengeRetriesExhausted = errors.New("challenges: solving failed: challenge failed to move to final state (timeout)")
 
-// Solve accepts an ACME identifier and a slice of challenges and then solves the challenge using a provider
-// for the specific domain. If no provider exists or solving otherwise fails, an error is returned.
-func (service *Service) Solve(identifier acme.Identifier, challenges []acme.Challenge, key acme.AccountKey, acmeService *acme.Service) (err error) {
-	// confirm Type is correct (only dns is supported)
-	if identifier.Type != acme.IdentifierTypeDns {
-		return fmt.Errorf("challenges: acme identifier is type (%s); only 'dns' is supported", string(identifier.Type))
+// Solve accepts a slice of ACME authorizations and solves the challenge of each one using the provider for
+// the authorization's identifier. All of the resources are provisioned first (concurrently, but bounded per
+// provider and batched for providers that support it), then one shared wait for propagation is done, and
+// then the ACME server is told to validate all of the challenges. If an identifier has more than one provider
+// and provisioning fails, the identifier's next provider is used instead. If the challenge is invalid, the
+// authorization (and its order) can't be retried, so the next Solve for the identifier starts with the next
+// provider instead (see FallbackPending). The returned slice contains an error for
+// each authorization (in the same order) that could not be solved and nil for the others. Steps taken are
+// recorded to rec (which may be nil).
+func (service *Service) Solve(auths []acme.Authorization, key acme.AccountKey, acmeService *acme.Service, rec *history.Recorder) []error {
+	jobs := make([]*solveJob, len(auths))
+	for i := range auths {
+		jobs[i] = service.newSolveJob(auths[i].Identifier, auths[i].Challenges, key)
 	}
 
-	// identifier value -> provision fqdn
-	provisionDomain := service.dnsIDValuetoDomain(identifier.Value)
+	// provision the needed resources for validation and defer deprovisioning; deprovision is
+	// done for every job that tried to provision (including with providers that were fallen
+	// back from), even if provision errored, to ensure any records that were created get
+	// cleaned up
+	retiredJobs := service.provisionJobsWithFallback(jobs, key, rec)
+	defer service.deprovisionJobs(append(jobs, retiredJobs...), rec)
 
-	// get provider for provision fqdn
-	provider, err := service.DNSIdentifierProviders.ProviderFor(provisionDomain)
-	if err != nil {
-		return err
-	}
+	// shared wait for propagation of all of the resources
+	service.waitForJobs(jobs, rec)
 
-	challengeType := provider.AcmeChallengeType()
-	challenge, err := acme.SelectChallenge(challengeType, challenges)
-	if err != nil {
-		return fmt.Errorf("challenges: error selecting challenge (%w)", err)
-	}
+	// Below this point is to inform ACME the challenges are ready to be validated
+	// by the server and to subsequently monitor the challenges to be moved to the
+	// valid or invalid state.
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		if job.err != nil {
+			continue
+		}
 
-	// vars for provision/deprovision
-	token := challenge.Token
-	keyAuth, err := key.KeyAuthorization(token)
-	if err != nil {
-		return fmt.Errorf("challenges: failed to make key auth (%s)", err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job.err = service.validate(job, key, acmeService, rec)
+		}()
 	}
+	wg.Wait()
 
-	// if using an alias, emit debug log message about required CNAME record (or error if challenge
-	// type doesn't support a CNAME record)
-	cnamePointsFrom, cnamePointsTo, err := acme.DNSChallengeCNAMEInfo(identifier.Value, provisionDomain, challengeType)
-	if err != nil {
-		return fmt.Errorf("challenges: cname error for identifier '%s', provision domain '%s', and challenge type '%s' (%w)",
-			identifier.Value, provisionDomain, challengeType, err)
-	}
-	if cnamePointsFrom != "" {
-		service.logger.Debugf("challenges: alias exists for acme identifier '%s' and manually created cname record pointing from '%s' to '%s' is required",
-			identifier.Value, cnamePointsFrom, cnamePointsTo)
+	errs := make([]error, len(jobs))
+	for i, job := range jobs {
+		errs[i] = job.err
+		if job.err != nil {
+			continue
+		}
+
+		switch job.challenge.Status {
+		case "valid":
+			// record which provider solved the challenge
+			_, _ = service.failedProviders.Pop(job.provisionDomain)
+			rec.Add(history.Step{
+				Action:      history.ActionChallengeSolved,
+				Identifier:  job.identifier.Value,
+				Provider:    job.providerType,
+				ProviderTag: job.providerTag,
+				Status:      string(job.challengeType),
+			}, nil)
+
+		case "invalid":
+			// an invalid challenge makes the authorization invalid, so the fallback can't be
+			// used now; remember the provider so the next attempt (e.g., a new order placed
+			// by the fulfiller) starts with the fallback
+			_, _ = service.failedProviders.Pop(job.provisionDomain)
+			_, _ = service.failedProviders.Add(job.provisionDomain, job.providerID)
+		}
 	}
 
-	// provision the needed resource for validation and defer deprovisioning
-	// add to wg to ensure deprovision completes during shutdown
-	service.shutdownWaitgroup.Add(1)
-	// Provision with the appropriate provider
-	err = service.provision(provisionDomain, token, keyAuth, provider)
+	return errs
+}
 
-	// do error check after Deprovision to ensure any records that were created
-	// get cleaned up, even if Provision errored.
-	defer func() {
-		// don't wait for deprovision to return as it isn't necessary for Solve to
-		// be considered concluded
-		go func() {
-			// wg done do shutdown can proceed after deprovision
-			defer service.shutdownWaitgroup.Done()
+// waitForJobs does the post provision wait of every job that was provisioned. The waits
+// run concurrently so the total time spent is that of the longest one. If a job's
+// challenge is dns-01 and propagation checking is enabled, the job's wait is instead the
+// maximum time to wait for the resource to be visible.
+func (service *Service) waitForJobs(jobs []*solveJob, rec *history.Recorder) {
+	var wg sync.WaitGroup
+	var maxWait time.Duration
+	for _, job := range jobs {
+		if job.err != nil {
+			continue
+		}
 
-			err = service.deprovision(provisionDomain, token, keyAuth, provider)
-			if err != nil {
-				service.logger.Errorf("challenges: deprovision failed (%s)", err)
-			}
+		if job.wait == time.Duration(0) {
+			service.logger.Debugf("challenges: no wait configured for propagation of resource for %s", job.identifier.Value)
+			continue
+		}
+		maxWait = max(maxWait, job.wait)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job.err = service.waitForJob(job, rec)
 		}()
-	}()
+	}
 
-	// Provision error check
-	if err != nil {
-		return err
+	if maxWait != time.Duration(0) {
+		service.logger.Infof("challenges: waiting to validate until resources are propagated (at most until %s)", time.Now().Add(maxWait).Format(time.RFC1123))
 	}
 
-	// specified wait time prior to resource check
-	wait := provider.PostProvisionResourceWait()
-	if wait != time.Duration(0) {
-		service.logger.Infof("challenges: waiting to validate %s until %s (delay for propagation of resource)", identifier.Value, time.Now().Add(wait).Format(time.RFC1123))
+	wg.Wait()
+}
+
+// waitForJob does the post provision wait for job. An error is only returned if the wait
+// was aborted due to shutdown.
+func (service *Service) waitForJob(job *solveJob, rec *history.Recorder) error {
+	// fixed wait
+	if service.propagationChecker == nil || job.challengeType != acme.ChallengeTypeDns01 {
+		service.logger.Debugf("challenges: waiting to validate %s until %s (delay for propagation of resource)", job.identifier.Value, time.Now().Add(job.wait).Format(time.RFC1123))
 		select {
-		case <-time.After(wait):
-			// continue
+		case <-time.After(job.wait):
+			return nil
 		case <-service.shutdownContext.Done():
-			return errShutdown(provisionDomain)
+			return errShutdown(job.provisionDomain)
 		}
+	}
+
+	// check dns-01 propagation
+	propagationStart := time.Now()
+	err := service.waitForDns01Propagation(job.identifier.Value, job.keyAuth, job.wait)
+	if service.shutdownContext.Err() != nil {
+		return errShutdown(job.provisionDomain)
+	}
+
+	status := "propagated"
+	if err != nil {
+		// fallback: proceed as if the fixed wait had been used
+		status = "wait_elapsed"
+		service.logger.Warnf("challenges: resource for %s not confirmed as propagated, validating anyway (%s)", job.identifier.Value, err)
 	} else {
-		service.logger.Debugf("challenges: no wait configured for propagation of resource for %s", identifier.Value)
+		service.logger.Infof("challenges: resource for %s propagated after %s", job.identifier.Value, time.Since(propagationStart).Round(time.Second))
 	}
+	rec.Add(history.Step{
+		Action:      history.ActionPropagation,
+		Identifier:  job.identifier.Value,
+		Provider:    job.providerType,
+		ProviderTag: job.providerTag,
+		Status:      status,
+	}, err)
 
-	// Below this point is to inform ACME the challenge is ready to be validated
-	// by the server and to subsequently monitor the challenge to be moved to the
-	// valid or invalid state.
+	return nil
+}
 
+// validate informs the ACME server that job's challenge is ready and then monitors the
+// challenge until it reaches a final status
+func (service *Service) validate(job *solveJob, key acme.AccountKey, acmeService *acme.Service, rec *history.Recorder) error {
 	// inform ACME that the challenge is ready
-	challenge, err = acmeService.InstructServerToValidateChallenge(challenge.Url, key)
+	challenge, err := acmeService.InstructServerToValidateChallenge(job.challenge.Url, key)
 	if err != nil {
+		rec.Add(history.Step{Action: history.ActionValidate, Identifier: job.identifier.Value, Provider: job.providerType, ProviderTag: job.providerTag}, err)
 		return err
 	}
 
 	// sleep a little before first check
-	time.Sleep(7 * time.Second)
+	select {
+	case <-time.After(7 * time.Second):
+	case <-service.shutdownContext.Done():
+		return errShutdown(job.provisionDomain)
+	}
 
 	// monitor challenge status using exponential backoff
 	challCheckFunc := func() error {
@@ -139,8 +208,44 @@ func (service *Service) Solve(identifier acme.Identifier, challenges []acme.Chal
 	err = backoff.RetryNotify(challCheckFunc, bo, notifyFunc)
 	// if err returned, retry was exhausted
 	if err != nil {
-		return errors.Join(errChallengeRetriesExhausted, err)
+		err = errors.Join(errChallengeRetriesExhausted, err)
+	}
+
+	// record final challenge state
+	job.challenge.Status = challenge.Status
+	rec.Add(history.Step{
+		Action:      history.ActionValidate,
+		Identifier:  job.identifier.Value,
+		Provider:    job.providerType,
+		ProviderTag: job.providerTag,
+		Status:      challenge.Status,
+		AcmeError:   challenge.Error,
+	}, err)
+
+	if err != nil {
+		return err
 	}
 
 	return nil
 }
+
+// observeProvisioning records the result of a (de)provision action for job to metrics and
+// to rec
+func (service *Service) observeProvisioning(job *solveJob, action string, duration time.Duration, err error, rec *history.Recorder) {
+	step := history.Step{
+		Identifier:  job.identifier.Value,
+		Provider:    job.providerType,
+		ProviderTag: job.providerTag,
+	}
+
+	switch action {
+	case metrics.ChallengeActionProvision:
+		step.Action = history.ActionProvision
+		step.Status = string(job.challengeType)
+	case metrics.ChallengeActionDeprovision:
+		step.Action = history.ActionDeprovision
+	}
+
+	service.metrics.ObserveChallengeAction(job.providerType, job.providerTag, action, duration, err)
+	rec.Add(step, err)
+}
@@ -0,0 +1,102 @@
+package history
+
+import (
+	"certwarden-backend/pkg/acme"
+	"sync"
+	"time"
+)
+
+// maxOutputLength is the maximum length of stdout/stderr saved in a Step
+const maxOutputLength = 16384
+
+// Actions that are recorded as Steps
+const (
+	ActionOrderStatus       = "order_status"
+	ActionFinalize          = "finalize"
+	ActionDownloadCert      = "download_certificate"
+	ActionGetAuthorization  = "get_authorization"
+	ActionProvision         = "provision"
+	ActionProviderFallback  = "provider_fallback"
+	ActionPropagation       = "propagation_check"
+	ActionValidate          = "validate_challenge"
+	ActionChallengeSolved   = "challenge_solved"
+	ActionDeprovision       = "deprovision"
+	ActionPostProcessClient = "post_process_client"
+	ActionPostProcessScript = "post_process_command"
+	ActionVerifyDeployment  = "verify_deployment"
+)
+
+// Step is a single step taken during an order fulfillment or post processing attempt
+type Step struct {
+	Time        int64       `json:"time"`
+	Action      string      `json:"action"`
+	Identifier  string      `json:"identifier,omitempty"`
+	Provider    string      `json:"provider,omitempty"`
+	ProviderTag string      `json:"provider_tag,omitempty"`
+	Status      string      `json:"status,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	AcmeError   *acme.Error `json:"acme_error,omitempty"`
+	ExitCode    *int        `json:"exit_code,omitempty"`
+	Stdout      string      `json:"stdout,omitempty"`
+	Stderr      string      `json:"stderr,omitempty"`
+}
+
+// Recorder collects the Steps of a single attempt. It is safe for concurrent use and
+// all methods are no-op on a nil Recorder, so callers that don't care about history
+// may pass nil.
+type Recorder struct {
+	steps []Step
+	mu    sync.Mutex
+}
+
+// NewRecorder creates a new empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		steps: []Step{},
+	}
+}
+
+// Add records step. If step's Time is not set, it is set to now. err (if not nil)
+// is saved as the Step's Error.
+func (r *Recorder) Add(step Step, err error) {
+	if r == nil {
+		return
+	}
+
+	if step.Time == 0 {
+		step.Time = time.Now().Unix()
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	step.Stdout = truncate(step.Stdout)
+	step.Stderr = truncate(step.Stderr)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.steps = append(r.steps, step)
+}
+
+// Steps returns a copy of all of the Steps recorded so far
+func (r *Recorder) Steps() []Step {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	steps := make([]Step, len(r.steps))
+	_ = copy(steps, r.steps)
+
+	return steps
+}
+
+// truncate limits s to maxOutputLength
+func truncate(s string) string {
+	if len(s) > maxOutputLength {
+		return s[:maxOutputLength] + "...[truncated]"
+	}
+	return s
+}
@@ -3,6 +3,7 @@ package acme_accounts
 import (
 	"bytes"
 	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/validation"
 	"encoding/json"
@@ -100,6 +101,7 @@ func (service *Service) PostNewAccount(w http.ResponseWriter, r *http.Request) *
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetAcmeAccount, newAcct.ID, nil, newAcct.SummaryResponse())
 
 	detailedResp, err := newAcct.detailedResponse(service)
 	if err != nil {
@@ -1,7 +1,7 @@
 package acme_accounts
 
 import (
-	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/validation"
 	"encoding/json"
@@ -48,7 +48,7 @@ func (service *Service) NewAcmeAccount(w http.ResponseWriter, r *http.Request) *
 	}
 
 	// get crypto key
-	key, err := key_crypto.PemStringToKey(account.AccountKey.Pem, account.AccountKey.Algorithm)
+	key, err := account.AccountKey.CryptoPrivateKey()
 	if err != nil {
 		service.logger.Error(err)
 		return output.JsonErrInternal(err)
@@ -76,11 +76,13 @@ func (service *Service) NewAcmeAccount(w http.ResponseWriter, r *http.Request) *
 	}
 
 	// save ACME response to storage
+	before := account.SummaryResponse()
 	account, err = service.storage.PutAcmeAccountUpdate(acmeAcctToUpdatePayload(idParam, acmeAcct))
 	if err != nil {
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetAcmeAccount, account.ID, before, account.SummaryResponse())
 
 	updatedAcctDetailedResp, err := account.detailedResponse(service)
 	if err != nil {
@@ -153,11 +155,13 @@ func (service *Service) RefreshAcmeAccount(w http.ResponseWriter, r *http.Reques
 	}
 
 	// save ACME response to storage
+	before := account.SummaryResponse()
 	account, err = service.storage.PutAcmeAccountUpdate(acmeAcctToUpdatePayload(idParam, acmeAcct))
 	if err != nil {
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetAcmeAccount, account.ID, before, account.SummaryResponse())
 
 	updatedAcctDetailedResp, err := account.detailedResponse(service)
 	if err != nil {
@@ -237,11 +241,13 @@ func (service *Service) Deactivate(w http.ResponseWriter, r *http.Request) *outp
 	}
 
 	// save ACME response to storage
+	before := account.SummaryResponse()
 	account, err = service.storage.PutAcmeAccountUpdate(acmeAcctToUpdatePayload(idParam, acmeAcct))
 	if err != nil {
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetAcmeAccount, account.ID, before, account.SummaryResponse())
 
 	updatedAcctDetailedResp, err := account.detailedResponse(service)
 	if err != nil {
@@ -1,6 +1,7 @@
 package acme_accounts
 
 import (
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"fmt"
@@ -47,7 +48,7 @@ func (service *Service) PutNameDescAccount(w http.ResponseWriter, r *http.Reques
 
 	// validation
 	// id
-	_, outErr := service.getAccount(payload.ID)
+	account, outErr := service.getAccount(payload.ID)
 	if outErr != nil {
 		return outErr
 	}
@@ -69,6 +70,7 @@ func (service *Service) PutNameDescAccount(w http.ResponseWriter, r *http.Reques
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetAcmeAccount, updatedAcct.ID, account.SummaryResponse(), updatedAcct.SummaryResponse())
 
 	detailedResp, err := updatedAcct.detailedResponse(service)
 	if err != nil {
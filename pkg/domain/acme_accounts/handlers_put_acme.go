@@ -2,6 +2,7 @@ package acme_accounts
 
 import (
 	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/validation"
 	"encoding/json"
@@ -82,11 +83,13 @@ func (service *Service) ChangeEmail(w http.ResponseWriter, r *http.Request) *out
 	}
 
 	// save ACME response to storage
+	before := account.SummaryResponse()
 	account, err = service.storage.PutAcmeAccountUpdate(acmeAcctToUpdatePayload(idParam, acmeAcct))
 	if err != nil {
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetAcmeAccount, account.ID, before, account.SummaryResponse())
 
 	detailedResp, err := account.detailedResponse(service)
 	if err != nil {
@@ -193,6 +196,7 @@ func (service *Service) RolloverKey(w http.ResponseWriter, r *http.Request) *out
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetAcmeAccount, updatedAcct.ID, account.SummaryResponse(), updatedAcct.SummaryResponse())
 
 	detailedResp, err := updatedAcct.detailedResponse(service)
 	if err != nil {
@@ -65,6 +65,26 @@ func (serv Server) summaryResponse(service *Service) (ServerSummaryResponse, err
 	}, nil
 }
 
+// serverAuditDetails contains the details of an ACME server that are stored locally,
+// for the audit log
+type serverAuditDetails struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	DirectoryURL string `json:"directory_url"`
+	IsStaging    bool   `json:"is_staging"`
+}
+
+func (serv Server) auditDetails() serverAuditDetails {
+	return serverAuditDetails{
+		ID:           serv.ID,
+		Name:         serv.Name,
+		Description:  serv.Description,
+		DirectoryURL: serv.DirectoryURL,
+		IsStaging:    serv.IsStaging,
+	}
+}
+
 // serverDetailedResponse contains full details about an ACME server
 type serverDetailedResponse struct {
 	ServerSummaryResponse
@@ -1,6 +1,7 @@
 package acme_servers
 
 import (
+	"certwarden-backend/pkg/metrics"
 	"context"
 	"net/http"
 	"sync"
@@ -15,6 +16,10 @@ func (serv *Service) GetLogger() *zap.SugaredLogger {
 	return serv.logger
 }
 
+func (serv *Service) GetMetrics() *metrics.Service {
+	return serv.metrics
+}
+
 func (serv *Service) GetHttpClient() *http.Client {
 	return serv.httpClient
 }
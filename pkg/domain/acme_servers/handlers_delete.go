@@ -1,6 +1,7 @@
 package acme_servers
 
 import (
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"fmt"
 	"net/http"
@@ -22,7 +23,7 @@ func (service *Service) DeleteServer(w http.ResponseWriter, r *http.Request) *ou
 
 	// validation
 	// verify server exists
-	_, outErr := service.getServer(id)
+	server, outErr := service.getServer(id)
 	if outErr != nil {
 		return outErr
 	}
@@ -45,6 +46,7 @@ func (service *Service) DeleteServer(w http.ResponseWriter, r *http.Request) *ou
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetAcmeServer, id, server.auditDetails(), nil)
 
 	// delete acme Service
 	service.mu.Lock()
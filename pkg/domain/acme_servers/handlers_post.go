@@ -2,6 +2,7 @@ package acme_servers
 
 import (
 	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"errors"
@@ -71,6 +72,7 @@ func (service *Service) PostNewServer(w http.ResponseWriter, r *http.Request) *o
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetAcmeServer, newServer.ID, nil, newServer.auditDetails())
 
 	// spin up new acme.Service
 	service.mu.Lock()
@@ -2,6 +2,7 @@ package acme_servers
 
 import (
 	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"fmt"
@@ -44,7 +45,7 @@ func (service *Service) PutServerUpdate(w http.ResponseWriter, r *http.Request)
 
 	// validation
 	// id
-	_, outErr := service.getServer(payload.ID)
+	server, outErr := service.getServer(payload.ID)
 	if outErr != nil {
 		return outErr
 	}
@@ -74,6 +75,7 @@ func (service *Service) PutServerUpdate(w http.ResponseWriter, r *http.Request)
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetAcmeServer, updatedServer.ID, server.auditDetails(), updatedServer.auditDetails())
 
 	// if directory url changed, create new acme.Service
 	if payload.DirectoryURL != nil {
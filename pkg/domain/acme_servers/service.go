@@ -2,6 +2,7 @@ package acme_servers
 
 import (
 	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/metrics"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/pagination_sort"
 	"context"
@@ -18,6 +19,7 @@ var errServiceComponent = errors.New("necessary acme_servers service component i
 type App interface {
 	GetLogger() *zap.SugaredLogger
 	GetOutputter() *output.Service
+	GetMetrics() *metrics.Service
 	GetAcmeServerStorage() Storage
 	GetHttpClient() *http.Client
 	GetShutdownContext() context.Context
@@ -41,6 +43,7 @@ type Storage interface {
 type Service struct {
 	logger            *zap.SugaredLogger
 	output            *output.Service
+	metrics           *metrics.Service
 	storage           Storage
 	httpClient        *http.Client
 	shutdownContext   context.Context
@@ -65,6 +68,9 @@ func NewService(app App) (*Service, error) {
 		return nil, errServiceComponent
 	}
 
+	// metrics (nil is permitted, metrics are then not recorded)
+	service.metrics = app.GetMetrics()
+
 	// storage
 	service.storage = app.GetAcmeServerStorage()
 	if service.storage == nil {
@@ -5,16 +5,21 @@ import (
 	"certwarden-backend/pkg/datatypes/safecert"
 	"certwarden-backend/pkg/domain/acme_accounts"
 	"certwarden-backend/pkg/domain/acme_servers"
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/domain/app/backup"
 	"certwarden-backend/pkg/domain/app/updater"
 	"certwarden-backend/pkg/domain/authorizations"
 	"certwarden-backend/pkg/domain/certificates"
 	"certwarden-backend/pkg/domain/download"
+	"certwarden-backend/pkg/domain/monitored_endpoints"
 	"certwarden-backend/pkg/domain/orders"
 	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/metrics"
+	"certwarden-backend/pkg/notifications"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/storage/sqlite3"
 	"context"
 	"net/http"
 	"sync"
@@ -53,28 +58,32 @@ type appLogger struct {
 
 // Application is the main app struct
 type Application struct {
-	restart           bool
-	config            *config
-	logger            *appLogger
-	output            *output.Service
-	backup            *backup.Service
-	shutdownContext   context.Context
-	shutdown          func(restart bool)
-	shutdownWaitgroup *sync.WaitGroup
-	httpsCert         *safecert.SafeCert
-	httpClient        *http.Client
-	router            http.Handler
-	storage           *storage.Storage
-	acmeServers       *acme_servers.Service
-	challenges        *challenges.Service
-	updater           *updater.Service
-	auth              *auth.Service
-	keys              *private_keys.Service
-	accounts          *acme_accounts.Service
-	authorizations    *authorizations.Service
-	orders            *orders.Service
-	certificates      *certificates.Service
-	download          *download.Service
+	restart            bool
+	config             *config
+	logger             *appLogger
+	output             *output.Service
+	metrics            *metrics.Service
+	backup             *backup.Service
+	shutdownContext    context.Context
+	shutdown           func(restart bool)
+	shutdownWaitgroup  *sync.WaitGroup
+	httpsCert          *safecert.SafeCert
+	httpClient         *http.Client
+	notifications      *notifications.Service
+	router             http.Handler
+	storage            *storage.Storage
+	acmeServers        *acme_servers.Service
+	challenges         *challenges.Service
+	updater            *updater.Service
+	auth               *auth.Service
+	audit              *audit.Service
+	keys               *private_keys.Service
+	accounts           *acme_accounts.Service
+	authorizations     *authorizations.Service
+	orders             *orders.Service
+	certificates       *certificates.Service
+	download           *download.Service
+	monitoredEndpoints *monitored_endpoints.Service
 }
 
 // return various app parts which are used as needed by services
@@ -112,6 +121,14 @@ func (app *Application) GetConfigVersion() int {
 	return appConfigVersion
 }
 
+func (app *Application) GetDbFilenameWithPath() string {
+	return dataStorageAppDataPath + "/" + sqlite3.DbFilename
+}
+
+func (app *Application) GetDbCurrentUserVersion() int {
+	return storage.DbCurrentUserVersion
+}
+
 func (app *Application) GetLogger() *zap.SugaredLogger {
 	return app.logger.SugaredLogger
 }
@@ -143,6 +160,14 @@ func (app *Application) GetOutputter() *output.Service {
 	return app.output
 }
 
+func (app *Application) GetMetrics() *metrics.Service {
+	return app.metrics
+}
+
+func (app *Application) GetNotifications() *notifications.Service {
+	return app.notifications
+}
+
 func (app *Application) GetChallengesService() *challenges.Service {
 	return app.challenges
 }
@@ -151,6 +176,9 @@ func (app *Application) GetChallengesService() *challenges.Service {
 func (app *Application) GetAuthStorage() auth.Storage {
 	return app.storage
 }
+func (app *Application) GetAuditStorage() audit.Storage {
+	return app.storage
+}
 func (app *Application) GetKeyStorage() private_keys.Storage {
 	return app.storage
 }
@@ -169,6 +197,9 @@ func (app *Application) GetOrderStorage() orders.Storage {
 func (app *Application) GetDownloadStorage() download.Storage {
 	return app.storage
 }
+func (app *Application) GetMonitoredEndpointStorage() monitored_endpoints.Storage {
+	return app.storage
+}
 
 //
 
@@ -5,14 +5,19 @@ import (
 	"certwarden-backend/pkg/datatypes/safecert"
 	"certwarden-backend/pkg/domain/acme_accounts"
 	"certwarden-backend/pkg/domain/acme_servers"
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/domain/app/backup"
 	"certwarden-backend/pkg/domain/app/updater"
 	"certwarden-backend/pkg/domain/authorizations"
 	"certwarden-backend/pkg/domain/certificates"
 	"certwarden-backend/pkg/domain/download"
+	"certwarden-backend/pkg/domain/monitored_endpoints"
 	"certwarden-backend/pkg/domain/orders"
 	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/domain/private_keys/key_backend"
+	"certwarden-backend/pkg/metrics"
+	"certwarden-backend/pkg/notifications"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/storage"
 	"context"
@@ -60,6 +65,21 @@ func create() (*Application, error) {
 		}
 	}
 
+	// backup config (the passphrase is needed to decrypt the staged restore and to
+	// encrypt the backup of the current data)
+	err = app.loadBackupConfig()
+	if err != nil {
+		app.logger.Errorf("failed to read app backup config (%s)", err)
+		return app, err
+	}
+
+	// restore staged backup, if there is one (must be done before config and storage load)
+	err = app.backup.ApplyStagedRestore()
+	if err != nil {
+		app.logger.Errorf("failed to restore staged backup (%s)", err)
+		return app, err
+	}
+
 	// parse config file (also create if doesn't exist)
 	err = app.loadConfigFile()
 	if err != nil {
@@ -75,6 +95,13 @@ func create() (*Application, error) {
 		app.logger.Errorf("config.yaml config_version (%d) does not match app (%d), review config change log", *app.config.ConfigVersion, appConfigVersion)
 	}
 
+	// metrics service
+	app.metrics, err = metrics.NewService(app, &app.config.Metrics)
+	if err != nil {
+		app.logger.Errorf("failed to configure app metrics (%s)", err)
+		return app, err
+	}
+
 	// context for shutdown OS signal
 	osSignalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	// wait for the OS signal and then stop listening and call shutdown
@@ -126,11 +153,29 @@ func create() (*Application, error) {
 	// create http client
 	app.httpClient = makeHttpClient()
 
+	// notifications service
+	app.notifications, err = notifications.NewService(app, &app.config.Notifications)
+	if err != nil {
+		app.logger.Errorf("failed to configure app notifications (%s)", err)
+		return app, err
+	}
+
 	// start automatic backup service
-	app.backup.StartAutoBackupService(app, &app.config.Backup)
+	err = app.backup.StartAutoBackupService(app, &app.config.Backup)
+	if err != nil {
+		app.logger.Errorf("failed to configure app backup (%s)", err)
+		return app, err
+	}
+
+	// pkcs11 modules (for keys that reside on hardware tokens)
+	err = key_backend.ConfigurePKCS11(app.config.PKCS11)
+	if err != nil {
+		app.logger.Errorf("failed to configure app pkcs11 (%s)", err)
+		return app, err
+	}
 
 	// storage
-	app.storage, err = storage.OpenStorage(app)
+	app.storage, err = storage.OpenStorage(app, &app.config.KeyEncryption)
 	if err != nil {
 		app.logger.Errorf("failed to configure app storage (%s)", err)
 		return app, err
@@ -174,6 +219,13 @@ func create() (*Application, error) {
 		return app, err
 	}
 
+	// audit service
+	app.audit, err = audit.NewService(app)
+	if err != nil {
+		app.logger.Errorf("failed to configure app audit (%s)", err)
+		return app, err
+	}
+
 	// keys service
 	app.keys, err = private_keys.NewService(app)
 	if err != nil {
@@ -216,6 +268,13 @@ func create() (*Application, error) {
 		return app, err
 	}
 
+	// monitored endpoints service
+	app.monitoredEndpoints, err = monitored_endpoints.NewService(app)
+	if err != nil {
+		app.logger.Errorf("failed to configure app monitored endpoints (%s)", err)
+		return app, err
+	}
+
 	// make router
 	app.makeRouterAndRoutes()
 
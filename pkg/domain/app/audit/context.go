@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// pendingEntry holds details that handlers add to the audit entry of the request they
+// are serving
+type pendingEntry struct {
+	actor      string
+	actorRole  string
+	mu         sync.Mutex
+	targetType string
+	targetID   string
+	changes    []Change
+}
+
+// contextKey is the type for this package's context keys
+type contextKey int
+
+const pendingEntryKey contextKey = 0
+
+// RecordChange adds details of a change to the audit entry of the request that ctx belongs
+// to. targetType and targetID identify the changed object and before and after are the
+// object's state before and after the change (nil if the object didn't or doesn't exist).
+// If the request is not being audited, this is a no-op.
+func RecordChange(ctx context.Context, targetType string, targetID int, before any, after any) {
+	pending, ok := ctx.Value(pendingEntryKey).(*pendingEntry)
+	if !ok {
+		return
+	}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		// should never happen, but record that the diff wasn't possible
+		changes = []Change{{Field: "error", After: "failed to compute changes (" + err.Error() + ")"}}
+	}
+
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+
+	pending.targetType = targetType
+	pending.targetID = strconv.Itoa(targetID)
+	pending.changes = append(pending.changes, changes...)
+}
+
+// Begin returns a copy of r that handlers can record changes to (using RecordChange). actor
+// and actorRole identify the authenticated user that made the request.
+func (service *Service) Begin(r *http.Request, actor string, actorRole string) *http.Request {
+	if service == nil {
+		return r
+	}
+
+	pending := &pendingEntry{
+		actor:     actor,
+		actorRole: actorRole,
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), pendingEntryKey, pending))
+}
+
+// Finish saves the audit entry for r (which must have been returned by Begin). action
+// describes what the request did and statusCode is the result of the request.
+func (service *Service) Finish(r *http.Request, action string, statusCode int) {
+	if service == nil {
+		return
+	}
+
+	pending, ok := r.Context().Value(pendingEntryKey).(*pendingEntry)
+	if !ok {
+		service.logger.Errorf("audit: request %s %s was not started, entry not saved", r.Method, r.URL.Path)
+		return
+	}
+
+	payload := NewEntryPayload{
+		CreatedAt:  int(time.Now().Unix()),
+		Actor:      pending.actor,
+		ActorRole:  pending.actorRole,
+		Action:     action,
+		ClientIP:   clientIP(r),
+		StatusCode: statusCode,
+	}
+
+	// target & changes
+	pending.mu.Lock()
+	payload.TargetType = pending.targetType
+	payload.TargetID = pending.targetID
+	payload.Changes = pending.changes
+	pending.mu.Unlock()
+
+	// if handler didn't specify the target, use the route's params
+	if payload.TargetID == "" {
+		params := []string{}
+		for _, p := range httprouter.ParamsFromContext(r.Context()) {
+			params = append(params, p.Key+"="+p.Value)
+		}
+		payload.TargetID = strings.Join(params, ",")
+	}
+
+	err := service.storage.PostAuditEntry(payload)
+	if err != nil {
+		service.logger.Errorf("audit: failed to save entry for %s by %s (%s)", action, payload.Actor, err)
+	}
+}
+
+// clientIP returns the IP address of the client that made r
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"certwarden-backend/pkg/output"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Change is a single field that was changed
+type Change struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// secretFieldParts are parts of field names that indicate the field's value is secret
+var secretFieldParts = []string{
+	"api_key",
+	"access_key",
+	"client_key",
+	"credential",
+	"environment",
+	"hmac",
+	"password",
+	"pem",
+	"secret",
+	"token",
+}
+
+// isSecretField returns true if the field name indicates the value is secret
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range secretFieldParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactValue returns a redacted version of val. Strings are redacted directly and other
+// values are first converted to their JSON representation.
+func redactValue(val any) any {
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case string:
+		return output.RedactString(v)
+	default:
+		jsonBytes, err := json.Marshal(v)
+		if err != nil {
+			return output.RedactString(fmt.Sprint(v))
+		}
+		return output.RedactString(string(jsonBytes))
+	}
+}
+
+// redactNested returns a copy of val with the values of any secret fields in nested
+// objects redacted
+func redactNested(val any) any {
+	switch v := val.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for name, fieldVal := range v {
+			if isSecretField(name) {
+				redacted[name] = redactValue(fieldVal)
+			} else {
+				redacted[name] = redactNested(fieldVal)
+			}
+		}
+		return redacted
+
+	case []any:
+		redacted := make([]any, len(v))
+		for i := range v {
+			redacted[i] = redactNested(v[i])
+		}
+		return redacted
+
+	default:
+		return val
+	}
+}
+
+// toFieldMap marshals obj to JSON and then unmarshals it into a map of its top level
+// fields. A nil obj returns an empty map.
+func toFieldMap(obj any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if obj == nil {
+		return fields, nil
+	}
+
+	jsonBytes, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(jsonBytes, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("object is not a json object (%s)", err)
+	}
+
+	return fields, nil
+}
+
+// Diff returns the top level fields that differ between before and after, sorted by
+// field name. Both are compared as they would be marshalled to JSON. A nil before or
+// after indicates the object did not exist (e.g. it was created or deleted). Values of
+// secret fields, including those in nested objects, are redacted.
+func Diff(before any, after any) ([]Change, error) {
+	beforeFields, err := toFieldMap(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := toFieldMap(after)
+	if err != nil {
+		return nil, err
+	}
+
+	// all field names from both
+	names := []string{}
+	for name := range beforeFields {
+		names = append(names, name)
+	}
+	for name := range afterFields {
+		if _, exists := beforeFields[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []Change{}
+	for _, name := range names {
+		beforeVal := beforeFields[name]
+		afterVal := afterFields[name]
+
+		if reflect.DeepEqual(beforeVal, afterVal) {
+			continue
+		}
+
+		if isSecretField(name) {
+			beforeVal = redactValue(beforeVal)
+			afterVal = redactValue(afterVal)
+		} else {
+			beforeVal = redactNested(beforeVal)
+			afterVal = redactNested(afterVal)
+		}
+
+		changes = append(changes, Change{
+			Field:  name,
+			Before: beforeVal,
+			After:  afterVal,
+		})
+	}
+
+	return changes, nil
+}
+
+// Snapshot returns a copy of obj's current state for use as RecordChange's before; this
+// is needed when obj is a pointer that is going to be modified in place
+func Snapshot(obj any) any {
+	fields, err := toFieldMap(obj)
+	if err != nil {
+		return nil
+	}
+
+	return fields
+}
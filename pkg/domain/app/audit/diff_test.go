@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"testing"
+)
+
+type testObject struct {
+	Name       string            `json:"name"`
+	Command    string            `json:"post_processing_command"`
+	ApiKey     string            `json:"api_key"`
+	ApiKeyNew  string            `json:"api_key_new,omitempty"`
+	Config     map[string]string `json:"config"`
+	LastAccess int               `json:"last_access"`
+}
+
+func TestDiff(t *testing.T) {
+	before := testObject{
+		Name:    "cert",
+		Command: "./old.sh",
+		ApiKey:  "abcdefghijklmnop",
+		Config:  map[string]string{"api_token": "1234567890", "zone": "example.com"},
+	}
+	after := before
+	after.Command = "./new.sh"
+	after.ApiKeyNew = "qrstuvwxyz123456"
+	after.Config = map[string]string{"api_token": "0987654321", "zone": "example.com"}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sorted by field name
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes but got %d (%+v)", len(changes), changes)
+	}
+
+	// secret field (did not exist before)
+	if changes[0].Field != "api_key_new" || changes[0].Before != nil || changes[0].After != "qr************56" {
+		t.Errorf("api_key_new change wrong (%+v)", changes[0])
+	}
+
+	// nested secret
+	if changes[1].Field != "config" {
+		t.Fatalf("expected config change but got %s", changes[1].Field)
+	}
+	afterConfig, ok := changes[1].After.(map[string]any)
+	if !ok || afterConfig["api_token"] != "09************21" || afterConfig["zone"] != "example.com" {
+		t.Errorf("config change not redacted correctly (%+v)", changes[1])
+	}
+
+	// normal field
+	if changes[2].Field != "post_processing_command" || changes[2].Before != "./old.sh" || changes[2].After != "./new.sh" {
+		t.Errorf("post_processing_command change wrong (%+v)", changes[2])
+	}
+
+	// creation
+	changes, err = Diff(nil, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range changes {
+		if change.Before != nil {
+			t.Errorf("expected nil before for created object field %s", change.Field)
+		}
+		if change.Field == "api_key" && change.After != "ab************op" {
+			t.Errorf("api_key not redacted on creation (%+v)", change)
+		}
+	}
+
+	// not an object
+	_, err = Diff("string", nil)
+	if err == nil {
+		t.Error("expected error diffing a non-object")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	obj := &testObject{Name: "before"}
+	snapshot := Snapshot(obj)
+	obj.Name = "after"
+
+	changes, err := Diff(snapshot, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Before != "before" || changes[0].After != "after" {
+		t.Errorf("snapshot diff wrong (%+v)", changes)
+	}
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"strconv"
+	"time"
+)
+
+// Entry is a single record in the audit log
+type Entry struct {
+	ID         int
+	CreatedAt  time.Time
+	Actor      string
+	ActorRole  string
+	Action     string
+	TargetType string
+	TargetID   string
+	Changes    []Change
+	ClientIP   string
+	StatusCode int
+}
+
+// entryResponse is the JSON response for an Entry
+type entryResponse struct {
+	ID         int      `json:"id"`
+	CreatedAt  int      `json:"created_at"`
+	Actor      string   `json:"actor"`
+	ActorRole  string   `json:"actor_role"`
+	Action     string   `json:"action"`
+	TargetType string   `json:"target_type"`
+	TargetID   string   `json:"target_id"`
+	Changes    []Change `json:"changes"`
+	ClientIP   string   `json:"client_ip"`
+	StatusCode int      `json:"status_code"`
+}
+
+func (entry Entry) response() entryResponse {
+	changes := entry.Changes
+	if changes == nil {
+		changes = []Change{}
+	}
+
+	return entryResponse{
+		ID:         entry.ID,
+		CreatedAt:  int(entry.CreatedAt.Unix()),
+		Actor:      entry.Actor,
+		ActorRole:  entry.ActorRole,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Changes:    changes,
+		ClientIP:   entry.ClientIP,
+		StatusCode: entry.StatusCode,
+	}
+}
+
+// csvHeader is the header row of a CSV export; it must match the order of csvRecord
+var csvHeader = []string{"id", "created_at", "actor", "actor_role", "action", "target_type", "target_id", "changes", "client_ip", "status_code"}
+
+// csvRecord returns the entry as a CSV record; changes are encoded as JSON
+func (entry Entry) csvRecord(changesJson string) []string {
+	return []string{
+		strconv.Itoa(entry.ID),
+		entry.CreatedAt.UTC().Format(time.RFC3339),
+		entry.Actor,
+		entry.ActorRole,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		changesJson,
+		entry.ClientIP,
+		strconv.Itoa(entry.StatusCode),
+	}
+}
+
+// NewEntryPayload is the payload to save an audit entry to storage
+type NewEntryPayload struct {
+	CreatedAt  int
+	Actor      string
+	ActorRole  string
+	Action     string
+	TargetType string
+	TargetID   string
+	Changes    []Change
+	ClientIP   string
+	StatusCode int
+}
+
+// target types of audited objects
+const (
+	TargetAcmeAccount       = "acme_account"
+	TargetAcmeServer        = "acme_server"
+	TargetApiToken          = "api_token"
+	TargetCertificate       = "certificate"
+	TargetCertTemplate      = "cert_template"
+	TargetDNSProvider       = "dns_provider"
+	TargetMonitoredEndpoint = "monitored_endpoint"
+	TargetOrder             = "order"
+	TargetPrivateKey        = "private_key"
+	TargetUser              = "user"
+)
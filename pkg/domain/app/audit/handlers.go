@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"bytes"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errTimeFilterBad = errors.New("since and until must be unix timestamps")
+
+// parseQuery parses the request's pagination, sorting, and filters and validates the
+// filters that storage can't validate itself
+func parseQuery(r *http.Request) (pagination_sort.Query, error) {
+	q := pagination_sort.ParseRequestToQuery(r)
+
+	for _, filter := range []string{"since", "until"} {
+		val := q.Filter(filter)
+		if val == "" {
+			continue
+		}
+
+		_, err := strconv.Atoi(val)
+		if err != nil {
+			return q, errTimeFilterBad
+		}
+	}
+
+	return q, nil
+}
+
+// auditLogResponse provides the json response struct
+// to answer a query for a portion of the audit log
+type auditLogResponse struct {
+	output.JsonResponse
+	TotalEntries int             `json:"total_records"`
+	Entries      []entryResponse `json:"entries"`
+}
+
+// GetAuditLog returns a portion of the audit log, newest first unless otherwise sorted
+func (service *Service) GetAuditLog(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// parse pagination, sorting, and filters
+	query, err := parseQuery(r)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get entries from storage
+	entries, totalRows, err := service.storage.GetAuditEntries(query)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &auditLogResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.TotalEntries = totalRows
+	response.Entries = []entryResponse{}
+	for i := range entries {
+		response.Entries = append(response.Entries, entries[i].response())
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// ExportAuditLog sends the client all audit log entries that match the request's
+// filters as a file. The format is specified with the `format` query param and can
+// be `json` (default) or `csv`.
+func (service *Service) ExportAuditLog(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// parse sorting and filters; export always includes all matching entries
+	query, err := parseQuery(r)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	query = query.WithoutPagination()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		err = fmt.Errorf("export format '%s' is not valid (must be json or csv)", format)
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get entries from storage
+	entries, _, err := service.storage.GetAuditEntries(query)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	filenameNoExt := "certwarden_audit_log_" + time.Now().Format("2006.01.02-15.04.05")
+
+	// json
+	if format == "json" {
+		responses := []entryResponse{}
+		for i := range entries {
+			responses = append(responses, entries[i].response())
+		}
+
+		content, err := json.MarshalIndent(responses, "", "\t")
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
+
+		service.output.WriteJSONFile(w, r, filenameNoExt, content)
+		return nil
+	}
+
+	// csv
+	content := &bytes.Buffer{}
+	csvWriter := csv.NewWriter(content)
+
+	err = csvWriter.Write(csvHeader)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+
+	for i := range entries {
+		changesJson, err := json.Marshal(entries[i].response().Changes)
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
+
+		err = csvWriter.Write(entries[i].csvRecord(string(changesJson)))
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
+	}
+
+	csvWriter.Flush()
+	err = csvWriter.Error()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+
+	service.output.WriteCSV(w, r, filenameNoExt, content.Bytes())
+	return nil
+}
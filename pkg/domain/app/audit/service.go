@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+var errServiceComponent = errors.New("audit: necessary service component is missing")
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetOutputter() *output.Service
+	GetAuditStorage() Storage
+}
+
+// Storage interface for storage functions
+type Storage interface {
+	PostAuditEntry(payload NewEntryPayload) (err error)
+	GetAuditEntries(q pagination_sort.Query) (entries []Entry, totalRows int, err error)
+}
+
+// Service is the audit service, it records changes made by users
+type Service struct {
+	logger  *zap.SugaredLogger
+	output  *output.Service
+	storage Storage
+}
+
+// NewService creates a new audit service
+func NewService(app App) (*Service, error) {
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// output service
+	service.output = app.GetOutputter()
+	if service.output == nil {
+		return nil, errServiceComponent
+	}
+
+	// storage
+	service.storage = app.GetAuditStorage()
+	if service.storage == nil {
+		return nil, errServiceComponent
+	}
+
+	return service, nil
+}
@@ -0,0 +1,39 @@
+package auth
+
+import "context"
+
+// AuthenticatedUser is the user that made a request
+type AuthenticatedUser struct {
+	Username string
+	UserType string
+	Role     Role
+}
+
+// TypeAndName returns the user's type and name combined into a string, separated
+// by a | (matching the format used in auth log messages)
+func (user AuthenticatedUser) TypeAndName() string {
+	return user.UserType + "|" + user.Username
+}
+
+// contextKey is the type for this package's context keys
+type contextKey int
+
+const authenticatedUserKey contextKey = 0
+
+// ContextWithUser returns a copy of ctx that contains user
+func ContextWithUser(ctx context.Context, user AuthenticatedUser) context.Context {
+	return context.WithValue(ctx, authenticatedUserKey, user)
+}
+
+// ContextPermits returns true if ctx contains an authenticated user whose role has permission
+func ContextPermits(ctx context.Context, permission Permission) bool {
+	user, ok := UserFromContext(ctx)
+	return ok && user.Role.Permits(permission)
+}
+
+// UserFromContext returns the authenticated user from ctx; ok is false if there isn't one
+// (e.g. the request was not made on a secure route)
+func UserFromContext(ctx context.Context) (user AuthenticatedUser, ok bool) {
+	user, ok = ctx.Value(authenticatedUserKey).(AuthenticatedUser)
+	return user, ok
+}
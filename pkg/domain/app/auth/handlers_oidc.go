@@ -249,6 +249,13 @@ func (service *Service) OIDCLoginFinalize(w http.ResponseWriter, r *http.Request
 		return output.JsonErrUnauthorized
 	}
 
+	// determine user's role
+	role, err := service.oidcOwnerRoleFor(oidcStateObj.oidcIDToken)
+	if err != nil {
+		service.logger.Infof("client %s: login failed for oidc user '%s' (%s)", r.RemoteAddr, oidcStateObj.oidcIDToken.Subject, err)
+		return output.JsonErrUnauthorized
+	}
+
 	// validation done
 	idTokenStr, _ := oidcStateObj.oauth2Token.Extra("id_token").(string)
 	scopeStr, _ := oidcStateObj.oauth2Token.Extra("scope").(string)
@@ -263,10 +270,14 @@ func (service *Service) OIDCLoginFinalize(w http.ResponseWriter, r *http.Request
 			IDToken:      idTokenStr,
 			Scope:        scopeStr,
 		},
+		roleFor: service.oidcOwnerRoleFor,
+		ownerRevoked: func() {
+			service.oidcSetTokensOwnerRole(oidcStateObj.oidcIDToken.Subject, "")
+		},
 	}
 
 	// make new session
-	auth, err := service.sessionManager.NewSession(oidcStateObj.oidcIDToken.Subject, session_manager.UserTypeOIDC, extraFuncs)
+	auth, err := service.sessionManager.NewSession(oidcStateObj.oidcIDToken.Subject, session_manager.UserTypeOIDC, string(role), extraFuncs)
 	if err != nil {
 		service.logger.Errorf("client %s: login failed (internal error: %s)", r.RemoteAddr, err)
 		return output.JsonErrInternal(nil)
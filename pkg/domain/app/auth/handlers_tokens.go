@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/validation"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	errApiTokenIdBad      = errors.New("api token id is invalid")
+	errApiTokenNameBad    = errors.New("api token name is invalid")
+	errApiTokenNameInUse  = errors.New("api token name is already in use")
+	errApiTokenScopesBad  = errors.New("at least one scope must be specified")
+	errApiTokenExpiresBad = errors.New("api token expiration must be in the future")
+)
+
+// allApiTokensResponse is the JSON response for all api tokens
+type allApiTokensResponse struct {
+	output.JsonResponse
+	ApiTokens []apiTokenResponse `json:"api_tokens"`
+}
+
+// newApiTokenResponse is the JSON response for a newly created api token. This is the only
+// time the token itself is ever returned.
+type newApiTokenResponse struct {
+	output.JsonResponse
+	ApiToken apiTokenResponse `json:"api_token"`
+	Token    string           `json:"token"`
+}
+
+// tokenManagingUser returns the user that made r, provided the user is not an api token
+func tokenManagingUser(r *http.Request) (AuthenticatedUser, *output.JsonError) {
+	user, ok := UserFromContext(r.Context())
+	if !ok {
+		return AuthenticatedUser{}, output.JsonErrUnauthorized
+	}
+
+	// Note: Do NOT send detailed error since forbidden
+	if user.UserType == UserTypeApiToken {
+		return AuthenticatedUser{}, output.JsonErrForbidden
+	}
+
+	return user, nil
+}
+
+// GetApiTokens returns api tokens. Admins get all tokens, other users only get their own.
+func (service *Service) GetApiTokens(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, outErr := tokenManagingUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	tokens, err := service.tokens.storage.GetAllApiTokens()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	response := &allApiTokensResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.ApiTokens = []apiTokenResponse{}
+	for i := range tokens {
+		if user.Role.Permits(PermissionAdmin) || tokens[i].ownedBy(user) {
+			response.ApiTokens = append(response.ApiTokens, tokens[i].response())
+		}
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// NewApiTokenPayload is used to create a new api token
+type NewApiTokenPayload struct {
+	Name        *string  `json:"name"`
+	Scopes      []Scope  `json:"scopes"`
+	AllowedIPs  []string `json:"allowed_ips"`
+	ExpiresAt   *int     `json:"expires_at"`
+	TokenHash   string   `json:"-"`
+	TokenPrefix string   `json:"-"`
+	OwnerType   string   `json:"-"`
+	OwnerName   string   `json:"-"`
+	OwnerRole   Role     `json:"-"`
+	CreatedAt   int      `json:"-"`
+}
+
+// PostNewApiToken creates a new api token owned by the user making the request. A token's
+// scopes can't exceed the permissions of its owner's role.
+func (service *Service) PostNewApiToken(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, outErr := tokenManagingUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	var payload NewApiTokenPayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// name
+	if payload.Name == nil || !validation.NameValid(*payload.Name) {
+		service.logger.Debug(errApiTokenNameBad)
+		return output.JsonErrValidationFailed(errApiTokenNameBad)
+	}
+	tokens, err := service.tokens.storage.GetAllApiTokens()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	for i := range tokens {
+		if strings.EqualFold(tokens[i].Name, *payload.Name) {
+			service.logger.Debug(errApiTokenNameInUse)
+			return output.JsonErrValidationFailed(errApiTokenNameInUse)
+		}
+	}
+	// scopes
+	if len(payload.Scopes) == 0 {
+		service.logger.Debug(errApiTokenScopesBad)
+		return output.JsonErrValidationFailed(errApiTokenScopesBad)
+	}
+	for _, scope := range payload.Scopes {
+		_, permission, err := scope.parse()
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+		if !user.Role.Permits(permission) {
+			err = fmt.Errorf("scope '%s' exceeds the permissions of role '%s'", scope, user.Role)
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	}
+	// allowed ips
+	for i := range payload.AllowedIPs {
+		payload.AllowedIPs[i], err = parseAllowedIP(payload.AllowedIPs[i])
+		if err != nil {
+			err = fmt.Errorf("allowed ip is invalid (%s)", err)
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	}
+	// expiration (0 is never)
+	now := time.Now()
+	if payload.ExpiresAt != nil && *payload.ExpiresAt != 0 && int64(*payload.ExpiresAt) <= now.Unix() {
+		service.logger.Debug(errApiTokenExpiresBad)
+		return output.JsonErrValidationFailed(errApiTokenExpiresBad)
+	}
+	// end validation
+
+	token, tokenHash, err := generateApiToken()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+
+	payload.TokenHash = tokenHash
+	payload.TokenPrefix = token[:apiTokenDisplayLength]
+	payload.OwnerType = user.UserType
+	payload.OwnerName = user.Username
+	payload.OwnerRole = user.Role
+	payload.CreatedAt = int(now.Unix())
+
+	id, err := service.tokens.storage.PostNewApiToken(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	apiToken, err := service.tokens.storage.GetOneApiTokenById(id)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: user '%s' created api token '%s' (id: %d, scopes: %v)", r.RemoteAddr, user.TypeAndName(), apiToken.Name, apiToken.ID, apiToken.Scopes)
+	audit.RecordChange(r.Context(), audit.TargetApiToken, apiToken.ID, nil, apiToken.response())
+
+	response := &newApiTokenResponse{}
+	response.StatusCode = http.StatusCreated
+	response.Message = "created api token"
+	response.ApiToken = apiToken.response()
+	response.Token = token
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// DeleteApiToken revokes (deletes) the specified api token. Users can revoke their own
+// tokens and admins can revoke any token.
+func (service *Service) DeleteApiToken(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, outErr := tokenManagingUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil || !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(errApiTokenIdBad)
+		return output.JsonErrValidationFailed(errApiTokenIdBad)
+	}
+
+	apiToken, err := service.tokens.storage.GetOneApiTokenById(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			service.logger.Debug(err)
+			return output.JsonErrNotFound(fmt.Errorf("api token id %d not found", id))
+		}
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// don't reveal other users' tokens exist
+	if !user.Role.Permits(PermissionAdmin) && !apiToken.ownedBy(user) {
+		service.logger.Debugf("user '%s' tried to delete api token id %d which they don't own", user.TypeAndName(), id)
+		return output.JsonErrNotFound(fmt.Errorf("api token id %d not found", id))
+	}
+
+	err = service.tokens.storage.DeleteApiToken(apiToken.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: user '%s' revoked api token '%s' (id: %d)", r.RemoteAddr, user.TypeAndName(), apiToken.Name, apiToken.ID)
+	audit.RecordChange(r.Context(), audit.TargetApiToken, apiToken.ID, apiToken.response(), nil)
+
+	response := &output.JsonResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = fmt.Sprintf("revoked api token (id: %d)", apiToken.ID)
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
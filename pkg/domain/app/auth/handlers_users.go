@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"certwarden-backend/pkg/output"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errLocalDisabled = errors.New("auth: user management is for local logins, which are not configured")
+
+// allUsersResponse is the JSON response for all users
+type allUsersResponse struct {
+	output.JsonResponse
+	Users []userResponse `json:"users"`
+}
+
+// oneUserResponse is the JSON response for a single user
+type oneUserResponse struct {
+	output.JsonResponse
+	User userResponse `json:"user"`
+}
+
+// GetAllUsers returns all local users
+func (service *Service) GetAllUsers(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	if !service.methodLocalEnabled() {
+		return output.JsonErrNotFound(errLocalDisabled)
+	}
+
+	users, err := service.local.storage.GetAllUsers()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	response := &allUsersResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Users = []userResponse{}
+	for i := range users {
+		response.Users = append(response.Users, users[i].response())
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// GetOneUser returns the specified local user
+func (service *Service) GetOneUser(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	if !service.methodLocalEnabled() {
+		return output.JsonErrNotFound(errLocalDisabled)
+	}
+
+	user, outErr := service.getUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	response := &oneUserResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.User = user.response()
+
+	err := service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// NewUserPayload is used to create a new local user
+type NewUserPayload struct {
+	Username     *string `json:"username"`
+	Password     *string `json:"password"`
+	Role         *Role   `json:"role"`
+	PasswordHash string  `json:"-"`
+	CreatedAt    int     `json:"-"`
+	UpdatedAt    int     `json:"-"`
+}
+
+// PostNewUser creates a new local user
+func (service *Service) PostNewUser(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	if !service.methodLocalEnabled() {
+		return output.JsonErrNotFound(errLocalDisabled)
+	}
+
+	var payload NewUserPayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// username
+	if payload.Username == nil {
+		service.logger.Debug(errUsernameBad)
+		return output.JsonErrValidationFailed(errUsernameBad)
+	}
+	outErr := service.usernameAvailable(*payload.Username)
+	if outErr != nil {
+		return outErr
+	}
+	// password
+	if payload.Password == nil || len(*payload.Password) < 1 {
+		service.logger.Debug(errPasswordBad)
+		return output.JsonErrValidationFailed(errPasswordBad)
+	}
+	// role
+	if payload.Role == nil || !payload.Role.IsValid() {
+		service.logger.Debug(errRoleBad)
+		return output.JsonErrValidationFailed(errRoleBad)
+	}
+	// end validation
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(*payload.Password), BcryptCost)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+	payload.PasswordHash = string(passwordHash)
+	payload.CreatedAt = int(time.Now().Unix())
+	payload.UpdatedAt = payload.CreatedAt
+
+	id, err := service.local.storage.PostNewUser(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	user, err := service.local.storage.GetOneUserById(id)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: created user '%s' (id: %d, role: %s)", r.RemoteAddr, user.Username, user.ID, user.Role)
+	audit.RecordChange(r.Context(), audit.TargetUser, user.ID, nil, user.response())
+
+	response := &oneUserResponse{}
+	response.StatusCode = http.StatusCreated
+	response.Message = "created user"
+	response.User = user.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// updateUserPayload is used to change a local user's role and/or reset their password
+type updateUserPayload struct {
+	Role     *Role   `json:"role"`
+	Password *string `json:"password"`
+}
+
+// PutUser updates the specified user's role and/or password
+func (service *Service) PutUser(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	if !service.methodLocalEnabled() {
+		return output.JsonErrNotFound(errLocalDisabled)
+	}
+
+	user, outErr := service.getUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	var payload updateUserPayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// role
+	if payload.Role != nil {
+		if !payload.Role.IsValid() {
+			service.logger.Debug(errRoleBad)
+			return output.JsonErrValidationFailed(errRoleBad)
+		}
+
+		if *payload.Role != RoleAdmin {
+			lastAdmin, err := service.isLastAdmin(user)
+			if err != nil {
+				service.logger.Error(err)
+				return output.JsonErrStorageGeneric(err)
+			}
+			if lastAdmin {
+				service.logger.Debug(errLastAdmin)
+				return output.JsonErrValidationFailed(errLastAdmin)
+			}
+		}
+	}
+	// password
+	if payload.Password != nil && len(*payload.Password) < 1 {
+		service.logger.Debug(errPasswordBad)
+		return output.JsonErrValidationFailed(errPasswordBad)
+	}
+	// end validation
+
+	before := user.response()
+
+	// update
+	if payload.Role != nil {
+		err = service.local.storage.PutUserRole(user.ID, *payload.Role)
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrStorageGeneric(err)
+		}
+	}
+
+	if payload.Password != nil {
+		passwordHash, err := bcrypt.GenerateFromPassword([]byte(*payload.Password), BcryptCost)
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
+
+		_, err = service.local.storage.UpdateUserPassword(user.Username, string(passwordHash))
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrStorageGeneric(err)
+		}
+	}
+
+	user, err = service.local.storage.GetOneUserById(user.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: updated user '%s' (id: %d, role: %s, password changed: %t)", r.RemoteAddr, user.Username, user.ID, user.Role, payload.Password != nil)
+
+	// the user must log in again after a password or role change
+	if payload.Password != nil || user.Role != before.Role {
+		deleted := service.sessionManager.DeleteUserSessions(user.Username, session_manager.UserTypeLocal)
+		service.logger.Infof("client %s: revoked %d session(s) of user '%s'", r.RemoteAddr, deleted, user.Username)
+	}
+
+	audit.RecordChange(r.Context(), audit.TargetUser, user.ID, before, user.response())
+
+	response := &oneUserResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "updated user"
+	response.User = user.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the specified user. The currently logged in user and the last admin
+// cannot be deleted.
+func (service *Service) DeleteUser(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	if !service.methodLocalEnabled() {
+		return output.JsonErrNotFound(errLocalDisabled)
+	}
+
+	user, outErr := service.getUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	// validation
+	authUser, ok := UserFromContext(r.Context())
+	if ok && authUser.UserType == session_manager.UserTypeLocal && authUser.Username == user.Username {
+		service.logger.Debug(errDeleteSelf)
+		return output.JsonErrValidationFailed(errDeleteSelf)
+	}
+
+	lastAdmin, err := service.isLastAdmin(user)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	if lastAdmin {
+		service.logger.Debug(errLastAdmin)
+		return output.JsonErrValidationFailed(errLastAdmin)
+	}
+	// end validation
+
+	err = service.local.storage.DeleteUser(user.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: deleted user '%s' (id: %d)", r.RemoteAddr, user.Username, user.ID)
+	audit.RecordChange(r.Context(), audit.TargetUser, user.ID, user.response(), nil)
+
+	response := &output.JsonResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = fmt.Sprintf("deleted user (id: %d)", user.ID)
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
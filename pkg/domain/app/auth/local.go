@@ -6,13 +6,14 @@ type localExtraFuncs struct {
 	storageService Storage
 }
 
-// RefreshCheck for local users just queries the DB to confirm no-error
-func (lef *localExtraFuncs) RefreshCheck() error {
+// RefreshCheck for local users queries the DB to confirm the user still exists and
+// returns the user's current role
+func (lef *localExtraFuncs) RefreshCheck() (string, error) {
 	// get user must work
-	_, err := lef.storageService.GetOneUserByName(lef.dbUsername)
+	user, err := lef.storageService.GetOneUserByName(lef.dbUsername)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return string(user.Role), nil
 }
@@ -73,13 +73,17 @@ type oidcExtraFuncs struct {
 	cfg               *oauth2.Config
 	idTokenVerifier   *oidc.IDTokenVerifier
 	token             *expectedToken
+	roleFor           func(*oidc.IDToken) (Role, error)
+	// ownerRevoked is called if the Idp rejects the refresh token (e.g. the user was disabled)
+	ownerRevoked func()
 
 	mu sync.Mutex
 }
 
 // RefreshCheck for oidc performs a token refresh with the Idp; this is done manually instead
-// of with the OIDC package because that pkg doesn't appear to have a force refresh option
-func (oef *oidcExtraFuncs) RefreshCheck() error {
+// of with the OIDC package because that pkg doesn't appear to have a force refresh option. The
+// user's role is re-evaluated using the new id token.
+func (oef *oidcExtraFuncs) RefreshCheck() (string, error) {
 	oef.mu.Lock()
 	defer oef.mu.Unlock()
 
@@ -93,51 +97,56 @@ func (oef *oidcExtraFuncs) RefreshCheck() error {
 	// make http request
 	req, err := http.NewRequest("POST", oef.cfg.Endpoint.TokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
-		return err
+		return "", err
 	}
 	req.Header.Add("content-type", "application/x-www-form-urlencoded")
 
 	// do request with ctx http client
 	httpClient, found := oef.ctxWithHttpClient.Value(oauth2.HTTPClient).(*http.Client)
 	if !found {
-		return fmt.Errorf("oidc refresh failed, http client is missing")
+		return "", fmt.Errorf("oidc refresh failed, http client is missing")
 	}
 	res, err := httpClient.Do(req)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer res.Body.Close()
 
 	// see: https://datatracker.ietf.org/doc/html/rfc6749#section-5.1
 	// success must return 200
 	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("oidc refresh failed, status %d", res.StatusCode)
+		// see: https://datatracker.ietf.org/doc/html/rfc6749#section-5.2
+		// the refresh token is no longer valid for the user
+		if (res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnauthorized) && oef.ownerRevoked != nil {
+			oef.ownerRevoked()
+		}
+		return "", fmt.Errorf("oidc refresh failed, status %d", res.StatusCode)
 	}
 
 	// unmarshal the token
 	bodyBytes, err := io.ReadAll(res.Body)
 	if err != nil {
-		return fmt.Errorf("oidc refresh failed, failed to read body (%s)", err)
+		return "", fmt.Errorf("oidc refresh failed, failed to read body (%s)", err)
 	}
 
 	var t expectedToken
 	err = json.Unmarshal(bodyBytes, &t)
 	if err != nil {
-		return fmt.Errorf("oidc refresh failed, failed to unmarshal new token (%s)", err)
+		return "", fmt.Errorf("oidc refresh failed, failed to unmarshal new token (%s)", err)
 	}
 
 	// validate token values
 	if t.AccessToken == "" {
-		return errors.New("oidc refresh failed, new access token empty")
+		return "", errors.New("oidc refresh failed, new access token empty")
 	}
 
 	if t.RefreshToken == "" {
-		return errors.New("oidc refresh failed, new refresh token empty")
+		return "", errors.New("oidc refresh failed, new refresh token empty")
 	}
 
-	_, err = oef.idTokenVerifier.Verify(oef.ctxWithHttpClient, t.IDToken)
+	idToken, err := oef.idTokenVerifier.Verify(oef.ctxWithHttpClient, t.IDToken)
 	if err != nil {
-		return fmt.Errorf("oidc refresh failed, id token failed verification (%s)", err)
+		return "", fmt.Errorf("oidc refresh failed, id token failed verification (%s)", err)
 	}
 
 	// Validate the required scopes were granted
@@ -153,14 +162,20 @@ func (oef *oidcExtraFuncs) RefreshCheck() error {
 			}
 
 			if !found {
-				return fmt.Errorf("oidc refresh failed, required scope '%s' was not granted", requiredScope)
+				return "", fmt.Errorf("oidc refresh failed, required scope '%s' was not granted", requiredScope)
 			}
 		}
 	}
 
+	// role may have changed
+	role, err := oef.roleFor(idToken)
+	if err != nil {
+		return "", fmt.Errorf("oidc refresh failed, %s", err)
+	}
+
 	// set new token & return ok
 	oef.token = &t
-	return nil
+	return string(role), nil
 }
 
 // startOidcCleanerService starts a goroutine to remove pending sessions that were abandoned;
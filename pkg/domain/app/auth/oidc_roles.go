@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+var errOidcNoRole = errors.New("oidc user does not match any role mapping and there is no default role")
+
+// configureOidcRoles validates and saves the OIDC role mapping config
+func (service *Service) configureOidcRoles(cfg *Config) error {
+	for role := range cfg.OIDC.RoleMappings {
+		if !role.IsValid() {
+			return fmt.Errorf("auth: oidc role mapping role '%s' is not valid", role)
+		}
+	}
+
+	if len(cfg.OIDC.RoleMappings) > 0 && cfg.OIDC.RoleClaim == "" {
+		return errors.New("auth: oidc role mappings require role_claim to be specified")
+	}
+
+	if cfg.OIDC.DefaultRole != "" && !cfg.OIDC.DefaultRole.IsValid() {
+		return fmt.Errorf("auth: oidc default role '%s' is not valid", cfg.OIDC.DefaultRole)
+	}
+
+	service.oidc.roleClaim = cfg.OIDC.RoleClaim
+	service.oidc.roleMappings = cfg.OIDC.RoleMappings
+	service.oidc.defaultRole = cfg.OIDC.DefaultRole
+
+	return nil
+}
+
+// oidcRoleFor returns the role for the user of idToken. If there are no role mappings, all
+// users are admins. Otherwise, the most privileged role that has a value matching the token's
+// role claim is returned. If nothing matches, the default role is used (if there is one).
+func (service *Service) oidcRoleFor(idToken *oidc.IDToken) (Role, error) {
+	if len(service.oidc.roleMappings) == 0 {
+		return RoleAdmin, nil
+	}
+
+	claims := map[string]any{}
+	err := idToken.Claims(&claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse id token claims (%s)", err)
+	}
+
+	// claim may be a single string or an array of strings
+	claimValues := []string{}
+	switch val := claims[service.oidc.roleClaim].(type) {
+	case string:
+		claimValues = append(claimValues, val)
+	case []any:
+		for i := range val {
+			s, ok := val[i].(string)
+			if ok {
+				claimValues = append(claimValues, s)
+			}
+		}
+	}
+
+	for _, role := range rolesByPrivilege {
+		for _, mappedVal := range service.oidc.roleMappings[role] {
+			if slices.Contains(claimValues, mappedVal) {
+				return role, nil
+			}
+		}
+	}
+
+	if service.oidc.defaultRole != "" {
+		return service.oidc.defaultRole, nil
+	}
+
+	return "", errOidcNoRole
+}
+
+// oidcOwnerRoleFor returns the role for the user of idToken (see oidcRoleFor) and also saves
+// it as the owner role of the user's api tokens, so the tokens follow the user's role as it
+// is re-evaluated on each login and session refresh. If the user no longer has a role, the
+// tokens' owner role is cleared (which makes them invalid).
+func (service *Service) oidcOwnerRoleFor(idToken *oidc.IDToken) (Role, error) {
+	role, err := service.oidcRoleFor(idToken)
+	if err != nil && !errors.Is(err, errOidcNoRole) {
+		return "", err
+	}
+
+	service.oidcSetTokensOwnerRole(idToken.Subject, role)
+
+	return role, err
+}
+
+// oidcSetTokensOwnerRole saves role as the owner role of the oidc user's api tokens
+func (service *Service) oidcSetTokensOwnerRole(subject string, role Role) {
+	err := service.tokens.storage.PutApiTokensOwnerRole(session_manager.UserTypeOIDC, subject, role)
+	if err != nil {
+		service.logger.Errorf("failed to update api tokens owner role for oidc user '%s' (%s)", subject, err)
+	}
+}
@@ -0,0 +1,51 @@
+package auth
+
+import "slices"
+
+// Role is a user's role, which determines what the user is permitted to do
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleOperator Role = "operator"
+	RoleViewer   Role = "viewer"
+	RoleDownload Role = "download"
+)
+
+// rolesByPrivilege is all of the roles, ordered from most to least privileged
+var rolesByPrivilege = []Role{RoleAdmin, RoleOperator, RoleViewer, RoleDownload}
+
+// Permission is the permission a route requires
+type Permission string
+
+const (
+	// PermissionAuthenticated is permitted for any logged in user
+	PermissionAuthenticated Permission = "authenticated"
+	// PermissionView is for read only access to (non-sensitive) data
+	PermissionView Permission = "view"
+	// PermissionDownload is for downloading keys and certificates
+	PermissionDownload Permission = "download"
+	// PermissionOrder is for placing, retrying, and revoking orders and running post processing
+	PermissionOrder Permission = "order"
+	// PermissionAdmin is for everything else (e.g. creating, modifying, and deleting objects,
+	// app control, user management)
+	PermissionAdmin Permission = "admin"
+)
+
+// rolePermissions maps each role to its permissions
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin:    {PermissionAuthenticated, PermissionView, PermissionDownload, PermissionOrder, PermissionAdmin},
+	RoleOperator: {PermissionAuthenticated, PermissionView, PermissionDownload, PermissionOrder},
+	RoleViewer:   {PermissionAuthenticated, PermissionView},
+	RoleDownload: {PermissionAuthenticated, PermissionDownload},
+}
+
+// IsValid returns true if role is a known Role
+func (role Role) IsValid() bool {
+	return slices.Contains(rolesByPrivilege, role)
+}
+
+// Permits returns true if role has permission
+func (role Role) Permits(permission Permission) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
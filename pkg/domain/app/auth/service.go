@@ -18,6 +18,9 @@ import (
 
 var errServiceComponent = errors.New("necessary auth service component is missing")
 
+// ErrForbidden is returned when an authenticated user's role does not have the required permission
+var ErrForbidden = errors.New("auth: user's role does not have the required permission")
+
 // constant for bcrypt cost value
 const BcryptCost = 12
 
@@ -39,13 +42,27 @@ type User struct {
 	ID           int
 	Username     string
 	PasswordHash string
+	Role         Role
 	CreatedAt    int
 	UpdatedAt    int
 }
 
 type Storage interface {
+	GetAllApiTokens() ([]ApiToken, error)
+	GetOneApiTokenById(id int) (ApiToken, error)
+	GetApiTokenByHash(tokenHash string) (ApiToken, error)
+	PostNewApiToken(payload NewApiTokenPayload) (id int, err error)
+	PutApiTokenLastUsed(id int, lastUsedAt int) (err error)
+	PutApiTokensOwnerRole(ownerType string, ownerName string, role Role) (err error)
+	DeleteApiToken(id int) (err error)
+
+	GetAllUsers() ([]User, error)
+	GetOneUserById(id int) (User, error)
 	GetOneUserByName(username string) (User, error)
+	PostNewUser(payload NewUserPayload) (userId int, err error)
+	PutUserRole(id int, role Role) (err error)
 	UpdateUserPassword(username string, newPasswordHash string) (userId int, err error)
+	DeleteUser(id int) (err error)
 }
 
 type Config struct {
@@ -57,6 +74,13 @@ type Config struct {
 		ClientID       string `yaml:"client_id"`
 		ClientSecret   string `yaml:"client_secret"`
 		APIRedirectURI string `yaml:"api_redirect_uri"`
+		// RoleClaim is the id token claim used for RoleMappings (e.g. 'groups')
+		RoleClaim string `yaml:"role_claim"`
+		// RoleMappings maps each role to the claim values that grant it; if no mappings are
+		// configured, all OIDC users are admins
+		RoleMappings map[Role][]string `yaml:"role_mappings"`
+		// DefaultRole is the role of users who don't match any mapping; if blank, they can't log in
+		DefaultRole Role `yaml:"default_role"`
 	} `yaml:"oidc"`
 }
 
@@ -71,11 +95,17 @@ type Service struct {
 	local                     struct {
 		storage Storage
 	}
+	tokens struct {
+		storage Storage
+	}
 	oidc struct {
 		ctxWithHttpClient context.Context
 		pendingSessions   *safemap.SafeMap[*oidcPendingSession]
 		oauth2Config      *oauth2.Config
 		idTokenVerifier   *oidc.IDTokenVerifier
+		roleClaim         string
+		roleMappings      map[Role][]string
+		defaultRole       Role
 	}
 }
 
@@ -106,6 +136,12 @@ func NewService(app App, cfg *Config) (*Service, error) {
 	service.sessionManager.StartCleanerService(app.GetShutdownContext(), app.GetShutdownWaitGroup())
 
 	// storage
+	// api tokens are always available (regardless of login methods)
+	service.tokens.storage = app.GetAuthStorage()
+	if service.tokens.storage == nil {
+		return nil, errServiceComponent
+	}
+
 	if cfg.Local.Enabled != nil && *cfg.Local.Enabled {
 		service.local.storage = app.GetAuthStorage()
 		if service.local.storage == nil {
@@ -158,6 +194,13 @@ func NewService(app App, cfg *Config) (*Service, error) {
 			// oidc id token verifier
 			service.oidc.idTokenVerifier = provider.Verifier(&oidc.Config{ClientID: cfg.OIDC.ClientID})
 
+			// role mapping
+			err = service.configureOidcRoles(cfg)
+			if err != nil {
+				service.logger.Error(err)
+				return nil, err
+			}
+
 			// clean stale pending sessions
 			service.startOidcCleanerService(service.oidc.ctxWithHttpClient, app.GetShutdownWaitGroup())
 		}
@@ -166,10 +209,34 @@ func NewService(app App, cfg *Config) (*Service, error) {
 	return service, nil
 }
 
-// make ValidateAuthHeader available to App
-func (service *Service) ValidateAuthHeader(r *http.Request, w http.ResponseWriter, logTaskName string) error {
-	_, err := service.sessionManager.ValidateAuthHeader(r, w, logTaskName)
-	return err
+// ValidateAuthHeader validates the access token (or api token) in r's auth header and confirms
+// the user's role has permission. If the user is authenticated but lacks permission, ErrForbidden
+// is returned.
+func (service *Service) ValidateAuthHeader(r *http.Request, w http.ResponseWriter, logTaskName string, permission Permission) (AuthenticatedUser, error) {
+	// api token
+	if token, isToken := isApiToken(r.Header.Get("Authorization")); isToken {
+		// indicate Authorization header influenced the response
+		w.Header().Add("Vary", "Authorization")
+		return service.validateApiToken(r, token, logTaskName, permission)
+	}
+
+	auth, err := service.sessionManager.ValidateAuthHeader(r, w, logTaskName)
+	if err != nil {
+		return AuthenticatedUser{}, err
+	}
+
+	user := AuthenticatedUser{
+		Username: auth.Username,
+		UserType: string(auth.UserType),
+		Role:     Role(auth.Role),
+	}
+
+	if !user.Role.Permits(permission) {
+		service.logger.Infof("client %s: %s failed (user '%s' with role '%s' does not have permission '%s')", r.RemoteAddr, logTaskName, auth.UserTypeAndName(), user.Role, permission)
+		return user, ErrForbidden
+	}
+
+	return user, nil
 }
 
 // auth method enabled checks
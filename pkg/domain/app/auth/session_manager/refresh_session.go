@@ -49,9 +49,10 @@ func (sm *SessionManager) RefreshSession(r *http.Request, w http.ResponseWriter)
 		return nil, err
 	}
 
-	// run any extra check
+	// run any extra check (and update role)
+	role := session.authorization.Role
 	if session.extraFuncs != nil {
-		err = session.extraFuncs.RefreshCheck()
+		role, err = session.extraFuncs.RefreshCheck()
 		if err != nil {
 			sm.DeleteSessionCookie(w)
 			return nil, err
@@ -59,7 +60,7 @@ func (sm *SessionManager) RefreshSession(r *http.Request, w http.ResponseWriter)
 	}
 
 	// session was found, update it and return username and new auth
-	session.authorization, err = sm.newAuthorization(session.authorization.Username, userType(session.authorization.UserType))
+	session.authorization, err = sm.newAuthorization(session.authorization.Username, userType(session.authorization.UserType), role)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't make new auth: %s", err)
 	}
@@ -15,8 +15,9 @@ var errInvalidSessionID = errors.New("invalid session id")
 var errAddExisting = errors.New("cannot add session (duplicate id)")
 
 type extraFuncs interface {
-	// RefreshCheck performs additional validation prior to returning a succesful refresh
-	RefreshCheck() error
+	// RefreshCheck performs additional validation prior to returning a succesful refresh; it
+	// returns the user's current role (which may have changed since the session was created)
+	RefreshCheck() (role string, err error)
 }
 
 // session contains information about a given session
@@ -48,10 +49,10 @@ func NewSessionManager(https bool, corsPermitted bool, logger *zap.SugaredLogger
 	return sm
 }
 
-// NewSession creates a new session for the specified username and returns
+// NewSession creates a new session for the specified username and role and returns
 // the newly created authorization.
-func (sm *SessionManager) NewSession(username string, usertype userType, extraFuncs extraFuncs) (*authorization, error) {
-	auth, err := sm.newAuthorization(username, usertype)
+func (sm *SessionManager) NewSession(username string, usertype userType, role string, extraFuncs extraFuncs) (*authorization, error) {
+	auth, err := sm.newAuthorization(username, usertype, role)
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +117,23 @@ func (sm *SessionManager) DeleteSession(r *http.Request) (_deletedAuthorization
 	return deletedAuthorization, nil
 }
 
+// DeleteUserSessions deletes all of the sessions of the specified user (e.g., because the
+// user's credentials or role changed) and returns the number of sessions deleted
+func (sm *SessionManager) DeleteUserSessions(username string, usertype userType) int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	deleted := 0
+	for sid, session := range sm.sessions {
+		if session.authorization.Username == username && session.authorization.UserType == usertype {
+			delete(sm.sessions, sid)
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
 // StartCleanerService starts a goroutine that is an indefinite for loop
 // that checks for expired sessions and removes them. This is to
 // prevent the accumulation of expired sessions that were never
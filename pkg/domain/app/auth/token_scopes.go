@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resource is a group of API routes that an api token scope can grant access to
+type Resource string
+
+const (
+	// ResourceCertificates is certificates, their orders, certificate templates, import, and
+	// monitored endpoints
+	ResourceCertificates Resource = "certificates"
+	ResourcePrivateKeys  Resource = "privatekeys"
+	ResourceAcmeAccounts Resource = "acmeaccounts"
+	ResourceAcmeServers  Resource = "acmeservers"
+	// ResourceProviders is challenge providers and domain aliases
+	ResourceProviders Resource = "providers"
+	// ResourceApp is everything else (e.g. app status, logs, backups)
+	ResourceApp Resource = "app"
+
+	// resourceAll can be used in a scope to mean every resource
+	resourceAll Resource = "*"
+)
+
+var allResources = []Resource{
+	ResourceCertificates,
+	ResourcePrivateKeys,
+	ResourceAcmeAccounts,
+	ResourceAcmeServers,
+	ResourceProviders,
+	ResourceApp,
+}
+
+// Scope is a permission on a resource that is granted to an api token, in the form
+// `resource:permission` (e.g. `certificates:view` or `providers:admin`). The resource
+// may be `*` for all resources. The admin permission on a resource grants every
+// permission on that resource.
+type Scope string
+
+// parse returns the scope's resource and permission, or an error if the scope is not valid
+func (scope Scope) parse() (Resource, Permission, error) {
+	resourceStr, permissionStr, found := strings.Cut(string(scope), ":")
+	if !found {
+		return "", "", fmt.Errorf("scope '%s' is not in the form resource:permission", scope)
+	}
+
+	resource := Resource(resourceStr)
+	if resource != resourceAll && !resourceIsValid(resource) {
+		return "", "", fmt.Errorf("scope '%s' resource is not valid", scope)
+	}
+
+	permission := Permission(permissionStr)
+	switch permission {
+	case PermissionView, PermissionDownload, PermissionOrder, PermissionAdmin:
+		// valid
+	default:
+		return "", "", fmt.Errorf("scope '%s' permission is not valid", scope)
+	}
+
+	return resource, permission, nil
+}
+
+// permits returns true if the scope grants permission on resource
+func (scope Scope) permits(resource Resource, permission Permission) bool {
+	scopeResource, scopePermission, err := scope.parse()
+	if err != nil {
+		return false
+	}
+
+	if scopeResource != resourceAll && scopeResource != resource {
+		return false
+	}
+
+	// any valid scope is sufficient for routes that only require authentication
+	return permission == PermissionAuthenticated || scopePermission == PermissionAdmin || scopePermission == permission
+}
+
+// resourceIsValid returns true if resource is a known resource
+func resourceIsValid(resource Resource) bool {
+	for _, r := range allResources {
+		if r == resource {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resourceForPath returns the Resource that the API path belongs to
+func (service *Service) resourceForPath(path string) Resource {
+	v1Path, isV1 := strings.CutPrefix(path, service.apiURLPath+"/v1/")
+	if !isV1 {
+		return ResourceApp
+	}
+
+	firstSegment, _, _ := strings.Cut(v1Path, "/")
+	switch firstSegment {
+	case "certificates", "orders", "certtemplates", "certimport", "monitoredendpoints":
+		return ResourceCertificates
+	case "privatekeys":
+		return ResourcePrivateKeys
+	case "acmeaccounts":
+		return ResourceAcmeAccounts
+	case "acmeservers":
+		return ResourceAcmeServers
+	}
+
+	if strings.HasPrefix(v1Path, "app/challenges/") {
+		return ResourceProviders
+	}
+
+	return ResourceApp
+}
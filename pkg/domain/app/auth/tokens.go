@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"certwarden-backend/pkg/randomness"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// apiTokenPrefix is the start of every api token; it is used to distinguish api tokens
+// from session access tokens
+const apiTokenPrefix = "cwt_"
+
+// apiTokenDisplayLength is the number of characters of a token (including the prefix) that
+// are saved in plaintext so users can identify their tokens
+const apiTokenDisplayLength = 10
+
+// apiTokenLastUsedInterval limits how often a token's last used time is written to storage
+const apiTokenLastUsedInterval = time.Minute
+
+// UserTypeApiToken is the user type of requests authenticated with an api token
+const UserTypeApiToken = "api_token"
+
+// ApiToken is a long lived token that can be used to access the API (e.g. for automation).
+// Only a hash of the token is saved.
+type ApiToken struct {
+	ID          int
+	Name        string
+	TokenHash   string
+	TokenPrefix string
+	OwnerType   string
+	OwnerName   string
+	// OwnerRole is the owner's most recently known role; local owners' current role is
+	// used instead when the token is validated and oidc owners' role is updated each time
+	// their role is re-evaluated (at login and session refresh)
+	OwnerRole  Role
+	Scopes     []Scope
+	AllowedIPs []string
+	ExpiresAt  int
+	LastUsedAt int
+	CreatedAt  int
+}
+
+// apiTokenResponse is the JSON response for an ApiToken (the hash is never included)
+type apiTokenResponse struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	TokenPrefix string   `json:"token_prefix"`
+	Owner       string   `json:"owner"`
+	Scopes      []Scope  `json:"scopes"`
+	AllowedIPs  []string `json:"allowed_ips"`
+	ExpiresAt   int      `json:"expires_at"`
+	LastUsedAt  int      `json:"last_used_at"`
+	CreatedAt   int      `json:"created_at"`
+}
+
+func (token ApiToken) response() apiTokenResponse {
+	return apiTokenResponse{
+		ID:          token.ID,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Owner:       token.ownerTypeAndName(),
+		Scopes:      token.Scopes,
+		AllowedIPs:  token.AllowedIPs,
+		ExpiresAt:   token.ExpiresAt,
+		LastUsedAt:  token.LastUsedAt,
+		CreatedAt:   token.CreatedAt,
+	}
+}
+
+// ownerTypeAndName returns the token owner's type and name, separated by a |
+func (token ApiToken) ownerTypeAndName() string {
+	return token.OwnerType + "|" + token.OwnerName
+}
+
+// ownedBy returns true if user is the owner of token
+func (token ApiToken) ownedBy(user AuthenticatedUser) bool {
+	return token.OwnerType == user.UserType && token.OwnerName == user.Username
+}
+
+// expired returns true if token has an expiration and it has passed
+func (token ApiToken) expired(now time.Time) bool {
+	return token.ExpiresAt != 0 && now.Unix() >= int64(token.ExpiresAt)
+}
+
+// ipAllowed returns true if token has no IP allowlist or if ip is in the allowlist
+func (token ApiToken) ipAllowed(ip netip.Addr) bool {
+	if len(token.AllowedIPs) == 0 {
+		return true
+	}
+
+	for _, allowed := range token.AllowedIPs {
+		prefix, err := netip.ParsePrefix(allowed)
+		if err == nil && prefix.Contains(ip.Unmap()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generateApiToken returns a new random api token and its hash
+func generateApiToken() (token string, tokenHash string, err error) {
+	secret, err := randomness.Generate32ByteSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	token = apiTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return token, hashApiToken(token), nil
+}
+
+// hashApiToken returns the hex encoded SHA-256 hash of token. A fast hash is fine
+// since tokens are long random values (not user chosen passwords).
+func hashApiToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// parseAllowedIP parses an IP address or CIDR into a prefix string (single addresses become
+// a /32 or /128 prefix)
+func parseAllowedIP(s string) (string, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return "", err
+		}
+		return prefix.Masked().String(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return "", err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+}
+
+// isApiToken returns the api token in the auth header value, if the value is an api
+// token (optionally using the Bearer scheme)
+func isApiToken(headerVal string) (token string, ok bool) {
+	token = strings.TrimPrefix(headerVal, "Bearer ")
+	return token, strings.HasPrefix(token, apiTokenPrefix)
+}
+
+// IsApiTokenHeader returns true if r's auth header contains an api token
+func IsApiTokenHeader(r *http.Request) bool {
+	_, ok := isApiToken(r.Header.Get("Authorization"))
+	return ok
+}
+
+// validateApiToken validates an api token and confirms it has permission for r. If the
+// token is valid but lacks permission, ErrForbidden is returned.
+func (service *Service) validateApiToken(r *http.Request, token string, logTaskName string, permission Permission) (AuthenticatedUser, error) {
+	apiToken, err := service.tokens.storage.GetApiTokenByHash(hashApiToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("client %s: %s failed (api token is not valid)", r.RemoteAddr, logTaskName)
+			service.logger.Info(err)
+			return AuthenticatedUser{}, err
+		}
+		service.logger.Errorf("client %s: %s failed (api token lookup error: %s)", r.RemoteAddr, logTaskName, err)
+		return AuthenticatedUser{}, err
+	}
+
+	user := AuthenticatedUser{
+		Username: apiToken.Name,
+		UserType: UserTypeApiToken,
+		Role:     apiToken.OwnerRole,
+	}
+
+	now := time.Now()
+
+	// expiration
+	if apiToken.expired(now) {
+		err = fmt.Errorf("client %s: %s failed (api token '%s' is expired)", r.RemoteAddr, logTaskName, apiToken.Name)
+		service.logger.Info(err)
+		return AuthenticatedUser{}, err
+	}
+
+	// ip allowlist
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	clientIP, err := netip.ParseAddr(host)
+	if err != nil || !apiToken.ipAllowed(clientIP) {
+		err = fmt.Errorf("client %s: %s failed (api token '%s' is not allowed from this address)", r.RemoteAddr, logTaskName, apiToken.Name)
+		service.logger.Info(err)
+		return AuthenticatedUser{}, err
+	}
+
+	// local owners' current role is used (and the token is invalid if the owner was deleted)
+	if apiToken.OwnerType == session_manager.UserTypeLocal {
+		owner, err := service.tokens.storage.GetOneUserByName(apiToken.OwnerName)
+		if err != nil {
+			err = fmt.Errorf("client %s: %s failed (api token '%s' owner could not be loaded: %s)", r.RemoteAddr, logTaskName, apiToken.Name, err)
+			service.logger.Info(err)
+			return AuthenticatedUser{}, err
+		}
+		user.Role = owner.Role
+	}
+
+	// oidc owners need oidc to still be enabled and a role (it is cleared if the user no longer
+	// has one, or the Idp rejected them, at their last login or session refresh)
+	if apiToken.OwnerType == session_manager.UserTypeOIDC {
+		if service.oidc.oauth2Config == nil || !user.Role.IsValid() {
+			err = fmt.Errorf("client %s: %s failed (api token '%s' oidc owner is not enabled or has no role)", r.RemoteAddr, logTaskName, apiToken.Name)
+			service.logger.Info(err)
+			return AuthenticatedUser{}, err
+		}
+	}
+
+	// permission (both the token and its owner must have it)
+	resource := service.resourceForPath(r.URL.Path)
+	scopePermits := false
+	for _, scope := range apiToken.Scopes {
+		if scope.permits(resource, permission) {
+			scopePermits = true
+			break
+		}
+	}
+	if !scopePermits || !user.Role.Permits(permission) {
+		service.logger.Infof("client %s: %s failed (api token '%s' does not have permission '%s' on '%s')", r.RemoteAddr, logTaskName, apiToken.Name, permission, resource)
+		return user, ErrForbidden
+	}
+
+	// update last used (limited to avoid a write on every request)
+	if now.Sub(time.Unix(int64(apiToken.LastUsedAt), 0)) >= apiTokenLastUsedInterval {
+		err = service.tokens.storage.PutApiTokenLastUsed(apiToken.ID, int(now.Unix()))
+		if err != nil {
+			service.logger.Errorf("failed to update api token '%s' last used time (%s)", apiToken.Name, err)
+		}
+	}
+
+	return user, nil
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/validation"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	errUserIdBad     = errors.New("user id is invalid")
+	errUsernameBad   = errors.New("username is invalid")
+	errUsernameInUse = errors.New("username is already in use")
+	errPasswordBad   = errors.New("password must be specified")
+	errRoleBad       = errors.New("role is invalid")
+	errLastAdmin     = errors.New("cannot remove the last admin")
+	errDeleteSelf    = errors.New("cannot delete the currently logged in user")
+)
+
+// userResponse is the JSON response for a User (the password hash is never included)
+type userResponse struct {
+	ID        int    `json:"id"`
+	Username  string `json:"username"`
+	Role      Role   `json:"role"`
+	CreatedAt int    `json:"created_at"`
+	UpdatedAt int    `json:"updated_at"`
+}
+
+func (user User) response() userResponse {
+	return userResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// getUser parses the id param from r and returns the matching user from storage
+func (service *Service) getUser(r *http.Request) (User, *output.JsonError) {
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil || !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(errUserIdBad)
+		return User{}, output.JsonErrValidationFailed(errUserIdBad)
+	}
+
+	user, err := service.local.storage.GetOneUserById(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			service.logger.Debug(err)
+			return User{}, output.JsonErrNotFound(fmt.Errorf("user id %d not found", id))
+		}
+		service.logger.Error(err)
+		return User{}, output.JsonErrStorageGeneric(err)
+	}
+
+	return user, nil
+}
+
+// usernameAvailable returns nil if username is valid and is not already used by another user
+func (service *Service) usernameAvailable(username string) *output.JsonError {
+	if !validation.NameValid(username) {
+		service.logger.Debug(errUsernameBad)
+		return output.JsonErrValidationFailed(errUsernameBad)
+	}
+
+	_, err := service.local.storage.GetOneUserByName(username)
+	if err == nil {
+		service.logger.Debug(errUsernameInUse)
+		return output.JsonErrValidationFailed(errUsernameInUse)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	return nil
+}
+
+// isLastAdmin returns true if user is the only user with the admin role
+func (service *Service) isLastAdmin(user User) (bool, error) {
+	if user.Role != RoleAdmin {
+		return false, nil
+	}
+
+	users, err := service.local.storage.GetAllUsers()
+	if err != nil {
+		return false, err
+	}
+
+	for i := range users {
+		if users[i].ID != user.ID && users[i].Role == RoleAdmin {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
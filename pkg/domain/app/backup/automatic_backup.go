@@ -22,11 +22,24 @@ type Config struct {
 		MaxDays  *int `yaml:"max_days" json:"max_days"`
 		MaxCount *int `yaml:"max_count" json:"max_count"`
 	} `yaml:"retention" json:"retention"`
+
+	// if set, backups are encrypted using this passphrase
+	Passphrase *string `yaml:"passphrase" json:"-"`
+
+	// remote destinations that each automatic backup is also uploaded to
+	Destinations []DestinationConfig `yaml:"destinations" json:"-"`
+}
+
+// SetConfig sets the backup config without starting the automatic backup service. It
+// must be called before any backup is made so the configured passphrase is used.
+func (service *Service) SetConfig(cfg *Config) {
+	service.config = cfg
 }
 
 // StartAutoBackupService starts the automated backup process using the specified
-// configuration params
-func (service *Service) StartAutoBackupService(app App, cfg *Config) {
+// configuration params. An error is only returned if the remote destination config is
+// invalid.
+func (service *Service) StartAutoBackupService(app App, cfg *Config) error {
 	// set service config
 	service.config = cfg
 
@@ -48,10 +61,16 @@ func (service *Service) StartAutoBackupService(app App, cfg *Config) {
 		retentionCount = *cfg.Retention.MaxCount
 	}
 
+	// remote destinations
+	err := service.configureDestinations(cfg, app.GetHttpClient(), retentionDuration, retentionCount)
+	if err != nil {
+		return err
+	}
+
 	// return no-op if cfg not enabled or interval <= 0 days
 	if !enabled || backupInterval <= 0 {
 		service.logger.Warnf("not starting automatic backup service (not enabled or invalid backup interval)")
-		return
+		return nil
 	}
 
 	// find newest and oldest backups' timestamp
@@ -85,10 +104,14 @@ func (service *Service) StartAutoBackupService(app App, cfg *Config) {
 	oldestBackupTime := time.Unix(int64(oldestBackupUnixTime), 0)
 
 	// do a backup on start if backup is overdue
+	var startBackupToUpload *backupFileDetails
 	if time.Since(lastBackupTime) > backupInterval {
 		newBackupFileDetails, err := service.CreateBackupOnDisk()
 		if err != nil {
 			service.logger.Errorf("failed to create automatic on disk backup (%s)", err)
+		} else {
+			// upload once the service is running (so start isn't blocked)
+			startBackupToUpload = &newBackupFileDetails
 		}
 
 		// update last backup time to the one that was just made
@@ -107,6 +130,10 @@ func (service *Service) StartAutoBackupService(app App, cfg *Config) {
 		defer shutdownWg.Done()
 		nextBackup := lastBackupTime.Add(backupInterval)
 
+		if startBackupToUpload != nil {
+			service.uploadToDestinations(shutdownCtx, *startBackupToUpload)
+		}
+
 		for {
 			delayTimer := time.NewTimer(time.Until(nextBackup))
 
@@ -141,6 +168,8 @@ func (service *Service) StartAutoBackupService(app App, cfg *Config) {
 				if err != nil {
 					service.logger.Errorf("failed to delete backups over retention count (%s)", err)
 				}
+
+				service.uploadToDestinations(shutdownCtx, newBackupFileDetails)
 			}
 		}
 	}()
@@ -186,4 +215,41 @@ func (service *Service) StartAutoBackupService(app App, cfg *Config) {
 			}
 		}()
 	}
+
+	// start go routine for remote destinations' time based retention (also enforced after
+	// each upload)
+	if len(service.destinations) > 0 {
+		shutdownWg.Add(1)
+		go func() {
+			defer shutdownWg.Done()
+			service.logger.Infof("starting remote backup destinations time based deletion service")
+
+			for {
+				delayTimer := time.NewTimer(destinationRetentionInterval)
+
+				select {
+				case <-shutdownCtx.Done():
+					// ensure timer releases resources
+					if !delayTimer.Stop() {
+						<-delayTimer.C
+					}
+
+					// exit
+					service.logger.Info("remote backup destinations time based deletion service shutdown complete")
+					return
+
+				case <-delayTimer.C:
+					// continue and run
+				}
+
+				for _, remote := range service.destinations {
+					if remote.retentionDuration > 0 {
+						service.enforceDestinationRetention(shutdownCtx, remote)
+					}
+				}
+			}
+		}()
+	}
+
+	return nil
 }
@@ -0,0 +1,203 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// S3Config is the config for an S3-compatible object storage destination
+type S3Config struct {
+	// Endpoint is the base URL of the service (e.g. https://s3.us-east-1.amazonaws.com)
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	// use virtual hosted style (bucket.endpoint) URLs instead of path style (endpoint/bucket)
+	VirtualHostedStyle bool `yaml:"virtual_hosted_style"`
+}
+
+// s3Destination uploads backups to an S3-compatible bucket
+type s3Destination struct {
+	httpClient  *http.Client
+	signer      *v4.Signer
+	credentials aws.Credentials
+	region      string
+	bucketURL   *url.URL
+	prefix      string
+}
+
+// newS3Destination validates cfg and creates an s3Destination
+func newS3Destination(cfg S3Config, httpClient *http.Client) (*s3Destination, error) {
+	if cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("s3 bucket, access_key_id, and secret_access_key must be specified")
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	bucketURL, err := url.Parse(endpoint)
+	if err != nil || (bucketURL.Scheme != "https" && bucketURL.Scheme != "http") || bucketURL.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint '%s' is not a valid url", endpoint)
+	}
+	if cfg.VirtualHostedStyle {
+		bucketURL.Host = cfg.Bucket + "." + bucketURL.Host
+	} else {
+		bucketURL = bucketURL.JoinPath(cfg.Bucket)
+	}
+
+	// prefix is used as a directory
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &s3Destination{
+		httpClient: httpClient,
+		signer: v4.NewSigner(func(opts *v4.SignerOptions) {
+			// s3 paths are not double escaped
+			opts.DisableURIPathEscaping = true
+		}),
+		credentials: aws.Credentials{
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+		},
+		region:    region,
+		bucketURL: bucketURL,
+		prefix:    prefix,
+	}, nil
+}
+
+// do signs and sends a request to the bucket and returns the response body; any non 2xx
+// response is returned as an error
+func (dest *s3Destination) do(ctx context.Context, method string, key string, query url.Values, body []byte) ([]byte, error) {
+	reqURL := *dest.bucketURL
+	if key != "" {
+		reqURL.Path = strings.TrimSuffix(reqURL.Path, "/") + "/" + key
+		reqURL.RawPath = strings.TrimSuffix(reqURL.EscapedPath(), "/") + "/" + s3EscapeKey(key)
+	}
+	reqURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	payloadHash := sha256.Sum256(body)
+	payloadHashHex := hex.EncodeToString(payloadHash[:])
+	req.Header.Set("X-Amz-Content-Sha256", payloadHashHex)
+
+	err = dest.signer.SignHTTP(ctx, dest.credentials, req, payloadHashHex, "s3", dest.region, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign s3 request (%s)", err)
+	}
+
+	resp, err := dest.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("s3 %s returned status %d (%s)", method, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return respBody, nil
+}
+
+// s3EscapeKey escapes each segment of key for use in a request path; unlike
+// url.PathEscape it also escapes '+' (which s3 would otherwise decode as a space)
+func s3EscapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segments[i]), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
+
+// upload implements destination
+func (dest *s3Destination) upload(ctx context.Context, filename string, data []byte) error {
+	_, err := dest.do(ctx, http.MethodPut, dest.prefix+filename, nil, data)
+	return err
+}
+
+// s3ListBucketResult is the relevant portion of an s3 ListObjectsV2 response
+type s3ListBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// list implements destination
+func (dest *s3Destination) list(ctx context.Context) ([]string, error) {
+	names := []string{}
+
+	continuationToken := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", dest.prefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		body, err := dest.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result s3ListBucketResult
+		err = xml.Unmarshal(body, &result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode s3 list response (%s)", err)
+		}
+
+		for _, object := range result.Contents {
+			name := strings.TrimPrefix(object.Key, dest.prefix)
+			// skip objects in "subdirectories"
+			if !strings.Contains(name, "/") {
+				names = append(names, name)
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		continuationToken = result.NextContinuationToken
+	}
+
+	return names, nil
+}
+
+// delete implements destination
+func (dest *s3Destination) delete(ctx context.Context, filename string) error {
+	_, err := dest.do(ctx, http.MethodDelete, dest.prefix+filename, nil, nil)
+	return err
+}
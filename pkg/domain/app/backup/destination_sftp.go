@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const defaultSFTPPort = 22
+
+// SFTPConfig is the config for an SFTP destination
+type SFTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     *int   `yaml:"port"`
+	Username string `yaml:"username"`
+	// password and/or an (unencrypted) private key file to authenticate with
+	Password       string `yaml:"password"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	// HostKey is the server's public key in authorized_keys format (e.g. `ssh-ed25519 AAAA...`)
+	HostKey string `yaml:"host_key"`
+	// Directory (which must already exist) to save backups in; relative paths are relative
+	// to the user's home
+	Directory string `yaml:"directory"`
+}
+
+// sftpDestination uploads backups to a directory on an SFTP server
+type sftpDestination struct {
+	addr      string
+	sshConfig *ssh.ClientConfig
+	directory string
+}
+
+// newSFTPDestination validates cfg and creates an sftpDestination
+func newSFTPDestination(cfg SFTPConfig) (*sftpDestination, error) {
+	if cfg.Host == "" || cfg.Username == "" {
+		return nil, errors.New("sftp host and username must be specified")
+	}
+
+	port := defaultSFTPPort
+	if cfg.Port != nil {
+		port = *cfg.Port
+	}
+	if port < 1 || port > 65535 {
+		return nil, fmt.Errorf("sftp port %d is invalid", port)
+	}
+
+	// host key is required (never blindly trust the server)
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cfg.HostKey))
+	if err != nil {
+		return nil, fmt.Errorf("sftp host_key is invalid (%s)", err)
+	}
+
+	auths := []ssh.AuthMethod{}
+	if cfg.PrivateKeyFile != "" {
+		keyPem, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sftp private_key_file (%s)", err)
+		}
+		signer, err := ssh.ParsePrivateKey(keyPem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sftp private_key_file (%s)", err)
+		}
+		auths = append(auths, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		auths = append(auths, ssh.Password(cfg.Password))
+	}
+	if len(auths) == 0 {
+		return nil, errors.New("sftp password or private_key_file must be specified")
+	}
+
+	directory := cfg.Directory
+	if directory == "" {
+		directory = "."
+	}
+
+	return &sftpDestination{
+		addr: net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		sshConfig: &ssh.ClientConfig{
+			User:            cfg.Username,
+			Auth:            auths,
+			HostKeyCallback: ssh.FixedHostKey(hostKey),
+			Timeout:         destinationTimeout,
+		},
+		directory: directory,
+	}, nil
+}
+
+// withClient connects to the server, runs fn with an sftp client, and then disconnects
+func (dest *sftpDestination) withClient(ctx context.Context, fn func(client *sftp.Client) error) error {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", dest.addr)
+	if err != nil {
+		return err
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, dest.addr, dest.sshConfig)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+	defer sshClient.Close()
+
+	// abort if ctx is done
+	stop := context.AfterFunc(ctx, func() { _ = sshClient.Close() })
+	defer stop()
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return fmt.Errorf("failed to start sftp client (%s)", err)
+	}
+	defer client.Close()
+
+	err = fn(client)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// upload implements destination
+func (dest *sftpDestination) upload(ctx context.Context, filename string, data []byte) error {
+	return dest.withClient(ctx, func(client *sftp.Client) error {
+		f, err := client.OpenFile(path.Join(dest.directory, filename), os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return err
+		}
+
+		_, err = f.Write(data)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+
+		return f.Close()
+	})
+}
+
+// list implements destination
+func (dest *sftpDestination) list(ctx context.Context) (names []string, err error) {
+	err = dest.withClient(ctx, func(client *sftp.Client) error {
+		entries, err := client.ReadDir(dest.directory)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return nil
+	})
+	return names, err
+}
+
+// delete implements destination
+func (dest *sftpDestination) delete(ctx context.Context, filename string) error {
+	return dest.withClient(ctx, func(client *sftp.Client) error {
+		return client.Remove(path.Join(dest.directory, filename))
+	})
+}
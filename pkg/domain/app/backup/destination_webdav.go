@@ -0,0 +1,159 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// WebDAVConfig is the config for a WebDAV destination
+type WebDAVConfig struct {
+	// URL of the (existing) collection to save backups in
+	URL      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// webDAVDestination uploads backups to a WebDAV collection
+type webDAVDestination struct {
+	httpClient    *http.Client
+	collectionURL *url.URL
+	username      string
+	password      string
+}
+
+// newWebDAVDestination validates cfg and creates a webDAVDestination
+func newWebDAVDestination(cfg WebDAVConfig, httpClient *http.Client) (*webDAVDestination, error) {
+	collectionURL, err := url.Parse(cfg.URL)
+	if err != nil || (collectionURL.Scheme != "https" && collectionURL.Scheme != "http") || collectionURL.Host == "" {
+		return nil, fmt.Errorf("webdav url '%s' is not a valid url", cfg.URL)
+	}
+
+	// always treat url as a collection
+	if !strings.HasSuffix(collectionURL.Path, "/") {
+		collectionURL.Path += "/"
+		collectionURL.RawPath = ""
+	}
+
+	return &webDAVDestination{
+		httpClient:    httpClient,
+		collectionURL: collectionURL,
+		username:      cfg.Username,
+		password:      cfg.Password,
+	}, nil
+}
+
+// do sends a request to the collection (or the named file in the collection) and returns
+// the response body; any non 2xx response is returned as an error
+func (dest *webDAVDestination) do(ctx context.Context, method string, filename string, header http.Header, body []byte) ([]byte, error) {
+	reqURL := dest.collectionURL
+	if filename != "" {
+		reqURL = reqURL.JoinPath(filename)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, vals := range header {
+		req.Header[key] = vals
+	}
+	if dest.username != "" || dest.password != "" {
+		req.SetBasicAuth(dest.username, dest.password)
+	}
+
+	resp, err := dest.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("webdav %s returned status %d", method, resp.StatusCode)
+	}
+
+	return respBody, nil
+}
+
+// upload implements destination
+func (dest *webDAVDestination) upload(ctx context.Context, filename string, data []byte) error {
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	_, err := dest.do(ctx, http.MethodPut, filename, header, data)
+	return err
+}
+
+// webDAVPropfindBody requests the minimum props needed to list the collection
+const webDAVPropfindBody = `<?xml version="1.0" encoding="utf-8"?><D:propfind xmlns:D="DAV:"><D:prop><D:resourcetype/></D:prop></D:propfind>`
+
+// webDAVMultistatus is the relevant portion of a PROPFIND response
+type webDAVMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Collection *struct{} `xml:"DAV: prop>resourcetype>collection"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// list implements destination
+func (dest *webDAVDestination) list(ctx context.Context) ([]string, error) {
+	header := http.Header{}
+	header.Set("Depth", "1")
+	header.Set("Content-Type", "application/xml")
+	body, err := dest.do(ctx, "PROPFIND", "", header, []byte(webDAVPropfindBody))
+	if err != nil {
+		return nil, err
+	}
+
+	var multistatus webDAVMultistatus
+	err = xml.Unmarshal(body, &multistatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode webdav propfind response (%s)", err)
+	}
+
+	names := []string{}
+	for _, response := range multistatus.Responses {
+		// skip collections (including the collection itself)
+		isCollection := false
+		for _, propstat := range response.Propstats {
+			if propstat.Collection != nil {
+				isCollection = true
+			}
+		}
+		if isCollection {
+			continue
+		}
+
+		href, err := url.Parse(response.Href)
+		if err != nil {
+			return nil, fmt.Errorf("webdav propfind response contains invalid href (%s)", err)
+		}
+		name := path.Base(href.Path)
+		if name == "." || name == "/" {
+			return nil, errors.New("webdav propfind response contains empty href")
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// delete implements destination
+func (dest *webDAVDestination) delete(ctx context.Context, filename string) error {
+	_, err := dest.do(ctx, http.MethodDelete, filename, nil, nil)
+	return err
+}
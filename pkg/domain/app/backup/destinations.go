@@ -0,0 +1,339 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// destinationTimeout is the max time any single remote destination operation may take
+const destinationTimeout = 10 * time.Minute
+
+// destinationRetentionInterval is how often remote time based retention is enforced
+// (in addition to after each upload)
+const destinationRetentionInterval = 24 * time.Hour
+
+// DestinationType is the type of a remote backup destination
+type DestinationType string
+
+const (
+	DestinationTypeS3     DestinationType = "s3"
+	DestinationTypeSFTP   DestinationType = "sftp"
+	DestinationTypeWebDAV DestinationType = "webdav"
+)
+
+// DestinationConfig is the config for one remote backup destination. Retention values
+// that are not set use the local backup retention values.
+type DestinationConfig struct {
+	Name string          `yaml:"name"`
+	Type DestinationType `yaml:"type"`
+
+	Retention struct {
+		MaxDays  *int `yaml:"max_days"`
+		MaxCount *int `yaml:"max_count"`
+	} `yaml:"retention"`
+
+	S3     S3Config     `yaml:"s3"`
+	SFTP   SFTPConfig   `yaml:"sftp"`
+	WebDAV WebDAVConfig `yaml:"webdav"`
+}
+
+// destination is a remote location that backup files can be uploaded to
+type destination interface {
+	// upload saves data to the destination as filename
+	upload(ctx context.Context, filename string, data []byte) error
+	// list returns the names of the files in the destination
+	list(ctx context.Context) ([]string, error)
+	// delete removes filename from the destination
+	delete(ctx context.Context, filename string) error
+}
+
+// uploadResult is the result of the most recent upload to a destination
+type uploadResult struct {
+	Filename string `json:"filename"`
+	Time     int    `json:"time"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// remoteDestination is a configured destination along with its retention policy and
+// upload status
+type remoteDestination struct {
+	name              string
+	destType          DestinationType
+	dest              destination
+	retentionDuration time.Duration
+	retentionCount    int
+
+	mu                 sync.RWMutex
+	lastUpload         *uploadResult
+	lastRetentionError string
+}
+
+// newRemoteDestination validates cfg and makes the corresponding destination
+func newRemoteDestination(cfg DestinationConfig, httpClient *http.Client, localRetentionDuration time.Duration, localRetentionCount int) (*remoteDestination, error) {
+	if strings.TrimSpace(cfg.Name) == "" {
+		return nil, errors.New("name must be specified")
+	}
+
+	remote := &remoteDestination{
+		name:              cfg.Name,
+		destType:          cfg.Type,
+		retentionDuration: localRetentionDuration,
+		retentionCount:    localRetentionCount,
+	}
+	if cfg.Retention.MaxDays != nil {
+		remote.retentionDuration = time.Duration(*cfg.Retention.MaxDays) * 24 * time.Hour
+	}
+	if cfg.Retention.MaxCount != nil {
+		remote.retentionCount = *cfg.Retention.MaxCount
+	}
+
+	var err error
+	switch cfg.Type {
+	case DestinationTypeS3:
+		remote.dest, err = newS3Destination(cfg.S3, httpClient)
+	case DestinationTypeSFTP:
+		remote.dest, err = newSFTPDestination(cfg.SFTP)
+	case DestinationTypeWebDAV:
+		remote.dest, err = newWebDAVDestination(cfg.WebDAV, httpClient)
+	default:
+		err = fmt.Errorf("unsupported type '%s'", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return remote, nil
+}
+
+// configureDestinations makes the service's remote destinations from cfg
+func (service *Service) configureDestinations(cfg *Config, httpClient *http.Client, retentionDuration time.Duration, retentionCount int) error {
+	service.destinations = nil
+	if cfg == nil {
+		return nil
+	}
+
+	names := make(map[string]struct{})
+	for i := range cfg.Destinations {
+		remote, err := newRemoteDestination(cfg.Destinations[i], httpClient, retentionDuration, retentionCount)
+		if err != nil {
+			return fmt.Errorf("backup: destination %d config invalid (%s)", i, err)
+		}
+
+		_, exists := names[strings.ToLower(remote.name)]
+		if exists {
+			return fmt.Errorf("backup: destination %d config invalid (name '%s' is already in use)", i, remote.name)
+		}
+		names[strings.ToLower(remote.name)] = struct{}{}
+
+		service.destinations = append(service.destinations, remote)
+	}
+
+	return nil
+}
+
+// uploadToDestinations uploads the specified local backup file to all of the remote
+// destinations and then enforces each destination's retention policy
+func (service *Service) uploadToDestinations(ctx context.Context, backupFile backupFileDetails) {
+	if len(service.destinations) == 0 {
+		return
+	}
+
+	data, err := os.ReadFile(service.cleanDataStorageBackupPath + "/" + backupFile.Name)
+	if err != nil {
+		service.logger.Errorf("failed to read backup file %s for upload to remote destinations (%s)", backupFile.Name, err)
+		for _, remote := range service.destinations {
+			remote.setLastUpload(backupFile.Name, err)
+		}
+		return
+	}
+
+	for _, remote := range service.destinations {
+		uploadCtx, cancel := context.WithTimeout(ctx, destinationTimeout)
+		err = remote.dest.upload(uploadCtx, backupFile.Name, data)
+		cancel()
+		remote.setLastUpload(backupFile.Name, err)
+		if err != nil {
+			service.logger.Errorf("failed to upload backup %s to destination '%s' (%s)", backupFile.Name, remote.name, err)
+			continue
+		}
+
+		service.logger.Infof("backup %s uploaded to destination '%s'", backupFile.Name, remote.name)
+
+		service.enforceDestinationRetention(ctx, remote)
+	}
+}
+
+// enforceDestinationRetention deletes backups from the remote that are over its count or
+// time based retention limits
+func (service *Service) enforceDestinationRetention(ctx context.Context, remote *remoteDestination) {
+	retentionCtx, cancel := context.WithTimeout(ctx, destinationTimeout)
+	defer cancel()
+
+	errs := []error{}
+
+	err := remote.deleteCountGreaterThan(retentionCtx, remote.retentionCount)
+	if err != nil {
+		errs = append(errs, err)
+		service.logger.Errorf("failed to delete backups over retention count from destination '%s' (%s)", remote.name, err)
+	}
+
+	if remote.retentionDuration > 0 {
+		err = remote.deleteOlderThan(retentionCtx, remote.retentionDuration)
+		if err != nil {
+			errs = append(errs, err)
+			service.logger.Errorf("failed to delete backups over retention time duration from destination '%s' (%s)", remote.name, err)
+		}
+	}
+
+	remote.mu.Lock()
+	defer remote.mu.Unlock()
+	remote.lastRetentionError = ""
+	if len(errs) > 0 {
+		remote.lastRetentionError = errors.Join(errs...).Error()
+	}
+}
+
+// setLastUpload records the result of an upload attempt
+func (remote *remoteDestination) setLastUpload(filename string, err error) {
+	result := &uploadResult{
+		Filename: filename,
+		Time:     int(time.Now().Unix()),
+		Success:  err == nil,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	remote.mu.Lock()
+	defer remote.mu.Unlock()
+	remote.lastUpload = result
+}
+
+// remoteBackupFile is a backup file in a remote destination
+type remoteBackupFile struct {
+	name     string
+	unixTime int64
+}
+
+// listBackupFiles returns the backup files in the remote destination; files that are not
+// backups are ignored
+func (remote *remoteDestination) listBackupFiles(ctx context.Context) ([]remoteBackupFile, error) {
+	names, err := remote.dest.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []remoteBackupFile{}
+	for _, name := range names {
+		if !isBackupFileName(name) {
+			continue
+		}
+
+		// remote mod times aren't reliable, only use files with a valid timestamp name
+		nameTime, err := backupZipTime(name)
+		if err != nil {
+			continue
+		}
+
+		files = append(files, remoteBackupFile{
+			name:     name,
+			unixTime: nameTime.Unix(),
+		})
+	}
+
+	return files, nil
+}
+
+// deleteOlderThan deletes backup files from the remote that are older than the specified
+// duration
+func (remote *remoteDestination) deleteOlderThan(ctx context.Context, maxAge time.Duration) error {
+	files, err := remote.listBackupFiles(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete aged backup files (%s)", err)
+	}
+
+	errs := []error{}
+	for i := range files {
+		// delete if file's unix time + maxAge is before (<) Now()
+		if time.Unix(files[i].unixTime, 0).Add(maxAge).Before(time.Now()) {
+			err = remote.dest.delete(ctx, files[i].name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete aged backup file %s (%s)", files[i].name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// deleteCountGreaterThan deletes the oldest backup files from the remote until the count
+// of backup files is equal to the specified count
+func (remote *remoteDestination) deleteCountGreaterThan(ctx context.Context, count int) error {
+	// if count <= 0, do nothing
+	if count <= 0 {
+		return nil
+	}
+
+	files, err := remote.listBackupFiles(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete backup files over max count (%s)", err)
+	}
+
+	// compare backup len to the specified count
+	if len(files) <= count {
+		return nil
+	}
+
+	// sort backup files by age (oldest to the end of the slice)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].unixTime > files[j].unixTime
+	})
+
+	errs := []error{}
+	for i := count; i < len(files); i++ {
+		err = remote.dest.delete(ctx, files[i].name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete old backup file %s that was over max count (%s)", files[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// destinationStatus is the status of one remote destination
+type destinationStatus struct {
+	Name               string          `json:"name"`
+	Type               DestinationType `json:"type"`
+	RetentionMaxDays   int             `json:"retention_max_days"`
+	RetentionMaxCount  int             `json:"retention_max_count"`
+	LastUpload         *uploadResult   `json:"last_upload"`
+	LastRetentionError string          `json:"last_retention_error,omitempty"`
+}
+
+// status returns the current status of the remote destination
+func (remote *remoteDestination) status() destinationStatus {
+	remote.mu.RLock()
+	defer remote.mu.RUnlock()
+
+	var lastUpload *uploadResult
+	if remote.lastUpload != nil {
+		lastUpload = new(*remote.lastUpload)
+	}
+
+	return destinationStatus{
+		Name:               remote.name,
+		Type:               remote.destType,
+		RetentionMaxDays:   int(remote.retentionDuration / (24 * time.Hour)),
+		RetentionMaxCount:  remote.retentionCount,
+		LastUpload:         lastUpload,
+		LastRetentionError: remote.lastRetentionError,
+	}
+}
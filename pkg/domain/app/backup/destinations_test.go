@@ -0,0 +1,412 @@
+package backup
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/webdav"
+)
+
+// testBackupName returns a backup file name for a backup made daysAgo
+func testBackupName(daysAgo int) string {
+	createdTime := time.Now().Add(-time.Duration(daysAgo) * 24 * time.Hour)
+	name := backupFilePrefix + createdTime.Local().Format(time.RFC3339) + backupFileSuffix
+	return strings.ReplaceAll(name, ":", "--")
+}
+
+// fakeS3Server is a minimal in memory S3-compatible server for a single bucket
+type fakeS3Server struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+}
+
+func newFakeS3Server(t *testing.T, bucket string) (*fakeS3Server, *httptest.Server) {
+	fake := &fakeS3Server{bucket: bucket, objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (fake *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-access/") ||
+		r.Header.Get("X-Amz-Content-Sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	key, isObject := strings.CutPrefix(r.URL.Path, "/"+fake.bucket+"/")
+	switch {
+	case isObject && r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		fake.objects[key] = data
+
+	case isObject && r.Method == http.MethodDelete:
+		delete(fake.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.URL.Path == "/"+fake.bucket && r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		type content struct {
+			Key string `xml:"Key"`
+		}
+		result := struct {
+			XMLName  xml.Name  `xml:"ListBucketResult"`
+			Contents []content `xml:"Contents"`
+		}{}
+		for key := range fake.objects {
+			if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+				result.Contents = append(result.Contents, content{Key: key})
+			}
+		}
+		_ = xml.NewEncoder(w).Encode(result)
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (fake *fakeS3Server) keys() []string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	keys := []string{}
+	for key := range fake.objects {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// testDestinationRoundTrip uploads, lists, and deletes a file in dest
+func testDestinationRoundTrip(t *testing.T, dest destination) {
+	ctx := context.Background()
+	name := testBackupName(0)
+	data := make([]byte, 100*1024)
+	_, _ = rand.Read(data)
+
+	err := dest.upload(ctx, name, data)
+	if err != nil {
+		t.Fatalf("upload failed (%s)", err)
+	}
+
+	names, err := dest.list(ctx)
+	if err != nil {
+		t.Fatalf("list failed (%s)", err)
+	}
+	if !slices.Contains(names, name) {
+		t.Fatalf("uploaded file %s not in list %v", name, names)
+	}
+
+	err = dest.delete(ctx, name)
+	if err != nil {
+		t.Fatalf("delete failed (%s)", err)
+	}
+
+	names, err = dest.list(ctx)
+	if err != nil {
+		t.Fatalf("list failed (%s)", err)
+	}
+	if slices.Contains(names, name) {
+		t.Fatalf("deleted file %s still in list %v", name, names)
+	}
+}
+
+func TestDestination_S3(t *testing.T) {
+	fake, server := newFakeS3Server(t, "backups")
+
+	dest, err := newS3Destination(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "backups",
+		Prefix:          "/certwarden/",
+		AccessKeyID:     "test-access",
+		SecretAccessKey: "test-secret",
+	}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDestinationRoundTrip(t, dest)
+
+	err = dest.upload(context.Background(), "cert_warden_backup.test.zip", []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := fake.keys(); len(keys) != 1 || keys[0] != "certwarden/cert_warden_backup.test.zip" {
+		t.Errorf("unexpected bucket contents %v", keys)
+	}
+}
+
+func TestDestination_WebDAV(t *testing.T) {
+	fs := webdav.NewMemFS()
+	err := fs.Mkdir(context.Background(), "/backups", 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := &webdav.Handler{FileSystem: fs, LockSystem: webdav.NewMemLS()}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	dest, err := newWebDAVDestination(WebDAVConfig{
+		URL:      server.URL + "/backups",
+		Username: "user",
+		Password: "pass",
+	}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDestinationRoundTrip(t, dest)
+
+	// wrong creds
+	dest.password = "wrong"
+	_, err = dest.list(context.Background())
+	if err == nil {
+		t.Error("expected error listing with wrong password")
+	}
+}
+
+// startTestSFTPServer starts an in process ssh server with an sftp subsystem (pkg/sftp's
+// server) serving root; it returns the server's address and host key
+func startTestSFTPServer(t *testing.T, root string) (addr string, hostKey string) {
+	_, hostPrivKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "backup" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("bad password")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSHConn(conn, config, root)
+		}
+	}()
+
+	return listener.Addr().String(), string(ssh.MarshalAuthorizedKey(hostSigner.PublicKey()))
+}
+
+func serveTestSSHConn(conn net.Conn, config *ssh.ServerConfig, root string) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			_ = newChan.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		channel, chanReqs, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer channel.Close()
+			for req := range chanReqs {
+				isSFTP := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(isSFTP, nil)
+				if isSFTP {
+					server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(root))
+					if err != nil {
+						return
+					}
+					_ = server.Serve()
+					return
+				}
+			}
+		}()
+	}
+}
+
+func TestDestination_SFTP(t *testing.T) {
+	root := t.TempDir()
+	err := os.Mkdir(filepath.Join(root, "backups"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, hostKey := startTestSFTPServer(t, root)
+	host, portString, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portString)
+
+	cfg := SFTPConfig{
+		Host:      host,
+		Port:      &port,
+		Username:  "backup",
+		Password:  "secret",
+		HostKey:   hostKey,
+		Directory: "backups",
+	}
+	dest, err := newSFTPDestination(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDestinationRoundTrip(t, dest)
+
+	// wrong host key
+	_, otherHostKey := startTestSFTPServer(t, root)
+	cfg.HostKey = otherHostKey
+	dest, err = newSFTPDestination(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dest.list(context.Background())
+	if err == nil {
+		t.Error("expected error connecting to server with unexpected host key")
+	}
+
+	// host key is required
+	cfg.HostKey = ""
+	_, err = newSFTPDestination(cfg)
+	if err == nil {
+		t.Error("expected error with no host key")
+	}
+}
+
+func TestDestination_UploadAndRetention(t *testing.T) {
+	service := makeTestService(t, 16, 5)
+	fake, server := newFakeS3Server(t, "backups")
+
+	cfg := &Config{
+		Destinations: []DestinationConfig{
+			{
+				Name: "s3",
+				Type: DestinationTypeS3,
+				S3: S3Config{
+					Endpoint:        server.URL,
+					Bucket:          "backups",
+					AccessKeyID:     "test-access",
+					SecretAccessKey: "test-secret",
+				},
+			},
+			{
+				Name: "broken",
+				Type: DestinationTypeS3,
+				S3: S3Config{
+					Endpoint:        server.URL,
+					Bucket:          "backups",
+					AccessKeyID:     "wrong",
+					SecretAccessKey: "test-secret",
+				},
+			},
+		},
+	}
+	cfg.Destinations[0].Retention.MaxCount = new(3)
+
+	// local retention of 30 days is inherited
+	err := service.configureDestinations(cfg, server.Client(), 30*24*time.Hour, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// existing remote backups: one too old, the rest within retention
+	s3Dest := service.destinations[0].dest
+	existingNames := make(map[int]string)
+	for _, daysAgo := range []int{40, 10, 5, 3} {
+		existingNames[daysAgo] = testBackupName(daysAgo)
+		err = s3Dest.upload(context.Background(), existingNames[daysAgo], []byte("old"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// not a backup, should never be deleted
+	err = s3Dest.upload(context.Background(), "notes.txt", []byte("keep"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backupFile, err := service.CreateBackupOnDisk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.uploadToDestinations(context.Background(), backupFile)
+
+	expected := []string{backupFile.Name, existingNames[3], existingNames[5], "notes.txt"}
+	slices.Sort(expected)
+	if keys := fake.keys(); !slices.Equal(keys, expected) {
+		t.Errorf("unexpected bucket contents after retention\n got: %v\nwant: %v", keys, expected)
+	}
+
+	// status
+	okStatus := service.destinations[0].status()
+	if okStatus.LastUpload == nil || !okStatus.LastUpload.Success || okStatus.LastUpload.Filename != backupFile.Name ||
+		okStatus.RetentionMaxDays != 30 || okStatus.RetentionMaxCount != 3 {
+		t.Errorf("unexpected status %+v", okStatus)
+	}
+	failedStatus := service.destinations[1].status()
+	if failedStatus.LastUpload == nil || failedStatus.LastUpload.Success || failedStatus.LastUpload.Error == "" {
+		t.Errorf("expected failed upload status, got %+v", failedStatus)
+	}
+}
+
+func TestDestination_ConfigInvalid(t *testing.T) {
+	service := makeTestService(t, 16, 5)
+
+	tests := map[string][]DestinationConfig{
+		"no name":      {{Type: DestinationTypeWebDAV, WebDAV: WebDAVConfig{URL: "https://dav.example.com/"}}},
+		"bad type":     {{Name: "a", Type: "ftp"}},
+		"bad url":      {{Name: "a", Type: DestinationTypeWebDAV, WebDAV: WebDAVConfig{URL: "dav.example.com"}}},
+		"no s3 bucket": {{Name: "a", Type: DestinationTypeS3, S3: S3Config{AccessKeyID: "a", SecretAccessKey: "b"}}},
+		"dupe name": {
+			{Name: "a", Type: DestinationTypeWebDAV, WebDAV: WebDAVConfig{URL: "https://dav.example.com/"}},
+			{Name: "A", Type: DestinationTypeWebDAV, WebDAV: WebDAVConfig{URL: "https://dav.example.com/"}},
+		},
+	}
+
+	for name, destinations := range tests {
+		err := service.configureDestinations(&Config{Destinations: destinations}, http.DefaultClient, 0, 0)
+		if err == nil {
+			t.Errorf("%s: expected config error", name)
+		}
+	}
+}
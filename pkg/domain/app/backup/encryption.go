@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted backups are: magic | scrypt salt | gcm nonce | AES-256-GCM ciphertext
+// of the (unencrypted) backup zip. The magic is used as additional data.
+
+var encryptedBackupMagic = []byte("CWBAK-AES256GCM-SCRYPT-1\n")
+
+const (
+	encryptedBackupSaltLen = 16
+
+	// scrypt params (recommended interactive params as of 2017)
+	encryptedBackupScryptN = 1 << 15
+	encryptedBackupScryptR = 8
+	encryptedBackupScryptP = 1
+	encryptedBackupKeyLen  = 32
+)
+
+var (
+	errBackupPassphraseMissing  = errors.New("backup is encrypted but no passphrase was provided")
+	errBackupPassphraseWrong    = errors.New("failed to decrypt backup (wrong passphrase or corrupt file)")
+	errBackupEncryptedMalformed = errors.New("encrypted backup is malformed")
+)
+
+// isEncryptedBackup returns true if data is an encrypted backup
+func isEncryptedBackup(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedBackupMagic)
+}
+
+// backupAEAD derives the backup key from passphrase and salt and returns the AEAD
+func backupAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, encryptedBackupScryptN, encryptedBackupScryptR, encryptedBackupScryptP, encryptedBackupKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive backup key (%s)", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encryptBackup encrypts a backup zip using passphrase
+func encryptBackup(zipData []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, encryptedBackupSaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate backup salt (%s)", err)
+	}
+
+	aead, err := backupAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate backup nonce (%s)", err)
+	}
+
+	out := make([]byte, 0, len(encryptedBackupMagic)+len(salt)+len(nonce)+len(zipData)+aead.Overhead())
+	out = append(out, encryptedBackupMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	out = aead.Seal(out, nonce, zipData, encryptedBackupMagic)
+
+	return out, nil
+}
+
+// decryptBackup decrypts an encrypted backup using passphrase and returns the backup zip
+func decryptBackup(data []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errBackupPassphraseMissing
+	}
+
+	data = bytes.TrimPrefix(data, encryptedBackupMagic)
+	if len(data) < encryptedBackupSaltLen {
+		return nil, errBackupEncryptedMalformed
+	}
+	salt := data[:encryptedBackupSaltLen]
+	data = data[encryptedBackupSaltLen:]
+
+	aead, err := backupAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, errBackupEncryptedMalformed
+	}
+
+	zipData, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], encryptedBackupMagic)
+	if err != nil {
+		return nil, errBackupPassphraseWrong
+	}
+
+	return zipData, nil
+}
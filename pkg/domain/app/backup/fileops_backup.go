@@ -128,8 +128,19 @@ func (service *Service) createDataBackup(withOnDiskBackups bool) (zipFileBytes [
 	return wrapperZipBuffer.Bytes(), nil
 }
 
+// passphrase returns the configured backup passphrase, or blank if backups are not
+// encrypted
+func (service *Service) passphrase() string {
+	if service.config == nil || service.config.Passphrase == nil {
+		return ""
+	}
+
+	return *service.config.Passphrase
+}
+
 // CreateBackupOnDisk backs up the app state and saves it to the local backup folder. It
-// optionally includes log files but never includes on disk backups.
+// optionally includes log files but never includes on disk backups. If a passphrase is
+// configured, the backup is encrypted.
 func (service *Service) CreateBackupOnDisk() (backupFileDetails, error) {
 	// make backup
 	zipFileData, err := service.createDataBackup(false)
@@ -137,8 +148,18 @@ func (service *Service) CreateBackupOnDisk() (backupFileDetails, error) {
 		return backupFileDetails{}, err
 	}
 
-	// save locally
+	// encrypt, if configured
 	fileName, createdAt := makeBackupZipFileName()
+	passphrase := service.passphrase()
+	if passphrase != "" {
+		zipFileData, err = encryptBackup(zipFileData, passphrase)
+		if err != nil {
+			return backupFileDetails{}, fmt.Errorf("could not encrypt backup (%s)", err)
+		}
+		fileName = strings.TrimSuffix(fileName, backupFileSuffix) + encryptedBackupFileSuffix
+	}
+
+	// save locally
 	fileNameWithPath := service.cleanDataStorageBackupPath + "/" + fileName
 	err = os.WriteFile(fileNameWithPath, zipFileData, backupFileMode)
 	if err != nil {
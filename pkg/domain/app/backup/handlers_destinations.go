@@ -0,0 +1,31 @@
+package backup
+
+import (
+	"certwarden-backend/pkg/output"
+	"net/http"
+)
+
+type destinationsStatusResponse struct {
+	output.JsonResponse
+	Destinations []destinationStatus `json:"destinations"`
+}
+
+// GetDestinationsStatusHandler returns the configured remote backup destinations along
+// with the result of the most recent upload to each
+func (service *Service) GetDestinationsStatusHandler(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	response := &destinationsStatusResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Destinations = []destinationStatus{}
+	for _, remote := range service.destinations {
+		response.Destinations = append(response.Destinations, remote.status())
+	}
+
+	err := service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -34,12 +34,25 @@ func (service *Service) DownloadBackupNowHandler(w http.ResponseWriter, r *http.
 		return output.JsonErrInternal(err)
 	}
 
-	// filename & remove extension
+	// filename
 	zipFileName, _ := makeBackupZipFileName()
+
+	// encrypt, if configured
+	passphrase := service.passphrase()
+	if passphrase != "" {
+		encryptedBytes, err := encryptBackup(zipBytes, passphrase)
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
+
+		service.output.WriteBinary(w, r, strings.TrimSuffix(zipFileName, backupFileSuffix)+encryptedBackupFileSuffix, encryptedBytes)
+		return nil
+	}
+
+	// output (remove extension)
 	extension := filepath.Ext(zipFileName)
 	zipFilenameNoExt := zipFileName[0 : len(zipFileName)-len(extension)]
-
-	// output
 	service.output.WriteZip(w, r, zipFilenameNoExt, zipBytes)
 
 	return nil
@@ -79,6 +92,12 @@ func (service *Service) DownloadDiskBackupHandler(w http.ResponseWriter, r *http
 		return output.JsonErrInternal(err)
 	}
 
+	// encrypted backups are sent as-is
+	if strings.HasSuffix(filenameParam, encryptedBackupFileSuffix) {
+		service.output.WriteBinary(w, r, filenameParam, zipBuffer.Bytes())
+		return nil
+	}
+
 	// remove extension
 	extension := filepath.Ext(filenameParam)
 	zipFilenameNoExt := filenameParam[0 : len(filenameParam)-len(extension)]
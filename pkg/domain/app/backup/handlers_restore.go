@@ -0,0 +1,127 @@
+package backup
+
+import (
+	"certwarden-backend/pkg/output"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// restoreMaxUploadSize is the largest backup file that can be uploaded for restore
+const restoreMaxUploadSize = 256 << 20 // 256 MiB
+
+// restoreMaxMemory is the max amount of the upload held in memory while parsing
+const restoreMaxMemory = 32 << 20 // 32 MiB
+
+type restoreStagedResponse struct {
+	output.JsonResponse
+	Restore restoreDetails `json:"restore"`
+}
+
+// RestoreBackupHandler validates a backup and stages it to be restored the next time
+// the app restarts. The request is multipart/form-data with either `file` (an uploaded
+// backup) or `filename` (an existing on disk backup). `passphrase` is optional and if
+// not specified the configured backup passphrase is used to decrypt encrypted backups.
+func (service *Service) RestoreBackupHandler(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	r.Body = http.MaxBytesReader(w, r.Body, restoreMaxUploadSize)
+	err := r.ParseMultipartForm(restoreMaxMemory)
+	if err != nil {
+		err = fmt.Errorf("failed to parse restore form (%s)", err)
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	// passphrase
+	passphrase := r.FormValue("passphrase")
+	if passphrase == "" {
+		passphrase = service.passphrase()
+	}
+
+	// backup data (upload -or- on disk file)
+	var backupData []byte
+	uploadFile, _, err := r.FormFile("file")
+	if err == nil {
+		defer uploadFile.Close()
+
+		backupData, err = io.ReadAll(uploadFile)
+		if err != nil {
+			err = fmt.Errorf("failed to read uploaded backup (%s)", err)
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
+	} else if errors.Is(err, http.ErrMissingFile) {
+		filename := r.FormValue("filename")
+		if !isBackupFileName(filename) {
+			return output.JsonErrValidationFailed(errors.New("either a backup file or a valid backup filename must be specified"))
+		}
+
+		backupData, err = os.ReadFile(service.cleanDataStorageBackupPath + string(filepath.Separator) + filename)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return output.JsonErrNotFound(errors.New(service.cleanDataStorageBackupPath + string(filepath.Separator) + filename))
+			}
+			err = fmt.Errorf("failed to read disk backup for restore (%s)", err)
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
+	} else {
+		err = fmt.Errorf("failed to read uploaded backup (%s)", err)
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validate and stage
+	details, err := service.StageRestore(backupData, passphrase)
+	if err != nil {
+		err = fmt.Errorf("failed to stage backup for restore (%s)", err)
+		service.logger.Info(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// write response
+	response := &restoreStagedResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "backup staged for restore, restart Cert Warden to complete the restore"
+	response.Restore = details
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// CancelRestoreHandler removes a staged restore so it will not be restored on restart
+func (service *Service) CancelRestoreHandler(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	err := service.cancelStagedRestore()
+	if err != nil {
+		if errors.Is(err, errRestoreNotStaged) {
+			return output.JsonErrNotFound(err)
+		}
+		err = fmt.Errorf("failed to cancel staged restore (%s)", err)
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+
+	service.logger.Info("staged backup restore canceled")
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    "staged restore canceled",
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 const backupFilePrefix = "cert_warden_backup."
 const backupFileSuffix = ".zip"
+const encryptedBackupFileSuffix = backupFileSuffix + ".enc"
 
 // makeBackupZipFileName creates the filename for a new backup created now
 func makeBackupZipFileName() (filename string, createdAt int) {
@@ -19,7 +20,7 @@ func makeBackupZipFileName() (filename string, createdAt int) {
 // getBackupZipFileTime attempts to return the time from the backup zip filename
 func backupZipTime(name string) (time.Time, error) {
 	name = strings.ReplaceAll(name, "--", ":")
-	timeString := strings.TrimSuffix(strings.TrimPrefix(name, backupFilePrefix), backupFileSuffix)
+	timeString := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, backupFilePrefix), encryptedBackupFileSuffix), backupFileSuffix)
 
 	fileTime, err := time.Parse(time.RFC3339, timeString)
 	if err != nil {
@@ -30,8 +31,9 @@ func backupZipTime(name string) (time.Time, error) {
 }
 
 // isBackupFileName returns true if the fileName string provided starts with the
-// backup file prefix and ends in the proper file extension; it also only permits
-// certain characters in the filename to avoid things like path traversal
+// backup file prefix and ends in the proper file extension (encrypted or not); it also
+// only permits certain characters in the filename to avoid things like path traversal
 func isBackupFileName(fileName string) bool {
-	return strings.HasPrefix(fileName, backupFilePrefix) && strings.HasSuffix(fileName, backupFileSuffix)
+	return strings.HasPrefix(fileName, backupFilePrefix) &&
+		(strings.HasSuffix(fileName, backupFileSuffix) || strings.HasSuffix(fileName, encryptedBackupFileSuffix))
 }
@@ -0,0 +1,328 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// restoreStagedFile is the name of the staged restore (in the backup folder, so it is
+// never included in a backup itself)
+const restoreStagedFile = "restore.staged.zip"
+
+// sqliteHeader is the magic header string of a sqlite3 database file
+const sqliteHeader = "SQLite format 3\x00"
+
+// sqliteUserVersionOffset is the offset of the big-endian user_version in a sqlite3 database
+// file header
+const sqliteUserVersionOffset = 60
+
+var (
+	errRestoreNotStaged = errors.New("no restore is staged")
+	errRestoreNoDb      = errors.New("backup does not contain a database")
+	errRestoreNoConfig  = errors.New("backup does not contain a config file")
+
+	errRestoreNeedsPassphrase = errors.New("backup.passphrase must be configured to stage an encrypted backup (it is used to keep the staged restore encrypted)")
+)
+
+// restoreDetails contains information about a validated backup
+type restoreDetails struct {
+	DbUserVersion int `json:"db_user_version"`
+	ConfigVersion int `json:"config_version"`
+	FileCount     int `json:"file_count"`
+}
+
+// restoreStagedPath returns the full path to the staged restore file
+func (service *Service) restoreStagedPath() string {
+	return service.cleanDataStorageBackupPath + string(filepath.Separator) + restoreStagedFile
+}
+
+// restoreRelPath returns the path of fullPath relative to the data root, as it
+// would be named in a backup zip
+func (service *Service) restoreRelPath(fullPath string) string {
+	relPath, err := filepath.Rel(service.cleanDataStorageRootPath, filepath.Clean(fullPath))
+	if err != nil {
+		return fullPath
+	}
+
+	return relPath
+}
+
+// unwrapBackup decrypts the backup (if it is encrypted) and verifies the backup's hash. It
+// returns the internal backup zip.
+func unwrapBackup(data []byte, passphrase string) ([]byte, error) {
+	var err error
+	if isEncryptedBackup(data) {
+		data, err = decryptBackup(data, passphrase)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wrapperZip, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("backup is not a valid zip (%s)", err)
+	}
+
+	internalZipData, err := readZipFile(wrapperZip, internalBackupFile)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := readZipFile(wrapperZip, internalBackupHashFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if fmt.Sprintf("%x", sha1.Sum(internalZipData)) != strings.TrimSpace(string(hash)) {
+		return nil, errors.New("backup hash does not match (file is corrupt)")
+	}
+
+	return internalZipData, nil
+}
+
+// readZipFile returns the content of the named file in the zip
+func readZipFile(zipReader *zip.Reader, name string) ([]byte, error) {
+	f, err := zipReader.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in backup (%w)", name, err)
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// zipEntryName returns the slash separated name of a zip entry (backups made on Windows
+// use backslash separators)
+func zipEntryName(f *zip.File) string {
+	return strings.ReplaceAll(f.Name, `\`, "/")
+}
+
+// readZipEntry returns the content of a zip entry
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in backup (%w)", f.Name, err)
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// validateRestore confirms the internal backup zip only contains safe paths and that
+// its db and config can be migrated by this version of the app
+func (service *Service) validateRestore(internalZipData []byte) (restoreDetails, error) {
+	internalZip, err := zip.NewReader(bytes.NewReader(internalZipData), int64(len(internalZipData)))
+	if err != nil {
+		return restoreDetails{}, fmt.Errorf("backup data is not a valid zip (%s)", err)
+	}
+
+	details := restoreDetails{}
+	dbName := filepath.ToSlash(service.restoreRelPath(service.dbFilenameWithPath))
+	configName := filepath.ToSlash(service.restoreRelPath(service.configFilenameWithPath))
+	hasDb := false
+	hasConfig := false
+
+	for _, f := range internalZip.File {
+		name := zipEntryName(f)
+
+		// paths must stay within data root and never overwrite on disk backups
+		if !filepath.IsLocal(filepath.FromSlash(name)) || strings.HasPrefix(name, dataStorageBackupDirName+"/") || f.FileInfo().IsDir() {
+			return restoreDetails{}, fmt.Errorf("backup contains invalid file path %s", f.Name)
+		}
+		details.FileCount++
+
+		switch name {
+		case dbName:
+			hasDb = true
+			data, err := readZipEntry(f)
+			if err != nil {
+				return restoreDetails{}, err
+			}
+			if len(data) < sqliteUserVersionOffset+4 || !bytes.HasPrefix(data, []byte(sqliteHeader)) {
+				return restoreDetails{}, errors.New("backup database is not a valid sqlite database")
+			}
+
+			details.DbUserVersion = int(int32(binary.BigEndian.Uint32(data[sqliteUserVersionOffset:])))
+			if details.DbUserVersion < 0 || details.DbUserVersion > service.dbCurrentUserVersion {
+				return restoreDetails{}, fmt.Errorf("backup database user_version %d is not supported by this version of Cert Warden (max %d)", details.DbUserVersion, service.dbCurrentUserVersion)
+			}
+
+		case configName:
+			hasConfig = true
+			data, err := readZipEntry(f)
+			if err != nil {
+				return restoreDetails{}, err
+			}
+
+			cfg := struct {
+				ConfigVersion *int `yaml:"config_version"`
+			}{}
+			err = yaml.Unmarshal(data, &cfg)
+			if err != nil {
+				return restoreDetails{}, fmt.Errorf("backup config file is not valid (%s)", err)
+			}
+			if cfg.ConfigVersion == nil {
+				return restoreDetails{}, errors.New("backup config file is missing config_version")
+			}
+
+			details.ConfigVersion = *cfg.ConfigVersion
+			if details.ConfigVersion < 1 || details.ConfigVersion > service.configVersion {
+				return restoreDetails{}, fmt.Errorf("backup config_version %d is not supported by this version of Cert Warden (max %d)", details.ConfigVersion, service.configVersion)
+			}
+		}
+	}
+
+	if !hasDb {
+		return restoreDetails{}, errRestoreNoDb
+	}
+	if !hasConfig {
+		return restoreDetails{}, errRestoreNoConfig
+	}
+
+	return details, nil
+}
+
+// StageRestore validates a backup (encrypted or not) and stages it to be restored the
+// next time the app starts. If backups are encrypted, the staged file is encrypted with
+// the configured passphrase so the backup is never written to disk in plaintext.
+func (service *Service) StageRestore(backupData []byte, passphrase string) (restoreDetails, error) {
+	var err error
+	encrypted := isEncryptedBackup(backupData)
+
+	wrapperData := backupData
+	if encrypted {
+		wrapperData, err = decryptBackup(backupData, passphrase)
+		if err != nil {
+			return restoreDetails{}, err
+		}
+	}
+
+	internalZipData, err := unwrapBackup(wrapperData, "")
+	if err != nil {
+		return restoreDetails{}, err
+	}
+
+	details, err := service.validateRestore(internalZipData)
+	if err != nil {
+		return restoreDetails{}, err
+	}
+
+	// stage the backup encrypted with the configured passphrase (the upload as-is if it
+	// already is)
+	stagedData := backupData
+	configuredPassphrase := service.passphrase()
+	if configuredPassphrase == "" {
+		if encrypted {
+			return restoreDetails{}, errRestoreNeedsPassphrase
+		}
+	} else if !encrypted || passphrase != configuredPassphrase {
+		stagedData, err = encryptBackup(wrapperData, configuredPassphrase)
+		if err != nil {
+			return restoreDetails{}, fmt.Errorf("failed to encrypt staged restore (%s)", err)
+		}
+	}
+
+	err = os.WriteFile(service.restoreStagedPath(), stagedData, backupFileMode)
+	if err != nil {
+		return restoreDetails{}, fmt.Errorf("failed to write staged restore (%s)", err)
+	}
+
+	service.logger.Infof("backup staged for restore (db user_version: %d, config_version: %d); it will be restored when Cert Warden restarts", details.DbUserVersion, details.ConfigVersion)
+
+	return details, nil
+}
+
+// cancelStagedRestore removes a staged restore
+func (service *Service) cancelStagedRestore() error {
+	err := os.Remove(service.restoreStagedPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return errRestoreNotStaged
+	}
+
+	return err
+}
+
+// ApplyStagedRestore restores a staged backup (if there is one). It must be called
+// before storage is opened and after the backup config is set (the staged restore is
+// decrypted, and the current data is backed up to disk before it is overwritten, using
+// the configured passphrase).
+func (service *Service) ApplyStagedRestore() error {
+	stagedData, err := os.ReadFile(service.restoreStagedPath())
+	if errors.Is(err, os.ErrNotExist) {
+		// nothing staged
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read staged restore (%s)", err)
+	}
+
+	service.logger.Info("staged backup restore found, restoring")
+
+	internalZipData, err := unwrapBackup(stagedData, service.passphrase())
+	if err != nil {
+		return fmt.Errorf("failed to read staged restore (backup.passphrase must not change while a restore is staged) (%s)", err)
+	}
+
+	// re-validate (e.g. in case the app was downgraded since staging)
+	details, err := service.validateRestore(internalZipData)
+	if err != nil {
+		return fmt.Errorf("staged restore is not valid (%s)", err)
+	}
+
+	// backup current data first
+	currentBackup, err := service.CreateBackupOnDisk()
+	if err != nil {
+		return fmt.Errorf("failed to backup current data before restore (%s)", err)
+	}
+
+	internalZip, err := zip.NewReader(bytes.NewReader(internalZipData), int64(len(internalZipData)))
+	if err != nil {
+		return err
+	}
+
+	// remove any sqlite journal files that would otherwise be applied to the restored db
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		err = os.Remove(service.dbFilenameWithPath + suffix)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove old database file (%s)", err)
+		}
+	}
+
+	// write each file
+	for _, f := range internalZip.File {
+		fullPath := filepath.Join(service.cleanDataStorageRootPath, filepath.FromSlash(zipEntryName(f)))
+
+		err = os.MkdirAll(filepath.Dir(fullPath), 0700)
+		if err != nil {
+			return fmt.Errorf("failed to make directory for %s (%s)", f.Name, err)
+		}
+
+		data, err := readZipEntry(f)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(fullPath, data, backupFileMode)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s (%s) (previous data was backed up to %s)", f.Name, err, currentBackup.Name)
+		}
+	}
+
+	err = os.Remove(service.restoreStagedPath())
+	if err != nil {
+		return fmt.Errorf("failed to remove staged restore after restoring (%s)", err)
+	}
+
+	service.logger.Infof("backup restored (%d files, db user_version: %d, config_version: %d); previous data was backed up to %s",
+		details.FileCount, details.DbUserVersion, details.ConfigVersion, currentBackup.Name)
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// makeTestService makes a backup service using a temp data root that contains a db and
+// config with the specified versions
+func makeTestService(t *testing.T, dbUserVersion int, configVersion int) *Service {
+	root := t.TempDir()
+	appPath := filepath.Join(root, "app")
+	err := os.MkdirAll(filepath.Join(root, dataStorageBackupDirName), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(appPath, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fake sqlite db (only the header is checked)
+	db := make([]byte, 100)
+	copy(db, sqliteHeader)
+	binary.BigEndian.PutUint32(db[sqliteUserVersionOffset:], uint32(dbUserVersion))
+	err = os.WriteFile(filepath.Join(appPath, "appdata.db"), db, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(appPath, "config.yaml"), []byte("config_version: "+strconv.Itoa(configVersion)+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Service{
+		cleanDataStorageRootPath:   filepath.Clean(root),
+		cleanDataStorageBackupPath: filepath.Join(root, dataStorageBackupDirName),
+		lockSQLForBackup:           func() (func(), error) { return func() {}, nil },
+		logger:                     zaptest.NewLogger(t, zaptest.Level(zap.FatalLevel)).Sugar(),
+		config:                     &Config{},
+		configFilenameWithPath:     filepath.Join(appPath, "config.yaml"),
+		configVersion:              5,
+		dbFilenameWithPath:         filepath.Join(appPath, "appdata.db"),
+		dbCurrentUserVersion:       16,
+	}
+}
+
+func TestBackup_EncryptDecrypt(t *testing.T) {
+	data := []byte("some zip data")
+
+	encrypted, err := encryptBackup(data, "correct horse")
+	if err != nil {
+		t.Fatalf("encrypt failed (%s)", err)
+	}
+	if !isEncryptedBackup(encrypted) || bytes.Contains(encrypted, data) {
+		t.Fatal("backup does not appear to be encrypted")
+	}
+
+	decrypted, err := decryptBackup(encrypted, "correct horse")
+	if err != nil || !bytes.Equal(decrypted, data) {
+		t.Errorf("decrypt failed (err: %v)", err)
+	}
+
+	_, err = decryptBackup(encrypted, "wrong")
+	if err != errBackupPassphraseWrong {
+		t.Errorf("expected wrong passphrase error, got %v", err)
+	}
+
+	_, err = decryptBackup(encrypted, "")
+	if err != errBackupPassphraseMissing {
+		t.Errorf("expected missing passphrase error, got %v", err)
+	}
+}
+
+func TestBackup_StageAndApplyRestore(t *testing.T) {
+	service := makeTestService(t, 16, 5)
+	passphrase := "backup-passphrase"
+	service.config.Passphrase = &passphrase
+
+	// make an encrypted backup
+	details, err := service.CreateBackupOnDisk()
+	if err != nil {
+		t.Fatalf("failed to make backup (%s)", err)
+	}
+	if !strings.HasSuffix(details.Name, encryptedBackupFileSuffix) || !isBackupFileName(details.Name) {
+		t.Errorf("encrypted backup has unexpected name %s", details.Name)
+	}
+	backupData, err := os.ReadFile(filepath.Join(service.cleanDataStorageBackupPath, details.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wrong passphrase
+	_, err = service.StageRestore(backupData, "nope")
+	if err == nil {
+		t.Error("expected error staging restore with wrong passphrase")
+	}
+
+	// stage
+	restore, err := service.StageRestore(backupData, passphrase)
+	if err != nil {
+		t.Fatalf("failed to stage restore (%s)", err)
+	}
+	if restore.DbUserVersion != 16 || restore.ConfigVersion != 5 {
+		t.Errorf("unexpected restore details %+v", restore)
+	}
+	staged, err := os.ReadFile(service.restoreStagedPath())
+	if err != nil || !isEncryptedBackup(staged) {
+		t.Errorf("staged restore is not encrypted (err: %v)", err)
+	}
+
+	// encrypted backup can't be staged without a configured passphrase
+	service.config.Passphrase = nil
+	_, err = service.StageRestore(backupData, passphrase)
+	if err != errRestoreNeedsPassphrase {
+		t.Errorf("expected passphrase required error, got %v", err)
+	}
+	service.config.Passphrase = &passphrase
+
+	// change current data, then apply restore
+	err = os.WriteFile(service.configFilenameWithPath, []byte("config_version: 5\nchanged: true\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = service.ApplyStagedRestore()
+	if err != nil {
+		t.Fatalf("failed to apply staged restore (%s)", err)
+	}
+
+	cfg, err := os.ReadFile(service.configFilenameWithPath)
+	if err != nil || strings.Contains(string(cfg), "changed") {
+		t.Errorf("config was not restored (err: %v)", err)
+	}
+	_, err = os.Stat(service.restoreStagedPath())
+	if !os.IsNotExist(err) {
+		t.Error("staged restore was not removed after restore")
+	}
+
+	// no-op when nothing staged
+	err = service.ApplyStagedRestore()
+	if err != nil {
+		t.Errorf("expected no-op when nothing staged, got %s", err)
+	}
+}
+
+func TestBackup_ValidateRestore(t *testing.T) {
+	makeInternalZip := func(files map[string][]byte) []byte {
+		buf := &bytes.Buffer{}
+		zw := zip.NewWriter(buf)
+		for name, data := range files {
+			f, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = f.Write(data)
+		}
+		_ = zw.Close()
+		return buf.Bytes()
+	}
+
+	service := makeTestService(t, 16, 5)
+	db, _ := os.ReadFile(service.dbFilenameWithPath)
+	newerDb := bytes.Clone(db)
+	binary.BigEndian.PutUint32(newerDb[sqliteUserVersionOffset:], 17)
+
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		wantErr bool
+	}{
+		{"valid", map[string][]byte{"app/appdata.db": db, "app/config.yaml": []byte("config_version: 4")}, false},
+		{"no db", map[string][]byte{"app/config.yaml": []byte("config_version: 5")}, true},
+		{"no config", map[string][]byte{"app/appdata.db": db}, true},
+		{"newer db", map[string][]byte{"app/appdata.db": newerDb, "app/config.yaml": []byte("config_version: 5")}, true},
+		{"newer config", map[string][]byte{"app/appdata.db": db, "app/config.yaml": []byte("config_version: 6")}, true},
+		{"not sqlite", map[string][]byte{"app/appdata.db": []byte("nope"), "app/config.yaml": []byte("config_version: 5")}, true},
+		{"path traversal", map[string][]byte{"app/appdata.db": db, "app/config.yaml": []byte("config_version: 5"), "../evil": nil}, true},
+		{"backup dir", map[string][]byte{"app/appdata.db": db, "app/config.yaml": []byte("config_version: 5"), "backup/x.zip": nil}, true},
+	}
+
+	for _, test := range tests {
+		_, err := service.validateRestore(makeInternalZip(test.files))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %t, got %v", test.name, test.wantErr, err)
+		}
+	}
+}
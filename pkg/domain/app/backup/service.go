@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
@@ -22,6 +23,11 @@ type App interface {
 	LockSQLForBackup() (unlockFunc func(), err error)
 	GetShutdownContext() context.Context
 	GetShutdownWaitGroup() *sync.WaitGroup
+	GetHttpClient() *http.Client
+	GetConfigFilenameWithPath() string
+	GetConfigVersion() int
+	GetDbFilenameWithPath() string
+	GetDbCurrentUserVersion() int
 }
 
 // Keys service struct
@@ -32,6 +38,13 @@ type Service struct {
 	logger                     *zap.SugaredLogger
 	output                     *output.Service
 	config                     *Config
+	destinations               []*remoteDestination
+
+	// for restore validation
+	configFilenameWithPath string
+	configVersion          int
+	dbFilenameWithPath     string
+	dbCurrentUserVersion   int
 }
 
 // NewService creates a new service
@@ -59,6 +72,12 @@ func NewService(app App) (*Service, error) {
 		return nil, fmt.Errorf("backup: failed to make directory for on disk backups (%s)", err)
 	}
 
+	// restore validation info
+	service.configFilenameWithPath = filepath.Clean(app.GetConfigFilenameWithPath())
+	service.configVersion = app.GetConfigVersion()
+	service.dbFilenameWithPath = filepath.Clean(app.GetDbFilenameWithPath())
+	service.dbCurrentUserVersion = app.GetDbCurrentUserVersion()
+
 	// storage lock func
 	service.lockSQLForBackup = app.LockSQLForBackup
 
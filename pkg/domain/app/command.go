@@ -0,0 +1,42 @@
+package app
+
+import (
+	"certwarden-backend/pkg/domain/app/backup"
+	"certwarden-backend/pkg/output"
+	"context"
+	"sync"
+)
+
+// createForCommand creates a minimal app (logger, output, backup, and config) for running
+// a one off command instead of the server. The caller must call app.logger.syncAndClose().
+func createForCommand(commandName string) (*Application, error) {
+	app := new(Application)
+	var err error
+
+	app.initZapLogger()
+	app.logger.Infof("starting Cert Warden v%s %s", appVersion, commandName)
+
+	app.shutdownContext = context.Background()
+	app.shutdownWaitgroup = new(sync.WaitGroup)
+
+	app.output, err = output.NewService(app)
+	if err != nil {
+		app.logger.Errorf("failed to configure app output (%s)", err)
+		return app, err
+	}
+
+	app.backup, err = backup.NewService(app)
+	if err != nil {
+		app.logger.Errorf("failed to configure app backup (%s)", err)
+		return app, err
+	}
+
+	err = app.loadConfigFile()
+	if err != nil {
+		app.logger.Errorf("failed to read app config file (%s)", err)
+		return app, err
+	}
+	app.initZapLogger()
+
+	return app, nil
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/storage"
+	"errors"
+	"os"
+)
+
+// importPfxPasswordEnv is the environment variable that can be used to supply the
+// password of pfx files being imported from the command line
+const importPfxPasswordEnv = "CERTWARDEN_IMPORT_PFX_PASSWORD"
+
+// importCerts imports the certificates found at importPath (see certificates.ReadImportPath)
+// into storage using the specified acme account and (optional) template. Imported certs
+// are available for download immediately and are renewed once Cert Warden is running.
+func importCerts(importPath string, acmeAccountID int, templateID int) (err error) {
+	app, err := createForCommand("certificate import")
+	defer func() {
+		app.logger.syncAndClose()
+	}()
+	if err != nil {
+		return err
+	}
+
+	if acmeAccountID <= 0 {
+		err = errors.New("an acme account id must be specified to import certificates")
+		app.logger.Error(err)
+		return err
+	}
+
+	opts := certificates.ImportOptions{
+		AcmeAccountID: acmeAccountID,
+	}
+	if templateID > 0 {
+		opts.TemplateID = &templateID
+	}
+
+	// read import source(s)
+	bundles, failures, err := certificates.ReadImportPath(importPath, os.Getenv(importPfxPasswordEnv))
+	if err != nil {
+		app.logger.Errorf("failed to read certificates to import (%s)", err)
+		return err
+	}
+
+	// storage
+	app.storage, err = storage.OpenStorage(app, &app.config.KeyEncryption)
+	if err != nil {
+		app.logger.Errorf("failed to configure app storage (%s)", err)
+		return err
+	}
+	defer func() {
+		closeErr := app.storage.Close()
+		if closeErr != nil {
+			app.logger.Errorf("error closing storage: %s", closeErr)
+		}
+	}()
+
+	// import
+	importResults, importedCerts, err := certificates.ImportCertificates(app.storage, bundles, opts)
+	if err != nil {
+		app.logger.Errorf("failed to import certificates (%s)", err)
+		return err
+	}
+	results := append(failures, importResults...)
+
+	for _, result := range results {
+		if result.Error != "" {
+			app.logger.Errorf("failed to import %s (%s)", result.Source, result.Error)
+		} else {
+			app.logger.Infof("imported %s as certificate '%s' (id: %d)", result.Source, result.Name, *result.CertificateID)
+		}
+	}
+	app.logger.Infof("imported %d of %d certificate(s)", len(importedCerts), len(results))
+
+	if len(importedCerts) != len(results) {
+		return errors.New("one or more certificates failed to import")
+	}
+
+	return nil
+}
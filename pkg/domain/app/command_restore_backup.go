@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"os"
+)
+
+// restoreBackupPassphraseEnv is the environment variable that can be used to supply the
+// passphrase of an encrypted backup being restored from the command line (if it is not
+// set, the configured backup passphrase is used)
+const restoreBackupPassphraseEnv = "CERTWARDEN_BACKUP_PASSPHRASE"
+
+// restoreBackup validates the specified backup file and stages it to be restored the
+// next time Cert Warden starts.
+func restoreBackup(backupFile string) (err error) {
+	app, err := createForCommand("backup restore")
+	defer func() {
+		app.logger.syncAndClose()
+	}()
+	if err != nil {
+		return err
+	}
+
+	backupData, err := os.ReadFile(backupFile)
+	if err != nil {
+		err = fmt.Errorf("failed to read backup file (%s)", err)
+		app.logger.Error(err)
+		return err
+	}
+
+	passphrase, exists := os.LookupEnv(restoreBackupPassphraseEnv)
+	if !exists && app.config.Backup.Passphrase != nil {
+		passphrase = *app.config.Backup.Passphrase
+	}
+
+	_, err = app.backup.StageRestore(backupData, passphrase)
+	if err != nil {
+		app.logger.Errorf("failed to stage backup for restore (%s)", err)
+		return err
+	}
+
+	app.logger.Info("start Cert Warden to complete the restore")
+
+	return nil
+}
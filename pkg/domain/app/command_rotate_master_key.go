@@ -0,0 +1,58 @@
+package app
+
+import (
+	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/storage/envelope"
+)
+
+// rotateMasterKey re-wraps all private keys in storage with the master key contained
+// in newKeyFile. The currently configured master key (if any) is used to unwrap the
+// existing keys. Cert Warden should NOT be running when this is used. After rotation,
+// the config must be updated to supply the new master key.
+func rotateMasterKey(newKeyFile string) (err error) {
+	app, err := createForCommand("master key rotation")
+	defer func() {
+		app.logger.syncAndClose()
+	}()
+	if err != nil {
+		return err
+	}
+
+	// new key
+	newKey, err := envelope.LoadMasterKeyFile(newKeyFile)
+	if err != nil {
+		app.logger.Errorf("failed to load new master key (%s)", err)
+		return err
+	}
+
+	// storage (using the current master key)
+	app.storage, err = storage.OpenStorage(app, &app.config.KeyEncryption)
+	if err != nil {
+		app.logger.Errorf("failed to configure app storage (%s)", err)
+		return err
+	}
+	defer func() {
+		closeErr := app.storage.Close()
+		if closeErr != nil {
+			app.logger.Errorf("error closing storage: %s", closeErr)
+		}
+	}()
+
+	// backup before modifying keys (the backup remains decryptable with the old master key)
+	err = app.CreateBackupOnDisk()
+	if err != nil {
+		app.logger.Errorf("failed to backup data before master key rotation (%s)", err)
+		return err
+	}
+
+	count, err := app.storage.RotateMasterKey(newKey)
+	if err != nil {
+		app.logger.Errorf("failed to rotate master key (%s)", err)
+		return err
+	}
+
+	app.logger.Infof("re-wrapped %d private key(s) with new master key %s", count, newKey.ID())
+	app.logger.Warn("update `key_encryption` in the config file to supply the new master key before starting Cert Warden")
+
+	return nil
+}
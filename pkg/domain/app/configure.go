@@ -2,11 +2,16 @@ package app
 
 import (
 	"certwarden-backend/pkg/challenges"
+	"certwarden-backend/pkg/challenges/propagation"
 	"certwarden-backend/pkg/challenges/providers"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
 	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/domain/app/backup"
 	"certwarden-backend/pkg/domain/app/updater"
+	"certwarden-backend/pkg/domain/private_keys/key_backend"
+	"certwarden-backend/pkg/metrics"
+	"certwarden-backend/pkg/notifications"
+	"certwarden-backend/pkg/storage/envelope"
 	"errors"
 	"fmt"
 	"io"
@@ -33,23 +38,27 @@ func (app *Application) GetFileBackupFolderMode() fs.FileMode {
 
 // config is the configuration structure for app (and subsequently services)
 type config struct {
-	ConfigVersion             *int              `yaml:"config_version"`
-	BindAddress               *string           `yaml:"bind_address"`
-	HttpsPort                 *int              `yaml:"https_port"`
-	HttpPort                  *int              `yaml:"http_port"`
-	EnableHttpRedirect        *bool             `yaml:"enable_http_redirect"`
-	FrontendServe             *bool             `yaml:"serve_frontend"`
-	CORSPermittedCrossOrigins []string          `yaml:"cors_permitted_crossorigins"`
-	CertificateName           *string           `yaml:"certificate_name"`
-	DisableHSTS               *bool             `yaml:"disable_hsts"`
-	LogLevel                  *string           `yaml:"log_level"`
-	EnablePprof               *bool             `yaml:"enable_pprof"`
-	PprofHttpsPort            *int              `yaml:"pprof_https_port"`
-	PprofHttpPort             *int              `yaml:"pprof_http_port"`
-	Auth                      auth.Config       `yaml:"auth"`
-	Backup                    backup.Config     `yaml:"backup"`
-	Updater                   updater.Config    `yaml:"updater"`
-	Challenges                challenges.Config `yaml:"challenges"`
+	ConfigVersion             *int                     `yaml:"config_version"`
+	BindAddress               *string                  `yaml:"bind_address"`
+	HttpsPort                 *int                     `yaml:"https_port"`
+	HttpPort                  *int                     `yaml:"http_port"`
+	EnableHttpRedirect        *bool                    `yaml:"enable_http_redirect"`
+	FrontendServe             *bool                    `yaml:"serve_frontend"`
+	CORSPermittedCrossOrigins []string                 `yaml:"cors_permitted_crossorigins"`
+	CertificateName           *string                  `yaml:"certificate_name"`
+	DisableHSTS               *bool                    `yaml:"disable_hsts"`
+	LogLevel                  *string                  `yaml:"log_level"`
+	EnablePprof               *bool                    `yaml:"enable_pprof"`
+	PprofHttpsPort            *int                     `yaml:"pprof_https_port"`
+	PprofHttpPort             *int                     `yaml:"pprof_http_port"`
+	Auth                      auth.Config              `yaml:"auth"`
+	Backup                    backup.Config            `yaml:"backup"`
+	KeyEncryption             envelope.Config          `yaml:"key_encryption"`
+	PKCS11                    key_backend.PKCS11Config `yaml:"pkcs11"`
+	Updater                   updater.Config           `yaml:"updater"`
+	Challenges                challenges.Config        `yaml:"challenges"`
+	Metrics                   metrics.Config           `yaml:"metrics"`
+	Notifications             notifications.Config     `yaml:"notifications"`
 }
 
 // httpAddress() returns formatted http server address string
@@ -104,6 +113,12 @@ func (app *Application) loadConfigFile() (err error) {
 	}
 	// ignore any other Stat error, should error out below when opening
 
+	// backup config first, in case a backup is made for a schema migration
+	err = app.loadBackupConfig()
+	if err != nil {
+		return err
+	}
+
 	// open config file
 	cfgFile, err := os.Open(configFilenameWithPath)
 	if err != nil {
@@ -208,10 +223,38 @@ func (app *Application) loadConfigFile() (err error) {
 	// set defaults on anything that wasn't specified
 	app.setDefaultConfigValues()
 
+	// backup service uses the full config from here on
+	app.backup.SetConfig(&app.config.Backup)
+
 	// success
 	return nil
 }
 
+// loadBackupConfig parses only the backup section of the config file and sets it on the
+// backup service, so any backup made before the full config is loaded (e.g. before a
+// staged restore or a config schema migration) uses the configured passphrase. It is a
+// no-op if there is no config file.
+func (app *Application) loadBackupConfig() error {
+	cfgFileData, err := os.ReadFile(configFilenameWithPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read config file (%s)", err)
+	}
+
+	cfg := struct {
+		Backup backup.Config `yaml:"backup"`
+	}{}
+	err = yaml.Unmarshal(cfgFileData, &cfg)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file backup section (%s)", err)
+	}
+
+	app.backup.SetConfig(&cfg.Backup)
+
+	return nil
+}
+
 // setDefaultConfigValues checks each field of the config that has a default
 // value and if the value is not set it sets the default
 func (app *Application) setDefaultConfigValues() {
@@ -303,6 +346,53 @@ func (app *Application) setDefaultConfigValues() {
 		*app.config.Updater.Channel = updater.ChannelBeta
 	}
 
+	// metrics
+	if app.config.Metrics.Enabled == nil {
+		app.config.Metrics.Enabled = new(bool)
+		*app.config.Metrics.Enabled = false
+	}
+
+	// notifications
+	if app.config.Notifications.Email.Enabled == nil {
+		app.config.Notifications.Email.Enabled = new(bool)
+		*app.config.Notifications.Email.Enabled = false
+	}
+	// Email.Port is not defaulted here, the default depends on Security (see notifications)
+	if app.config.Notifications.Email.Security == nil {
+		app.config.Notifications.Email.Security = new(notifications.SMTPSecurity)
+		*app.config.Notifications.Email.Security = notifications.SMTPSecurityStartTLS
+	}
+	if app.config.Notifications.Email.Digest.Enabled == nil {
+		app.config.Notifications.Email.Digest.Enabled = new(bool)
+		*app.config.Notifications.Email.Digest.Enabled = true
+	}
+	if app.config.Notifications.Email.Digest.Hour == nil {
+		app.config.Notifications.Email.Digest.Hour = new(int)
+		*app.config.Notifications.Email.Digest.Hour = notifications.DefaultDigestHour
+	}
+	if app.config.Notifications.Email.Digest.ExpiringDays == nil {
+		app.config.Notifications.Email.Digest.ExpiringDays = new(int)
+		*app.config.Notifications.Email.Digest.ExpiringDays = notifications.DefaultDigestExpiringDays
+	}
+
+	// challenge dns propagation check
+	if app.config.Challenges.PropagationCheck.Enabled == nil {
+		app.config.Challenges.PropagationCheck.Enabled = new(bool)
+		*app.config.Challenges.PropagationCheck.Enabled = propagation.DefaultEnabled
+	}
+	if app.config.Challenges.PropagationCheck.CheckAuthoritative == nil {
+		app.config.Challenges.PropagationCheck.CheckAuthoritative = new(bool)
+		*app.config.Challenges.PropagationCheck.CheckAuthoritative = propagation.DefaultCheckAuthoritative
+	}
+	if app.config.Challenges.PropagationCheck.CheckResolvers == nil {
+		app.config.Challenges.PropagationCheck.CheckResolvers = new(bool)
+		*app.config.Challenges.PropagationCheck.CheckResolvers = propagation.DefaultCheckResolvers
+	}
+	if app.config.Challenges.PropagationCheck.IntervalSeconds == nil {
+		app.config.Challenges.PropagationCheck.IntervalSeconds = new(int)
+		*app.config.Challenges.PropagationCheck.IntervalSeconds = propagation.DefaultIntervalSeconds
+	}
+
 	// challenge provider
 	if app.config.Challenges.ProviderConfigs.Len() <= 0 {
 		http01Port := new(int)
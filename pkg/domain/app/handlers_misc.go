@@ -1,9 +1,12 @@
 package app
 
 import (
+	"certwarden-backend/pkg/challenges/providers/http01internal"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/storage"
 	"net/http"
+
+	"github.com/julienschmidt/httprouter"
 )
 
 // serverStatusResponse
@@ -46,3 +49,19 @@ func healthHandler(w http.ResponseWriter, r *http.Request) *output.JsonError {
 
 	return nil
 }
+
+// http01ChallengeHandler serves acme http-01 challenge resources for any http01internal
+// providers that are configured to serve on the app's listener. If no provider has the
+// requested resource (including when no provider serves on the app's listener), a 404 reply
+// is sent.
+func (app *Application) http01ChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	token := httprouter.ParamsFromContext(r.Context()).ByName("token")
+
+	if !app.challenges.DNSIdentifierProviders.ServeHttp01AppListenerChallenge(w, r, token) {
+		app.logger.Debugf("http-01 challenge resource %s not found on app listener", token)
+
+		// same as the http01internal server's 404
+		http01internal.SetNoCacheHeaders(w)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
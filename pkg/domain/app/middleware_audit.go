@@ -0,0 +1,52 @@
+package app
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/domain/app/auth"
+	"certwarden-backend/pkg/output"
+	"net/http"
+)
+
+// statusRecordingWriter is a ResponseWriter that records the status code written
+type statusRecordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusRecordingWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// middlewareApplyAudit applies middleware that records an audit log entry for the route
+// each time it is accessed. action is the description of the route used in the entry
+// (e.g. `PUT /v1/certificates/:certid`). The actor is the user the auth middleware added
+// to the request's context, so this must be applied before (i.e. inside) the auth
+// middleware. The audit entry is saved after next completes; handlers can add details to
+// the entry with audit.RecordChange.
+func middlewareApplyAudit(next handlerFunc, auditService *audit.Service, action string) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) *output.JsonError {
+		// actor (from the request's session)
+		actor, actorRole := "", ""
+		user, ok := auth.UserFromContext(r.Context())
+		if ok {
+			actor = user.TypeAndName()
+			actorRole = string(user.Role)
+		}
+
+		r = auditService.Begin(r, actor, actorRole)
+		recordingW := &statusRecordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		// do next
+		outErr := next(recordingW, r)
+
+		// save entry
+		statusCode := recordingW.statusCode
+		if outErr != nil {
+			statusCode = outErr.StatusCode
+		}
+		auditService.Finish(r, action, statusCode)
+
+		return outErr
+	}
+}
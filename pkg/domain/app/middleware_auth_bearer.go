@@ -0,0 +1,35 @@
+package app
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"certwarden-backend/pkg/output"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// middlewareApplyAuthBearerOrJWT applies middleware that first checks the auth header
+// for a static bearer token (e.g. for a metrics scraper). If the token is blank or the
+// header does not match it, the request is validated as a normal JWT access token or api
+// token (which must have permission).
+func middlewareApplyAuthBearerOrJWT(next handlerFunc, authService *auth.Service, bearerToken string, permission auth.Permission) handlerFunc {
+	jwtNext := middlewareApplyAuthJWT(next, authService, permission)
+
+	return func(w http.ResponseWriter, r *http.Request) *output.JsonError {
+		if bearerToken != "" && !auth.IsApiTokenHeader(r) {
+			headerToken, isBearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if isBearer {
+				if subtle.ConstantTimeCompare([]byte(headerToken), []byte(bearerToken)) != 1 {
+					// Note: Do NOT send detailed error since unauthorized
+					return output.JsonErrUnauthorized
+				}
+
+				// valid bearer token, do next
+				return next(w, r)
+			}
+		}
+
+		// not a bearer token, try JWT
+		return jwtNext(w, r)
+	}
+}
@@ -3,25 +3,30 @@ package app
 import (
 	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/output"
+	"errors"
 	"fmt"
 	"net/http"
 )
 
 // middlewareApplyAuthJWT applies middleware that validates the jwt access token
-// contained in the auth header. If it is not valid, an error is returned instead of
-// executing next.
-func middlewareApplyAuthJWT(next handlerFunc, auth *auth.Service) handlerFunc {
+// contained in the auth header and confirms the user's role has permission. If it
+// is not valid, an error is returned instead of executing next. The authenticated
+// user is added to the request's context.
+func middlewareApplyAuthJWT(next handlerFunc, authService *auth.Service, permission auth.Permission) handlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) *output.JsonError {
 		// shorten URI for logging
 		trimmedURI := loggableRequestURI(r)
 
-		err := auth.ValidateAuthHeader(r, w, fmt.Sprintf("%s %s", r.Method, trimmedURI))
+		user, err := authService.ValidateAuthHeader(r, w, fmt.Sprintf("%s %s", r.Method, trimmedURI), permission)
 		if err != nil {
 			// Note: Do NOT send detailed error since unauthorized
+			if errors.Is(err, auth.ErrForbidden) {
+				return output.JsonErrForbidden
+			}
 			return output.JsonErrUnauthorized
 		}
 
 		// if valid, do next
-		return next(w, r)
+		return next(w, r.WithContext(auth.ContextWithUser(r.Context(), user)))
 	}
 }
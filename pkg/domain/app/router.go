@@ -1,9 +1,11 @@
 package app
 
 import (
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/output"
 	"net/http"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 	"go.uber.org/zap"
@@ -18,6 +20,7 @@ type router struct {
 	logger *zap.SugaredLogger
 	output *output.Service
 	auth   *auth.Service
+	audit  *audit.Service
 	// actual router
 	r *httprouter.Router
 	// config options
@@ -41,10 +44,16 @@ func (router *router) handleAPIRouteInsecure(method string, path string, handler
 	router.r.Handler(method, path, httpHandlerFunc)
 }
 
-// handleAPIRouteSecure creates a route on router intended for an authenticated API route
-func (router *router) handleAPIRouteSecure(method string, path string, handlerFunc handlerFunc) {
+// handleAPIRouteSecure creates a route on router intended for an authenticated API route that
+// requires the user's role to have permission. Routes that can make changes are audited.
+func (router *router) handleAPIRouteSecure(method string, path string, permission auth.Permission, handlerFunc handlerFunc) {
+	// Audit (all routes that can make changes)
+	if method != http.MethodGet {
+		handlerFunc = middlewareApplyAudit(handlerFunc, router.audit, method+" "+strings.TrimPrefix(path, apiUrlPath))
+	}
+
 	// JWT Auth
-	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth)
+	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth, permission)
 
 	// CORS
 	handlerFunc = middlewareApplyCORS(handlerFunc, router.permittedCrossOrigins)
@@ -57,10 +66,16 @@ func (router *router) handleAPIRouteSecure(method string, path string, handlerFu
 }
 
 // handleAPIRouteSecure creates a route on router intended for an authenticated API route WITH
-// enhanced logging to ensure any time these routes are accessed they are explicitly logged
-func (router *router) handleAPIRouteSecureSensitive(method string, path string, handlerFunc handlerFunc) {
+// enhanced logging to ensure any time these routes are accessed they are explicitly logged. Routes
+// that can make changes are audited.
+func (router *router) handleAPIRouteSecureSensitive(method string, path string, permission auth.Permission, handlerFunc handlerFunc) {
+	// Audit (all routes that can make changes)
+	if method != http.MethodGet {
+		handlerFunc = middlewareApplyAudit(handlerFunc, router.audit, method+" "+strings.TrimPrefix(path, apiUrlPath))
+	}
+
 	// JWT Auth
-	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth)
+	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth, permission)
 
 	// CORS
 	handlerFunc = middlewareApplyCORS(handlerFunc, router.permittedCrossOrigins)
@@ -72,11 +87,27 @@ func (router *router) handleAPIRouteSecureSensitive(method string, path string,
 	router.r.HandlerFunc(method, path, httpHandlerFunc)
 }
 
+// handleAPIRouteSecureBearer creates a route on router intended for an authenticated API
+// route that can also be accessed with a static bearer token (e.g. by a metrics scraper)
+func (router *router) handleAPIRouteSecureBearer(method string, path string, bearerToken string, permission auth.Permission, handlerFunc handlerFunc) {
+	// Bearer or JWT Auth
+	handlerFunc = middlewareApplyAuthBearerOrJWT(handlerFunc, router.auth, bearerToken, permission)
+
+	// CORS
+	handlerFunc = middlewareApplyCORS(handlerFunc, router.permittedCrossOrigins)
+
+	// Logger / handle custom handler func's error
+	httpHandlerFunc := middlewareApplyReturnValHandling(handlerFunc, false, router.logger, router.output)
+
+	// make handler
+	router.r.HandlerFunc(method, path, httpHandlerFunc)
+}
+
 // handleAPIRouteSecureDownload creates a route on router intended for downloading files via
 // a logged in (SECURE) user.
-func (router *router) handleAPIRouteSecureDownload(method string, path string, handlerFunc handlerFunc) {
+func (router *router) handleAPIRouteSecureDownload(method string, path string, permission auth.Permission, handlerFunc handlerFunc) {
 	// JWT Auth
-	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth)
+	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth, permission)
 
 	// CORS
 	handlerFunc = middlewareApplyCORS(handlerFunc, router.permittedCrossOrigins)
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"certwarden-backend/pkg/domain/app/auth"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
@@ -16,6 +17,9 @@ const apiKeyDownloadUrlPath = apiUrlPath + "/v1/download"
 // frontend React app path (e.g. Vite config `base`)
 const frontendUrlPath = baseUrlPath + "/app"
 
+// acme http-01 challenge path, per rfc8555 8.3
+const http01ChallengeUrlPath = "/.well-known/acme-challenge/"
+
 // makeRouterAndRoutes creates the application's router and adds the routes. It also
 // inserts the common CORS middleware before assigning the router to app
 func (app *Application) makeRouterAndRoutes() {
@@ -23,6 +27,7 @@ func (app *Application) makeRouterAndRoutes() {
 		logger:                app.logger.SugaredLogger,
 		output:                app.output,
 		auth:                  app.auth,
+		audit:                 app.audit,
 		permittedCrossOrigins: app.config.CORSPermittedCrossOrigins,
 		r:                     httprouter.New(),
 	}
@@ -43,113 +48,165 @@ func (app *Application) makeRouterAndRoutes() {
 	router.handleAPIRouteInsecure(http.MethodGet, apiUrlPath+"/v1/app/auth/oidc/callback", app.auth.OIDCGetCallback)
 
 	// app auth - secure
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/app/auth/changepassword", app.auth.LocalChangePassword)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/auth/logout", app.auth.Logout)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/app/auth/changepassword", auth.PermissionAuthenticated, app.auth.LocalChangePassword)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/auth/logout", auth.PermissionAuthenticated, app.auth.Logout)
+
+	// app auth - users (local)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/auth/users", auth.PermissionAdmin, app.auth.GetAllUsers)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/auth/users/:id", auth.PermissionAdmin, app.auth.GetOneUser)
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/auth/users", auth.PermissionAdmin, app.auth.PostNewUser)
+	router.handleAPIRouteSecureSensitive(http.MethodPut, apiUrlPath+"/v1/app/auth/users/:id", auth.PermissionAdmin, app.auth.PutUser)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/auth/users/:id", auth.PermissionAdmin, app.auth.DeleteUser)
+
+	// app auth - api tokens
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/auth/tokens", auth.PermissionAuthenticated, app.auth.GetApiTokens)
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/auth/tokens", auth.PermissionAuthenticated, app.auth.PostNewApiToken)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/auth/tokens/:id", auth.PermissionAuthenticated, app.auth.DeleteApiToken)
 
 	// status
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/status", app.statusHandler)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/status", auth.PermissionAuthenticated, app.statusHandler)
+
+	// metrics
+	if app.metrics.Enabled() {
+		router.handleAPIRouteSecureBearer(http.MethodGet, apiUrlPath+"/metrics", app.metrics.BearerToken(), auth.PermissionView, app.metrics.GetMetrics)
+	}
 
 	// app
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/log", app.viewCurrentLogHandler)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/logs", app.downloadLogsHandler)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/log", auth.PermissionAdmin, app.viewCurrentLogHandler)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/logs", auth.PermissionAdmin, app.downloadLogsHandler)
+
+	// audit log
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/audit", auth.PermissionAdmin, app.audit.GetAuditLog)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/app/audit/export", auth.PermissionAdmin, app.audit.ExportAuditLog)
+
+	// notifications
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/notifications/test", auth.PermissionAdmin, app.notifications.PostSendTest)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/notifications/email/test", auth.PermissionAdmin, app.notifications.PostSendTestEmail)
 
 	// app control
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/control/shutdown", app.doShutdownHandler)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/control/restart", app.doRestartHandler)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/control/shutdown", auth.PermissionAdmin, app.doShutdownHandler)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/control/restart", auth.PermissionAdmin, app.doRestartHandler)
 
 	// app updater
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/updater/new-version", app.updater.GetNewVersionInfo)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/updater/new-version", app.updater.CheckForNewVersion)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/updater/new-version", auth.PermissionView, app.updater.GetNewVersionInfo)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/updater/new-version", auth.PermissionAdmin, app.updater.CheckForNewVersion)
 
 	// app backup and restore
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/backup/disk", app.backup.ListDiskBackupsHandler)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/backup/disk", auth.PermissionAdmin, app.backup.ListDiskBackupsHandler)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/backup/destinations", auth.PermissionAdmin, app.backup.GetDestinationsStatusHandler)
+
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/backup/disk", auth.PermissionAdmin, app.backup.MakeDiskBackupNowHandler)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/backup/disk/:filename", auth.PermissionAdmin, app.backup.DeleteDiskBackupHandler)
 
-	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/backup/disk", app.backup.MakeDiskBackupNowHandler)
-	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/backup/disk/:filename", app.backup.DeleteDiskBackupHandler)
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/backup/restore", auth.PermissionAdmin, app.backup.RestoreBackupHandler)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/backup/restore", auth.PermissionAdmin, app.backup.CancelRestoreHandler)
 
-	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/app/backup", app.backup.DownloadBackupNowHandler)
-	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/app/backup/disk/:filename", app.backup.DownloadDiskBackupHandler)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/app/backup", auth.PermissionAdmin, app.backup.DownloadBackupNowHandler)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/app/backup/disk/:filename", auth.PermissionAdmin, app.backup.DownloadDiskBackupHandler)
 
 	// challenges: dns aliases
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/domainaliases", app.challenges.GetDomainAliases)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/challenges/domainaliases", app.challenges.PostDomainAliases)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/domainaliases", auth.PermissionView, app.challenges.GetDomainAliases)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/challenges/domainaliases", auth.PermissionAdmin, app.challenges.PostDomainAliases)
 
 	// challenges: providers
-	// router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/domains", app.challenges.Providers.GetAllDomains)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/services", app.challenges.DNSIdentifierProviders.GetAllProviders)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/services/:id", app.challenges.DNSIdentifierProviders.GetOneProvider)
+	// router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/domains", auth.PermissionView, app.challenges.Providers.GetAllDomains)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/services", auth.PermissionAdmin, app.challenges.DNSIdentifierProviders.GetAllProviders)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/services/:id", auth.PermissionAdmin, app.challenges.DNSIdentifierProviders.GetOneProvider)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/match", auth.PermissionAdmin, app.challenges.GetProvidersFor)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/challenges/providers/services", app.challenges.DNSIdentifierProviders.CreateProvider)
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/app/challenges/providers/services/:id", app.challenges.DNSIdentifierProviders.ModifyProvider)
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/app/challenges/providers/services/:id", app.challenges.DNSIdentifierProviders.DeleteProvider)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/challenges/providers/services", auth.PermissionAdmin, app.challenges.DNSIdentifierProviders.CreateProvider)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/app/challenges/providers/services/:id", auth.PermissionAdmin, app.challenges.DNSIdentifierProviders.ModifyProvider)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/app/challenges/providers/services/:id", auth.PermissionAdmin, app.challenges.DNSIdentifierProviders.DeleteProvider)
 
 	// acme_servers
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeservers", app.acmeServers.GetAllServers)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeservers/:id", app.acmeServers.GetOneServer)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeservers", auth.PermissionView, app.acmeServers.GetAllServers)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeservers/:id", auth.PermissionView, app.acmeServers.GetOneServer)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeservers", app.acmeServers.PostNewServer)
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeservers/:id", app.acmeServers.PutServerUpdate)
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/acmeservers/:id", app.acmeServers.DeleteServer)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeservers", auth.PermissionAdmin, app.acmeServers.PostNewServer)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeservers/:id", auth.PermissionAdmin, app.acmeServers.PutServerUpdate)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/acmeservers/:id", auth.PermissionAdmin, app.acmeServers.DeleteServer)
 
 	// private_keys
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatekeys", app.keys.GetAllKeys)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatekeys/:id", app.keys.GetOneKey)
-	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/privatekeys/:id/download", app.keys.DownloadOneKey)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatekeys", auth.PermissionView, app.keys.GetAllKeys)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatekeys/:id", auth.PermissionView, app.keys.GetOneKey)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/privatekeys/:id/download", auth.PermissionDownload, app.keys.DownloadOneKey)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/privatekeys", app.keys.PostNewKey)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/privatekeys/:id/apikey", app.keys.StageNewApiKey)
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/privatekeys/:id/apikey", app.keys.RemoveOldApiKey)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/privatekeys", auth.PermissionAdmin, app.keys.PostNewKey)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/privatekeys/:id/apikey", auth.PermissionAdmin, app.keys.StageNewApiKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/privatekeys/:id/apikey", auth.PermissionAdmin, app.keys.RemoveOldApiKey)
 
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/privatekeys/:id", app.keys.PutKeyUpdate)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/privatekeys/:id", auth.PermissionAdmin, app.keys.PutKeyUpdate)
 
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/privatekeys/:id", app.keys.DeleteKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/privatekeys/:id", auth.PermissionAdmin, app.keys.DeleteKey)
 
 	// acme_accounts
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeaccounts", app.accounts.GetAllAccounts)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeaccounts/:id", app.accounts.GetOneAccount)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeaccounts", auth.PermissionView, app.accounts.GetAllAccounts)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeaccounts/:id", auth.PermissionView, app.accounts.GetOneAccount)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts", app.accounts.PostNewAccount)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts", auth.PermissionAdmin, app.accounts.PostNewAccount)
 
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id", app.accounts.PutNameDescAccount)
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id/email", app.accounts.ChangeEmail)
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id/key-change", app.accounts.RolloverKey)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id", auth.PermissionAdmin, app.accounts.PutNameDescAccount)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id/email", auth.PermissionAdmin, app.accounts.ChangeEmail)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id/key-change", auth.PermissionAdmin, app.accounts.RolloverKey)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/register", app.accounts.NewAcmeAccount)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/refresh", app.accounts.RefreshAcmeAccount)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/deactivate", app.accounts.Deactivate)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/post-as-get", app.accounts.PostAsGet)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/register", auth.PermissionAdmin, app.accounts.NewAcmeAccount)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/refresh", auth.PermissionAdmin, app.accounts.RefreshAcmeAccount)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/deactivate", auth.PermissionAdmin, app.accounts.Deactivate)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/post-as-get", auth.PermissionAdmin, app.accounts.PostAsGet)
 
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/acmeaccounts/:id", app.accounts.DeleteAccount)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/acmeaccounts/:id", auth.PermissionAdmin, app.accounts.DeleteAccount)
 
 	// certificates
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates", app.certificates.GetAllCerts)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid", app.certificates.GetOneCert)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates", auth.PermissionView, app.certificates.GetAllCerts)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid", auth.PermissionView, app.certificates.GetOneCert)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates", app.certificates.PostNewCert)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/apikey", app.certificates.StageNewApiKey)
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid/apikey", app.certificates.RemoveOldApiKey)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/clientkey", app.certificates.MakeNewClientKey)
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid/clientkey", app.certificates.DisableClientKey)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates", auth.PermissionAdmin, app.certificates.PostNewCert)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/apikey", auth.PermissionAdmin, app.certificates.StageNewApiKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid/apikey", auth.PermissionAdmin, app.certificates.RemoveOldApiKey)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/clientkey", auth.PermissionAdmin, app.certificates.MakeNewClientKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid/clientkey", auth.PermissionAdmin, app.certificates.DisableClientKey)
 
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/certificates/:certid", app.certificates.PutDetailsCert)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/certificates/:certid", auth.PermissionAdmin, app.certificates.PutDetailsCert)
 
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid", app.certificates.DeleteCert)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid", auth.PermissionAdmin, app.certificates.DeleteCert)
+
+	// certificate templates
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certtemplates", auth.PermissionView, app.certificates.GetAllTemplates)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certtemplates/:id", auth.PermissionView, app.certificates.GetOneTemplate)
+
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certtemplates", auth.PermissionAdmin, app.certificates.PostNewTemplate)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/certtemplates/:id", auth.PermissionAdmin, app.certificates.PutTemplate)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certtemplates/:id", auth.PermissionAdmin, app.certificates.DeleteTemplate)
+
+	// certificate import (of certificates issued elsewhere)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certimport", auth.PermissionAdmin, app.certificates.PostImport)
+
+	// monitored endpoints (external endpoints whose served certificate is checked)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/monitoredendpoints", auth.PermissionView, app.monitoredEndpoints.GetAllEndpoints)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/monitoredendpoints/:id", auth.PermissionView, app.monitoredEndpoints.GetOneEndpoint)
+
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/monitoredendpoints", auth.PermissionAdmin, app.monitoredEndpoints.PostNewEndpoint)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/monitoredendpoints/:id", auth.PermissionAdmin, app.monitoredEndpoints.PutEndpoint)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/monitoredendpoints/:id", auth.PermissionAdmin, app.monitoredEndpoints.DeleteEndpoint)
+
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/monitoredendpoints/:id/check", auth.PermissionOrder, app.monitoredEndpoints.PostCheckEndpoint)
 
 	// orders (for certificates)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/currentvalid", app.orders.GetAllValidCurrentOrders)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/fulfilling/status", app.orders.GetFulfillWorkStatus)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/post-process/status", app.orders.GetPostProcessWorkStatus)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/currentvalid", auth.PermissionView, app.orders.GetAllValidCurrentOrders)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/fulfilling/status", auth.PermissionView, app.orders.GetFulfillWorkStatus)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/post-process/status", auth.PermissionView, app.orders.GetPostProcessWorkStatus)
 
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders", app.orders.GetCertOrders)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders", app.orders.NewOrder)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders", auth.PermissionView, app.orders.GetCertOrders)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders", auth.PermissionOrder, app.orders.NewOrder)
 
-	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/download", app.orders.DownloadCertNewestOrder)
-	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/download", app.orders.DownloadOneOrder)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/download", auth.PermissionDownload, app.orders.DownloadCertNewestOrder)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/download", auth.PermissionDownload, app.orders.DownloadOneOrder)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid", app.orders.FulfillExistingOrder)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/revoke", app.orders.RevokeOrder)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid", auth.PermissionOrder, app.orders.FulfillExistingOrder)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/revoke", auth.PermissionOrder, app.orders.RevokeOrder)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/postprocess", app.orders.PostProcessOrder)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/postprocess", auth.PermissionOrder, app.orders.PostProcessOrder)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/history", auth.PermissionView, app.orders.GetOrderHistory)
 
 	// download keys and certs
 	router.handleAPIRouteDownloadWithAPIKey(http.MethodGet, apiKeyDownloadUrlPath+"/privatekeys/:name", app.download.DownloadKeyViaHeader)
@@ -167,6 +224,11 @@ func (app *Application) makeRouterAndRoutes() {
 	router.handleAPIRouteDownloadWithAPIKey(http.MethodGet, apiKeyDownloadUrlPath+"/certrootchains/:name/*apiKey", app.download.DownloadCertRootChainViaUrl)
 	router.handleAPIRouteDownloadWithAPIKey(http.MethodGet, apiKeyDownloadUrlPath+"/pfx/:name/*apiKey", app.download.DownloadPfxViaUrl)
 
+	// acme http-01 challenges (for http01internal providers that serve on the app listener);
+	// always registered since providers can be added or changed while running, if no
+	// provider serves on the app listener every request is a 404
+	router.r.HandlerFunc(http.MethodGet, http01ChallengeUrlPath+":token", app.http01ChallengeHandler)
+
 	// frontend (if enabled)
 	if *app.config.FrontendServe {
 		// log availability
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"flag"
 	"net"
 	"net/http"
 	"os"
@@ -19,6 +20,41 @@ const maxShutdownTime = 30 * time.Second
 // Run starts the application and also contains restart logic
 // in the event the app calls for a restart after termination
 func Run() {
+	// command line flags
+	rotateMasterKeyFile := flag.String("rotate-master-key", "", "re-wrap all private keys with the master key in the specified file and then exit")
+	restoreBackupFile := flag.String("restore-backup", "", "validate the specified backup file and stage it to be restored on the next start, then exit")
+	importPath := flag.String("import", "", "import the certbot dir, acme.sh dir, or pem/pfx file at the specified path, then exit")
+	importAccountID := flag.Int("import-account", 0, "the acme account id to renew imported certificates with (required with -import)")
+	importTemplateID := flag.Int("import-template", 0, "the certificate template id to apply to imported certificates (optional)")
+	flag.Parse()
+
+	// stage backup restore (instead of running the server)
+	if *restoreBackupFile != "" {
+		err := restoreBackup(*restoreBackupFile)
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// certificate import (instead of running the server)
+	if *importPath != "" {
+		err := importCerts(*importPath, *importAccountID, *importTemplateID)
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// master key rotation (instead of running the server)
+	if *rotateMasterKeyFile != "" {
+		err := rotateMasterKey(*rotateMasterKeyFile)
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// run the actual run func and wait for exit
 	restart := run()
 
@@ -121,6 +157,12 @@ func run() (restart bool) {
 			redirectSrv = &http.Server{
 				Addr: app.config.httpServAddress(),
 				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					// serve acme http-01 challenges directly (don't redirect)
+					if strings.HasPrefix(r.URL.Path, http01ChallengeUrlPath) {
+						app.router.ServeHTTP(w, r)
+						return
+					}
+
 					// remove port (if present) to get request hostname alone (since changing port)
 					hostName, _, _ := strings.Cut(r.Host, ":")
 
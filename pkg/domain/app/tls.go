@@ -1,7 +1,11 @@
 package app
 
 import (
+	"certwarden-backend/pkg/domain/private_keys"
+	"crypto"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
 )
@@ -49,9 +53,18 @@ func (app *Application) LoadHttpsCertificate() error {
 	}
 
 	// make tls certificate
-	tlsCert, err := tls.X509KeyPair([]byte(*order.Pem), []byte(order.FinalizedKey.Pem))
-	if err != nil {
-		return fmt.Errorf("failed to make x509 key pair (%s)", err)
+	var tlsCert tls.Certificate
+	if order.FinalizedKey.Exportable() {
+		tlsCert, err = tls.X509KeyPair([]byte(*order.Pem), []byte(order.FinalizedKey.Pem))
+		if err != nil {
+			return fmt.Errorf("failed to make x509 key pair (%s)", err)
+		}
+	} else {
+		// key resides on a hardware token, use its signer
+		tlsCert, err = tlsCertificateWithSigner([]byte(*order.Pem), order.FinalizedKey)
+		if err != nil {
+			return fmt.Errorf("failed to make tls certificate with hardware key (%s)", err)
+		}
 	}
 
 	// update certificate
@@ -59,3 +72,45 @@ func (app *Application) LoadHttpsCertificate() error {
 
 	return nil
 }
+
+// tlsCertificateWithSigner makes a tls.Certificate from a cert chain pem and a key that
+// is not exportable (i.e. its private key is a crypto.Signer)
+func tlsCertificateWithSigner(certPem []byte, key *private_keys.Key) (tls.Certificate, error) {
+	tlsCert := tls.Certificate{}
+	for {
+		var certBlock *pem.Block
+		certBlock, certPem = pem.Decode(certPem)
+		if certBlock == nil {
+			break
+		}
+		if certBlock.Type == "CERTIFICATE" {
+			tlsCert.Certificate = append(tlsCert.Certificate, certBlock.Bytes)
+		}
+	}
+	if len(tlsCert.Certificate) == 0 {
+		return tls.Certificate{}, errors.New("no certificate in cert pem")
+	}
+
+	leaf, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	tlsCert.Leaf = leaf
+
+	tlsCert.PrivateKey, err = key.CryptoPrivateKey()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	// confirm the key matches the cert
+	signer, ok := tlsCert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return tls.Certificate{}, errors.New("key is not a signer")
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(leaf.PublicKey) {
+		return tls.Certificate{}, errors.New("private key does not match certificate")
+	}
+
+	return tlsCert, nil
+}
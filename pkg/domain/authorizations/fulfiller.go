@@ -2,50 +2,83 @@ package authorizations
 
 import (
 	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/datatypes/history"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 )
 
 // acceptable final (non-error) authorization statuses (see: rfc8555 s 7.1.6)
 var finalAuthStatuses = []string{"valid", "invalid", "deactivated", "expired", "revoked"}
 
-// FulfillAuths attempts to validate each of the auth URLs in the slice of auth URLs. It returns an error if any
-// auth was not confirmed as in a final state (e.g., 'invalid' auth will not throw an error).
-func (service *Service) FulfillAuths(authUrls []string, key acme.AccountKey, acmeService *acme.Service) error {
-	// aysnc checking the authz for validity
-	var wg sync.WaitGroup
-	wgSize := len(authUrls)
+// FulfillAuths attempts to validate each of the auth URLs in the slice of auth URLs. All of the auths that
+// need solving are solved together (see challenges.Solve). It returns an error if any auth was not confirmed
+// as in a final state (e.g., 'invalid' auth will not throw an error). Steps taken are recorded to rec (which
+// may be nil).
+func (service *Service) FulfillAuths(authUrls []string, key acme.AccountKey, acmeService *acme.Service, rec *history.Recorder) error {
+	// dedupe and sort so auths are always locked in the same order (two orders that share
+	// auths can't each hold one the other is waiting on)
+	authUrls = slices.Clone(authUrls)
+	slices.Sort(authUrls)
+	authUrls = slices.Compact(authUrls)
+
+	// lock all of the auths while they're worked
+	for _, authUrl := range authUrls {
+		service.lockAuth(authUrl)
+	}
+	defer func() {
+		for _, authUrl := range authUrls {
+			service.unlockAuth(authUrl)
+		}
+	}()
 
-	wg.Add(wgSize)
-	wgErrors := make(chan error, wgSize)
+	// PaG each authorization
+	auths := make([]acme.Authorization, len(authUrls))
+	authErrs := make([]error, len(authUrls))
+	forEachAuth(authUrls, func(i int) {
+		auths[i], authErrs[i] = acmeService.GetAuth(authUrls[i], key)
+		rec.Add(history.Step{Action: history.ActionGetAuthorization, Identifier: auths[i].Identifier.Value, Status: auths[i].Status}, authErrs[i])
+	})
+
+	// call solver for all auths that are 'pending' (i.e., need solving)
+	pendingIndexes := []int{}
+	pendingAuths := []acme.Authorization{}
+	for i := range auths {
+		if authErrs[i] == nil && auths[i].Status == "pending" {
+			pendingIndexes = append(pendingIndexes, i)
+			pendingAuths = append(pendingAuths, auths[i])
+		}
+	}
 
-	// fulfill each auth concurrently
-	for i := range authUrls {
-		go func(authUrl string) {
-			defer wg.Done()
-			err := service.fulfillAuth(authUrl, key, acmeService)
+	if len(pendingAuths) > 0 {
+		solveErrs := service.challenges.Solve(pendingAuths, key, acmeService, rec)
+		for j, i := range pendingIndexes {
+			authErrs[i] = solveErrs[j]
+		}
 
-			// log individual errors before sending err to channel
-			if err != nil {
-				service.logger.Errorf("auths: failed to fulfill auth %s (%s)", authUrl, err)
+		// PaG the solved authorizations again (to confirm state after solve attempt)
+		forEachAuth(authUrls, func(i int) {
+			if !slices.Contains(pendingIndexes, i) || authErrs[i] != nil {
+				return
 			}
 
-			wgErrors <- err
-		}(authUrls[i])
+			auths[i], authErrs[i] = acmeService.GetAuth(authUrls[i], key)
+			rec.Add(history.Step{Action: history.ActionGetAuthorization, Identifier: auths[i].Identifier.Value, Status: auths[i].Status}, authErrs[i])
+		})
 	}
 
-	// wait for all auths to do their thing
-	wg.Wait()
-
-	// close channel
-	close(wgErrors)
-
-	// append any wgErrors to err
+	// check each auth's status is final
 	var err error
-	for wgErr := range wgErrors {
-		if wgErr != nil {
-			err = errors.Join(err, wgErr)
+	for i := range auths {
+		if authErrs[i] == nil && !slices.Contains(finalAuthStatuses, auths[i].Status) {
+			authErrs[i] = fmt.Errorf("authorizations: auth %s status (%s) is not final", authUrls[i], auths[i].Status)
+		}
+
+		// log individual errors before joining
+		if authErrs[i] != nil {
+			service.logger.Errorf("auths: failed to fulfill auth %s (%s)", authUrls[i], authErrs[i])
+			err = errors.Join(err, authErrs[i])
 		}
 	}
 	if err != nil {
@@ -56,18 +89,32 @@ func (service *Service) FulfillAuths(authUrls []string, key acme.AccountKey, acm
 	return nil
 }
 
-// fulfillAuth attempts to validate an auth URL by calling the challenge solver. If multiple calls are made for
-// the same auth, the additional calls will wait in a queue to proceed in turn. An error is returned if the auth
-// is not confirmed as in a final state.
-func (service *Service) fulfillAuth(authUrl string, key acme.AccountKey, acmeService *acme.Service) error {
-	// use a map and signal channels to ensure the same auth is not attempted to be solved simultaneously
+// forEachAuth concurrently calls do with the index of each of the authUrls and returns
+// once all of the calls have returned
+func forEachAuth(authUrls []string, do func(i int)) {
+	var wg sync.WaitGroup
+	for i := range authUrls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			do(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// lockAuth blocks until no other thread is working authUrl and then marks it as being
+// worked. This ensures the same auth is not attempted to be solved simultaneously.
+func (service *Service) lockAuth(authUrl string) {
+	// use a map and signal channels; if multiple calls are made for the same auth, the
+	// additional calls wait in a queue to proceed in turn
 	for {
 		// add auth
 		exists, signal := service.authsWorking.Add(authUrl, make(chan struct{}))
 
-		// if doesn't exist (not working) break from loop and solve
+		// if doesn't exist (not working) return, the caller now has the auth
 		if !exists {
-			break
+			return
 		}
 
 		// block until the other thread working this auth signals done
@@ -75,59 +122,22 @@ func (service *Service) fulfillAuth(authUrl string, key acme.AccountKey, acmeSer
 
 		// loop to try and Add to authsWorking again
 	}
+}
 
-	// defer removing auth once solving is done
-	defer func() {
-		// delete func closes the signal channel before returning true
-		delFunc := func(key string, signal chan struct{}) bool {
-			if key == authUrl {
-				close(signal)
-				return true
-			}
-			return false
-		}
-
-		deletedOk := service.authsWorking.DeleteFunc(delFunc)
-		if !deletedOk {
-			service.logger.Errorf("authorizations: failed to remove %s from work tracker", authUrl)
-		}
-	}()
-
-	// work the auth
-
-	// PaG the authorization
-	auth, err := acmeService.GetAuth(authUrl, key)
-	if err != nil {
-		return err
-	}
-
-	// call solver if auth is 'pending' (i.e., needs solving)
-	if auth.Status == "pending" {
-		err = service.challenges.Solve(auth.Identifier, auth.Challenges, key, acmeService)
-		// return error if couldn't solve
-		if err != nil {
-			return err
-		}
-
-		// PaG the authorization again (to confirm state after solve attempt)
-		auth, err = acmeService.GetAuth(authUrl, key)
-		if err != nil {
-			return err
-		}
-	}
-
-	// check if status is final
-	isFinal := false
-	for _, finalStatus := range finalAuthStatuses {
-		if auth.Status == finalStatus {
-			isFinal = true
+// unlockAuth removes authUrl from the auths being worked and signals any threads waiting
+// to work it
+func (service *Service) unlockAuth(authUrl string) {
+	// delete func closes the signal channel before returning true
+	delFunc := func(key string, signal chan struct{}) bool {
+		if key == authUrl {
+			close(signal)
+			return true
 		}
+		return false
 	}
 
-	// if not final, return error
-	if !isFinal {
-		return fmt.Errorf("authorizations: auth %s status (%s) is not final", authUrl, auth.Status)
+	deletedOk := service.authsWorking.DeleteFunc(delFunc)
+	if !deletedOk {
+		service.logger.Errorf("authorizations: failed to remove %s from work tracker", authUrl)
 	}
-
-	return nil
 }
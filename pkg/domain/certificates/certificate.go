@@ -34,6 +34,9 @@ type Certificate struct {
 	PostProcessingClientAddress string
 	PostProcessingClientKeyB64  string
 	Profile                     string
+	KeyRotation                 KeyRotation
+	DeployVerification          DeployVerification
+	TemplateID                  *int
 }
 
 // certificateSummaryResponse is a JSON response containing only
@@ -106,6 +109,9 @@ type certificateDetailedResponse struct {
 	CSRExtraExtensions          []CertExtensionJSON `json:"csr_extra_extensions"`
 	PreferredRootCN             string              `json:"preferred_root_cn"`
 	Profile                     string              `json:"profile"`
+	KeyRotation                 KeyRotation         `json:"key_rotation"`
+	DeployVerification          DeployVerification  `json:"deploy_verification"`
+	TemplateID                  *int                `json:"template_id"`
 	CreatedAt                   int64               `json:"created_at"`
 	UpdatedAt                   int64               `json:"updated_at"`
 	ApiKey                      string              `json:"api_key"`
@@ -134,6 +140,9 @@ func (cert Certificate) detailedResponse() certificateDetailedResponse {
 		CSRExtraExtensions:          extraExtensions,
 		PreferredRootCN:             cert.PreferredRootCN,
 		Profile:                     cert.Profile,
+		KeyRotation:                 cert.KeyRotation,
+		DeployVerification:          cert.DeployVerification,
+		TemplateID:                  cert.TemplateID,
 		CreatedAt:                   cert.CreatedAt.Unix(),
 		UpdatedAt:                   cert.UpdatedAt.Unix(),
 		ApiKey:                      cert.ApiKey,
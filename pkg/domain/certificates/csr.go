@@ -1,10 +1,10 @@
 package certificates
 
 import (
-	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"net"
 )
 
 // MakeCsrDer generates the CSR bytes for ACME to POST To a Finalize URL
@@ -35,9 +35,28 @@ func (cert *Certificate) MakeCsrDer() (csr []byte, err error) {
 		locality = append(locality, cert.City)
 	}
 
+	// split names into dns names and ip addresses (RFC 8738)
+	dnsNames := []string{}
+	ipAddresses := []net.IP{}
+	for _, name := range append([]string{cert.Subject}, cert.SubjectAltNames...) {
+		ip := net.ParseIP(name)
+		if ip != nil {
+			ipAddresses = append(ipAddresses, ip)
+		} else {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+
+	// common name can't be an ip address (CA/B Forum discourages it and some CAs
+	// reject it), so omit CN if the subject is an ip
+	commonName := cert.Subject
+	if net.ParseIP(cert.Subject) != nil {
+		commonName = ""
+	}
+
 	// create Subject
 	subj := pkix.Name{
-		CommonName:         cert.Subject,
+		CommonName:         commonName,
 		Organization:       org,
 		OrganizationalUnit: ou,
 		Country:            country,
@@ -58,13 +77,14 @@ func (cert *Certificate) MakeCsrDer() (csr []byte, err error) {
 	template := x509.CertificateRequest{
 		SignatureAlgorithm: cert.CertificateKey.Algorithm.CsrSigningAlg(),
 		Subject:            subj,
-		DNSNames:           append([]string{cert.Subject}, cert.SubjectAltNames...),
-		// unused: EmailAddresses, IPAddresses, URIs, Attributes (deprecated)
+		DNSNames:           dnsNames,
+		IPAddresses:        ipAddresses,
+		// unused: EmailAddresses, URIs, Attributes (deprecated)
 		ExtraExtensions: extraExts,
 	}
 
 	// cert's private key for signing
-	certKey, err := key_crypto.PemStringToKey(cert.CertificateKey.Pem, cert.CertificateKey.Algorithm)
+	certKey, err := cert.CertificateKey.CryptoPrivateKey()
 	if err != nil {
 		return nil, err
 	}
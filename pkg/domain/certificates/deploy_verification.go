@@ -0,0 +1,35 @@
+package certificates
+
+// DeployVerification is a certificate's deployment verification configuration. When
+// enabled, after post processing completes, each monitored endpoint linked to the
+// certificate is checked (and rechecked until WindowMinutes has passed) to confirm it
+// serves the new certificate.
+type DeployVerification struct {
+	Enabled       bool `json:"enabled"`
+	WindowMinutes int  `json:"window_minutes"`
+}
+
+// defaultDeployVerifyWindowMinutes is the window used if one is not specified
+const defaultDeployVerifyWindowMinutes = 5
+
+// validate confirms the DeployVerification is usable
+func (dv DeployVerification) validate() error {
+	if dv.WindowMinutes < 1 || dv.WindowMinutes > 60 {
+		return ErrDeployVerifyWindowBad
+	}
+
+	return nil
+}
+
+// deployVerificationPayloadApply applies the optional deployment verification payload
+// fields on top of the specified existing DeployVerification and then validates the result
+func deployVerificationPayloadApply(dv DeployVerification, enabled *bool, windowMinutes *int) (DeployVerification, error) {
+	if enabled != nil {
+		dv.Enabled = *enabled
+	}
+	if windowMinutes != nil {
+		dv.WindowMinutes = *windowMinutes
+	}
+
+	return dv, dv.validate()
+}
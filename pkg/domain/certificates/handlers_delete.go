@@ -1,6 +1,7 @@
 package certificates
 
 import (
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"errors"
 	"fmt"
@@ -22,7 +23,7 @@ func (service *Service) DeleteCert(w http.ResponseWriter, r *http.Request) *outp
 	}
 
 	// verify cert id exists
-	_, outErr := service.GetCertificate(id)
+	cert, outErr := service.GetCertificate(id)
 	if outErr != nil {
 		return outErr
 	}
@@ -33,6 +34,7 @@ func (service *Service) DeleteCert(w http.ResponseWriter, r *http.Request) *outp
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetCertificate, id, cert.detailedResponse(), nil)
 
 	// write response
 	response := &output.JsonResponse{}
@@ -74,6 +76,8 @@ func (service *Service) RemoveOldApiKey(w http.ResponseWriter, r *http.Request)
 	}
 	// validation -- end
 
+	before := cert.detailedResponse()
+
 	// update storage
 	// set current api key from new key
 	err = service.storage.PutCertApiKey(certId, cert.ApiKeyNew, int(time.Now().Unix()))
@@ -90,6 +94,7 @@ func (service *Service) RemoveOldApiKey(w http.ResponseWriter, r *http.Request)
 		return output.JsonErrStorageGeneric(err)
 	}
 	cert.ApiKeyNew = ""
+	audit.RecordChange(r.Context(), audit.TargetCertificate, cert.ID, before, cert.detailedResponse())
 
 	// write response
 	response := &certificateResponse{}
@@ -131,7 +136,9 @@ func (service *Service) DisableClientKey(w http.ResponseWriter, r *http.Request)
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	before := cert.detailedResponse()
 	cert.PostProcessingClientKeyB64 = ""
+	audit.RecordChange(r.Context(), audit.TargetCertificate, cert.ID, before, cert.detailedResponse())
 
 	// write response
 	response := &certificateResponse{}
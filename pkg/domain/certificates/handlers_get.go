@@ -2,6 +2,7 @@ package certificates
 
 import (
 	"certwarden-backend/pkg/domain/acme_accounts"
+	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"certwarden-backend/pkg/output"
@@ -89,6 +90,16 @@ func (service *Service) GetOneCert(w http.ResponseWriter, r *http.Request) *outp
 	response.Message = "ok"
 	response.Certificate = cert.detailedResponse()
 
+	// redact secrets the user's role doesn't permit access to
+	if !auth.ContextPermits(r.Context(), auth.PermissionDownload) {
+		response.Certificate.ApiKey = output.RedactString(response.Certificate.ApiKey)
+		response.Certificate.ApiKeyNew = output.RedactString(response.Certificate.ApiKeyNew)
+	}
+	if !auth.ContextPermits(r.Context(), auth.PermissionAdmin) {
+		response.Certificate.PostProcessingClientKeyB64 = output.RedactString(response.Certificate.PostProcessingClientKeyB64)
+		response.Certificate.PostProcessingEnvironment = []string{}
+	}
+
 	err = service.output.WriteJSON(w, response)
 	if err != nil {
 		service.logger.Errorf("failed to write json (%s)", err)
@@ -104,6 +115,7 @@ type newCertOptions struct {
 		AvailableKeys  []private_keys.KeySummaryResponse `json:"private_keys"`
 		KeyAlgorithms  []key_crypto.Algorithm            `json:"key_algorithms"`
 		UsableAccounts []usableAccount                   `json:"acme_accounts"`
+		Templates      []templateSummaryResponse         `json:"cert_templates"`
 	} `json:"certificate_options"`
 }
 
@@ -164,6 +176,18 @@ func (service *Service) GetNewCertOptions(w http.ResponseWriter, r *http.Request
 		outputAccounts = append(outputAccounts, acct)
 	}
 
+	// templates
+	templates, _, err := service.storage.GetAllCertTemplates(pagination_sort.Query{})
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	outputTemplates := []templateSummaryResponse{}
+	for i := range templates {
+		outputTemplates = append(outputTemplates, templates[i].summaryResponse())
+	}
+
 	// write response
 	response := &newCertOptions{}
 	response.StatusCode = http.StatusOK
@@ -171,6 +195,7 @@ func (service *Service) GetNewCertOptions(w http.ResponseWriter, r *http.Request
 	response.CertificateOptions.AvailableKeys = outputKeys
 	response.CertificateOptions.KeyAlgorithms = key_crypto.ListOfAlgorithms()
 	response.CertificateOptions.UsableAccounts = outputAccounts
+	response.CertificateOptions.Templates = outputTemplates
 
 	err = service.output.WriteJSON(w, response)
 	if err != nil {
@@ -0,0 +1,129 @@
+package certificates
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/output"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ImportRequestPayload is the struct for importing existing certificates. Bundles are
+// uploaded pem or pfx (base64 encoded) content; importing from the server's filesystem is
+// only available from the command line (see ReadImportPath).
+type ImportRequestPayload struct {
+	AcmeAccountID *int                  `json:"acme_account_id"`
+	TemplateID    *int                  `json:"template_id"`
+	Bundles       []importBundlePayload `json:"bundles"`
+}
+
+// importBundlePayload is a single uploaded bundle; exactly one of Pem or PfxB64 should be
+// specified
+type importBundlePayload struct {
+	Name        string `json:"name"`
+	Pem         string `json:"pem"`
+	PfxB64      string `json:"pfx_b64"`
+	PfxPassword string `json:"pfx_password"`
+}
+
+// importResponse is the response to an import, containing the outcome of each bundle
+type importResponse struct {
+	output.JsonResponse
+	Results []ImportResult `json:"results"`
+}
+
+// PostImport imports existing certificates (and their keys) that were issued elsewhere
+// so they can be downloaded immediately and then renewed by Cert Warden
+func (service *Service) PostImport(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload ImportRequestPayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// acme account
+	if payload.AcmeAccountID == nil {
+		err = errors.New("acme account id is unspecified")
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	acctUsable, _ := service.accounts.AccountUsable(*payload.AcmeAccountID)
+	if !acctUsable {
+		err = errors.New("acme account id does not exist or is not usable")
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	// template (optional)
+	if payload.TemplateID != nil {
+		_, outErr := service.getTemplate(*payload.TemplateID)
+		if outErr != nil {
+			return outErr
+		}
+	}
+	// something to import
+	if len(payload.Bundles) == 0 {
+		err = errors.New("no bundles to import")
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	// end validation
+
+	// read bundles (bundles that can't be read are reported in the results)
+	results := []ImportResult{}
+	bundles := []ImportBundle{}
+	for i, b := range payload.Bundles {
+		source := fmt.Sprintf("upload %d", i)
+
+		var bundle ImportBundle
+		if b.PfxB64 != "" {
+			var pfx []byte
+			pfx, err = base64.StdEncoding.DecodeString(b.PfxB64)
+			if err == nil {
+				bundle, err = PfxImportBundle(source, b.Name, pfx, b.PfxPassword)
+			}
+		} else {
+			bundle, err = PemImportBundle(source, b.Name, []byte(b.Pem))
+		}
+		if err != nil {
+			results = append(results, ImportResult{Source: source, Name: b.Name, Error: err.Error()})
+			continue
+		}
+		bundles = append(bundles, bundle)
+	}
+
+	// import
+	importResults, importedCerts, err := ImportCertificates(service.storage, bundles, ImportOptions{
+		AcmeAccountID: *payload.AcmeAccountID,
+		TemplateID:    payload.TemplateID,
+	})
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	results = append(results, importResults...)
+
+	for i := range importedCerts {
+		audit.RecordChange(r.Context(), audit.TargetCertificate, importedCerts[i].ID, nil, importedCerts[i].detailedResponse())
+	}
+	service.logger.Infof("imported %d of %d certificate(s)", len(importedCerts), len(results))
+
+	// write response
+	response := &importResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = fmt.Sprintf("imported %d of %d certificate(s)", len(importedCerts), len(results))
+	response.Results = results
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
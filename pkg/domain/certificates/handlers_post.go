@@ -1,6 +1,7 @@
 package certificates
 
 import (
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"certwarden-backend/pkg/output"
@@ -18,6 +19,7 @@ import (
 
 // NewPayload is the struct for creating a new certificate
 type NewPayload struct {
+	TemplateID                  *int                `json:"template_id"`
 	Name                        *string             `json:"name"`
 	Description                 *string             `json:"description"`
 	PrivateKeyID                *int                `json:"private_key_id"`
@@ -37,6 +39,14 @@ type NewPayload struct {
 	PostProcessingClientAddress *string             `json:"post_processing_client_address"`
 	PostProcessingClientKeyB64  string              `json:"-"`
 	Profile                     *string             `json:"profile"`
+	KeyRotationPolicy           *string             `json:"key_rotation_policy"`
+	KeyRotationIntervalDays     *int                `json:"key_rotation_interval_days"`
+	KeyRotationAlgorithmValue   *string             `json:"key_rotation_algorithm_value"`
+	KeyRotationGraceDays        *int                `json:"key_rotation_grace_days"`
+	KeyRotation                 KeyRotation         `json:"-"`
+	DeployVerify                *bool               `json:"deploy_verify"`
+	DeployVerifyWindowMinutes   *int                `json:"deploy_verify_window_minutes"`
+	DeployVerification          DeployVerification  `json:"-"`
 	ApiKey                      string              `json:"-"`
 	ApiKeyViaUrl                bool                `json:"-"`
 	CreatedAt                   int                 `json:"-"`
@@ -56,6 +66,15 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 		return output.JsonErrValidationFailed(err)
 	}
 
+	// template (optional) - any fields not specified are set from the template
+	if payload.TemplateID != nil {
+		tmpl, outErr := service.getTemplate(*payload.TemplateID)
+		if outErr != nil {
+			return outErr
+		}
+		payload.applyTemplate(tmpl)
+	}
+
 	// validation
 	// name
 	if payload.Name == nil || !service.nameValid(*payload.Name, nil) {
@@ -145,6 +164,20 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 			return output.JsonErrValidationFailed(err)
 		}
 	}
+	// key rotation (optional, default is to reuse the key)
+	payload.KeyRotation, err = keyRotationPayloadApply(KeyRotation{Policy: KeyRotationReuse, GraceDays: defaultKeyRotationGraceDays},
+		payload.KeyRotationPolicy, payload.KeyRotationIntervalDays, payload.KeyRotationAlgorithmValue, payload.KeyRotationGraceDays)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	// deployment verification (optional, default is disabled)
+	payload.DeployVerification, err = deployVerificationPayloadApply(DeployVerification{WindowMinutes: defaultDeployVerifyWindowMinutes},
+		payload.DeployVerify, payload.DeployVerifyWindowMinutes)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
 
 	// CSR
 	// set to blank if don't exist
@@ -252,6 +285,7 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetCertificate, newCert.ID, nil, newCert.detailedResponse())
 
 	// write response
 	response := &certificateResponse{}
@@ -306,7 +340,9 @@ func (service *Service) StageNewApiKey(w http.ResponseWriter, r *http.Request) *
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	before := cert.detailedResponse()
 	cert.ApiKeyNew = newApiKey
+	audit.RecordChange(r.Context(), audit.TargetCertificate, cert.ID, before, cert.detailedResponse())
 
 	// write response
 	response := &certificateResponse{}
@@ -356,7 +392,9 @@ func (service *Service) MakeNewClientKey(w http.ResponseWriter, r *http.Request)
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	before := cert.detailedResponse()
 	cert.PostProcessingClientKeyB64 = clientKey
+	audit.RecordChange(r.Context(), audit.TargetCertificate, cert.ID, before, cert.detailedResponse())
 
 	// write response
 	response := &certificateResponse{}
@@ -1,6 +1,7 @@
 package certificates
 
 import (
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/validation"
 	"encoding/json"
@@ -31,6 +32,15 @@ type DetailsUpdatePayload struct {
 	PostProcessingEnvironment   []string            `json:"post_processing_environment"`
 	PostProcessingClientAddress *string             `json:"post_processing_client_address"`
 	Profile                     *string             `json:"profile"`
+	KeyRotationPolicy           *string             `json:"key_rotation_policy"`
+	KeyRotationIntervalDays     *int                `json:"key_rotation_interval_days"`
+	KeyRotationAlgorithmValue   *string             `json:"key_rotation_algorithm_value"`
+	KeyRotationGraceDays        *int                `json:"key_rotation_grace_days"`
+	KeyRotation                 *KeyRotation        `json:"-"`
+	DeployVerify                *bool               `json:"deploy_verify"`
+	DeployVerifyWindowMinutes   *int                `json:"deploy_verify_window_minutes"`
+	DeployVerification          *DeployVerification `json:"-"`
+	TemplateID                  *int                `json:"template_id"` // 0 to unlink
 	ApiKey                      *string             `json:"api_key"`
 	ApiKeyNew                   *string             `json:"api_key_new"`
 	ApiKeyViaUrl                *bool               `json:"api_key_via_url"`
@@ -104,6 +114,32 @@ func (service *Service) PutDetailsCert(w http.ResponseWriter, r *http.Request) *
 			return output.JsonErrValidationFailed(err)
 		}
 	}
+	// key rotation (optional) - merge with existing config and validate the result
+	if payload.KeyRotationPolicy != nil || payload.KeyRotationIntervalDays != nil || payload.KeyRotationAlgorithmValue != nil || payload.KeyRotationGraceDays != nil {
+		keyRotation, err := keyRotationPayloadApply(cert.KeyRotation,
+			payload.KeyRotationPolicy, payload.KeyRotationIntervalDays, payload.KeyRotationAlgorithmValue, payload.KeyRotationGraceDays)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+		payload.KeyRotation = &keyRotation
+	}
+	// deployment verification (optional) - merge with existing config and validate the result
+	if payload.DeployVerify != nil || payload.DeployVerifyWindowMinutes != nil {
+		deployVerification, err := deployVerificationPayloadApply(cert.DeployVerification, payload.DeployVerify, payload.DeployVerifyWindowMinutes)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+		payload.DeployVerification = &deployVerification
+	}
+	// template link (optional, 0 unlinks)
+	if payload.TemplateID != nil && *payload.TemplateID != 0 {
+		_, outErr = service.getTemplate(*payload.TemplateID)
+		if outErr != nil {
+			return outErr
+		}
+	}
 	// api key must be at least 10 characters long
 	if payload.ApiKey != nil && len(*payload.ApiKey) < 10 {
 		service.logger.Debug(ErrApiKeyBad)
@@ -150,6 +186,7 @@ func (service *Service) PutDetailsCert(w http.ResponseWriter, r *http.Request) *
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetCertificate, updatedCert.ID, cert.detailedResponse(), updatedCert.detailedResponse())
 
 	// write response
 	response := &certificateResponse{}
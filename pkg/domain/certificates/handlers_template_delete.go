@@ -0,0 +1,50 @@
+package certificates
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/output"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// DeleteTemplate deletes a certificate template from storage. Certificates that were
+// created from the template are unlinked from it, but are otherwise unchanged.
+func (service *Service) DeleteTemplate(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// verify template id exists
+	tmpl, outErr := service.getTemplate(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// delete from storage
+	err = service.storage.DeleteCertTemplate(id)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	audit.RecordChange(r.Context(), audit.TargetCertTemplate, id, tmpl.detailedResponse(), nil)
+
+	// write response
+	response := &output.JsonResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = fmt.Sprintf("deleted certificate template (id: %d)", id)
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package certificates
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// allTemplatesResponse provides the json response struct
+// to answer a query for a portion of the templates
+type allTemplatesResponse struct {
+	output.JsonResponse
+	TotalTemplates int                       `json:"total_records"`
+	Templates      []templateSummaryResponse `json:"cert_templates"`
+}
+
+// GetAllTemplates fetches all certificate templates from storage and outputs them as JSON
+func (service *Service) GetAllTemplates(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// parse pagination and sorting
+	query := pagination_sort.ParseRequestToQuery(r)
+
+	// get templates from storage
+	templates, totalRows, err := service.storage.GetAllCertTemplates(query)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// populate template summaries for output
+	outputTemplates := []templateSummaryResponse{}
+	for i := range templates {
+		outputTemplates = append(outputTemplates, templates[i].summaryResponse())
+	}
+
+	// write response
+	response := &allTemplatesResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.TotalTemplates = totalRows
+	response.Templates = outputTemplates
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+type templateResponse struct {
+	output.JsonResponse
+	Template templateDetailedResponse `json:"cert_template"`
+}
+
+// GetOneTemplate is an http handler that returns one certificate Template based on its unique
+// id in the form of JSON written to w
+func (service *Service) GetOneTemplate(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get from storage
+	tmpl, outErr := service.getTemplate(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// write response
+	response := &templateResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Template = tmpl.detailedResponse()
+
+	// redact secrets the user's role doesn't permit access to
+	if !auth.ContextPermits(r.Context(), auth.PermissionAdmin) {
+		response.Template.PostProcessingEnvironment = []string{}
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
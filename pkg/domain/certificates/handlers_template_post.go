@@ -0,0 +1,199 @@
+package certificates
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/validation"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NewTemplatePayload is the struct for creating a new certificate template
+type NewTemplatePayload struct {
+	Name                        *string             `json:"name"`
+	Description                 *string             `json:"description"`
+	AcmeAccountID               *int                `json:"acme_account_id"`
+	KeyAlgorithmValue           *string             `json:"algorithm_value"`
+	Organization                *string             `json:"organization"`
+	OrganizationalUnit          *string             `json:"organizational_unit"`
+	Country                     *string             `json:"country"`
+	State                       *string             `json:"state"`
+	City                        *string             `json:"city"`
+	CSRExtraExtensions          []CertExtensionJSON `json:"csr_extra_extensions"`
+	PreferredRootCN             *string             `json:"preferred_root_cn"`
+	Profile                     *string             `json:"profile"`
+	PostProcessingCommand       *string             `json:"post_processing_command"`
+	PostProcessingEnvironment   []string            `json:"post_processing_environment"`
+	PostProcessingClientAddress *string             `json:"post_processing_client_address"`
+	KeyRotationPolicy           *string             `json:"key_rotation_policy"`
+	KeyRotationIntervalDays     *int                `json:"key_rotation_interval_days"`
+	KeyRotationAlgorithmValue   *string             `json:"key_rotation_algorithm_value"`
+	KeyRotationGraceDays        *int                `json:"key_rotation_grace_days"`
+	KeyRotation                 KeyRotation         `json:"-"`
+	CreatedAt                   int                 `json:"-"`
+	UpdatedAt                   int                 `json:"-"`
+}
+
+// templateAccountAndProfileValid validates a template's acme account (nil or 0 is none) and
+// confirms the profile (if not blank) is advertised by the account's acme server
+func (service *Service) templateAccountAndProfileValid(acmeAccountID *int, profile string) error {
+	if acmeAccountID == nil || *acmeAccountID == 0 {
+		if profile != "" {
+			return errors.New("certificate template profile requires an acme account")
+		}
+		return nil
+	}
+
+	acctUsable, acct := service.accounts.AccountUsable(*acmeAccountID)
+	if !acctUsable {
+		return errors.New("acme account id does not exist or is not usable")
+	}
+
+	if profile != "" {
+		return service.profileValid(acct.AcmeServer.ID, profile)
+	}
+
+	return nil
+}
+
+// profileValid returns an error if the specified acme server does not advertise profile
+func (service *Service) profileValid(acmeServerID int, profile string) error {
+	acmeService, err := service.acmeServerService.AcmeService(acmeServerID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve acme service (%s)", err)
+	}
+	if !acmeService.ProfileValidate(profile) {
+		return fmt.Errorf("acme service for specified account does not advertise profile `%s`", profile)
+	}
+
+	return nil
+}
+
+// PostNewTemplate creates a new certificate template in storage
+func (service *Service) PostNewTemplate(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload NewTemplatePayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// name
+	if payload.Name == nil || !service.templateNameValid(*payload.Name, nil) {
+		service.logger.Debug(ErrTemplateNameBad)
+		return output.JsonErrValidationFailed(ErrTemplateNameBad)
+	}
+	// description (if none, set to blank)
+	if payload.Description == nil {
+		payload.Description = new(string)
+	}
+	// key algorithm (optional)
+	if payload.KeyAlgorithmValue == nil {
+		payload.KeyAlgorithmValue = new(string)
+	} else if *payload.KeyAlgorithmValue != "" && key_crypto.AlgorithmByStorageValue(*payload.KeyAlgorithmValue) == key_crypto.UnknownAlgorithm {
+		service.logger.Debug(ErrTemplateAlgBad)
+		return output.JsonErrValidationFailed(ErrTemplateAlgBad)
+	}
+	// acme account & profile (optional)
+	if payload.Profile == nil {
+		payload.Profile = new(string)
+	}
+	err = service.templateAccountAndProfileValid(payload.AcmeAccountID, *payload.Profile)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	if payload.AcmeAccountID != nil && *payload.AcmeAccountID == 0 {
+		payload.AcmeAccountID = nil
+	}
+	// key rotation (optional, default is to reuse the key)
+	payload.KeyRotation, err = keyRotationPayloadApply(KeyRotation{Policy: KeyRotationReuse, GraceDays: defaultKeyRotationGraceDays},
+		payload.KeyRotationPolicy, payload.KeyRotationIntervalDays, payload.KeyRotationAlgorithmValue, payload.KeyRotationGraceDays)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// CSR
+	// set to blank if don't exist
+	if payload.Organization == nil {
+		payload.Organization = new(string)
+	}
+	if payload.OrganizationalUnit == nil {
+		payload.OrganizationalUnit = new(string)
+	}
+	if payload.Country == nil {
+		payload.Country = new(string)
+	}
+	if payload.State == nil {
+		payload.State = new(string)
+	}
+	if payload.City == nil {
+		payload.City = new(string)
+	}
+
+	// CSR Extra Extensions - check each extra extension for proper formatting
+	for i := range payload.CSRExtraExtensions {
+		_, err = payload.CSRExtraExtensions[i].ToCertExtension()
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	}
+
+	if payload.PreferredRootCN == nil {
+		payload.PreferredRootCN = new(string)
+	}
+
+	// post processing command / env
+	if payload.PostProcessingCommand == nil {
+		payload.PostProcessingCommand = new(string)
+	}
+	if payload.PostProcessingEnvironment == nil {
+		payload.PostProcessingEnvironment = []string{}
+	}
+	// post processing address
+	if payload.PostProcessingClientAddress == nil {
+		payload.PostProcessingClientAddress = new(string)
+	} else if *payload.PostProcessingClientAddress != "" {
+		valid := validation.DomainAndPortValid(*payload.PostProcessingClientAddress)
+		if !valid {
+			service.logger.Debug(ErrClientAddressBad)
+			return output.JsonErrValidationFailed(ErrClientAddressBad)
+		}
+	}
+	// end validation
+
+	// add additional details to the payload before saving
+	payload.CreatedAt = int(time.Now().Unix())
+	payload.UpdatedAt = payload.CreatedAt
+
+	// save new template
+	newTmpl, err := service.storage.PostNewCertTemplate(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	audit.RecordChange(r.Context(), audit.TargetCertTemplate, newTmpl.ID, nil, newTmpl.detailedResponse())
+
+	// write response
+	response := &templateResponse{}
+	response.StatusCode = http.StatusCreated
+	response.Message = "created certificate template"
+	response.Template = newTmpl.detailedResponse()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
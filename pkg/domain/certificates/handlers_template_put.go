@@ -0,0 +1,243 @@
+package certificates
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/validation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// TemplateUpdatePayload is the struct for editing an existing certificate template. If
+// Propagate is true, the changed fields (other than name, description, account, and key
+// algorithm) are also saved to every certificate linked to the template.
+type TemplateUpdatePayload struct {
+	ID                          int                 `json:"-"`
+	Name                        *string             `json:"name"`
+	Description                 *string             `json:"description"`
+	AcmeAccountID               *int                `json:"acme_account_id"` // 0 to clear
+	KeyAlgorithmValue           *string             `json:"algorithm_value"`
+	Organization                *string             `json:"organization"`
+	OrganizationalUnit          *string             `json:"organizational_unit"`
+	Country                     *string             `json:"country"`
+	State                       *string             `json:"state"`
+	City                        *string             `json:"city"`
+	CSRExtraExtensions          []CertExtensionJSON `json:"csr_extra_extensions"`
+	PreferredRootCN             *string             `json:"preferred_root_cn"`
+	Profile                     *string             `json:"profile"`
+	PostProcessingCommand       *string             `json:"post_processing_command"`
+	PostProcessingEnvironment   []string            `json:"post_processing_environment"`
+	PostProcessingClientAddress *string             `json:"post_processing_client_address"`
+	KeyRotationPolicy           *string             `json:"key_rotation_policy"`
+	KeyRotationIntervalDays     *int                `json:"key_rotation_interval_days"`
+	KeyRotationAlgorithmValue   *string             `json:"key_rotation_algorithm_value"`
+	KeyRotationGraceDays        *int                `json:"key_rotation_grace_days"`
+	KeyRotation                 *KeyRotation        `json:"-"`
+	Propagate                   bool                `json:"propagate"`
+	UpdatedAt                   int                 `json:"-"`
+}
+
+// certDetailsUpdate returns the cert update payload that applies the template changes
+// in this payload to a linked certificate. Only the key rotation fields that were sent
+// are applied, on top of the cert's own key rotation config.
+func (payload TemplateUpdatePayload) certDetailsUpdate(cert Certificate) (DetailsUpdatePayload, error) {
+	var keyRotation *KeyRotation
+	if payload.KeyRotation != nil {
+		kr, err := keyRotationPayloadApply(cert.KeyRotation,
+			payload.KeyRotationPolicy, payload.KeyRotationIntervalDays, payload.KeyRotationAlgorithmValue, payload.KeyRotationGraceDays)
+		if err != nil {
+			return DetailsUpdatePayload{}, err
+		}
+		keyRotation = &kr
+	}
+
+	return DetailsUpdatePayload{
+		ID:                          cert.ID,
+		Organization:                payload.Organization,
+		OrganizationalUnit:          payload.OrganizationalUnit,
+		Country:                     payload.Country,
+		State:                       payload.State,
+		City:                        payload.City,
+		CSRExtraExtensions:          payload.CSRExtraExtensions,
+		PreferredRootCN:             payload.PreferredRootCN,
+		PostProcessingCommand:       payload.PostProcessingCommand,
+		PostProcessingEnvironment:   payload.PostProcessingEnvironment,
+		PostProcessingClientAddress: payload.PostProcessingClientAddress,
+		Profile:                     payload.Profile,
+		KeyRotation:                 keyRotation,
+		UpdatedAt:                   payload.UpdatedAt,
+	}, nil
+}
+
+// templateUpdateResponse is the response to a template update, including the ids of
+// any certificates the changes were propagated to
+type templateUpdateResponse struct {
+	templateResponse
+	UpdatedCertificateIDs []int `json:"updated_certificate_ids"`
+}
+
+// PutTemplate is a handler that updates a certificate template and, optionally, propagates
+// the changes to the certificates linked to it
+func (service *Service) PutTemplate(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// payload decoding
+	var payload TemplateUpdatePayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	payload.ID, err = strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// id
+	tmpl, outErr := service.getTemplate(payload.ID)
+	if outErr != nil {
+		return outErr
+	}
+	// name (optional)
+	if payload.Name != nil && !service.templateNameValid(*payload.Name, &payload.ID) {
+		service.logger.Debug(ErrTemplateNameBad)
+		return output.JsonErrValidationFailed(ErrTemplateNameBad)
+	}
+	// key algorithm (optional, blank clears)
+	if payload.KeyAlgorithmValue != nil && *payload.KeyAlgorithmValue != "" && key_crypto.AlgorithmByStorageValue(*payload.KeyAlgorithmValue) == key_crypto.UnknownAlgorithm {
+		service.logger.Debug(ErrTemplateAlgBad)
+		return output.JsonErrValidationFailed(ErrTemplateAlgBad)
+	}
+	// acme account & profile (optional) - validate the resulting combination
+	acmeAccountID := tmpl.AcmeAccountID
+	if payload.AcmeAccountID != nil {
+		acmeAccountID = payload.AcmeAccountID
+	}
+	profile := tmpl.Profile
+	if payload.Profile != nil {
+		profile = *payload.Profile
+	}
+	if payload.AcmeAccountID != nil || payload.Profile != nil {
+		err = service.templateAccountAndProfileValid(acmeAccountID, profile)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	}
+	// key rotation (optional) - merge with existing config and validate the result
+	if payload.KeyRotationPolicy != nil || payload.KeyRotationIntervalDays != nil || payload.KeyRotationAlgorithmValue != nil || payload.KeyRotationGraceDays != nil {
+		keyRotation, err := keyRotationPayloadApply(tmpl.KeyRotation,
+			payload.KeyRotationPolicy, payload.KeyRotationIntervalDays, payload.KeyRotationAlgorithmValue, payload.KeyRotationGraceDays)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+		payload.KeyRotation = &keyRotation
+	}
+	// CSR Extra Extensions - check each extra extension for proper formatting
+	for i := range payload.CSRExtraExtensions {
+		_, err = payload.CSRExtraExtensions[i].ToCertExtension()
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	}
+	// post processing address
+	if payload.PostProcessingClientAddress != nil && *payload.PostProcessingClientAddress != "" {
+		valid := validation.DomainAndPortValid(*payload.PostProcessingClientAddress)
+		if !valid {
+			service.logger.Debug(ErrClientAddressBad)
+			return output.JsonErrValidationFailed(ErrClientAddressBad)
+		}
+	}
+
+	// linked certs (only needed if propagating)
+	linkedCerts := []Certificate{}
+	if payload.Propagate {
+		certIds, err := service.storage.GetCertIdsByTemplate(payload.ID)
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrStorageGeneric(err)
+		}
+
+		for _, certId := range certIds {
+			cert, outErr := service.GetCertificate(certId)
+			if outErr != nil {
+				return outErr
+			}
+
+			// a new profile must be valid for every linked cert's acme server
+			if payload.Profile != nil && *payload.Profile != "" {
+				err = service.profileValid(cert.CertificateAccount.AcmeServer.ID, *payload.Profile)
+				if err != nil {
+					err = fmt.Errorf("cannot propagate to certificate '%s' (%s)", cert.Name, err)
+					service.logger.Debug(err)
+					return output.JsonErrValidationFailed(err)
+				}
+			}
+
+			linkedCerts = append(linkedCerts, cert)
+		}
+	}
+	// end validation
+
+	// add additional details to the payload before saving
+	payload.UpdatedAt = int(time.Now().Unix())
+
+	// changes to propagate to linked certs
+	certUpdates := []DetailsUpdatePayload{}
+	for i := range linkedCerts {
+		certUpdate, err := payload.certDetailsUpdate(linkedCerts[i])
+		if err != nil {
+			err = fmt.Errorf("cannot propagate to certificate '%s' (%s)", linkedCerts[i].Name, err)
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+		certUpdates = append(certUpdates, certUpdate)
+	}
+
+	// save template and linked certs
+	updatedTmpl, err := service.storage.PutCertTemplate(payload, certUpdates)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	audit.RecordChange(r.Context(), audit.TargetCertTemplate, updatedTmpl.ID, tmpl.detailedResponse(), updatedTmpl.detailedResponse())
+
+	updatedCertIds := []int{}
+	for i := range linkedCerts {
+		updatedCertIds = append(updatedCertIds, linkedCerts[i].ID)
+
+		updatedCert, err := service.storage.GetOneCertById(linkedCerts[i].ID)
+		if err != nil {
+			// changes are already saved, so just log the missing audit entry
+			service.logger.Errorf("failed to fetch propagated certificate %d for audit (%s)", linkedCerts[i].ID, err)
+			continue
+		}
+		audit.RecordChange(r.Context(), audit.TargetCertificate, updatedCert.ID, linkedCerts[i].detailedResponse(), updatedCert.detailedResponse())
+	}
+
+	// write response
+	response := &templateUpdateResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "updated certificate template"
+	response.Template = updatedTmpl.detailedResponse()
+	response.UpdatedCertificateIDs = updatedCertIds
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
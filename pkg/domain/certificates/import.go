@@ -0,0 +1,334 @@
+package certificates
+
+import (
+	"certwarden-backend/pkg/domain/acme_accounts"
+	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"certwarden-backend/pkg/randomness"
+	"certwarden-backend/pkg/validation"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// importOrderLocationPrefix is the prefix of the (non-ACME) location saved for the synthetic
+// order of an imported certificate; the remainder is the sha256 of the leaf certificate so
+// the same certificate can't be imported twice
+const importOrderLocationPrefix = "urn:certwarden:import:"
+
+// ImportBundle is an existing certificate chain and its private key (both pem) that were
+// issued by something other than Cert Warden
+type ImportBundle struct {
+	Source       string
+	Name         string
+	CertChainPem string
+	KeyPem       string
+}
+
+// ImportOptions are the settings applied to every certificate being imported
+type ImportOptions struct {
+	AcmeAccountID int
+	TemplateID    *int
+}
+
+// ImportPayload is the data to save for an imported certificate: its private key, the
+// certificate itself, and a valid order containing the existing certificate chain
+type ImportPayload struct {
+	Cert NewPayload
+
+	KeyAlgorithm key_crypto.Algorithm
+	KeyPem       string
+	KeyApiKey    string
+
+	OrderLocation string
+	DnsIds        []string
+	IpIds         []string
+	ChainPem      string
+	ValidFrom     int
+	ValidTo       int
+	ChainRootCN   string
+}
+
+// ImportResult is the outcome of importing one bundle
+type ImportResult struct {
+	Source        string `json:"source"`
+	Name          string `json:"name"`
+	CertificateID *int   `json:"certificate_id,omitempty"`
+	OrderID       *int   `json:"order_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ImportStorage is the storage needed to import certificates (it is satisfied by the
+// Storage of this service and by the app's storage directly, for command line use)
+type ImportStorage interface {
+	GetOneAcmeAccountById(id int) (acme_accounts.Account, error)
+	GetOneCertTemplateById(id int) (Template, error)
+	GetOneCertByName(name string) (Certificate, error)
+	GetOneKeyByName(name string) (private_keys.Key, error)
+
+	PostImportedCert(payload ImportPayload) (cert Certificate, orderId int, err error)
+}
+
+// ImportCertificates saves each bundle as a new private key, certificate, and valid order
+// (so the existing certificate is immediately available for download). Once imported, the
+// certificates are renewed by the automatic ordering service the same as any other. A
+// failure to import one bundle does not stop the others from being imported.
+func ImportCertificates(store ImportStorage, bundles []ImportBundle, opts ImportOptions) ([]ImportResult, []Certificate, error) {
+	// account must be usable
+	acct, err := store.GetOneAcmeAccountById(opts.AcmeAccountID)
+	if err != nil || acct.Status != "valid" || !acct.AcceptedTos {
+		return nil, nil, errors.New("acme account id does not exist or is not usable")
+	}
+
+	// template (optional)
+	var tmpl *Template
+	if opts.TemplateID != nil {
+		t, err := store.GetOneCertTemplateById(*opts.TemplateID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil, ErrTemplateIdBad
+			}
+			return nil, nil, err
+		}
+		tmpl = &t
+	}
+
+	results := []ImportResult{}
+	importedCerts := []Certificate{}
+	for i := range bundles {
+		result := ImportResult{
+			Source: bundles[i].Source,
+			Name:   importName(bundles[i].Name),
+		}
+
+		cert, orderId, err := importBundle(store, bundles[i], result.Name, opts.AcmeAccountID, tmpl)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.CertificateID = &cert.ID
+			result.OrderID = &orderId
+			importedCerts = append(importedCerts, cert)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, importedCerts, nil
+}
+
+// importBundle validates a single bundle and saves it to storage
+func importBundle(store ImportStorage, bundle ImportBundle, name string, acmeAccountID int, tmpl *Template) (Certificate, int, error) {
+	// name must be valid and not in use by a cert or a key
+	if !validation.NameValid(name) {
+		return Certificate{}, -1, ErrNameBad
+	}
+	_, err := store.GetOneCertByName(name)
+	if err == nil {
+		return Certificate{}, -1, fmt.Errorf("certificate name '%s' is already in use", name)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return Certificate{}, -1, err
+	}
+	_, err = store.GetOneKeyByName(name)
+	if err == nil {
+		return Certificate{}, -1, fmt.Errorf("private key name '%s' is already in use", name)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return Certificate{}, -1, err
+	}
+
+	payload, err := bundle.importPayload(name, acmeAccountID, tmpl)
+	if err != nil {
+		return Certificate{}, -1, err
+	}
+
+	return store.PostImportedCert(payload)
+}
+
+// importPayload parses and validates the bundle's certificate chain and key and returns
+// the payload to save it
+func (bundle ImportBundle) importPayload(name string, acmeAccountID int, tmpl *Template) (ImportPayload, error) {
+	// key
+	keyPem, keyAlg, err := key_crypto.ValidateAndStandardizeKeyPem(bundle.KeyPem)
+	if err != nil {
+		return ImportPayload{}, fmt.Errorf("private key is not valid (%s)", err)
+	}
+	privKey, err := key_crypto.PemStringToKey(keyPem, keyAlg)
+	if err != nil {
+		return ImportPayload{}, fmt.Errorf("private key is not valid (%s)", err)
+	}
+	signer, ok := privKey.(crypto.Signer)
+	if !ok {
+		return ImportPayload{}, errors.New("private key is not valid (not a signer)")
+	}
+
+	// cert chain
+	chain, err := parseImportChain(bundle.CertChainPem, signer.Public())
+	if err != nil {
+		return ImportPayload{}, err
+	}
+	leaf := chain[0]
+
+	if time.Now().After(leaf.NotAfter) {
+		return ImportPayload{}, fmt.Errorf("certificate expired at %s", leaf.NotAfter)
+	}
+
+	// identifiers
+	ipIds := []string{}
+	for _, ip := range leaf.IPAddresses {
+		ipIds = append(ipIds, ip.String())
+	}
+	identifiers := append(slices.Clone(leaf.DNSNames), ipIds...)
+	if len(identifiers) == 0 {
+		return ImportPayload{}, errors.New("certificate does not contain any dns names or ip addresses")
+	}
+	if !subjectAltsValid(identifiers) {
+		return ImportPayload{}, ErrDomainBad
+	}
+
+	// subject is the common name (if it is one of the identifiers), else the first identifier
+	subject := identifiers[0]
+	if slices.Contains(identifiers, leaf.Subject.CommonName) {
+		subject = leaf.Subject.CommonName
+	}
+	subjectAlts := slices.DeleteFunc(slices.Clone(identifiers), func(id string) bool { return id == subject })
+
+	// cert payload
+	now := int(time.Now().Unix())
+	cert := NewPayload{
+		Name:            &name,
+		Description:     new(fmt.Sprintf("imported from %s", bundle.Source)),
+		PrivateKeyID:    new(-1),
+		AcmeAccountID:   &acmeAccountID,
+		Subject:         &subject,
+		SubjectAltNames: subjectAlts,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if tmpl != nil {
+		cert.applyTemplate(*tmpl)
+	}
+	cert.setImportDefaults()
+
+	cert.KeyRotation, err = keyRotationPayloadApply(KeyRotation{Policy: KeyRotationReuse, GraceDays: defaultKeyRotationGraceDays},
+		cert.KeyRotationPolicy, cert.KeyRotationIntervalDays, cert.KeyRotationAlgorithmValue, cert.KeyRotationGraceDays)
+	if err != nil {
+		return ImportPayload{}, err
+	}
+	cert.DeployVerification = DeployVerification{WindowMinutes: defaultDeployVerifyWindowMinutes}
+
+	cert.ApiKey, err = randomness.GenerateApiKey()
+	if err != nil {
+		return ImportPayload{}, err
+	}
+	if *cert.PostProcessingClientAddress != "" {
+		cert.PostProcessingClientKeyB64, err = randomness.GenerateAES256KeyAsBase64RawUrl()
+		if err != nil {
+			return ImportPayload{}, fmt.Errorf("failed to generate client key for certificate (%s)", err)
+		}
+	}
+
+	keyApiKey, err := randomness.GenerateApiKey()
+	if err != nil {
+		return ImportPayload{}, err
+	}
+
+	// re-encode chain (drops any text outside of the pem blocks)
+	chainPem := []byte{}
+	for i := range chain {
+		chainPem = append(chainPem, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: chain[i].Raw})...)
+	}
+
+	leafHash := sha256.Sum256(leaf.Raw)
+
+	return ImportPayload{
+		Cert:          cert,
+		KeyAlgorithm:  keyAlg,
+		KeyPem:        keyPem,
+		KeyApiKey:     keyApiKey,
+		OrderLocation: importOrderLocationPrefix + hex.EncodeToString(leafHash[:]),
+		DnsIds:        leaf.DNSNames,
+		IpIds:         ipIds,
+		ChainPem:      string(chainPem),
+		ValidFrom:     int(leaf.NotBefore.Unix()),
+		ValidTo:       int(leaf.NotAfter.Unix()),
+		ChainRootCN:   chain[len(chain)-1].Issuer.CommonName,
+	}, nil
+}
+
+// parseImportChain parses the certificates in chainPem and returns them with the leaf
+// (the certificate for publicKey) first
+func parseImportChain(chainPem string, publicKey crypto.PublicKey) ([]*x509.Certificate, error) {
+	chain := []*x509.Certificate{}
+	leafIndex := -1
+
+	rest := []byte(chainPem)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate (%s)", err)
+		}
+
+		pub, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+		if leafIndex == -1 && ok && pub.Equal(publicKey) {
+			leafIndex = len(chain)
+		}
+
+		chain = append(chain, cert)
+	}
+
+	if len(chain) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	if leafIndex == -1 {
+		return nil, errors.New("no certificate matches the private key")
+	}
+
+	// move leaf to the front
+	if leafIndex != 0 {
+		leaf := chain[leafIndex]
+		chain = append([]*x509.Certificate{leaf}, slices.Delete(chain, leafIndex, leafIndex+1)...)
+	}
+
+	return chain, nil
+}
+
+// setImportDefaults sets any unspecified optional fields to blank
+func (payload *NewPayload) setImportDefaults() {
+	for _, field := range []**string{&payload.Organization, &payload.OrganizationalUnit, &payload.Country,
+		&payload.State, &payload.City, &payload.PreferredRootCN, &payload.Profile, &payload.PostProcessingCommand,
+		&payload.PostProcessingClientAddress} {
+		if *field == nil {
+			*field = new(string)
+		}
+	}
+	if payload.PostProcessingEnvironment == nil {
+		payload.PostProcessingEnvironment = []string{}
+	}
+}
+
+// importName converts the name of an import source into a valid certificate name by
+// replacing any unsupported characters (e.g. the * of a wildcard) with an underscore
+func importName(sourceName string) string {
+	return strings.Map(func(r rune) rune {
+		if validation.NameValid(string(r)) {
+			return r
+		}
+		return '_'
+	}, sourceName)
+}
@@ -0,0 +1,219 @@
+package certificates
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ReadImportPath reads the import bundles from path, which may be any of:
+//   - a certbot config dir (e.g. /etc/letsencrypt) or its live dir, or a single cert's
+//     live dir (containing fullchain.pem and privkey.pem)
+//   - an acme.sh home dir, or a single cert's dir (containing fullchain.cer and <domain>.key)
+//   - a pem file containing the certificate chain and private key
+//   - a pfx (pkcs12) file, decrypted with pfxPassword
+//
+// Directories are searched recursively, skipping certbot's archive dir (the live dir links
+// to the current files in it). A subdirectory or cert dir that can't be read doesn't stop
+// the search, it is returned in failures instead. An error is returned if path can't be
+// read or nothing was found in it.
+//
+// path is read from the local filesystem, so this is only for command line use (never
+// pass a path from an API client).
+func ReadImportPath(path string, pfxPassword string) (bundles []ImportBundle, failures []ImportResult, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// single file
+	if !info.IsDir() {
+		bundle, err := readImportFile(path, pfxPassword)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []ImportBundle{bundle}, nil, nil
+	}
+
+	// directory
+	bundles = []ImportBundle{}
+	failures = []ImportResult{}
+	err = filepath.WalkDir(path, func(dirPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// root can't be read
+			if dirPath == path {
+				return err
+			}
+
+			// record and skip anything else that can't be read
+			failures = append(failures, ImportResult{Source: dirPath, Name: filepath.Base(dirPath), Error: err.Error()})
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == "archive" && dirPath != path {
+			return fs.SkipDir
+		}
+
+		bundle, found, err := readImportDir(dirPath)
+		if err != nil {
+			failures = append(failures, ImportResult{Source: dirPath, Name: filepath.Base(dirPath), Error: err.Error()})
+			return fs.SkipDir
+		}
+		if found {
+			bundles = append(bundles, bundle)
+			return fs.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(bundles) == 0 && len(failures) == 0 {
+		return nil, nil, fmt.Errorf("no certbot or acme.sh certificates found in %s", path)
+	}
+
+	return bundles, failures, nil
+}
+
+// readImportDir returns the bundle for dirPath if it is a certbot live cert dir or an
+// acme.sh cert dir (found is false if it is neither)
+func readImportDir(dirPath string) (bundle ImportBundle, found bool, err error) {
+	dirName := filepath.Base(dirPath)
+
+	// certbot
+	chainFile := filepath.Join(dirPath, "fullchain.pem")
+	keyFile := filepath.Join(dirPath, "privkey.pem")
+	if fileExists(chainFile) && fileExists(keyFile) {
+		bundle, err = readImportFiles("certbot "+dirPath, dirName, chainFile, keyFile)
+		return bundle, true, err
+	}
+
+	// acme.sh (ecc cert dirs have an _ecc suffix that is not part of the file names)
+	domain := strings.TrimSuffix(dirName, "_ecc")
+	chainFile = filepath.Join(dirPath, "fullchain.cer")
+	keyFile = filepath.Join(dirPath, domain+".key")
+	if fileExists(chainFile) && fileExists(keyFile) {
+		bundle, err = readImportFiles("acme.sh "+dirPath, dirName, chainFile, keyFile)
+		return bundle, true, err
+	}
+
+	return ImportBundle{}, false, nil
+}
+
+// readImportFiles reads a cert chain file and key file into a bundle
+func readImportFiles(source, name, chainFile, keyFile string) (ImportBundle, error) {
+	chainPem, err := os.ReadFile(chainFile)
+	if err != nil {
+		return ImportBundle{}, err
+	}
+	keyPem, err := os.ReadFile(keyFile)
+	if err != nil {
+		return ImportBundle{}, err
+	}
+
+	return ImportBundle{
+		Source:       source,
+		Name:         name,
+		CertChainPem: string(chainPem),
+		KeyPem:       string(keyPem),
+	}, nil
+}
+
+// readImportFile reads a single pem or pfx file into a bundle named for the file
+func readImportFile(filePath string, pfxPassword string) (ImportBundle, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return ImportBundle{}, err
+	}
+
+	ext := filepath.Ext(filePath)
+	name := strings.TrimSuffix(filepath.Base(filePath), ext)
+
+	switch strings.ToLower(ext) {
+	case ".pfx", ".p12":
+		return PfxImportBundle(filePath, name, data, pfxPassword)
+	default:
+		return PemImportBundle(filePath, name, data)
+	}
+}
+
+// PemImportBundle splits pem data containing a certificate chain and exactly one private
+// key into a bundle
+func PemImportBundle(source, name string, data []byte) (ImportBundle, error) {
+	bundle := ImportBundle{
+		Source: source,
+		Name:   name,
+	}
+
+	keyCount := 0
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		blockPem := string(pem.EncodeToMemory(block))
+		if block.Type == "CERTIFICATE" {
+			bundle.CertChainPem += blockPem
+		} else if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			bundle.KeyPem = blockPem
+			keyCount++
+		}
+	}
+
+	if bundle.CertChainPem == "" {
+		return ImportBundle{}, errors.New("pem does not contain a certificate")
+	}
+	if keyCount != 1 {
+		return ImportBundle{}, errors.New("pem must contain exactly one private key")
+	}
+
+	return bundle, nil
+}
+
+// PfxImportBundle decodes a pfx (pkcs12) file into a bundle
+func PfxImportBundle(source, name string, data []byte, password string) (ImportBundle, error) {
+	privKey, leaf, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return ImportBundle{}, fmt.Errorf("failed to decode pfx (%s)", err)
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(privKey)
+	if err != nil {
+		return ImportBundle{}, fmt.Errorf("failed to encode pfx private key (%s)", err)
+	}
+
+	chainPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw})
+	for i := range caCerts {
+		chainPem = append(chainPem, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCerts[i].Raw})...)
+	}
+
+	return ImportBundle{
+		Source:       source,
+		Name:         name,
+		CertChainPem: string(chainPem),
+		KeyPem:       string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})),
+	}, nil
+}
+
+// fileExists returns true if path exists and is not a directory (symlinks are followed,
+// as certbot's live dir links to its archive dir)
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
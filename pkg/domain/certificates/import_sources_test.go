@@ -0,0 +1,204 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// makeTestCert returns a new self-signed certificate and its key for name
+func makeTestCert(t *testing.T, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, privKey.Public(), privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, privKey
+}
+
+// writeTestCertFiles writes a new cert (chain) and key for name to the specified files
+func writeTestCertFiles(t *testing.T, name, chainFile, keyFile string) {
+	cert, privKey := makeTestCert(t, name)
+	keyDer, err := x509.MarshalECPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(chainFile), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(chainFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadImportPath(t *testing.T) {
+	dir := t.TempDir()
+
+	// certbot (live links to archive)
+	certbotDir := filepath.Join(dir, "letsencrypt")
+	archiveDir := filepath.Join(certbotDir, "archive", "a.example.com")
+	writeTestCertFiles(t, "a.example.com", filepath.Join(archiveDir, "fullchain1.pem"), filepath.Join(archiveDir, "privkey1.pem"))
+	liveDir := filepath.Join(certbotDir, "live", "a.example.com")
+	err := os.MkdirAll(liveDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"fullchain", "privkey"} {
+		err = os.Symlink(filepath.Join(archiveDir, f+"1.pem"), filepath.Join(liveDir, f+".pem"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bundles, _, err := ReadImportPath(certbotDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) != 1 || bundles[0].Name != "a.example.com" {
+		t.Fatalf("expected 1 certbot bundle named 'a.example.com' but got %+v", bundles)
+	}
+	_, err = bundles[0].importPayload(bundles[0].Name, 1, nil)
+	if err != nil {
+		t.Errorf("expected certbot bundle to be valid but got '%s'", err)
+	}
+
+	// acme.sh (rsa and ecc dirs, and a non-cert dir)
+	acmeShDir := filepath.Join(dir, "acme.sh")
+	writeTestCertFiles(t, "b.example.com", filepath.Join(acmeShDir, "b.example.com", "fullchain.cer"), filepath.Join(acmeShDir, "b.example.com", "b.example.com.key"))
+	writeTestCertFiles(t, "b.example.com", filepath.Join(acmeShDir, "b.example.com_ecc", "fullchain.cer"), filepath.Join(acmeShDir, "b.example.com_ecc", "b.example.com.key"))
+	err = os.MkdirAll(filepath.Join(acmeShDir, "ca", "acme-v02.api.letsencrypt.org"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundles, _, err = ReadImportPath(acmeShDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, b := range bundles {
+		names = append(names, b.Name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"b.example.com", "b.example.com_ecc"}) {
+		t.Errorf("expected acme.sh bundles for b.example.com and b.example.com_ecc but got %v", names)
+	}
+
+	// pfx
+	cert, privKey := makeTestCert(t, "c.example.com")
+	pfx, err := pkcs12.Modern.Encode(privKey, cert, nil, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pfxFile := filepath.Join(dir, "c.example.com.pfx")
+	err = os.WriteFile(pfxFile, pfx, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = ReadImportPath(pfxFile, "wrong")
+	if err == nil {
+		t.Error("expected pfx with wrong password to fail")
+	}
+	bundles, _, err = ReadImportPath(pfxFile, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) != 1 || bundles[0].Name != "c.example.com" {
+		t.Fatalf("expected 1 pfx bundle named 'c.example.com' but got %+v", bundles)
+	}
+	payload, err := bundles[0].importPayload(bundles[0].Name, 1, nil)
+	if err != nil {
+		t.Fatalf("expected pfx bundle to be valid but got '%s'", err)
+	}
+	if *payload.Cert.Subject != "c.example.com" || !slices.Equal(payload.DnsIds, []string{"c.example.com"}) {
+		t.Errorf("unexpected pfx import payload %+v", payload)
+	}
+
+	// empty dir
+	_, _, err = ReadImportPath(t.TempDir(), "")
+	if err == nil {
+		t.Error("expected dir with no certificates to fail")
+	}
+}
+
+func TestReadImportPathUnreadableDir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	dir := t.TempDir()
+	writeTestCertFiles(t, "a.example.com", filepath.Join(dir, "a.example.com", "fullchain.cer"), filepath.Join(dir, "a.example.com", "a.example.com.key"))
+	unreadableDir := filepath.Join(dir, "unreadable")
+	err := os.Mkdir(unreadableDir, 0o000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(unreadableDir, 0o755) })
+
+	// the unreadable dir is a failure, the rest is still read
+	bundles, failures, err := ReadImportPath(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) != 1 || bundles[0].Name != "a.example.com" {
+		t.Errorf("expected 1 bundle named 'a.example.com' but got %+v", bundles)
+	}
+	if len(failures) != 1 || failures[0].Source != unreadableDir || failures[0].Error == "" {
+		t.Errorf("expected 1 failure for %s but got %+v", unreadableDir, failures)
+	}
+}
+
+func TestImportPayloadKeyMismatch(t *testing.T) {
+	cert, _ := makeTestCert(t, "d.example.com")
+	_, otherKey := makeTestCert(t, "e.example.com")
+	keyDer, err := x509.MarshalECPrivateKey(otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := ImportBundle{
+		Source:       "test",
+		Name:         "d.example.com",
+		CertChainPem: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+		KeyPem:       string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})),
+	}
+	_, err = bundle.importPayload(bundle.Name, 1, nil)
+	if err == nil {
+		t.Error("expected bundle with mismatched key to fail")
+	}
+}
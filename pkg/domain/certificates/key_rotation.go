@@ -0,0 +1,112 @@
+package certificates
+
+import (
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"time"
+)
+
+// KeyRotationPolicy controls if and when a certificate's private key is replaced
+// when the certificate is automatically renewed
+type KeyRotationPolicy string
+
+const (
+	// reuse the same key for every renewal (default)
+	KeyRotationReuse KeyRotationPolicy = "reuse"
+	// generate a new key for every renewal
+	KeyRotationEveryRenewal KeyRotationPolicy = "every_renewal"
+	// generate a new key on renewal once the key is older than the interval
+	KeyRotationInterval KeyRotationPolicy = "interval"
+)
+
+// valid returns true if the policy is one of the defined policies
+func (policy KeyRotationPolicy) valid() bool {
+	switch policy {
+	case KeyRotationReuse, KeyRotationEveryRenewal, KeyRotationInterval:
+		return true
+	}
+
+	return false
+}
+
+// KeyRotation is a certificate's private key rotation configuration. When a key is
+// rotated, the old key is retired (deleted) once GraceDays has passed so clients can
+// continue to download it until they have picked up the new certificate.
+type KeyRotation struct {
+	Policy       KeyRotationPolicy    `json:"policy"`
+	IntervalDays int                  `json:"interval_days"`
+	Algorithm    key_crypto.Algorithm `json:"algorithm"` // UnknownAlgorithm = same as the current key
+	GraceDays    int                  `json:"grace_days"`
+}
+
+// defaultKeyRotationGraceDays is the grace period used if one is not specified
+const defaultKeyRotationGraceDays = 7
+
+// validate confirms the KeyRotation is usable
+func (kr KeyRotation) validate() error {
+	if !kr.Policy.valid() {
+		return ErrKeyRotationPolicyBad
+	}
+	if kr.Policy == KeyRotationInterval && (kr.IntervalDays < 1 || kr.IntervalDays > 3650) {
+		return ErrKeyRotationIntervalBad
+	}
+	if kr.GraceDays < 0 || kr.GraceDays > 365 {
+		return ErrKeyRotationGraceBad
+	}
+
+	return nil
+}
+
+// keyRotationPayloadApply applies the optional key rotation payload fields on top of
+// the specified existing KeyRotation and then validates the result
+func keyRotationPayloadApply(kr KeyRotation, policy *string, intervalDays *int, algorithmValue *string, graceDays *int) (KeyRotation, error) {
+	if policy != nil {
+		kr.Policy = KeyRotationPolicy(*policy)
+	}
+	if intervalDays != nil {
+		kr.IntervalDays = *intervalDays
+	}
+	if algorithmValue != nil {
+		kr.Algorithm = key_crypto.UnknownAlgorithm
+		if *algorithmValue != "" {
+			kr.Algorithm = key_crypto.AlgorithmByStorageValue(*algorithmValue)
+			if kr.Algorithm == key_crypto.UnknownAlgorithm {
+				return KeyRotation{}, ErrKeyRotationAlgorithmBad
+			}
+		}
+	}
+	if graceDays != nil {
+		kr.GraceDays = *graceDays
+	}
+
+	return kr, kr.validate()
+}
+
+// KeyRotationDue returns true if the certificate's key should be replaced before placing
+// a renewal order. finalizedKeyID is the key used to finalize the cert's newest valid order;
+// if the cert's key has changed since then (e.g. it was changed manually and the renewal
+// hasn't completed yet) the key is not rotated.
+func (cert Certificate) KeyRotationDue(finalizedKeyID int, now time.Time) bool {
+	if cert.CertificateKey.ID != finalizedKeyID {
+		return false
+	}
+
+	switch cert.KeyRotation.Policy {
+	case KeyRotationEveryRenewal:
+		return true
+
+	case KeyRotationInterval:
+		return !now.Before(cert.CertificateKey.CreatedAt.AddDate(0, 0, cert.KeyRotation.IntervalDays))
+
+	default:
+		return false
+	}
+}
+
+// KeyRotationAlgorithm returns the Algorithm to use when generating the cert's next key
+func (cert Certificate) KeyRotationAlgorithm() key_crypto.Algorithm {
+	if cert.KeyRotation.Algorithm != key_crypto.UnknownAlgorithm {
+		return cert.KeyRotation.Algorithm
+	}
+
+	return cert.CertificateKey.Algorithm
+}
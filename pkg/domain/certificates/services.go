@@ -39,6 +39,21 @@ type Storage interface {
 	DeleteCert(id int) (err error)
 
 	PostNewKey(private_keys.NewPayload) (private_keys.Key, error)
+
+	// templates
+	GetAllCertTemplates(q pagination_sort.Query) (templates []Template, totalRowCount int, err error)
+	GetOneCertTemplateById(id int) (tmpl Template, err error)
+	GetOneCertTemplateByName(name string) (tmpl Template, err error)
+	GetCertIdsByTemplate(templateId int) (certIds []int, err error)
+
+	PostNewCertTemplate(payload NewTemplatePayload) (Template, error)
+	PutCertTemplate(payload TemplateUpdatePayload, certUpdates []DetailsUpdatePayload) (Template, error)
+	DeleteCertTemplate(id int) (err error)
+
+	// import
+	GetOneAcmeAccountById(id int) (acme_accounts.Account, error)
+	GetOneKeyByName(name string) (private_keys.Key, error)
+	PostImportedCert(payload ImportPayload) (cert Certificate, orderId int, err error)
 }
 
 // Keys service struct
@@ -0,0 +1,163 @@
+package certificates
+
+import (
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"time"
+)
+
+// Template is a set of certificate settings that new certificates can be created
+// from. Certificates created from a template remain linked to it so that changes
+// to the template can be propagated to them.
+type Template struct {
+	ID                          int
+	Name                        string
+	Description                 string
+	AcmeAccountID               *int
+	KeyAlgorithm                key_crypto.Algorithm // for new keys, UnknownAlgorithm = none
+	Organization                string
+	OrganizationalUnit          string
+	Country                     string
+	State                       string
+	City                        string
+	CSRExtraExtensions          []CertExtension
+	PreferredRootCN             string
+	Profile                     string
+	PostProcessingCommand       string
+	PostProcessingEnvironment   []string
+	PostProcessingClientAddress string
+	KeyRotation                 KeyRotation
+	CreatedAt                   time.Time
+	UpdatedAt                   time.Time
+}
+
+// templateSummaryResponse is a JSON response containing only
+// fields desired for the summary
+type templateSummaryResponse struct {
+	ID            int                  `json:"id"`
+	Name          string               `json:"name"`
+	Description   string               `json:"description"`
+	AcmeAccountID *int                 `json:"acme_account_id"`
+	KeyAlgorithm  key_crypto.Algorithm `json:"algorithm"`
+}
+
+func (tmpl Template) summaryResponse() templateSummaryResponse {
+	return templateSummaryResponse{
+		ID:            tmpl.ID,
+		Name:          tmpl.Name,
+		Description:   tmpl.Description,
+		AcmeAccountID: tmpl.AcmeAccountID,
+		KeyAlgorithm:  tmpl.KeyAlgorithm,
+	}
+}
+
+// templateDetailedResponse is a JSON response containing all
+// fields that can be returned as JSON
+type templateDetailedResponse struct {
+	templateSummaryResponse
+	Organization                string              `json:"organization"`
+	OrganizationalUnit          string              `json:"organizational_unit"`
+	Country                     string              `json:"country"`
+	State                       string              `json:"state"`
+	City                        string              `json:"city"`
+	CSRExtraExtensions          []CertExtensionJSON `json:"csr_extra_extensions"`
+	PreferredRootCN             string              `json:"preferred_root_cn"`
+	Profile                     string              `json:"profile"`
+	PostProcessingCommand       string              `json:"post_processing_command"`
+	PostProcessingEnvironment   []string            `json:"post_processing_environment"`
+	PostProcessingClientAddress string              `json:"post_processing_client_address"`
+	KeyRotation                 KeyRotation         `json:"key_rotation"`
+	CreatedAt                   int64               `json:"created_at"`
+	UpdatedAt                   int64               `json:"updated_at"`
+}
+
+func (tmpl Template) detailedResponse() templateDetailedResponse {
+	return templateDetailedResponse{
+		templateSummaryResponse:     tmpl.summaryResponse(),
+		Organization:                tmpl.Organization,
+		OrganizationalUnit:          tmpl.OrganizationalUnit,
+		Country:                     tmpl.Country,
+		State:                       tmpl.State,
+		City:                        tmpl.City,
+		CSRExtraExtensions:          tmpl.csrExtraExtensionsJSON(),
+		PreferredRootCN:             tmpl.PreferredRootCN,
+		Profile:                     tmpl.Profile,
+		PostProcessingCommand:       tmpl.PostProcessingCommand,
+		PostProcessingEnvironment:   tmpl.PostProcessingEnvironment,
+		PostProcessingClientAddress: tmpl.PostProcessingClientAddress,
+		KeyRotation:                 tmpl.KeyRotation,
+		CreatedAt:                   tmpl.CreatedAt.Unix(),
+		UpdatedAt:                   tmpl.UpdatedAt.Unix(),
+	}
+}
+
+// csrExtraExtensionsJSON returns the template's extra extensions as json objects
+func (tmpl Template) csrExtraExtensionsJSON() []CertExtensionJSON {
+	extraExtensions := []CertExtensionJSON{}
+	for i := range tmpl.CSRExtraExtensions {
+		extraExtensions = append(extraExtensions, tmpl.CSRExtraExtensions[i].toJSONObj())
+	}
+
+	return extraExtensions
+}
+
+// applyTemplate sets any fields of the new cert payload that were not specified
+// to the template's values
+func (payload *NewPayload) applyTemplate(tmpl Template) {
+	payload.TemplateID = &tmpl.ID
+
+	if payload.AcmeAccountID == nil && tmpl.AcmeAccountID != nil {
+		payload.AcmeAccountID = new(*tmpl.AcmeAccountID)
+	}
+	// new key, if the template has an algorithm and no key was specified
+	if payload.PrivateKeyID == nil && tmpl.KeyAlgorithm != key_crypto.UnknownAlgorithm {
+		payload.PrivateKeyID = new(-1)
+		if payload.NewKeyAlgorithmValue == nil || *payload.NewKeyAlgorithmValue == "" {
+			payload.NewKeyAlgorithmValue = new(tmpl.KeyAlgorithm.StorageValue())
+		}
+	}
+	if payload.Organization == nil {
+		payload.Organization = new(tmpl.Organization)
+	}
+	if payload.OrganizationalUnit == nil {
+		payload.OrganizationalUnit = new(tmpl.OrganizationalUnit)
+	}
+	if payload.Country == nil {
+		payload.Country = new(tmpl.Country)
+	}
+	if payload.State == nil {
+		payload.State = new(tmpl.State)
+	}
+	if payload.City == nil {
+		payload.City = new(tmpl.City)
+	}
+	if payload.CSRExtraExtensions == nil {
+		payload.CSRExtraExtensions = tmpl.csrExtraExtensionsJSON()
+	}
+	if payload.PreferredRootCN == nil {
+		payload.PreferredRootCN = new(tmpl.PreferredRootCN)
+	}
+	if payload.Profile == nil {
+		payload.Profile = new(tmpl.Profile)
+	}
+	if payload.PostProcessingCommand == nil {
+		payload.PostProcessingCommand = new(tmpl.PostProcessingCommand)
+	}
+	if payload.PostProcessingEnvironment == nil {
+		payload.PostProcessingEnvironment = tmpl.PostProcessingEnvironment
+	}
+	if payload.PostProcessingClientAddress == nil {
+		payload.PostProcessingClientAddress = new(tmpl.PostProcessingClientAddress)
+	}
+	if payload.KeyRotationPolicy == nil {
+		payload.KeyRotationPolicy = new(string(tmpl.KeyRotation.Policy))
+	}
+	if payload.KeyRotationIntervalDays == nil {
+		payload.KeyRotationIntervalDays = new(tmpl.KeyRotation.IntervalDays)
+	}
+	if payload.KeyRotationAlgorithmValue == nil {
+		payload.KeyRotationAlgorithmValue = new(tmpl.KeyRotation.Algorithm.StorageValue())
+	}
+	if payload.KeyRotationGraceDays == nil {
+		payload.KeyRotationGraceDays = new(tmpl.KeyRotation.GraceDays)
+	}
+}
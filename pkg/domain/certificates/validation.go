@@ -28,6 +28,23 @@ var (
 	// domain
 	ErrDomainBad        = errors.New("domain or subject name not valid")
 	ErrClientAddressBad = errors.New("client address is not valid")
+
+	// key rotation
+	ErrKeyRotationPolicyBad    = errors.New("key rotation policy is not valid")
+	ErrKeyRotationIntervalBad  = errors.New("key rotation interval days must be between 1 and 3650 when the interval policy is used")
+	ErrKeyRotationAlgorithmBad = errors.New("key rotation algorithm is not valid")
+	ErrKeyRotationGraceBad     = errors.New("key rotation grace days must be between 0 and 365")
+
+	// deployment verification
+	ErrDeployVerifyWindowBad = errors.New("deployment verification window minutes must be between 1 and 60")
+
+	// template
+	ErrTemplateIdBad   = errors.New("certificate template id is invalid")
+	ErrTemplateNameBad = errors.New("certificate template name is not valid")
+	ErrTemplateAlgBad  = errors.New("certificate template key algorithm is not valid")
+
+	// import
+	ErrImportExists = errors.New("certificate has already been imported")
 )
 
 // GetCertificate returns the Certificate for the specified id.
@@ -107,10 +124,10 @@ func (service *Service) privateKeyIdValid(keyId int, certId *int) bool {
 	return false
 }
 
-// subjectValid validates domain name
+// subjectValid validates domain name or ip address (RFC 8738)
 func subjectValid(domain string) bool {
-	// check domain is valid
-	return validation.DomainValid(domain, true)
+	// check domain or ip is valid
+	return validation.DomainValid(domain, true) || validation.IPAddressValid(domain)
 }
 
 // subjectAltsValid validates each domain contained in the slice
@@ -124,3 +141,53 @@ func subjectAltsValid(alts []string) bool {
 
 	return true
 }
+
+// getTemplate returns the Template for the specified id.
+func (service *Service) getTemplate(id int) (Template, *output.JsonError) {
+	// if id is not in valid range, it is definitely not valid
+	if !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(ErrTemplateIdBad)
+		return Template{}, output.JsonErrValidationFailed(ErrTemplateIdBad)
+	}
+
+	// get from storage
+	tmpl, err := service.storage.GetOneCertTemplateById(id)
+	if err != nil {
+		// special error case for no record found
+		if errors.Is(err, sql.ErrNoRows) {
+			service.logger.Debug(err)
+			return Template{}, output.JsonErrNotFound(fmt.Errorf("certificate template id %d not found", id))
+		} else {
+			service.logger.Error(err)
+			return Template{}, output.JsonErrStorageGeneric(err)
+		}
+	}
+
+	return tmpl, nil
+}
+
+// templateNameValid returns if a template name is valid (meets char requirements
+// and is not in use in storage OR is in use by the specified templateId)
+func (service *Service) templateNameValid(templateName string, templateId *int) bool {
+	// basic check
+	if !validation.NameValid(templateName) {
+		return false
+	}
+
+	// make sure the name isn't already in use in storage
+	tmpl, err := service.storage.GetOneCertTemplateByName(templateName)
+	if errors.Is(err, sql.ErrNoRows) {
+		// no rows means name is not in use
+		return true
+	} else if err != nil {
+		// any other error, invalid
+		return false
+	}
+
+	// if the returned template is the template being edited, no error
+	if templateId != nil && tmpl.ID == *templateId {
+		return true
+	}
+
+	return false
+}
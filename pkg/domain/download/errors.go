@@ -13,4 +13,6 @@ var (
 	errFinalizedKeyMissing = errors.New("cert has a valid order but the finalized key is missing")
 
 	errNoPem = errors.New("pem is blank")
+
+	errKeyNotExportable = errors.New("private key resides on a hardware token and is not exportable")
 )
@@ -53,6 +53,12 @@ func (service *Service) getKey(keyName string, apiKey string, apiKeyViaUrl bool)
 		return private_keys.Key{}, output.JsonErrUnauthorized
 	}
 
+	// keys on hardware tokens can't be downloaded
+	if !key.Exportable() {
+		service.logger.Debug(errKeyNotExportable)
+		return private_keys.Key{}, output.JsonErrForbidden
+	}
+
 	// before return, update key last access, dont fail our though if this step fails, just log error
 	err = service.storage.PutKeyLastAccess(key.ID, time.Now().Unix())
 	if err != nil {
@@ -120,6 +120,12 @@ func (service *Service) getCertNewestValidOrder(certName string, apiKeyOrKeys st
 		return orders.Order{}, output.JsonErrUnauthorized
 	}
 
+	// keys on hardware tokens can't be downloaded
+	if !order.FinalizedKey.Exportable() {
+		service.logger.Debug(errKeyNotExportable)
+		return orders.Order{}, output.JsonErrForbidden
+	}
+
 	// before return, update cert AND KEY last access, dont fail our though if this step fails, just log error
 	nowT := time.Now()
 	err = service.storage.PutCertLastAccess(order.Certificate.ID, nowT.Unix())
@@ -55,7 +55,7 @@ func keyPemToKey(keyPem []byte) (key any, err error) {
 		}
 		return ecdKey, nil
 
-	case "PRIVATE KEY": // PKCS8
+	case "PRIVATE KEY": // PKCS8 (RSA, ECDSA, or Ed25519)
 		pkcs8Key, err := x509.ParsePKCS8PrivateKey(keyPemBlock.Bytes)
 		if err != nil {
 			return nil, err
@@ -5,12 +5,15 @@ import (
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/test_helpers"
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/julienschmidt/httprouter"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 // TODO: actually check pfx creation?
@@ -332,3 +335,51 @@ func TestOutPFXViaURL(t *testing.T) {
 	// Test: correct apikey but api is disabled
 	onePfxTest(t, service.DownloadKeyViaUrl, nil, &apiKey, "test-f", output.JsonErrUnauthorized)
 }
+
+func TestOutPFXKeyAlgorithms(t *testing.T) {
+	// create testing service
+	app := makeFakeApp(t)
+	service, err := download.NewService(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		certName  string
+		legacy    bool
+		keyTypeOk func(key any) bool
+	}{
+		{"test-h", false, func(key any) bool { _, ok := key.(ed25519.PrivateKey); return ok }},
+		{"test-i", false, func(key any) bool { k, ok := key.(*ecdsa.PrivateKey); return ok && k.Curve.Params().Name == "P-521" }},
+		{"test-i", true, func(key any) bool { k, ok := key.(*ecdsa.PrivateKey); return ok && k.Curve.Params().Name == "P-521" }},
+	}
+
+	for _, tc := range testCases {
+		url := "/certwarden/api/v1/download/pfx"
+		if tc.legacy {
+			url += "?3des"
+		}
+		r := httptest.NewRequest(http.MethodGet, url, nil)
+		r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "name", Value: tc.certName}}))
+		r.Header.Add("x-api-key", "c-abc.k-123")
+
+		w := httptest.NewRecorder()
+		jsonErr := service.DownloadPfxViaHeader(w, r)
+		if jsonErr != nil {
+			t.Errorf("%s: pfx download failed (%s)", tc.certName, jsonErr)
+			continue
+		}
+
+		key, cert, _, err := pkcs12.DecodeChain(w.Body.Bytes(), "k-123")
+		if err != nil {
+			t.Errorf("%s: failed to decode pfx (%s)", tc.certName, err)
+			continue
+		}
+		if !tc.keyTypeOk(key) {
+			t.Errorf("%s: pfx contains unexpected key type %T", tc.certName, key)
+		}
+		if cert == nil || cert.Subject.Organization[0] != "Leaf-Test-"+tc.certName[len("test-"):] {
+			t.Errorf("%s: pfx contains unexpected certificate", tc.certName)
+		}
+	}
+}
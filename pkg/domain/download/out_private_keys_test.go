@@ -275,3 +275,31 @@ N/VC8wvORy4lKBkAeRy0oK+9o6R5mdzP3Fjpz9I4
 	apiKey = "k-123"
 	oneTest(t, service.DownloadKeyViaUrl, nil, &apiKey, "test-f", "", output.JsonErrUnauthorized)
 }
+
+func TestOutKeyNotExportable(t *testing.T) {
+	// create testing service
+	app := makeFakeApp(t)
+	service, err := download.NewService(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Test: correct apikeys provided but key resides on a hardware token
+	apiKey := "k-123"
+	oneTest(t, service.DownloadKeyViaHeader, &apiKey, nil, "test-j", "", output.JsonErrForbidden)
+	apiKey = "c-abc.k-123"
+	oneTest(t, service.DownloadPrivateCertViaHeader, &apiKey, nil, "test-j", "", output.JsonErrForbidden)
+	oneTest(t, service.DownloadPrivateCertChainViaHeader, &apiKey, nil, "test-j", "", output.JsonErrForbidden)
+	oneTest(t, service.DownloadPfxViaHeader, &apiKey, nil, "test-j", "", output.JsonErrForbidden)
+
+	// Test: cert (without key) can still be downloaded
+	apiKey = "c-abc"
+	oneTest(t, service.DownloadCertViaHeader, &apiKey, nil, "test-j", `-----BEGIN CERTIFICATE-----
+MIHxMIGkoAMCAQICAWMwBQYDK2VwMBYxFDASBgNVBAoTC0xlYWYtVGVzdC1oMB4X
+DTI2MDUwMTAwMDAwMFoXDTM2MDUwMTAwMDAwMFowFjEUMBIGA1UEChMLTGVhZi1U
+ZXN0LWgwKjAFBgMrZXADIQAKkNh92saVeMfB23T6x17UPKEVxq0PDZSj5WJid2q5
+aqMXMBUwEwYDVR0lBAwwCgYIKwYBBQUHAwEwBQYDK2VwA0EAeRCZ+fARHgvQ2cDQ
+oOhoor/Qy6gAT4jfo0hL8AbSDdssQifMl8YHs9wLfXRIeui0RRVgfi5BiIR7cMIU
+GRQ6CA==
+-----END CERTIFICATE-----`, nil)
+}
@@ -0,0 +1,168 @@
+package monitored_endpoints
+
+import (
+	"bytes"
+	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/tlsprobe"
+	"context"
+	"crypto/x509"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// monitorRunInterval is how often endpoints are evaluated to see if a check is due
+	monitorRunInterval = 1 * time.Minute
+	// checkTimeout is the maximum time for a single endpoint check
+	checkTimeout = 30 * time.Second
+	// maxConcurrentChecks limits how many endpoints are checked at once
+	maxConcurrentChecks = 10
+)
+
+// startMonitoringService starts a go routine that checks each enabled endpoint once its
+// check interval has elapsed since its last check
+func (service *Service) startMonitoringService(ctx context.Context, wg *sync.WaitGroup) {
+	service.logger.Info("monitored endpoints: starting endpoint monitoring service")
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// close routine
+				service.logger.Info("monitored endpoints: endpoint monitoring service shutdown complete")
+				return
+
+			case <-time.After(monitorRunInterval):
+				// proceed to run
+			}
+
+			service.checkDueEndpoints(ctx)
+		}
+	}()
+}
+
+// checkDueEndpoints checks all endpoints that are due for a check
+func (service *Service) checkDueEndpoints(ctx context.Context) {
+	endpoints, _, err := service.storage.GetAllMonitoredEndpoints(pagination_sort.Query{})
+	if err != nil {
+		service.logger.Errorf("monitored endpoints: failed to get endpoints (%s)", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentChecks)
+	now := time.Now()
+
+	for i := range endpoints {
+		if !endpoints[i].checkDue(now) {
+			continue
+		}
+
+		// wait for a free slot (stop starting checks on shutdown)
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			_, err := service.checkAndSave(ctx, endpoints[i])
+			if err != nil {
+				service.logger.Errorf("monitored endpoints: failed to save check of endpoint %s (%s)", endpoints[i].Name, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// checkAndSave checks the endpoint and saves the result to storage
+func (service *Service) checkAndSave(ctx context.Context, ep Endpoint) (Check, error) {
+	check := service.check(ctx, ep)
+	if check.Error != "" {
+		service.logger.Warnf("monitored endpoints: check of endpoint %s (%s) failed (%s)", ep.Name, ep.target().Address(), check.Error)
+	} else if check.MatchesNewestOrder != nil && !*check.MatchesNewestOrder {
+		service.logger.Warnf("monitored endpoints: endpoint %s (%s) is not serving the newest order of certificate %s", ep.Name, ep.target().Address(), ep.Certificate.Name)
+	} else {
+		service.logger.Debugf("monitored endpoints: checked endpoint %s (%s)", ep.Name, ep.target().Address())
+	}
+
+	return check, service.storage.PutMonitoredEndpointCheck(ep.ID, check)
+}
+
+// check connects to the endpoint and returns the details of the certificate it serves
+func (service *Service) check(ctx context.Context, ep Endpoint) Check {
+	check := Check{
+		CheckedAt:    time.Now(),
+		LeafDNSNames: []string{},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	chain, err := tlsprobe.Probe(ctx, ep.target())
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	leaf := chain[0]
+
+	servedChain := []byte{}
+	for i := range chain {
+		servedChain = append(servedChain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: chain[i].Raw})...)
+	}
+
+	check.ServedChain = string(servedChain)
+	check.LeafSerial = leaf.SerialNumber.Text(16)
+	check.LeafSubject = leaf.Subject.String()
+	check.LeafIssuer = leaf.Issuer.String()
+	if leaf.DNSNames != nil {
+		check.LeafDNSNames = leaf.DNSNames
+	}
+	check.LeafNotBefore = &leaf.NotBefore
+	check.LeafNotAfter = &leaf.NotAfter
+
+	// compare to linked cert's newest order
+	if ep.Certificate != nil {
+		check.MatchesNewestOrder, err = service.matchesNewestOrder(ep.Certificate.ID, leaf)
+		if err != nil {
+			service.logger.Errorf("monitored endpoints: failed to compare endpoint %s to certificate %s (%s)", ep.Name, ep.Certificate.Name, err)
+		}
+	}
+
+	return check
+}
+
+// matchesNewestOrder returns if leaf is the certificate of the specified cert's newest
+// valid order (nil if the cert does not have a valid order)
+func (service *Service) matchesNewestOrder(certId int, leaf *x509.Certificate) (*bool, error) {
+	order, err := service.storage.GetCertNewestValidOrderById(certId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if order.Pem == nil {
+		return nil, nil
+	}
+
+	block, _ := pem.Decode([]byte(*order.Pem))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode pem of order %d", order.ID)
+	}
+
+	return new(bytes.Equal(block.Bytes, leaf.Raw)), nil
+}
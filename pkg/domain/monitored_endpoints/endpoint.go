@@ -0,0 +1,172 @@
+package monitored_endpoints
+
+import (
+	"certwarden-backend/pkg/tlsprobe"
+	"time"
+)
+
+// Endpoint is an external tls endpoint (that is not necessarily managed by Cert
+// Warden) whose served certificate is periodically checked
+type Endpoint struct {
+	ID                   int
+	Name                 string
+	Description          string
+	Host                 string
+	Port                 int
+	ServerName           string
+	StartTLS             tlsprobe.StartTLS
+	Certificate          *EndpointCertificate
+	CheckIntervalMinutes int
+	Enabled              bool
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	LastCheck            *Check
+}
+
+// EndpointCertificate is the Cert Warden certificate that an endpoint is expected
+// to serve
+type EndpointCertificate struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Check is the result of checking an endpoint. If the check failed, Error is set
+// and the other fields are blank.
+type Check struct {
+	CheckedAt          time.Time
+	Error              string
+	ServedChain        string
+	LeafSerial         string
+	LeafSubject        string
+	LeafIssuer         string
+	LeafDNSNames       []string
+	LeafNotBefore      *time.Time
+	LeafNotAfter       *time.Time
+	MatchesNewestOrder *bool // nil if there is no linked certificate or it has no valid order
+}
+
+// target returns the tlsprobe target for the endpoint
+func (ep Endpoint) target() tlsprobe.Target {
+	return tlsprobe.Target{
+		Host:       ep.Host,
+		Port:       ep.Port,
+		ServerName: ep.ServerName,
+		StartTLS:   ep.StartTLS,
+	}
+}
+
+// checkDue returns true if the endpoint is enabled and has not been checked within
+// its check interval
+func (ep Endpoint) checkDue(now time.Time) bool {
+	if !ep.Enabled {
+		return false
+	}
+	if ep.LastCheck == nil {
+		return true
+	}
+
+	return !now.Before(ep.LastCheck.CheckedAt.Add(time.Duration(ep.CheckIntervalMinutes) * time.Minute))
+}
+
+// checkResponse is the JSON response for a Check
+type checkResponse struct {
+	CheckedAt          int64    `json:"checked_at"`
+	Error              string   `json:"error,omitempty"`
+	LeafSerial         string   `json:"leaf_serial"`
+	LeafSubject        string   `json:"leaf_subject"`
+	LeafIssuer         string   `json:"leaf_issuer"`
+	LeafDNSNames       []string `json:"leaf_dns_names"`
+	LeafNotBefore      *int64   `json:"leaf_not_before"`
+	LeafNotAfter       *int64   `json:"leaf_not_after"`
+	MatchesNewestOrder *bool    `json:"matches_newest_order"`
+}
+
+func (check Check) response() checkResponse {
+	var notBefore, notAfter *int64
+	if check.LeafNotBefore != nil {
+		notBefore = new(check.LeafNotBefore.Unix())
+	}
+	if check.LeafNotAfter != nil {
+		notAfter = new(check.LeafNotAfter.Unix())
+	}
+
+	return checkResponse{
+		CheckedAt:          check.CheckedAt.Unix(),
+		Error:              check.Error,
+		LeafSerial:         check.LeafSerial,
+		LeafSubject:        check.LeafSubject,
+		LeafIssuer:         check.LeafIssuer,
+		LeafDNSNames:       check.LeafDNSNames,
+		LeafNotBefore:      notBefore,
+		LeafNotAfter:       notAfter,
+		MatchesNewestOrder: check.MatchesNewestOrder,
+	}
+}
+
+// endpointSummaryResponse is a JSON response containing only
+// fields desired for the summary
+type endpointSummaryResponse struct {
+	ID                   int                  `json:"id"`
+	Name                 string               `json:"name"`
+	Description          string               `json:"description"`
+	Host                 string               `json:"host"`
+	Port                 int                  `json:"port"`
+	ServerName           string               `json:"server_name"`
+	StartTLS             tlsprobe.StartTLS    `json:"starttls"`
+	Certificate          *EndpointCertificate `json:"certificate"`
+	CheckIntervalMinutes int                  `json:"check_interval_minutes"`
+	Enabled              bool                 `json:"enabled"`
+	LastCheck            *checkResponse       `json:"last_check"`
+}
+
+func (ep Endpoint) summaryResponse() endpointSummaryResponse {
+	var lastCheck *checkResponse
+	if ep.LastCheck != nil {
+		lastCheck = new(ep.LastCheck.response())
+	}
+
+	return endpointSummaryResponse{
+		ID:                   ep.ID,
+		Name:                 ep.Name,
+		Description:          ep.Description,
+		Host:                 ep.Host,
+		Port:                 ep.Port,
+		ServerName:           ep.ServerName,
+		StartTLS:             ep.StartTLS,
+		Certificate:          ep.Certificate,
+		CheckIntervalMinutes: ep.CheckIntervalMinutes,
+		Enabled:              ep.Enabled,
+		LastCheck:            lastCheck,
+	}
+}
+
+// endpointDetailedResponse is a JSON response containing all
+// fields that can be returned as JSON
+type endpointDetailedResponse struct {
+	endpointSummaryResponse
+	ServedChain string `json:"served_chain"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
+
+func (ep Endpoint) detailedResponse() endpointDetailedResponse {
+	servedChain := ""
+	if ep.LastCheck != nil {
+		servedChain = ep.LastCheck.ServedChain
+	}
+
+	return endpointDetailedResponse{
+		endpointSummaryResponse: ep.summaryResponse(),
+		ServedChain:             servedChain,
+		CreatedAt:               ep.CreatedAt.Unix(),
+		UpdatedAt:               ep.UpdatedAt.Unix(),
+	}
+}
+
+// auditDetails is the endpoint configuration recorded in the audit log (check results
+// are excluded as they are not user changes)
+func (ep Endpoint) auditDetails() endpointSummaryResponse {
+	summary := ep.summaryResponse()
+	summary.LastCheck = nil
+	return summary
+}
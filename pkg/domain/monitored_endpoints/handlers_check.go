@@ -0,0 +1,50 @@
+package monitored_endpoints
+
+import (
+	"certwarden-backend/pkg/output"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// PostCheckEndpoint is a handler that immediately checks a monitored endpoint (regardless
+// of its schedule or whether it is enabled), saves the result, and returns the updated
+// endpoint
+func (service *Service) PostCheckEndpoint(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// verify endpoint id exists
+	ep, outErr := service.getEndpoint(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// check and save
+	check, err := service.checkAndSave(r.Context(), ep)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	ep.LastCheck = &check
+
+	// write response
+	response := &endpointResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "checked monitored endpoint"
+	response.Endpoint = ep.detailedResponse()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
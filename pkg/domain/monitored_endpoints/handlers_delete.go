@@ -0,0 +1,49 @@
+package monitored_endpoints
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/output"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// DeleteEndpoint deletes a monitored endpoint (and its check results) from storage
+func (service *Service) DeleteEndpoint(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// verify endpoint id exists
+	ep, outErr := service.getEndpoint(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// delete from storage
+	err = service.storage.DeleteMonitoredEndpoint(id)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	audit.RecordChange(r.Context(), audit.TargetMonitoredEndpoint, id, ep.auditDetails(), nil)
+
+	// write response
+	response := &output.JsonResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = fmt.Sprintf("deleted monitored endpoint (id: %d)", id)
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package monitored_endpoints
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// allEndpointsResponse provides the json response struct
+// to answer a query for a portion of the monitored endpoints
+type allEndpointsResponse struct {
+	output.JsonResponse
+	TotalEndpoints int                       `json:"total_records"`
+	Endpoints      []endpointSummaryResponse `json:"monitored_endpoints"`
+}
+
+// GetAllEndpoints fetches all monitored endpoints (including their last check) from storage
+// and outputs them as JSON
+func (service *Service) GetAllEndpoints(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// parse pagination and sorting
+	query := pagination_sort.ParseRequestToQuery(r)
+
+	// get endpoints from storage
+	endpoints, totalRows, err := service.storage.GetAllMonitoredEndpoints(query)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// populate endpoint summaries for output
+	outputEndpoints := []endpointSummaryResponse{}
+	for i := range endpoints {
+		outputEndpoints = append(outputEndpoints, endpoints[i].summaryResponse())
+	}
+
+	// write response
+	response := &allEndpointsResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.TotalEndpoints = totalRows
+	response.Endpoints = outputEndpoints
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+type endpointResponse struct {
+	output.JsonResponse
+	Endpoint endpointDetailedResponse `json:"monitored_endpoint"`
+}
+
+// GetOneEndpoint is an http handler that returns one monitored endpoint based on its unique
+// id in the form of JSON written to w
+func (service *Service) GetOneEndpoint(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get from storage
+	ep, outErr := service.getEndpoint(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// write response
+	response := &endpointResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Endpoint = ep.detailedResponse()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package monitored_endpoints
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/tlsprobe"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// NewPayload is the struct for creating a new monitored endpoint
+type NewPayload struct {
+	Name                 *string            `json:"name"`
+	Description          *string            `json:"description"`
+	Host                 *string            `json:"host"`
+	Port                 *int               `json:"port"`
+	ServerName           *string            `json:"server_name"`
+	StartTLS             *tlsprobe.StartTLS `json:"starttls"`
+	CertificateID        *int               `json:"certificate_id"`
+	CheckIntervalMinutes *int               `json:"check_interval_minutes"`
+	Enabled              *bool              `json:"enabled"`
+	CreatedAt            int                `json:"-"`
+	UpdatedAt            int                `json:"-"`
+}
+
+// PostNewEndpoint creates a new monitored endpoint in storage
+func (service *Service) PostNewEndpoint(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload NewPayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// name
+	if payload.Name == nil || !service.nameValid(*payload.Name, nil) {
+		service.logger.Debug(ErrNameBad)
+		return output.JsonErrValidationFailed(ErrNameBad)
+	}
+	// description (if none, set to blank)
+	if payload.Description == nil {
+		payload.Description = new(string)
+	}
+	// host
+	if payload.Host == nil || !hostValid(*payload.Host) {
+		service.logger.Debug(ErrHostBad)
+		return output.JsonErrValidationFailed(ErrHostBad)
+	}
+	// port (default 443)
+	if payload.Port == nil {
+		payload.Port = new(443)
+	} else if !portValid(*payload.Port) {
+		service.logger.Debug(ErrPortBad)
+		return output.JsonErrValidationFailed(ErrPortBad)
+	}
+	// server name (optional)
+	if payload.ServerName == nil {
+		payload.ServerName = new(string)
+	} else if !serverNameValid(*payload.ServerName) {
+		service.logger.Debug(ErrServerNameBad)
+		return output.JsonErrValidationFailed(ErrServerNameBad)
+	}
+	// starttls (optional)
+	if payload.StartTLS == nil {
+		payload.StartTLS = new(tlsprobe.StartTLSNone)
+	} else if !payload.StartTLS.Valid() {
+		service.logger.Debug(ErrStartTLSBad)
+		return output.JsonErrValidationFailed(ErrStartTLSBad)
+	}
+	// certificate (optional, 0 is none)
+	if payload.CertificateID != nil && *payload.CertificateID == 0 {
+		payload.CertificateID = nil
+	}
+	if payload.CertificateID != nil {
+		outErr := service.certificateIdValid(*payload.CertificateID)
+		if outErr != nil {
+			return outErr
+		}
+	}
+	// check interval (optional)
+	if payload.CheckIntervalMinutes == nil {
+		payload.CheckIntervalMinutes = new(defaultCheckIntervalMinutes)
+	} else if !checkIntervalValid(*payload.CheckIntervalMinutes) {
+		service.logger.Debug(ErrCheckIntervalBad)
+		return output.JsonErrValidationFailed(ErrCheckIntervalBad)
+	}
+	// enabled (default true)
+	if payload.Enabled == nil {
+		payload.Enabled = new(true)
+	}
+	// end validation
+
+	// add additional details to the payload before saving
+	payload.CreatedAt = int(time.Now().Unix())
+	payload.UpdatedAt = payload.CreatedAt
+
+	// save new endpoint
+	newEp, err := service.storage.PostNewMonitoredEndpoint(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	audit.RecordChange(r.Context(), audit.TargetMonitoredEndpoint, newEp.ID, nil, newEp.auditDetails())
+
+	// write response
+	response := &endpointResponse{}
+	response.StatusCode = http.StatusCreated
+	response.Message = "created monitored endpoint"
+	response.Endpoint = newEp.detailedResponse()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package monitored_endpoints
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/tlsprobe"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// UpdatePayload is the struct for editing an existing monitored endpoint
+type UpdatePayload struct {
+	ID                   int                `json:"-"`
+	Name                 *string            `json:"name"`
+	Description          *string            `json:"description"`
+	Host                 *string            `json:"host"`
+	Port                 *int               `json:"port"`
+	ServerName           *string            `json:"server_name"`
+	StartTLS             *tlsprobe.StartTLS `json:"starttls"`
+	CertificateID        *int               `json:"certificate_id"` // 0 to clear
+	CheckIntervalMinutes *int               `json:"check_interval_minutes"`
+	Enabled              *bool              `json:"enabled"`
+	UpdatedAt            int                `json:"-"`
+}
+
+// PutEndpoint is a handler that updates a monitored endpoint
+func (service *Service) PutEndpoint(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// payload decoding
+	var payload UpdatePayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	payload.ID, err = strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// id
+	ep, outErr := service.getEndpoint(payload.ID)
+	if outErr != nil {
+		return outErr
+	}
+	// name (optional)
+	if payload.Name != nil && !service.nameValid(*payload.Name, &payload.ID) {
+		service.logger.Debug(ErrNameBad)
+		return output.JsonErrValidationFailed(ErrNameBad)
+	}
+	// host (optional)
+	if payload.Host != nil && !hostValid(*payload.Host) {
+		service.logger.Debug(ErrHostBad)
+		return output.JsonErrValidationFailed(ErrHostBad)
+	}
+	// port (optional)
+	if payload.Port != nil && !portValid(*payload.Port) {
+		service.logger.Debug(ErrPortBad)
+		return output.JsonErrValidationFailed(ErrPortBad)
+	}
+	// server name (optional)
+	if payload.ServerName != nil && !serverNameValid(*payload.ServerName) {
+		service.logger.Debug(ErrServerNameBad)
+		return output.JsonErrValidationFailed(ErrServerNameBad)
+	}
+	// starttls (optional)
+	if payload.StartTLS != nil && !payload.StartTLS.Valid() {
+		service.logger.Debug(ErrStartTLSBad)
+		return output.JsonErrValidationFailed(ErrStartTLSBad)
+	}
+	// certificate (optional, 0 unlinks)
+	if payload.CertificateID != nil && *payload.CertificateID != 0 {
+		outErr = service.certificateIdValid(*payload.CertificateID)
+		if outErr != nil {
+			return outErr
+		}
+	}
+	// check interval (optional)
+	if payload.CheckIntervalMinutes != nil && !checkIntervalValid(*payload.CheckIntervalMinutes) {
+		service.logger.Debug(ErrCheckIntervalBad)
+		return output.JsonErrValidationFailed(ErrCheckIntervalBad)
+	}
+	// end validation
+
+	// add additional details to the payload before saving
+	payload.UpdatedAt = int(time.Now().Unix())
+
+	// save endpoint
+	updatedEp, err := service.storage.PutMonitoredEndpoint(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	audit.RecordChange(r.Context(), audit.TargetMonitoredEndpoint, updatedEp.ID, ep.auditDetails(), updatedEp.auditDetails())
+
+	// write response
+	response := &endpointResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "updated monitored endpoint"
+	response.Endpoint = updatedEp.detailedResponse()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package monitored_endpoints
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/orders"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"context"
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+var errServiceComponent = errors.New("necessary monitored endpoints service component is missing")
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetOutputter() *output.Service
+	GetMonitoredEndpointStorage() Storage
+	GetCertificatesService() *certificates.Service
+	GetShutdownContext() context.Context
+	GetShutdownWaitGroup() *sync.WaitGroup
+}
+
+// Storage interface for storage functions
+type Storage interface {
+	GetAllMonitoredEndpoints(q pagination_sort.Query) (endpoints []Endpoint, totalRowCount int, err error)
+	GetOneMonitoredEndpointById(id int) (Endpoint, error)
+	GetOneMonitoredEndpointByName(name string) (Endpoint, error)
+
+	PostNewMonitoredEndpoint(payload NewPayload) (Endpoint, error)
+	PutMonitoredEndpoint(payload UpdatePayload) (Endpoint, error)
+	PutMonitoredEndpointCheck(endpointId int, check Check) (err error)
+	DeleteMonitoredEndpoint(id int) (err error)
+
+	GetCertNewestValidOrderById(certId int) (order orders.Order, err error)
+}
+
+// Service struct
+type Service struct {
+	shutdownContext context.Context
+	logger          *zap.SugaredLogger
+	output          *output.Service
+	storage         Storage
+	certificates    *certificates.Service
+}
+
+// NewService creates a new service
+func NewService(app App) (*Service, error) {
+	service := new(Service)
+
+	// shutdown context
+	service.shutdownContext = app.GetShutdownContext()
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// output service
+	service.output = app.GetOutputter()
+	if service.output == nil {
+		return nil, errServiceComponent
+	}
+
+	// storage
+	service.storage = app.GetMonitoredEndpointStorage()
+	if service.storage == nil {
+		return nil, errServiceComponent
+	}
+
+	// certificates service
+	service.certificates = app.GetCertificatesService()
+	if service.certificates == nil {
+		return nil, errServiceComponent
+	}
+
+	// start periodic checking
+	service.startMonitoringService(app.GetShutdownContext(), app.GetShutdownWaitGroup())
+
+	return service, nil
+}
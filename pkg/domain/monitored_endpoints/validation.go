@@ -0,0 +1,101 @@
+package monitored_endpoints
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/validation"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrIdBad            = errors.New("monitored endpoint id is invalid")
+	ErrNameBad          = errors.New("monitored endpoint name is not valid")
+	ErrHostBad          = errors.New("host must be a valid domain or ip address")
+	ErrPortBad          = errors.New("port must be between 1 and 65535")
+	ErrServerNameBad    = errors.New("server name must be blank or a valid domain")
+	ErrStartTLSBad      = errors.New("starttls must be blank, smtp, or imap")
+	ErrCheckIntervalBad = errors.New("check interval minutes must be between 5 and 10080")
+)
+
+const (
+	minCheckIntervalMinutes     = 5
+	maxCheckIntervalMinutes     = 60 * 24 * 7
+	defaultCheckIntervalMinutes = 60
+)
+
+// getEndpoint returns the Endpoint for the specified id.
+func (service *Service) getEndpoint(id int) (Endpoint, *output.JsonError) {
+	// if id is not in valid range, it is definitely not valid
+	if !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(ErrIdBad)
+		return Endpoint{}, output.JsonErrValidationFailed(ErrIdBad)
+	}
+
+	// get from storage
+	ep, err := service.storage.GetOneMonitoredEndpointById(id)
+	if err != nil {
+		// special error case for no record found
+		if errors.Is(err, sql.ErrNoRows) {
+			service.logger.Debug(err)
+			return Endpoint{}, output.JsonErrNotFound(fmt.Errorf("monitored endpoint id %d not found", id))
+		} else {
+			service.logger.Error(err)
+			return Endpoint{}, output.JsonErrStorageGeneric(err)
+		}
+	}
+
+	return ep, nil
+}
+
+// nameValid returns if an endpoint name is valid (meets char requirements
+// and is not in use in storage OR is in use by the specified endpointId)
+func (service *Service) nameValid(name string, endpointId *int) bool {
+	// basic check
+	if !validation.NameValid(name) {
+		return false
+	}
+
+	// make sure the name isn't already in use in storage
+	ep, err := service.storage.GetOneMonitoredEndpointByName(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		// no rows means name is not in use
+		return true
+	} else if err != nil {
+		// any other error, invalid
+		return false
+	}
+
+	// if the returned endpoint is the endpoint being edited, no error
+	if endpointId != nil && ep.ID == *endpointId {
+		return true
+	}
+
+	return false
+}
+
+// hostValid returns if the host is a valid domain or ip address
+func hostValid(host string) bool {
+	return validation.DomainValid(host, false) || validation.IPAddressValid(host)
+}
+
+// portValid returns if port is a valid tcp port
+func portValid(port int) bool {
+	return port >= 1 && port <= 65535
+}
+
+// serverNameValid returns if the SNI server name is blank (use host) or a valid domain
+func serverNameValid(serverName string) bool {
+	return serverName == "" || validation.DomainValid(serverName, false)
+}
+
+// checkIntervalValid returns if the check interval is within the allowed range
+func checkIntervalValid(minutes int) bool {
+	return minutes >= minCheckIntervalMinutes && minutes <= maxCheckIntervalMinutes
+}
+
+// certificateIdValid returns an error if certId is not an existing certificate
+func (service *Service) certificateIdValid(certId int) *output.JsonError {
+	_, outErr := service.certificates.GetCertificate(certId)
+	return outErr
+}
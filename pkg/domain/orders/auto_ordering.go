@@ -50,6 +50,9 @@ func (service *Service) startAutoOrderService(ctx context.Context, wg *sync.Wait
 			// order expiring certificates
 			service.orderExpiringCerts()
 
+			// delete keys replaced by key rotation once their grace period ends
+			service.retireRotatedKeys()
+
 			// next run time (add autoOrderRunInterval and some jitter)
 			// add random second to runtime, as preferred by Let's Encrypt
 			// see: https://letsencrypt.org/docs/integration-guide/#when-to-renew
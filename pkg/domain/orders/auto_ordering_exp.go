@@ -3,6 +3,9 @@ package orders
 import (
 	"certwarden-backend/pkg/pagination_sort"
 	"certwarden-backend/pkg/randomness"
+	"database/sql"
+	"errors"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -18,6 +21,16 @@ func (service *Service) orderExpiringCerts() {
 		return
 	}
 
+	// forget renewal window notifications for orders that are no longer a cert's newest valid order
+	currentOrderIDs := make(map[string]struct{}, len(orders))
+	for i := range orders {
+		currentOrderIDs[strconv.Itoa(orders[i].ID)] = struct{}{}
+	}
+	service.renewalWindowNotified.DeleteFunc(func(orderID string, _ struct{}) bool {
+		_, current := currentOrderIDs[orderID]
+		return !current
+	})
+
 	// aysnc checking and updating
 	var wg sync.WaitGroup
 	wg.Add(len(orders))
@@ -95,13 +108,34 @@ func (service *Service) orderExpiringCerts() {
 			if renewalTime.Before(time.Now().Add(autoOrderRunInterval)) {
 				service.logger.Debugf("orders: auto order placing new order for expiring cert %s (window from: %s; to: %s; selected renewal time: %s)",
 					orders[i].Certificate.Name, ari.SuggestedWindow.Start, ari.SuggestedWindow.End, renewalTime)
+
+				// stage a new key for the order first, if the cert's rotation policy calls for it
+				err = service.rotateCertKeyIfDue(orders[i])
+				if err != nil {
+					service.logger.Errorf("orders: auto order failed to rotate key for cert %s, renewing with the existing key (%s)", orders[i].Certificate.Name, err)
+				}
+
 				_, outErr := service.placeNewOrderAndFulfill(orders[i].Certificate.ID, false)
 				if outErr != nil {
-					service.logger.Errorf("orders: auto order failed to place new order for cert %s (%s)", orders[i].Certificate.Name, err)
+					service.logger.Errorf("orders: auto order failed to place new order for cert %s (%s)", orders[i].Certificate.Name, outErr)
+					// don't leave a new key waiting on an order that doesn't exist
+					service.cancelUnusedKeyRotation(orders[i].Certificate)
 				} else {
 					addedMu.Lock()
 					addedCount++
 					addedMu.Unlock()
+					return
+				}
+			}
+
+			// Step 3: If in the renewal window and there is no new order, notify (once per valid order)
+			if time.Now().After(ari.SuggestedWindow.Start) {
+				_, err = service.storage.GetNewestIncompleteCertOrderId(orders[i].Certificate.ID)
+				if errors.Is(err, sql.ErrNoRows) {
+					alreadyNotified, _ := service.renewalWindowNotified.Add(strconv.Itoa(orders[i].ID), struct{}{})
+					if !alreadyNotified {
+						service.notifyRenewalWindowNoNewOrder(orders[i], ari)
+					}
 				}
 			}
 		}()
@@ -2,9 +2,13 @@ package orders
 
 import (
 	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/datatypes/history"
+	"certwarden-backend/pkg/metrics"
 	"certwarden-backend/pkg/randomness"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"time"
 )
 
@@ -23,18 +27,49 @@ func (j *orderFulfillJob) Do(workerID int) {
 	// always info log ordering
 	j.service.logger.Infof("orders: fulfilling worker %d: ordering order id %d (certificate name: %s, subject: %s)", workerID, order.ID, order.Certificate.Name, order.Certificate.Subject)
 
+	// acmeOrder to hold the Order responses and to later update storage
+	var acmeOrder acme.Order
+
+	// history of this attempt
+	attemptStart := time.Now()
+	rec := history.NewRecorder()
+
+	// record outcome metric, send notification, and save history (anything that doesn't
+	// reach a final status is an error); failErr is the reason the attempt failed and must
+	// be set before any failed return
+	outcome := metrics.OrderOutcomeError
+	var failErr error
+	defer func() {
+		j.service.metrics.RecordOrderOutcome(order.Certificate.CertificateAccount.AcmeServer.Name, outcome)
+		j.service.notifyOrderOutcome(order, acmeOrder, outcome, failErr)
+		j.service.saveAttempt(order.ID, AttemptKindFulfill, outcome, attemptStart, rec)
+	}()
+
 	// update certificate timestamp after fulfiller is done
 	defer func() {
-		err = j.service.storage.UpdateCertUpdatedTime(order.Certificate.ID)
+		err := j.service.storage.UpdateCertUpdatedTime(order.Certificate.ID)
 		if err != nil {
 			j.service.logger.Errorf("orders: fulfilling worker %d: update cert time error: %s", workerID, err)
 		}
 	}()
 
+	// finalize with the new key of a pending key rotation (if any)
+	rotation, err := j.service.useKeyRotation(&order)
+	if err != nil {
+		j.service.logger.Errorf("orders: fulfilling worker %d: key rotation error: %s", workerID, err)
+		failErr = err
+		return // done, failed
+	}
+	finalizedKeyID := -1
+	if order.FinalizedKey != nil {
+		finalizedKeyID = order.FinalizedKey.ID
+	}
+
 	// get account key
 	key, err := order.Certificate.CertificateAccount.AcmeAccountKey()
 	if err != nil {
 		j.service.logger.Errorf("orders: fulfilling worker %d: get account key error: %s", workerID, err)
+		failErr = err
 		return // done, failed
 	}
 
@@ -42,16 +77,15 @@ func (j *orderFulfillJob) Do(workerID int) {
 	csr, err := order.Certificate.MakeCsrDer()
 	if err != nil {
 		j.service.logger.Errorf("orders: fulfilling worker %d: make csr error: %s", workerID, err)
+		failErr = err
 		return // done, failed
 	}
 
-	// acmeOrder to hold the Order responses and to later update storage
-	var acmeOrder acme.Order
-
 	// acmeService to avoid repeated logic
 	acmeService, err := j.service.acmeServerService.AcmeService(order.Certificate.CertificateAccount.AcmeServer.ID)
 	if err != nil {
 		j.service.logger.Errorf("orders: fulfilling worker %d: select acme service error: %s", workerID, err)
+		failErr = err
 		return // done, failed
 	}
 
@@ -63,10 +97,17 @@ func (j *orderFulfillJob) Do(workerID int) {
 	// exponential backoff for retrying while 'processing' (no max as outer loop will handle timeout)
 	bo := randomness.BackoffACME(0, j.service.shutdownContext)
 
+	// only record order status in history when it changes
+	lastStatus := ""
+
 fulfillLoop:
 	for time.Since(startTime) <= timeoutLength {
 		// Get the order (for most recent Order object and Status)
 		acmeOrder, err = acmeService.GetOrder(order.Location, key)
+		if err != nil || acmeOrder.Status != lastStatus {
+			rec.Add(history.Step{Action: history.ActionOrderStatus, Status: acmeOrder.Status, AcmeError: acmeOrder.Error}, err)
+			lastStatus = acmeOrder.Status
+		}
 		if err != nil {
 			// if ACME returned 404, the order object is now invalid;
 			// assume the ACME server deleted it and update accordingly
@@ -76,10 +117,14 @@ fulfillLoop:
 			acmeErr := new(acme.Error)
 			if errors.As(err, &acmeErr) && acmeErr.Status == http.StatusNotFound {
 				j.service.storage.PutOrderInvalid(order.ID)
+				outcome = metrics.OrderOutcomeInvalid
+				j.service.finishKeyRotation(order, rotation, false, finalizedKeyID)
+				failErr = err
 				return // done, permanent status
 			}
 
 			j.service.logger.Errorf("orders: fulfilling worker %d: get order error: %s", workerID, err)
+			failErr = err
 			return // done, failed
 		}
 
@@ -94,9 +139,10 @@ fulfillLoop:
 		switch acmeOrder.Status {
 
 		case "pending": // needs to be authed
-			err = j.service.authorizations.FulfillAuths(acmeOrder.Authorizations, key, acmeService)
+			err = j.service.authorizations.FulfillAuths(acmeOrder.Authorizations, key, acmeService, rec)
 			if err != nil {
 				j.service.logger.Errorf("orders: fulfilling worker %d: fulfill auths error: %s", workerID, err)
+				failErr = err
 				return // done, failed
 			}
 
@@ -107,13 +153,17 @@ fulfillLoop:
 			err = j.service.storage.UpdateFinalizedKey(order.ID, order.Certificate.CertificateKey.ID)
 			if err != nil {
 				j.service.logger.Errorf("orders: fulfilling worker %d: update finalized key error: %s", workerID, err)
+				failErr = err
 				return // done, failed
 			}
+			finalizedKeyID = order.Certificate.CertificateKey.ID
 
 			// finalize the order
 			_, err = acmeService.FinalizeOrder(acmeOrder.Finalize, csr, key)
+			rec.Add(history.Step{Action: history.ActionFinalize}, err)
 			if err != nil {
 				j.service.logger.Errorf("orders: fulfilling worker %d: finalize order error: %s", workerID, err)
+				failErr = err
 				return // done, failed
 			}
 
@@ -128,9 +178,12 @@ fulfillLoop:
 				continue
 			}
 
-			cert, err := acmeService.DownloadCertificate(*acmeOrder.Certificate, key, order.Certificate.PreferredRootCN)
+			var cert *acme.Certificate
+			cert, err = acmeService.DownloadCertificate(*acmeOrder.Certificate, key, order.Certificate.PreferredRootCN)
+			rec.Add(history.Step{Action: history.ActionDownloadCert}, err)
 			if err != nil {
 				j.service.logger.Errorf("orders: fulfilling worker %d: download cert error: %s", workerID, err)
+				failErr = err
 				return // done, failed
 			}
 
@@ -148,6 +201,7 @@ fulfillLoop:
 			err = j.saveAcmeCert(order.ID, cert, acmeARI)
 			if err != nil {
 				j.service.logger.Errorf("orders: fulfilling worker %d: save pem error: %s", workerID, err)
+				failErr = err
 				return // done, failed
 			}
 
@@ -166,6 +220,7 @@ fulfillLoop:
 			// cancel on shutdown context
 			case <-j.service.shutdownContext.Done():
 				j.service.logger.Errorf("orders: fulfilling worker %d: order job canceled due to shutdown", workerID)
+				failErr = errors.New("order job canceled due to shutdown")
 				return
 
 			case <-time.After(nextBackoffDuration):
@@ -181,6 +236,7 @@ fulfillLoop:
 		// should never happen
 		default:
 			j.service.logger.Errorf("orders: fulfilling worker %d: error: order status unknown", workerID)
+			failErr = fmt.Errorf("order status (%s) unknown", acmeOrder.Status)
 			return // done, failed
 		}
 	}
@@ -196,10 +252,35 @@ fulfillLoop:
 
 	// if loop timed out, log error and finish
 	if loopTimedOut {
+		outcome = metrics.OrderOutcomeTimeout
+		failErr = fmt.Errorf("exhausted retry loop time with status %s", acmeOrder.Status)
 		j.service.logger.Errorf("orders: fulfilling worker %d: order id %d exhausted retry loop time and terminated with status %s (certificate name: %s, subject: %s)", workerID, order.ID, acmeOrder.Status, order.Certificate.Name, order.Certificate.Subject)
 		return
 	}
 
+	// set final outcome
+	switch acmeOrder.Status {
+	case "valid":
+		outcome = metrics.OrderOutcomeValid
+	case "invalid":
+		outcome = metrics.OrderOutcomeInvalid
+	}
+
+	// finish key rotation (the new key replaces the cert's key if it was used)
+	if acmeOrder.Status == "valid" || acmeOrder.Status == "invalid" {
+		j.service.finishKeyRotation(order, rotation, acmeOrder.Status == "valid", finalizedKeyID)
+	}
+
+	// if order invalid and a challenge can be retried with a fallback provider, place a new
+	// order (its challenges start with the fallback)
+	if acmeOrder.Status == "invalid" && slices.ContainsFunc(acmeOrder.Identifiers, j.service.challenges.FallbackPending) {
+		j.service.logger.Infof("orders: fulfilling worker %d: order id %d invalid, placing new order to retry with fallback challenge provider (certificate name: %s)", workerID, order.ID, order.Certificate.Name)
+		_, outErr := j.service.placeNewOrderAndFulfill(order.Certificate.ID, j.IsHighPriority())
+		if outErr != nil {
+			j.service.logger.Errorf("orders: fulfilling worker %d: failed to place new order to retry with fallback (%s)", workerID, outErr)
+		}
+	}
+
 	// if order valid, do post processing
 	if acmeOrder.Status == "valid" {
 		// send to post-processing queue
@@ -0,0 +1,65 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/output"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// orderHistoryResponse provides the json response struct
+// to answer a query for an order's attempt history
+type orderHistoryResponse struct {
+	output.JsonResponse
+	Attempts []orderAttemptResponse `json:"attempts"`
+}
+
+// GetOrderHistory is an http handler that returns the fulfillment and post processing
+// attempt history of the specified order
+func (service *Service) GetOrderHistory(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// convert id params to integers
+	certIdParam := httprouter.ParamsFromContext(r.Context()).ByName("certid")
+	certId, err := strconv.Atoi(certIdParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	orderIdParam := httprouter.ParamsFromContext(r.Context()).ByName("orderid")
+	orderId, err := strconv.Atoi(orderIdParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validate order belongs to cert
+	_, outErr := service.getOrder(certId, orderId)
+	if outErr != nil {
+		return outErr
+	}
+
+	// get attempts from storage
+	attempts, err := service.storage.GetOrderAttempts(orderId)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &orderHistoryResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Attempts = []orderAttemptResponse{}
+	for i := range attempts {
+		response.Attempts = append(response.Attempts, attempts[i].response())
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package orders
 
 import (
 	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"errors"
@@ -159,6 +160,8 @@ func (service *Service) RevokeOrder(w http.ResponseWriter, r *http.Request) *out
 	}
 	// end validation
 
+	before := order.summaryResponse(service)
+
 	// get account key
 	key, err := order.Certificate.CertificateAccount.AcmeAccountKey()
 	if err != nil {
@@ -202,6 +205,7 @@ func (service *Service) RevokeOrder(w http.ResponseWriter, r *http.Request) *out
 	if outErr != nil {
 		return outErr
 	}
+	audit.RecordChange(r.Context(), audit.TargetOrder, orderId, before, order.summaryResponse(service))
 
 	// write response
 	response := &orderResponse{}
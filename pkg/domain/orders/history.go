@@ -0,0 +1,78 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/datatypes/history"
+	"time"
+)
+
+// attempt kinds
+const (
+	AttemptKindFulfill          = "fulfill"
+	AttemptKindPostProcess      = "post_process"
+	AttemptKindVerifyDeployment = "verify_deployment"
+)
+
+// post processing and verify deployment attempt outcomes (fulfill attempts use the
+// metrics order outcomes)
+const (
+	postProcessOutcomeSuccess = "success"
+	postProcessOutcomeFailed  = "failed"
+)
+
+// OrderAttempt is the record of a single attempt to fulfill, post process, or verify the
+// deployment of an order
+type OrderAttempt struct {
+	ID        int
+	OrderID   int
+	Kind      string
+	Outcome   string
+	Steps     []history.Step
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+// orderAttemptResponse is the JSON response for an OrderAttempt
+type orderAttemptResponse struct {
+	ID        int            `json:"id"`
+	Kind      string         `json:"kind"`
+	Outcome   string         `json:"outcome"`
+	Steps     []history.Step `json:"steps"`
+	StartedAt int            `json:"started_at"`
+	EndedAt   int            `json:"ended_at"`
+}
+
+func (attempt OrderAttempt) response() orderAttemptResponse {
+	return orderAttemptResponse{
+		ID:        attempt.ID,
+		Kind:      attempt.Kind,
+		Outcome:   attempt.Outcome,
+		Steps:     attempt.Steps,
+		StartedAt: int(attempt.StartedAt.Unix()),
+		EndedAt:   int(attempt.EndedAt.Unix()),
+	}
+}
+
+// NewOrderAttemptPayload is the payload to save an attempt's history to storage
+type NewOrderAttemptPayload struct {
+	OrderID   int
+	Kind      string
+	Outcome   string
+	Steps     []history.Step
+	StartedAt int
+	EndedAt   int
+}
+
+// saveAttempt saves the steps recorded in rec to storage as an attempt for orderID
+func (service *Service) saveAttempt(orderID int, kind string, outcome string, startedAt time.Time, rec *history.Recorder) {
+	err := service.storage.PostOrderAttempt(NewOrderAttemptPayload{
+		OrderID:   orderID,
+		Kind:      kind,
+		Outcome:   outcome,
+		Steps:     rec.Steps(),
+		StartedAt: int(startedAt.Unix()),
+		EndedAt:   int(time.Now().Unix()),
+	})
+	if err != nil {
+		service.logger.Errorf("orders: failed to save %s attempt history for order %d (%s)", kind, orderID, err)
+	}
+}
@@ -0,0 +1,211 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"certwarden-backend/pkg/randomness"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StageKeyRotationPayload contains the information needed to save a new private key that will
+// replace a certificate's key once the cert's next order is valid
+type StageKeyRotationPayload struct {
+	CertID            int
+	OldKeyID          int
+	NewKeyName        string
+	NewKeyDescription string
+	NewKeyAlgorithm   key_crypto.Algorithm
+	NewKeyPem         string
+	NewKeyApiKey      string
+	GraceDays         int
+	CreatedAt         int
+}
+
+// PendingKeyRotation is a certificate's key rotation that is waiting for an order finalized
+// with the new key to become valid
+type PendingKeyRotation struct {
+	CertID    int
+	OldKeyID  int
+	NewKeyID  int
+	GraceDays int
+}
+
+// CompleteKeyRotationPayload contains the information needed to finish a certificate's
+// pending key rotation
+type CompleteKeyRotationPayload struct {
+	CertID int
+	// appended to the old key's name, freeing it for the new key
+	OldKeyRetiredSuffix string
+	RetireAt            int64
+	UpdatedAt           int
+}
+
+// rotateCertKeyIfDue generates a new private key for the order's certificate if the cert's key
+// rotation policy calls for one. This is called before placing a renewal order so that the new
+// order is finalized with the new key. The cert's current key (and its name and api key) is
+// left alone until that order is valid, see useKeyRotation.
+func (service *Service) rotateCertKeyIfDue(currentOrder Order) error {
+	cert := currentOrder.Certificate
+	now := time.Now()
+
+	// only rotate if the cert is still using the key from its newest valid order
+	if currentOrder.FinalizedKey == nil || !cert.KeyRotationDue(currentOrder.FinalizedKey.ID, now) {
+		return nil
+	}
+
+	// don't rotate if an order is already in progress (it will use the cert's current key)
+	_, err := service.storage.GetNewestIncompleteCertOrderId(cert.ID)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// already staged (e.g. placing the order failed last time), use it
+	_, err = service.storage.GetCertKeyRotation(cert.ID)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// keys on a hardware token can't be generated here, leave them alone
+	if !cert.CertificateKey.Exportable() {
+		service.logger.Warnf("orders: key rotation skipped for certificate '%s' (key '%s' is not exportable)", cert.Name, cert.CertificateKey.Name)
+		return nil
+	}
+
+	// generate the new key
+	alg := cert.KeyRotationAlgorithm()
+	newKeyPem, err := alg.GeneratePrivateKeyPem()
+	if err != nil {
+		return fmt.Errorf("failed to generate new key (%w)", err)
+	}
+	// not usable until the rotation completes (and then replaced with the old key's)
+	apiKey, err := randomness.GenerateApiKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate new key's api key (%w)", err)
+	}
+
+	payload := StageKeyRotationPayload{
+		CertID:            cert.ID,
+		OldKeyID:          cert.CertificateKey.ID,
+		NewKeyName:        fmt.Sprintf("%s.next.%d", cert.CertificateKey.Name, now.Unix()),
+		NewKeyDescription: fmt.Sprintf("replacement for key '%s' (pending renewal of certificate '%s')", cert.CertificateKey.Name, cert.Name),
+		NewKeyAlgorithm:   alg,
+		NewKeyPem:         newKeyPem,
+		NewKeyApiKey:      apiKey,
+		GraceDays:         cert.KeyRotation.GraceDays,
+		CreatedAt:         int(now.Unix()),
+	}
+
+	newKeyID, err := service.storage.StageCertKeyRotation(payload)
+	if err != nil {
+		return fmt.Errorf("failed to save new key (%w)", err)
+	}
+
+	service.logger.Infof("orders: staged private key rotation for certificate '%s' (new key id: %d; it replaces key '%s' (id: %d) once the renewal is valid)",
+		cert.Name, newKeyID, cert.CertificateKey.Name, cert.CertificateKey.ID)
+
+	return nil
+}
+
+// useKeyRotation replaces the order's cert key with the new key of the cert's pending key
+// rotation (if there is one), so the order is finalized with the new key. The pending
+// rotation is returned (nil if none) to be finished with finishKeyRotation.
+func (service *Service) useKeyRotation(order *Order) (*PendingKeyRotation, error) {
+	rotation, err := service.storage.GetCertKeyRotation(order.Certificate.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// cert's key was changed since the rotation was staged, discard it
+	if rotation.OldKeyID != order.Certificate.CertificateKey.ID {
+		service.logger.Warnf("orders: key of certificate '%s' changed, discarding pending key rotation", order.Certificate.Name)
+		return nil, service.storage.CancelCertKeyRotation(order.Certificate.ID)
+	}
+
+	newKey, err := service.storage.GetOneKeyById(rotation.NewKeyID)
+	if err != nil {
+		return nil, err
+	}
+	order.Certificate.CertificateKey = newKey
+
+	return &rotation, nil
+}
+
+// finishKeyRotation completes the pending rotation if the order is valid and was finalized
+// with the new key, otherwise it discards the rotation (deleting the new key)
+func (service *Service) finishKeyRotation(order Order, rotation *PendingKeyRotation, valid bool, finalizedKeyID int) {
+	if rotation == nil {
+		return
+	}
+
+	if !valid || finalizedKeyID != rotation.NewKeyID {
+		err := service.storage.CancelCertKeyRotation(rotation.CertID)
+		if err != nil {
+			service.logger.Errorf("orders: failed to discard pending key rotation for certificate '%s' (%s)", order.Certificate.Name, err)
+			return
+		}
+		service.logger.Infof("orders: discarded pending key rotation for certificate '%s' (order %d was not completed with the new key)", order.Certificate.Name, order.ID)
+		return
+	}
+
+	now := time.Now()
+	payload := CompleteKeyRotationPayload{
+		CertID:              rotation.CertID,
+		OldKeyRetiredSuffix: fmt.Sprintf(".retired.%d", now.Unix()),
+		RetireAt:            now.AddDate(0, 0, rotation.GraceDays).Unix(),
+		UpdatedAt:           int(now.Unix()),
+	}
+	err := service.storage.CompleteCertKeyRotation(payload)
+	if err != nil {
+		service.logger.Errorf("orders: failed to complete key rotation for certificate '%s' (%s)", order.Certificate.Name, err)
+		return
+	}
+
+	service.logger.Infof("orders: rotated private key for certificate '%s' (new key id: %d; old key id %d will be retired at %s)",
+		order.Certificate.Name, rotation.NewKeyID, rotation.OldKeyID, time.Unix(payload.RetireAt, 0))
+}
+
+// cancelUnusedKeyRotation discards the cert's pending key rotation if there is no order in
+// progress that may use it (e.g. placing the renewal order failed)
+func (service *Service) cancelUnusedKeyRotation(cert certificates.Certificate) {
+	_, err := service.storage.GetNewestIncompleteCertOrderId(cert.ID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+
+	err = service.storage.CancelCertKeyRotation(cert.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		service.logger.Errorf("orders: failed to discard pending key rotation for certificate '%s' (%s)", cert.Name, err)
+	}
+}
+
+// retireRotatedKeys deletes keys replaced by key rotation whose grace period has ended
+func (service *Service) retireRotatedKeys() {
+	keyIDs, err := service.storage.GetDueKeyRetirements(time.Now().Unix())
+	if err != nil {
+		service.logger.Errorf("orders: failed to get keys due for retirement (%s)", err)
+		return
+	}
+
+	for _, keyID := range keyIDs {
+		retired, err := service.storage.RetireKey(keyID)
+		if err != nil {
+			service.logger.Errorf("orders: failed to retire rotated key %d (%s)", keyID, err)
+			continue
+		}
+
+		if retired {
+			service.logger.Infof("orders: retired rotated key %d", keyID)
+		} else {
+			service.logger.Debugf("orders: rotated key %d is still in use or was reassigned, not retired", keyID)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/metrics"
+	"certwarden-backend/pkg/pagination_sort"
+	"strconv"
+	"time"
+)
+
+// registerMetrics registers the orders gauges with the metrics service
+func (service *Service) registerMetrics() {
+	// days until each cert's newest valid order expires
+	service.metrics.RegisterGaugeFunc("certwarden_certificate_expiry_days",
+		"Days remaining until the certificate's newest valid order expires.",
+		service.certificateExpiryDaysSamples, "certificate_id", "certificate_name")
+
+	// job queue depths
+	service.metrics.RegisterGaugeFunc("certwarden_job_queue_depth",
+		"Number of order jobs currently being worked on or waiting to be worked on.",
+		service.jobQueueDepthSamples, "queue", "state")
+}
+
+// certificateExpiryDaysSamples returns a sample for every certificate that currently
+// has a valid order
+func (service *Service) certificateExpiryDaysSamples() []metrics.GaugeSample {
+	validOrders, _, err := service.storage.GetAllValidCurrentOrders(pagination_sort.Query{})
+	if err != nil {
+		service.logger.Errorf("orders: failed to get valid orders for metrics (%s)", err)
+		return nil
+	}
+
+	samples := []metrics.GaugeSample{}
+	for _, order := range validOrders {
+		if order.ValidTo == nil {
+			continue
+		}
+
+		samples = append(samples, metrics.GaugeSample{
+			LabelValues: []string{strconv.Itoa(order.Certificate.ID), order.Certificate.Name},
+			Value:       time.Until(*order.ValidTo).Hours() / 24,
+		})
+	}
+
+	return samples
+}
+
+// jobQueueDepthSamples returns the working and waiting job counts for the fulfilling
+// and post processing queues
+func (service *Service) jobQueueDepthSamples() []metrics.GaugeSample {
+	fulfilling := service.orderFulfilling.AllCurrentJobs()
+	postProcessing := service.postProcessing.AllCurrentJobs()
+
+	return []metrics.GaugeSample{
+		{LabelValues: []string{"fulfilling", "working"}, Value: float64(len(fulfilling.WorkingJobs))},
+		{LabelValues: []string{"fulfilling", "waiting"}, Value: float64(len(fulfilling.WaitingJobs))},
+		{LabelValues: []string{"post_processing", "working"}, Value: float64(len(postProcessing.WorkingJobs))},
+		{LabelValues: []string{"post_processing", "waiting"}, Value: float64(len(postProcessing.WaitingJobs))},
+	}
+}
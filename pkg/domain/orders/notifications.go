@@ -0,0 +1,106 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/metrics"
+	"certwarden-backend/pkg/notifications"
+	"certwarden-backend/pkg/pagination_sort"
+	"fmt"
+	"time"
+)
+
+// notifyOrderOutcome sends the appropriate notification for the outcome of an order
+// fulfillment attempt. err is the most recent error (if any) encountered while fulfilling.
+func (service *Service) notifyOrderOutcome(order Order, acmeOrder acme.Order, outcome string, err error) {
+	event := notifications.Event{
+		CertificateID:   order.Certificate.ID,
+		CertificateName: order.Certificate.Name,
+		OrderID:         order.ID,
+	}
+
+	if outcome == metrics.OrderOutcomeValid {
+		event.Type = notifications.EventOrderValid
+		event.Message = fmt.Sprintf("Order for certificate %s is valid.", order.Certificate.Name)
+	} else {
+		event.Type = notifications.EventOrderFailed
+		event.Message = fmt.Sprintf("Order for certificate %s failed (outcome: %s).", order.Certificate.Name, outcome)
+
+		// include the reason, if known
+		if acmeOrder.Error != nil {
+			event.Message += fmt.Sprintf(" ACME error: %s", acmeOrder.Error)
+		} else if err != nil {
+			event.Message += fmt.Sprintf(" Error: %s", err)
+		}
+	}
+
+	service.notifications.Notify(event)
+}
+
+// notifyPostProcessFailed sends a notification that post processing for order failed
+func (service *Service) notifyPostProcessFailed(order Order, reason string) {
+	service.notifications.Notify(notifications.Event{
+		Type:            notifications.EventPostProcessFailed,
+		CertificateID:   order.Certificate.ID,
+		CertificateName: order.Certificate.Name,
+		OrderID:         order.ID,
+		Message:         fmt.Sprintf("Post processing for certificate %s failed (%s).", order.Certificate.Name, reason),
+	})
+}
+
+// notifyDeploymentUnverified sends a notification that the deployment of order could
+// not be verified
+func (service *Service) notifyDeploymentUnverified(order Order, reason string) {
+	service.notifications.Notify(notifications.Event{
+		Type:            notifications.EventDeploymentUnverified,
+		CertificateID:   order.Certificate.ID,
+		CertificateName: order.Certificate.Name,
+		OrderID:         order.ID,
+		Message:         fmt.Sprintf("Deployment of certificate %s could not be verified (%s).", order.Certificate.Name, reason),
+	})
+}
+
+// notifyRenewalWindowNoNewOrder sends a notification that order is a cert's newest valid order, the
+// renewal window has started, and there is not yet a new order for the cert
+func (service *Service) notifyRenewalWindowNoNewOrder(order Order, ari *renewalInfo) {
+	service.notifications.Notify(notifications.Event{
+		Type:            notifications.EventRenewalWindowNoNewOrder,
+		CertificateID:   order.Certificate.ID,
+		CertificateName: order.Certificate.Name,
+		OrderID:         order.ID,
+		Message: fmt.Sprintf("Certificate %s is in its renewal window (%s to %s) but does not have a new order yet.",
+			order.Certificate.Name, ari.SuggestedWindow.Start.Format(time.RFC1123), ari.SuggestedWindow.End.Format(time.RFC1123)),
+	})
+}
+
+// certificateStatuses returns the status of every certificate's newest valid order for
+// the notifications digest
+func (service *Service) certificateStatuses() []notifications.CertificateStatus {
+	validOrders, _, err := service.storage.GetAllValidCurrentOrders(pagination_sort.Query{})
+	if err != nil {
+		service.logger.Errorf("orders: failed to get valid orders for notification digest (%s)", err)
+		return nil
+	}
+
+	statuses := []notifications.CertificateStatus{}
+	for _, order := range validOrders {
+		if order.ValidFrom == nil || order.ValidTo == nil {
+			continue
+		}
+
+		// if renewal info hasn't been populated yet, use the default window
+		ari := order.RenewalInfo
+		if ari == nil {
+			ari = MakeRenewalInfo(*order.ValidFrom, *order.ValidTo)
+		}
+
+		statuses = append(statuses, notifications.CertificateStatus{
+			CertificateID:      order.Certificate.ID,
+			CertificateName:    order.Certificate.Name,
+			OrderID:            order.ID,
+			ValidTo:            *order.ValidTo,
+			RenewalWindowStart: ari.SuggestedWindow.Start,
+		})
+	}
+
+	return statuses
+}
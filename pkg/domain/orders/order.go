@@ -16,26 +16,29 @@ import (
 // Order is a single ACME order object
 // Finalized key is included as the cert may change keys after an order is finalized.
 type Order struct {
-	ID             int
-	Certificate    certificates.Certificate
-	Location       string
-	Status         string
-	KnownRevoked   bool
-	Error          *acme.Error
-	Expires        *int
-	DnsIdentifiers []string
-	Authorizations []string
-	Finalize       string
-	FinalizedKey   *private_keys.Key
-	CertificateUrl *string
-	Pem            *string
-	ValidFrom      *time.Time
-	ValidTo        *time.Time
-	ChainRootCN    *string
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Profile        *string
-	RenewalInfo    *renewalInfo
+	ID              int
+	Certificate     certificates.Certificate
+	Location        string
+	Status          string
+	KnownRevoked    bool
+	Error           *acme.Error
+	Expires         *int
+	DnsIdentifiers  []string
+	IpIdentifiers   []string
+	Authorizations  []string
+	Finalize        string
+	FinalizedKey    *private_keys.Key
+	CertificateUrl  *string
+	Pem             *string
+	ValidFrom       *time.Time
+	ValidTo         *time.Time
+	ChainRootCN     *string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Profile         *string
+	RenewalInfo     *renewalInfo
+	DeployStatus    DeployStatus
+	DeployCheckedAt *time.Time
 }
 
 // orderSummaryResponse is a JSON response containing only
@@ -48,12 +51,15 @@ type orderSummaryResponse struct {
 	KnownRevoked      bool                            `json:"known_revoked"`
 	Error             *acme.Error                     `json:"error"`
 	DnsIdentifiers    []string                        `json:"dns_identifiers"`
+	IpIdentifiers     []string                        `json:"ip_identifiers"`
 	FinalizedKey      *orderKeySummaryResponse        `json:"finalized_key"`
 	ValidFrom         *int                            `json:"valid_from"`
 	ValidTo           *int                            `json:"valid_to"`
 	ChainRootCN       *string                         `json:"chain_root_cn"`
 	Profile           *string                         `json:"profile,omitempty"`
 	RenewalInfo       *renewalInfo                    `json:"renewal_info"`
+	DeployStatus      DeployStatus                    `json:"deploy_status"`
+	DeployCheckedAt   *int                            `json:"deploy_checked_at"`
 	CreatedAt         int64                           `json:"created_at"`
 	UpdatedAt         int64                           `json:"updated_at"`
 }
@@ -112,6 +118,11 @@ func (order Order) summaryResponse(service *Service) orderSummaryResponse {
 		validToUnix = &validToUnixVal
 	}
 
+	var deployCheckedAtUnix *int
+	if order.DeployCheckedAt != nil {
+		deployCheckedAtUnix = new(int(order.DeployCheckedAt.Unix()))
+	}
+
 	return orderSummaryResponse{
 		FulfillmentWorker: fulfillingWorker,
 		ID:                order.ID,
@@ -132,18 +143,21 @@ func (order Order) summaryResponse(service *Service) orderSummaryResponse {
 			ApiKeyViaUrl:    order.Certificate.ApiKeyViaUrl,
 			LastAccess:      order.Certificate.LastAccess.Unix(),
 		},
-		Status:         order.Status,
-		KnownRevoked:   order.KnownRevoked,
-		Error:          order.Error,
-		DnsIdentifiers: order.DnsIdentifiers,
-		FinalizedKey:   finalKey,
-		ValidFrom:      validFromUnix,
-		ValidTo:        validToUnix,
-		ChainRootCN:    order.ChainRootCN,
-		Profile:        order.Profile,
-		RenewalInfo:    order.RenewalInfo,
-		CreatedAt:      order.CreatedAt.Unix(),
-		UpdatedAt:      order.UpdatedAt.Unix(),
+		Status:          order.Status,
+		KnownRevoked:    order.KnownRevoked,
+		Error:           order.Error,
+		DnsIdentifiers:  order.DnsIdentifiers,
+		IpIdentifiers:   order.IpIdentifiers,
+		FinalizedKey:    finalKey,
+		ValidFrom:       validFromUnix,
+		ValidTo:         validToUnix,
+		ChainRootCN:     order.ChainRootCN,
+		Profile:         order.Profile,
+		RenewalInfo:     order.RenewalInfo,
+		DeployStatus:    order.DeployStatus,
+		DeployCheckedAt: deployCheckedAtUnix,
+		CreatedAt:       order.CreatedAt.Unix(),
+		UpdatedAt:       order.UpdatedAt.Unix(),
 	}
 }
 
@@ -230,13 +244,13 @@ func (service *Service) NewOrderPayload(cert certificates.Certificate) acme.NewO
 	var identifiers []acme.Identifier
 
 	// subject is always required and should be first
-	// dns is the only supported type and is hardcoded
-	identifiers = append(identifiers, acme.Identifier{Type: "dns", Value: cert.Subject})
+	// names that are ip addresses are ip identifiers (RFC 8738), the rest are dns
+	identifiers = append(identifiers, acme.NewIdentifier(cert.Subject))
 
 	// add alt names if they exist
 	if cert.SubjectAltNames != nil {
 		for _, name := range cert.SubjectAltNames {
-			identifiers = append(identifiers, acme.Identifier{Type: "dns", Value: name})
+			identifiers = append(identifiers, acme.NewIdentifier(name))
 		}
 	}
 
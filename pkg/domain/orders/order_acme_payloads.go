@@ -18,6 +18,7 @@ type NewOrderAcmePayload struct {
 	KnownRevoked   bool
 	Expires        *time.Time
 	DnsIds         []string
+	IpIds          []string
 	Error          *string
 	Authorizations []string
 	Finalize       string
@@ -42,6 +43,7 @@ func makeNewOrderAcmePayload(cert certificates.Certificate, acmeResponse acme.Or
 		KnownRevoked:   false,
 		Expires:        acmeResponse.Expires,
 		DnsIds:         acmeResponse.Identifiers.DnsIdentifiers(),
+		IpIds:          acmeResponse.Identifiers.IpIdentifiers(),
 		Error:          acmeErr,
 		Authorizations: acmeResponse.Authorizations,
 		Finalize:       acmeResponse.Finalize,
@@ -59,6 +61,7 @@ type UpdateAcmeOrderPayload struct {
 	Status         string
 	Expires        *time.Time
 	DnsIds         []string
+	IpIds          []string
 	Error          *string
 	Authorizations []string
 	Finalize       string
@@ -79,6 +82,7 @@ func makeUpdateOrderAcmePayload(orderId int, acmeResponse acme.Order) UpdateAcme
 		Status:         acmeResponse.Status,
 		Expires:        acmeResponse.Expires,
 		DnsIds:         acmeResponse.Identifiers.DnsIdentifiers(),
+		IpIds:          acmeResponse.Identifiers.IpIdentifiers(),
 		Error:          acmeErr,
 		Authorizations: acmeResponse.Authorizations,
 		Finalize:       acmeResponse.Finalize,
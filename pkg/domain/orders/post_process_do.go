@@ -1,5 +1,12 @@
 package orders
 
+import (
+	"certwarden-backend/pkg/datatypes/history"
+	"fmt"
+	"strings"
+	"time"
+)
+
 // Do actually runs the post processing task(s)
 func (j *postProcessJob) Do(workerID int) {
 	// get order
@@ -9,9 +16,33 @@ func (j *postProcessJob) Do(workerID int) {
 		return // done, failed
 	}
 
+	// history of this attempt
+	attemptStart := time.Now()
+	rec := history.NewRecorder()
+
 	// run client post processing
-	j.doClientPostProcess(order, workerID)
+	j.doClientPostProcess(order, workerID, rec)
 
 	// run command post processing
-	j.doScriptOrBinaryPostProcess(order, workerID)
+	j.doScriptOrBinaryPostProcess(order, workerID, rec)
+
+	// save history (failed if any step failed)
+	outcome := postProcessOutcomeSuccess
+	failures := []string{}
+	for _, step := range rec.Steps() {
+		if step.Error == "" {
+			continue
+		}
+		outcome = postProcessOutcomeFailed
+		failures = append(failures, fmt.Sprintf("%s: %s", step.Action, step.Error))
+	}
+	j.service.saveAttempt(order.ID, AttemptKindPostProcess, outcome, attemptStart, rec)
+
+	// notify once for all of the failures
+	if len(failures) > 0 {
+		j.service.notifyPostProcessFailed(order, strings.Join(failures, "; "))
+	}
+
+	// confirm the new cert was deployed (in the background, it can take a while)
+	j.service.startDeploymentVerification(order)
 }
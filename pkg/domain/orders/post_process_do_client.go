@@ -2,11 +2,13 @@ package orders
 
 import (
 	"bytes"
+	"certwarden-backend/pkg/datatypes/history"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -31,8 +33,9 @@ type postProcessClientPayload struct {
 }
 
 // doClientPostProcess sends a data payload to the client located
-// at certificate's CN, using the encryption key specified on certificate
-func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
+// at certificate's CN, using the encryption key specified on certificate. The result is
+// recorded to rec.
+func (j *postProcessJob) doClientPostProcess(order Order, workerID int, rec *history.Recorder) {
 	// no-op if no client key
 	if order.Certificate.PostProcessingClientKeyB64 == "" || order.Certificate.PostProcessingClientAddress == "" {
 		j.service.logger.Debugf("orders: post processing worker %d: order %d: skipping client notify (cert does not have a client address and/or client key) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
@@ -41,6 +44,21 @@ func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
 
 	j.service.logger.Infof("orders: post processing worker %d: order %d: attempting to notify client (cert: %d, cn: %s, addr: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
 
+	// record result in history
+	step := history.Step{
+		Action:     history.ActionPostProcessClient,
+		Identifier: order.Certificate.PostProcessingClientAddress,
+	}
+	var err error
+	defer func() {
+		if err != nil {
+			step.Status = postProcessOutcomeFailed
+		} else {
+			step.Status = postProcessOutcomeSuccess
+		}
+		rec.Add(step, err)
+	}()
+
 	// decode AES key
 	aesKey, err := base64.RawURLEncoding.DecodeString(order.Certificate.PostProcessingClientKeyB64)
 	if err != nil {
@@ -50,10 +68,18 @@ func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
 
 	// verify pem exists (should never trigger)
 	if order.Pem == nil || order.FinalizedKey == nil {
+		err = errors.New("order pem or finalized key is nil")
 		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: something really weird happened and pem content is nil (cert: %d, cn: %s, addr: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
 		return
 	}
 
+	// client requires the key pem (keys on hardware tokens aren't exportable)
+	if !order.FinalizedKey.Exportable() {
+		err = errors.New("private key is not exportable")
+		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: private key resides on a hardware token and is not exportable (cert: %d, cn: %s, addr: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		return
+	}
+
 	// make inner payload for client
 	innerPayload := postProcessInnerClientPayload{
 		KeyPem:  order.FinalizedKey.Pem,
@@ -104,7 +130,8 @@ func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
 	clientPort := postProcessClientPortDefault
 	clientAddrSplit := strings.Split(order.Certificate.PostProcessingClientAddress, ":")
 	if len(clientAddrSplit) == 2 {
-		portNumb, err := strconv.Atoi(clientAddrSplit[1])
+		var portNumb int
+		portNumb, err = strconv.Atoi(clientAddrSplit[1])
 		if err != nil {
 			j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to parse client port number (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
 			return
@@ -113,6 +140,7 @@ func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
 		clientAddress = clientAddrSplit[0]
 		clientPort = portNumb
 	} else if len(clientAddrSplit) > 2 {
+		err = errors.New("client address contains more than one colon")
 		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to parse client address/port (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
 		return
 	}
@@ -129,6 +157,7 @@ func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
 
 	// error if not 200
 	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("client responded with status %d", resp.StatusCode)
 		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: post status %d (cert: %d, cn: %s, addr: %s)", workerID, order.ID, resp.StatusCode, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
 		return
 	}
@@ -0,0 +1,41 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/datatypes/history"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/domain/private_keys/key_backend"
+	"encoding/base64"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestClientPostProcessKeyNotExportable checks that a key that can't be sent to the client
+// is recorded as a failed step
+func TestClientPostProcessKeyNotExportable(t *testing.T) {
+	j := &postProcessJob{
+		service: &Service{logger: zap.NewNop().Sugar()},
+	}
+
+	pem := "-----BEGIN CERTIFICATE-----"
+	order := Order{
+		Certificate: certificates.Certificate{
+			PostProcessingClientAddress: "client.example.com",
+			PostProcessingClientKeyB64:  base64.RawURLEncoding.EncodeToString(make([]byte, 32)),
+		},
+		FinalizedKey: &private_keys.Key{Backend: key_backend.TypePKCS11, BackendRef: "pkcs11:token=test;object=key"},
+		Pem:          &pem,
+	}
+
+	rec := history.NewRecorder()
+	j.doClientPostProcess(order, 0, rec)
+
+	steps := rec.Steps()
+	if len(steps) != 1 {
+		t.Fatalf("expected 1 recorded step, got %d", len(steps))
+	}
+	if steps[0].Status != postProcessOutcomeFailed || steps[0].Error == "" {
+		t.Errorf("expected failed step with error, got status '%s' and error '%s'", steps[0].Status, steps[0].Error)
+	}
+}
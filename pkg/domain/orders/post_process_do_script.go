@@ -2,6 +2,7 @@ package orders
 
 import (
 	"certwarden-backend/pkg/datatypes/environment"
+	"certwarden-backend/pkg/datatypes/history"
 	"errors"
 	"fmt"
 	"io"
@@ -12,8 +13,9 @@ import (
 )
 
 // doScriptOrBinaryPost executes the certificate's post processing command. if the cert
-// does not have a command, this is a no-op
-func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int) {
+// does not have a command, this is a no-op. The result (including exit code and output)
+// is recorded to rec.
+func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int, rec *history.Recorder) {
 	// no-op if no command
 	if order.Certificate.PostProcessingCommand == "" {
 		j.service.logger.Debugf("orders: post processing worker %d: order %d: skipping command (cert does not have a command to run) (cert: %d, cn: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject)
@@ -22,14 +24,29 @@ func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int)
 
 	j.service.logger.Infof("orders: post processing worker %d: order %d: attempting to run command (cert: %d, cn: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject)
 
+	// record result in history
+	step := history.Step{
+		Action:     history.ActionPostProcessScript,
+		Identifier: order.Certificate.PostProcessingCommand,
+	}
+	var err error
+	defer func() {
+		if err != nil {
+			step.Status = postProcessOutcomeFailed
+		} else {
+			step.Status = postProcessOutcomeSuccess
+		}
+		rec.Add(step, err)
+	}()
+
 	// nil checks
 	if order.Pem == nil {
-		err := fmt.Errorf("orders: post processing worker %d: order %d: command failed: order pem is nil (should never happen)", workerID, order.ID)
+		err = fmt.Errorf("orders: post processing worker %d: order %d: command failed: order pem is nil (should never happen)", workerID, order.ID)
 		j.service.logger.Error(err)
 		return
 	}
 	if order.FinalizedKey == nil {
-		err := fmt.Errorf("orders: post processing worker %d: order %d: command failed: finalized key no longer exists", workerID, order.ID)
+		err = fmt.Errorf("orders: post processing worker %d: order %d: command failed: finalized key no longer exists", workerID, order.ID)
 		j.service.logger.Error(err)
 		return
 	}
@@ -56,6 +73,12 @@ func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int)
 			val = order.FinalizedKey.Name
 
 		case "{{PRIVATE_KEY_PEM}}":
+			// keys on hardware tokens aren't exportable, don't run the command without the key
+			if !order.FinalizedKey.Exportable() {
+				err = fmt.Errorf("orders: post processing worker %d: order %d: command failed: environment param %s requires the private key pem but the private key resides on a hardware token and is not exportable", workerID, order.ID, key)
+				j.service.logger.Error(err)
+				return
+			}
 			val = order.FinalizedKey.Pem
 
 		case "{{CERTIFICATE_NAME}}":
@@ -112,6 +135,7 @@ func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int)
 		// try to run as script if it wasn't an octet-stream and didn't have shebang
 		// if app failed to get suitable default shell at startup, post processing will fail
 		if j.service.defaultShellPath == "" {
+			err = errors.New("no suitable default shell")
 			j.service.logger.Errorf("orders: post processing worker %d: order %d: commaind failed to run post processing script (no suitable default shell was found during startup)", workerID, order.ID)
 			return
 		}
@@ -130,10 +154,16 @@ func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int)
 	// run command
 	result, err := cmd.Output()
 	j.service.logger.Debugf("orders: post processing worker %d: order %d: command output: %s", workerID, order.ID, string(result))
+	step.Stdout = string(result)
+	if cmd.ProcessState != nil {
+		exitCode := cmd.ProcessState.ExitCode()
+		step.ExitCode = &exitCode
+	}
 	if err != nil {
 		// try to get stderr and log it too
 		exitErr := new(exec.ExitError)
 		if errors.As(err, &exitErr) {
+			step.Stderr = string(exitErr.Stderr)
 			j.service.logger.Errorf("orders: post processing worker %d: order %d: command std err: %s", workerID, order.ID, exitErr.Stderr)
 		}
 
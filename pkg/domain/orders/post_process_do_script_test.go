@@ -0,0 +1,47 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/datatypes/history"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/domain/private_keys/key_backend"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestScriptPostProcessKeyNotExportable checks that a command that needs the key pem is
+// not run (and is recorded as a failed step) if the key can't be exported
+func TestScriptPostProcessKeyNotExportable(t *testing.T) {
+	j := &postProcessJob{
+		service: &Service{logger: zap.NewNop().Sugar()},
+	}
+
+	pem := "-----BEGIN CERTIFICATE-----"
+	order := Order{
+		Certificate: certificates.Certificate{
+			PostProcessingCommand:     "/nonexistent/command",
+			PostProcessingEnvironment: []string{"KEY_PEM={{PRIVATE_KEY_PEM}}"},
+		},
+		FinalizedKey: &private_keys.Key{Backend: key_backend.TypePKCS11, BackendRef: "pkcs11:token=test;object=key"},
+		Pem:          &pem,
+	}
+
+	rec := history.NewRecorder()
+	j.doScriptOrBinaryPostProcess(order, 0, rec)
+
+	steps := rec.Steps()
+	if len(steps) != 1 {
+		t.Fatalf("expected 1 recorded step, got %d", len(steps))
+	}
+	if steps[0].Status != postProcessOutcomeFailed || steps[0].Error == "" {
+		t.Errorf("expected failed step with error, got status '%s' and error '%s'", steps[0].Status, steps[0].Error)
+	}
+	if !strings.Contains(steps[0].Error, "not exportable") {
+		t.Errorf("expected not exportable error, got '%s'", steps[0].Error)
+	}
+	if steps[0].ExitCode != nil {
+		t.Errorf("expected command not to run, got exit code %d", *steps[0].ExitCode)
+	}
+}
@@ -0,0 +1,192 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/datatypes/history"
+	"certwarden-backend/pkg/tlsprobe"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeployStatus is the result of verifying an order's certificate was deployed
+type DeployStatus string
+
+const (
+	// deployment verification not configured (or not yet run)
+	DeployStatusNone DeployStatus = ""
+	// verification is in progress
+	DeployStatusPending DeployStatus = "pending"
+	// every endpoint served the order's certificate
+	DeployStatusVerified DeployStatus = "verified"
+	// at least one endpoint did not serve the order's certificate within the window
+	DeployStatusUnverified DeployStatus = "unverified"
+)
+
+const (
+	// deployVerifyRetryInterval is how long to wait between checks of endpoints that are
+	// not yet serving the order's certificate
+	deployVerifyRetryInterval = 30 * time.Second
+	// deployVerifyProbeTimeout is the maximum time for a single endpoint check
+	deployVerifyProbeTimeout = 30 * time.Second
+)
+
+// startDeploymentVerification checks each of the certificate's monitored endpoints until
+// they all serve the order's certificate or the certificate's verification window passes.
+// Verification can take the whole window so it runs in its own go routine (tracked by the
+// shutdown wait group) instead of holding a post processing worker. If the cert does not
+// have deployment verification enabled, this is a no-op. The result of each endpoint is
+// saved as the order's verification attempt and the overall result is saved to the order.
+func (service *Service) startDeploymentVerification(order Order) {
+	// no-op if not enabled
+	if !order.Certificate.DeployVerification.Enabled {
+		service.logger.Debugf("orders: order %d: skipping deployment verification (not enabled) (cert: %d, cn: %s)", order.ID, order.Certificate.ID, order.Certificate.Subject)
+		return
+	}
+
+	service.logger.Infof("orders: order %d: verifying deployment (cert: %d, cn: %s)", order.ID, order.Certificate.ID, order.Certificate.Subject)
+	service.saveDeployStatus(order.ID, DeployStatusPending, nil)
+
+	service.shutdownWaitgroup.Add(1)
+	go func() {
+		defer service.shutdownWaitgroup.Done()
+
+		attemptStart := time.Now()
+		rec := history.NewRecorder()
+
+		status, reason := service.verifyDeployment(order, rec)
+		service.saveDeployStatus(order.ID, status, new(int(time.Now().Unix())))
+
+		if status != DeployStatusVerified {
+			service.saveAttempt(order.ID, AttemptKindVerifyDeployment, postProcessOutcomeFailed, attemptStart, rec)
+			service.logger.Errorf("orders: order %d: deployment verification failed (%s) (cert: %d, cn: %s)", order.ID, reason, order.Certificate.ID, order.Certificate.Subject)
+			service.notifyDeploymentUnverified(order, reason)
+			return
+		}
+
+		service.saveAttempt(order.ID, AttemptKindVerifyDeployment, postProcessOutcomeSuccess, attemptStart, rec)
+		service.logger.Infof("orders: order %d: deployment verified", order.ID)
+	}()
+}
+
+// verifyDeployment does the work of startDeploymentVerification and returns the resulting
+// status (and the reason, if not verified)
+func (service *Service) verifyDeployment(order Order, rec *history.Recorder) (DeployStatus, string) {
+	// order's leaf
+	if order.Pem == nil {
+		err := errors.New("order pem is nil")
+		rec.Add(history.Step{Action: history.ActionVerifyDeployment, Status: string(DeployStatusUnverified)}, err)
+		return DeployStatusUnverified, err.Error()
+	}
+	block, _ := pem.Decode([]byte(*order.Pem))
+	if block == nil {
+		err := errors.New("failed to decode order pem")
+		rec.Add(history.Step{Action: history.ActionVerifyDeployment, Status: string(DeployStatusUnverified)}, err)
+		return DeployStatusUnverified, err.Error()
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		err = fmt.Errorf("failed to parse order certificate (%s)", err)
+		rec.Add(history.Step{Action: history.ActionVerifyDeployment, Status: string(DeployStatusUnverified)}, err)
+		return DeployStatusUnverified, err.Error()
+	}
+
+	// endpoints to check
+	targets, err := service.storage.GetCertDeployTargets(order.Certificate.ID)
+	if err != nil {
+		err = fmt.Errorf("failed to get endpoints (%s)", err)
+		rec.Add(history.Step{Action: history.ActionVerifyDeployment, Status: string(DeployStatusUnverified)}, err)
+		return DeployStatusUnverified, err.Error()
+	}
+	if len(targets) == 0 {
+		err = errors.New("certificate does not have any monitored endpoints")
+		rec.Add(history.Step{Action: history.ActionVerifyDeployment, Status: string(DeployStatusUnverified)}, err)
+		return DeployStatusUnverified, err.Error()
+	}
+
+	// check until all endpoints serve the leaf or window passes
+	deadline := time.Now().Add(time.Duration(order.Certificate.DeployVerification.WindowMinutes) * time.Minute)
+	lastErrs := make(map[tlsprobe.Target]error, len(targets))
+	pending := targets
+checkLoop:
+	for {
+		stillPending := []tlsprobe.Target{}
+		for _, target := range pending {
+			err = service.checkDeployTarget(target, leaf)
+			if err != nil {
+				service.logger.Debugf("orders: order %d: endpoint %s not yet verified (%s)", order.ID, deployTargetIdentifier(target), err)
+				lastErrs[target] = err
+				stillPending = append(stillPending, target)
+				continue
+			}
+
+			rec.Add(history.Step{Action: history.ActionVerifyDeployment, Identifier: deployTargetIdentifier(target), Status: string(DeployStatusVerified)}, nil)
+		}
+		pending = stillPending
+
+		// done if none pending or out of time
+		if len(pending) == 0 || time.Now().Add(deployVerifyRetryInterval).After(deadline) {
+			break
+		}
+
+		select {
+		case <-service.shutdownContext.Done():
+			for _, target := range pending {
+				lastErrs[target] = errors.New("shutdown before endpoint was verified")
+			}
+			break checkLoop
+		case <-time.After(deployVerifyRetryInterval):
+			// retry
+		}
+	}
+
+	if len(pending) == 0 {
+		return DeployStatusVerified, ""
+	}
+
+	// record failures
+	failedAddrs := []string{}
+	for _, target := range pending {
+		rec.Add(history.Step{Action: history.ActionVerifyDeployment, Identifier: deployTargetIdentifier(target), Status: string(DeployStatusUnverified)}, lastErrs[target])
+		failedAddrs = append(failedAddrs, deployTargetIdentifier(target))
+	}
+
+	return DeployStatusUnverified, fmt.Sprintf("not serving the new certificate: %s", strings.Join(failedAddrs, ", "))
+}
+
+// deployTargetIdentifier returns a description of target for logging and history
+func deployTargetIdentifier(target tlsprobe.Target) string {
+	if target.ServerName != "" && target.ServerName != target.Host {
+		return fmt.Sprintf("%s (sni: %s)", target.Address(), target.ServerName)
+	}
+
+	return target.Address()
+}
+
+// checkDeployTarget returns an error if target is not serving leaf
+func (service *Service) checkDeployTarget(target tlsprobe.Target, leaf *x509.Certificate) error {
+	ctx, cancel := context.WithTimeout(service.shutdownContext, deployVerifyProbeTimeout)
+	defer cancel()
+
+	chain, err := tlsprobe.Probe(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	if chain[0].SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		return fmt.Errorf("served certificate serial %s does not match %s", chain[0].SerialNumber.Text(16), leaf.SerialNumber.Text(16))
+	}
+
+	return nil
+}
+
+// saveDeployStatus saves the deployment verification status of the order to storage
+func (service *Service) saveDeployStatus(orderID int, status DeployStatus, checkedAt *int) {
+	err := service.storage.PutOrderDeployStatus(orderID, status, checkedAt)
+	if err != nil {
+		service.logger.Errorf("orders: failed to save deployment status for order %d (%s)", orderID, err)
+	}
+}
@@ -0,0 +1,117 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/tlsprobe"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// verifyTestStorage records the deployment verification results (other Storage methods
+// are not used by verification and panic if called)
+type verifyTestStorage struct {
+	Storage
+	target tlsprobe.Target
+
+	mu       sync.Mutex
+	statuses []DeployStatus
+	attempts []NewOrderAttemptPayload
+}
+
+func (store *verifyTestStorage) GetCertDeployTargets(certId int) ([]tlsprobe.Target, error) {
+	return []tlsprobe.Target{store.target}, nil
+}
+
+func (store *verifyTestStorage) PutOrderDeployStatus(orderId int, status DeployStatus, checkedAt *int) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.statuses = append(store.statuses, status)
+	return nil
+}
+
+func (store *verifyTestStorage) PostOrderAttempt(payload NewOrderAttemptPayload) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.attempts = append(store.attempts, payload)
+	return nil
+}
+
+// TestStartDeploymentVerification checks that verification runs in the background (so it
+// doesn't hold a post processing worker) and is tracked by the shutdown wait group
+func TestStartDeploymentVerification(t *testing.T) {
+	// order's cert
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{SerialNumber: big.NewInt(1)}, &x509.Certificate{SerialNumber: big.NewInt(1)}, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	// endpoint that is never serving (nothing listening)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+
+	ctx, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+	store := &verifyTestStorage{target: tlsprobe.Target{Host: "127.0.0.1", Port: port}}
+	service := &Service{
+		shutdownContext:   ctx,
+		shutdownWaitgroup: new(sync.WaitGroup),
+		logger:            zap.NewNop().Sugar(),
+		storage:           store,
+	}
+
+	order := Order{
+		ID: 1,
+		Certificate: certificates.Certificate{
+			DeployVerification: certificates.DeployVerification{Enabled: true, WindowMinutes: 60},
+		},
+		Pem: &orderPem,
+	}
+
+	start := time.Now()
+	service.startDeploymentVerification(order)
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("deployment verification did not run in the background")
+	}
+
+	// shutdown ends verification
+	shutdown()
+	done := make(chan struct{})
+	go func() {
+		service.shutdownWaitgroup.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("deployment verification was not tracked by the shutdown wait group")
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.statuses) != 2 || store.statuses[0] != DeployStatusPending || store.statuses[1] != DeployStatusUnverified {
+		t.Errorf("unexpected deploy statuses %v", store.statuses)
+	}
+	if len(store.attempts) != 1 || store.attempts[0].Kind != AttemptKindVerifyDeployment || store.attempts[0].Outcome != postProcessOutcomeFailed {
+		t.Errorf("unexpected attempts %+v", store.attempts)
+	}
+}
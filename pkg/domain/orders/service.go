@@ -1,12 +1,18 @@
 package orders
 
 import (
+	"certwarden-backend/pkg/challenges"
 	"certwarden-backend/pkg/datatypes/job_manager"
+	"certwarden-backend/pkg/datatypes/safemap"
 	"certwarden-backend/pkg/domain/acme_servers"
 	"certwarden-backend/pkg/domain/authorizations"
 	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/metrics"
+	"certwarden-backend/pkg/notifications"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/tlsprobe"
 	"context"
 	"errors"
 	"net/http"
@@ -24,12 +30,15 @@ type App interface {
 	GetShutdownContext() context.Context
 	GetLogger() *zap.SugaredLogger
 	GetOutputter() *output.Service
+	GetMetrics() *metrics.Service
+	GetNotifications() *notifications.Service
 	GetOrderStorage() Storage
 	GetAcmeServerService() *acme_servers.Service
 	GetCertificatesService() *certificates.Service
 
 	// for fulfiller
 	GetAuthsService() *authorizations.Service
+	GetChallengesService() *challenges.Service
 	GetShutdownWaitGroup() *sync.WaitGroup
 
 	IsHttps() bool
@@ -59,18 +68,39 @@ type Storage interface {
 	GetAllIncompleteOrderIds() (orderIds []int, err error)
 	GetNewestIncompleteCertOrderId(certId int) (orderId int, err error)
 
+	// order history
+	PostOrderAttempt(payload NewOrderAttemptPayload) (err error)
+	GetOrderAttempts(orderId int) (attempts []OrderAttempt, err error)
+
 	// certs
 	UpdateCertUpdatedTime(certId int) (err error)
+
+	// key rotation
+	StageCertKeyRotation(payload StageKeyRotationPayload) (newKeyId int, err error)
+	GetCertKeyRotation(certId int) (rotation PendingKeyRotation, err error)
+	CompleteCertKeyRotation(payload CompleteKeyRotationPayload) (err error)
+	CancelCertKeyRotation(certId int) (err error)
+	GetOneKeyById(id int) (private_keys.Key, error)
+	GetDueKeyRetirements(unixTime int64) (keyIds []int, err error)
+	RetireKey(keyId int) (retired bool, err error)
+
+	// deployment verification
+	GetCertDeployTargets(certId int) (targets []tlsprobe.Target, err error)
+	PutOrderDeployStatus(orderId int, status DeployStatus, checkedAt *int) (err error)
 }
 
 // service struct
 type Service struct {
 	shutdownContext   context.Context
+	shutdownWaitgroup *sync.WaitGroup
 	logger            *zap.SugaredLogger
 	output            *output.Service
+	metrics           *metrics.Service
+	notifications     *notifications.Service
 	storage           Storage
 	acmeServerService *acme_servers.Service
 	authorizations    *authorizations.Service
+	challenges        *challenges.Service
 	certificates      *certificates.Service
 
 	serverCertificateName    *string
@@ -80,6 +110,10 @@ type Service struct {
 
 	postProcessing  *job_manager.Manager[*postProcessJob]
 	orderFulfilling *job_manager.Manager[*orderFulfillJob]
+
+	// valid order IDs that have already been notified as being in their renewal
+	// window without a new order
+	renewalWindowNotified *safemap.SafeMap[struct{}]
 }
 
 // NewService creates a new private_key service
@@ -88,6 +122,7 @@ func NewService(app App) (*Service, error) {
 
 	// shutdown context
 	service.shutdownContext = app.GetShutdownContext()
+	service.shutdownWaitgroup = app.GetShutdownWaitGroup()
 
 	// logger
 	service.logger = app.GetLogger()
@@ -101,6 +136,13 @@ func NewService(app App) (*Service, error) {
 		return nil, errServiceComponent
 	}
 
+	// metrics (may be nil)
+	service.metrics = app.GetMetrics()
+
+	// notifications (may be nil)
+	service.notifications = app.GetNotifications()
+	service.renewalWindowNotified = safemap.NewSafeMap[struct{}]()
+
 	// storage
 	service.storage = app.GetOrderStorage()
 	if service.storage == nil {
@@ -119,6 +161,12 @@ func NewService(app App) (*Service, error) {
 		return nil, errServiceComponent
 	}
 
+	// challenges (to retry with a fallback provider)
+	service.challenges = app.GetChallengesService()
+	if service.challenges == nil {
+		return nil, errServiceComponent
+	}
+
 	// certificates
 	service.certificates = app.GetCertificatesService()
 	if service.certificates == nil {
@@ -171,6 +219,12 @@ func NewService(app App) (*Service, error) {
 		return nil, errServiceComponent
 	}
 
+	// register gauges that are calculated when metrics are scraped
+	service.registerMetrics()
+
+	// provide certificate status for notification digests
+	service.notifications.RegisterCertificateStatusSource(service.certificateStatuses)
+
 	// start service to automatically place and complete orders
 	service.startAutoOrderService(app.GetShutdownContext(), app.GetShutdownWaitGroup())
 
@@ -1,6 +1,7 @@
 package private_keys
 
 import (
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"errors"
 	"fmt"
@@ -22,7 +23,7 @@ func (service *Service) DeleteKey(w http.ResponseWriter, r *http.Request) *outpu
 	}
 
 	// validate key exists
-	_, outErr := service.getKey(id)
+	key, outErr := service.getKey(id)
 	if outErr != nil {
 		return outErr
 	}
@@ -45,6 +46,7 @@ func (service *Service) DeleteKey(w http.ResponseWriter, r *http.Request) *outpu
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetPrivateKey, id, key.detailedResponse(), nil)
 
 	// write response
 	response := &output.JsonResponse{
@@ -87,6 +89,8 @@ func (service *Service) RemoveOldApiKey(w http.ResponseWriter, r *http.Request)
 	}
 	// validation -- end
 
+	before := key.detailedResponse()
+
 	// update storage
 	// set current api key from new key
 	err = service.storage.PutKeyApiKey(keyId, key.ApiKeyNew, int(time.Now().Unix()))
@@ -103,6 +107,7 @@ func (service *Service) RemoveOldApiKey(w http.ResponseWriter, r *http.Request)
 		return output.JsonErrStorageGeneric(err)
 	}
 	key.ApiKeyNew = ""
+	audit.RecordChange(r.Context(), audit.TargetPrivateKey, key.ID, before, key.detailedResponse())
 
 	// write response
 	response := &privateKeyResponse{}
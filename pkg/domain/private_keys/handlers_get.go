@@ -1,6 +1,7 @@
 package private_keys
 
 import (
+	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/pagination_sort"
@@ -85,6 +86,12 @@ func (service *Service) GetOneKey(w http.ResponseWriter, r *http.Request) *outpu
 	response.Message = "ok"
 	response.PrivateKey = key.detailedResponse()
 
+	// redact secrets the user's role doesn't permit access to
+	if !auth.ContextPermits(r.Context(), auth.PermissionDownload) {
+		response.PrivateKey.ApiKey = output.RedactString(response.PrivateKey.ApiKey)
+		response.PrivateKey.ApiKeyNew = output.RedactString(response.PrivateKey.ApiKeyNew)
+	}
+
 	// return response to client
 	err = service.output.WriteJSON(w, response)
 	if err != nil {
@@ -111,6 +118,12 @@ func (service *Service) DownloadOneKey(w http.ResponseWriter, r *http.Request) *
 		return outErr
 	}
 
+	// keys on hardware tokens can't be downloaded
+	if !key.Exportable() {
+		service.logger.Debug(ErrKeyNotExportable)
+		return output.JsonErrValidationFailed(ErrKeyNotExportable)
+	}
+
 	// return pem file to client
 	service.output.WritePem(w, r, key)
 
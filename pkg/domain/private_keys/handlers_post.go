@@ -1,6 +1,8 @@
 package private_keys
 
 import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/domain/private_keys/key_backend"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/randomness"
@@ -19,11 +21,14 @@ type NewPayload struct {
 	Description    *string `json:"description"`
 	AlgorithmValue *string `json:"algorithm_value"`
 	PemContent     *string `json:"pem"`
-	ApiKey         string  `json:"-"`
-	ApiKeyDisabled *bool   `json:"api_key_disabled"`
-	ApiKeyViaUrl   bool    `json:"-"`
-	CreatedAt      int     `json:"-"`
-	UpdatedAt      int     `json:"-"`
+	// PKCS11 references a key on a PKCS#11 token; if AlgorithmValue is also specified, a
+	// new key is generated on the token
+	PKCS11         *key_backend.PKCS11Reference `json:"pkcs11"`
+	ApiKey         string                       `json:"-"`
+	ApiKeyDisabled *bool                        `json:"api_key_disabled"`
+	ApiKeyViaUrl   bool                         `json:"-"`
+	CreatedAt      int                          `json:"-"`
+	UpdatedAt      int                          `json:"-"`
 }
 
 // PostNewKey creates a new private key and saves it to storage
@@ -49,7 +54,7 @@ func (service *Service) PostNewKey(w http.ResponseWriter, r *http.Request) *outp
 	}
 	// key add method
 	// error if no method specified
-	if (payload.AlgorithmValue == nil || *payload.AlgorithmValue == "") && (payload.PemContent == nil || *payload.PemContent == "") {
+	if (payload.AlgorithmValue == nil || *payload.AlgorithmValue == "") && (payload.PemContent == nil || *payload.PemContent == "") && payload.PKCS11 == nil {
 		service.logger.Debug(ErrKeyOptionNone)
 		return output.JsonErrValidationFailed(ErrKeyOptionNone)
 	}
@@ -58,9 +63,45 @@ func (service *Service) PostNewKey(w http.ResponseWriter, r *http.Request) *outp
 		service.logger.Debug(ErrKeyOptionMultiple)
 		return output.JsonErrValidationFailed(ErrKeyOptionMultiple)
 	}
+	// pkcs11 can be combined with algorithm (generate on token) but not pem
+	if payload.PKCS11 != nil && payload.PemContent != nil && *payload.PemContent != "" {
+		service.logger.Debug(ErrKeyOptionMultiple)
+		return output.JsonErrValidationFailed(ErrKeyOptionMultiple)
+	}
 	// generate or verify the key
-	// generate with algorithm, error if fails
-	if payload.AlgorithmValue != nil && *payload.AlgorithmValue != "" {
+	if payload.PKCS11 != nil {
+		// key resides on a pkcs11 token
+		if payload.PKCS11.ModulePath == "" || payload.PKCS11.Label == "" {
+			service.logger.Debug(ErrKeyPKCS11Bad)
+			return output.JsonErrValidationFailed(ErrKeyPKCS11Bad)
+		}
+
+		// generate on the token, if algorithm specified
+		if payload.AlgorithmValue != nil && *payload.AlgorithmValue != "" {
+			err = key_backend.GeneratePKCS11Key(*payload.PKCS11, key_crypto.AlgorithmByStorageValue(*payload.AlgorithmValue))
+			if err != nil {
+				service.logger.Debug(err)
+				return output.JsonErrValidationFailed(err)
+			}
+		}
+
+		// confirm key is usable and determine its algorithm
+		signer, err := key_backend.NewPKCS11Signer(*payload.PKCS11)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+		alg := key_crypto.AlgorithmByPublicKey(signer.Public())
+		if alg == key_crypto.UnknownAlgorithm {
+			service.logger.Debug(ErrKeyPKCS11Unsupported)
+			return output.JsonErrValidationFailed(ErrKeyPKCS11Unsupported)
+		}
+
+		// save the uri in place of the pem
+		payload.PemContent = new(payload.PKCS11.URI())
+		payload.AlgorithmValue = new(alg.StorageValue())
+	} else if payload.AlgorithmValue != nil && *payload.AlgorithmValue != "" {
+		// generate with algorithm, error if fails
 		// must initialize to avoid invalid address
 		payload.PemContent = new(string)
 		*payload.PemContent, err = key_crypto.AlgorithmByStorageValue(*payload.AlgorithmValue).GeneratePrivateKeyPem()
@@ -104,6 +145,7 @@ func (service *Service) PostNewKey(w http.ResponseWriter, r *http.Request) *outp
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetPrivateKey, newKey.ID, nil, newKey.detailedResponse())
 
 	// write response
 	response := &privateKeyResponse{}
@@ -159,7 +201,9 @@ func (service *Service) StageNewApiKey(w http.ResponseWriter, r *http.Request) *
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	before := key.detailedResponse()
 	key.ApiKeyNew = newApiKey
+	audit.RecordChange(r.Context(), audit.TargetPrivateKey, key.ID, before, key.detailedResponse())
 
 	// write response
 	response := &privateKeyResponse{}
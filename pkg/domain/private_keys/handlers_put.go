@@ -1,6 +1,7 @@
 package private_keys
 
 import (
+	"certwarden-backend/pkg/domain/app/audit"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"net/http"
@@ -44,7 +45,7 @@ func (service *Service) PutKeyUpdate(w http.ResponseWriter, r *http.Request) *ou
 
 	// validation
 	// id
-	_, outErr := service.getKey(payload.ID)
+	key, outErr := service.getKey(payload.ID)
 	if outErr != nil {
 		return outErr
 	}
@@ -75,6 +76,7 @@ func (service *Service) PutKeyUpdate(w http.ResponseWriter, r *http.Request) *ou
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
+	audit.RecordChange(r.Context(), audit.TargetPrivateKey, updatedKey.ID, key.detailedResponse(), updatedKey.detailedResponse())
 
 	// write response
 	response := &privateKeyResponse{}
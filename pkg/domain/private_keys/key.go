@@ -1,18 +1,22 @@
 package private_keys
 
 import (
+	"certwarden-backend/pkg/domain/private_keys/key_backend"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"crypto"
 	"fmt"
 	"time"
 )
 
-// Key is a single private key with all data
+// Key is a single private key with all data. Pem is only set for pem keys; keys on
+// other backends (e.g. a PKCS#11 token) instead have their reference in BackendRef.
 type Key struct {
 	ID             int
 	Name           string
 	Description    string
 	Algorithm      key_crypto.Algorithm
+	Backend        key_backend.Type
+	BackendRef     string
 	Pem            string
 	ApiKey         string
 	ApiKeyNew      string
@@ -30,6 +34,7 @@ type KeySummaryResponse struct {
 	Name           string               `json:"name"`
 	Description    string               `json:"description"`
 	Algorithm      key_crypto.Algorithm `json:"algorithm"`
+	Exportable     bool                 `json:"exportable"`
 	ApiKeyDisabled bool                 `json:"api_key_disabled"`
 	ApiKeyViaUrl   bool                 `json:"api_key_via_url"`
 	LastAccess     int64                `json:"last_access"`
@@ -41,6 +46,7 @@ func (key Key) SummaryResponse() KeySummaryResponse {
 		Name:           key.Name,
 		Description:    key.Description,
 		Algorithm:      key.Algorithm,
+		Exportable:     key.Exportable(),
 		ApiKeyDisabled: key.ApiKeyDisabled,
 		ApiKeyViaUrl:   key.ApiKeyViaUrl,
 		LastAccess:     key.LastAccess.Unix(),
@@ -85,8 +91,27 @@ func (key Key) Modtime() time.Time {
 
 // end Output Methods
 
+// Exportable returns true if the Key's pem is available for export (keys that reside on a
+// PKCS#11 token are never exportable)
+func (key Key) Exportable() bool {
+	return key.Backend == key_backend.TypePem
+}
+
 // CryptoPrivateKey() provides a crypto.PrivateKey for the Key
-// for the Account
+// for the Account. For keys that reside on a PKCS#11 token, it
+// is a crypto.Signer that uses the token.
 func (key *Key) CryptoPrivateKey() (cryptoKey crypto.PrivateKey, err error) {
-	return (key_crypto.PemStringToKey(key.Pem, key.Algorithm))
+	switch key.Backend {
+	case key_backend.TypePem:
+		return (key_crypto.PemStringToKey(key.Pem, key.Algorithm))
+
+	case key_backend.TypePKCS11:
+		ref, err := key_backend.ParsePKCS11URI(key.BackendRef)
+		if err != nil {
+			return nil, err
+		}
+		return key_backend.NewPKCS11Signer(ref)
+	}
+
+	return nil, fmt.Errorf("private key backend '%s' is not supported", key.Backend)
 }
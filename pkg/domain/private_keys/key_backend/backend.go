@@ -0,0 +1,21 @@
+package key_backend
+
+// Type is where a private key resides
+type Type string
+
+const (
+	// TypePem keys are stored in the database as a pem
+	TypePem Type = "pem"
+	// TypePKCS11 keys reside on a PKCS#11 token and are stored in the database as a
+	// PKCS#11 URI
+	TypePKCS11 Type = "pkcs11"
+)
+
+// TypeOf returns the Type of a key value, as it is saved in storage (a pem or a PKCS#11
+// URI)
+func TypeOf(storedKey string) Type {
+	if IsPKCS11URI(storedKey) {
+		return TypePKCS11
+	}
+	return TypePem
+}
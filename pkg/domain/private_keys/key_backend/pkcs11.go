@@ -0,0 +1,477 @@
+package key_backend
+
+import (
+	"bytes"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// PKCS#11 v3.0 constants that aren't defined by the pkcs11 package
+const (
+	ckkECEdwards           = 0x40
+	ckmECEdwardsKeyPairGen = 0x1055
+	ckmEdDSA               = 0x1057
+)
+
+// DER encoded curve OIDs (CKA_EC_PARAMS)
+var (
+	ecParamsP256    = []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}
+	ecParamsP384    = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x22}
+	ecParamsP521    = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x23}
+	ecParamsEd25519 = []byte{0x06, 0x03, 0x2b, 0x65, 0x70}
+	// some modules use the curve name instead of the oid
+	ecParamsEd25519Name = append([]byte{0x13, 0x0c}, []byte("edwards25519")...)
+)
+
+// rsaDigestInfoPrefixes are the DER DigestInfo prefixes for PKCS #1 v1.5 signatures
+var rsaDigestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+var (
+	errPKCS11Unsupported       = errors.New("pkcs11: not supported in this build")
+	errPKCS11KeyNotFound       = errors.New("pkcs11: private key object not found")
+	errPKCS11KeyNotUnique      = errors.New("pkcs11: more than one private key object has the specified label")
+	errPKCS11KeyExists         = errors.New("pkcs11: an object with the specified label already exists")
+	errPKCS11UnsupportedKey    = errors.New("pkcs11: unsupported key type")
+	errPKCS11UnsupportedHash   = errors.New("pkcs11: unsupported signature hash")
+	errPKCS11MalformedPubKey   = errors.New("pkcs11: malformed public key")
+	errPKCS11MalformedECDSASig = errors.New("pkcs11: malformed ecdsa signature")
+)
+
+// p11Attribute is a CK_ATTRIBUTE
+type p11Attribute struct {
+	attrType uint
+	value    []byte
+}
+
+// p11Mechanism is a CK_MECHANISM
+type p11Mechanism struct {
+	mechType uint
+	param    []byte
+}
+
+// p11Error is a PKCS#11 function call that returned something other than CKR_OK
+type p11Error struct {
+	function string
+	rv       uint
+}
+
+func (e p11Error) Error() string {
+	return fmt.Sprintf("pkcs11: %s failed (CKR 0x%X)", e.function, e.rv)
+}
+
+// p11Bool encodes v as a CK_BBOOL attribute value
+func p11Bool(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// pkcs11Token is a slot on a module; a single session is kept open (and logged in) for
+// each token and the mutex serializes its use
+type pkcs11Token struct {
+	module *p11Module
+	slotID uint
+	pin    string
+
+	mu         sync.Mutex
+	session    uint
+	hasSession bool
+}
+
+// withSession runs fn with the token's session, opening one (and logging in) if needed. If
+// fn fails due to the session being invalid (e.g. the token was removed and reinserted) it
+// is retried once with a new session.
+func (token *pkcs11Token) withSession(fn func(session uint) error) error {
+	token.mu.Lock()
+	defer token.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if !token.hasSession {
+			token.session, err = token.module.openSession(token.slotID)
+			if err != nil {
+				return err
+			}
+			token.hasSession = true
+
+			// blank pin means the token doesn't require login
+			if token.pin != "" {
+				err = token.module.login(token.session, token.pin)
+				if err != nil {
+					_ = token.module.closeSession(token.session)
+					token.hasSession = false
+					return err
+				}
+			}
+		}
+
+		err = fn(token.session)
+
+		p11Err := p11Error{}
+		if errors.As(err, &p11Err) {
+			switch p11Err.rv {
+			case pkcs11.CKR_SESSION_HANDLE_INVALID, pkcs11.CKR_SESSION_CLOSED, pkcs11.CKR_USER_NOT_LOGGED_IN, pkcs11.CKR_DEVICE_REMOVED, pkcs11.CKR_TOKEN_NOT_PRESENT:
+				_ = token.module.closeSession(token.session)
+				token.hasSession = false
+				continue
+			}
+		}
+
+		return err
+	}
+
+	return err
+}
+
+// findObject returns the handle of the single object of class with label
+func (token *pkcs11Token) findObject(session uint, class uint, label string) (uint, error) {
+	objects, err := token.module.findObjects(session, []p11Attribute{
+		{attrType: pkcs11.CKA_CLASS, value: p11Ulong(class)},
+		{attrType: pkcs11.CKA_LABEL, value: []byte(label)},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(objects) {
+	case 0:
+		return 0, errPKCS11KeyNotFound
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, errPKCS11KeyNotUnique
+	}
+}
+
+// pkcs11Signer is a crypto.Signer for a private key object on a PKCS#11 token
+type pkcs11Signer struct {
+	token     *pkcs11Token
+	label     string
+	publicKey crypto.PublicKey
+}
+
+// NewPKCS11Signer returns a crypto.Signer for the referenced private key object
+func NewPKCS11Signer(ref PKCS11Reference) (crypto.Signer, error) {
+	token, err := getPKCS11Token(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := &pkcs11Signer{
+		token: token,
+		label: ref.Label,
+	}
+
+	err = token.withSession(func(session uint) error {
+		signer.publicKey, err = token.readPublicKey(session, ref.Label)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return signer, nil
+}
+
+// readPublicKey returns the public key of the private key object with label. The public
+// key is read from the corresponding public key object (matched by CKA_ID, or by label if
+// the private key has no id); RSA keys can fall back to the private key object's attrs.
+func (token *pkcs11Token) readPublicKey(session uint, label string) (crypto.PublicKey, error) {
+	privKey, err := token.findObject(session, pkcs11.CKO_PRIVATE_KEY, label)
+	if err != nil {
+		return nil, err
+	}
+
+	keyTypeBytes, err := token.module.getAttribute(session, privKey, pkcs11.CKA_KEY_TYPE)
+	if err != nil {
+		return nil, err
+	}
+	keyType, err := p11DecodeUlong(keyTypeBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// find public key object
+	id, err := token.module.getAttribute(session, privKey, pkcs11.CKA_ID)
+	if err != nil {
+		return nil, err
+	}
+	template := []p11Attribute{{attrType: pkcs11.CKA_CLASS, value: p11Ulong(pkcs11.CKO_PUBLIC_KEY)}}
+	if len(id) > 0 {
+		template = append(template, p11Attribute{attrType: pkcs11.CKA_ID, value: id})
+	} else {
+		template = append(template, p11Attribute{attrType: pkcs11.CKA_LABEL, value: []byte(label)})
+	}
+	pubObjects, err := token.module.findObjects(session, template)
+	if err != nil {
+		return nil, err
+	}
+
+	pubObject := privKey
+	if len(pubObjects) > 0 {
+		pubObject = pubObjects[0]
+	} else if keyType != pkcs11.CKK_RSA {
+		return nil, fmt.Errorf("pkcs11: public key object for '%s' not found", label)
+	}
+
+	switch keyType {
+	case pkcs11.CKK_RSA:
+		modulus, err := token.module.getAttribute(session, pubObject, pkcs11.CKA_MODULUS)
+		if err != nil {
+			return nil, err
+		}
+		exponent, err := token.module.getAttribute(session, pubObject, pkcs11.CKA_PUBLIC_EXPONENT)
+		if err != nil {
+			return nil, err
+		}
+		e := new(big.Int).SetBytes(exponent)
+		if len(modulus) == 0 || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errPKCS11MalformedPubKey
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(e.Int64())}, nil
+
+	case pkcs11.CKK_EC, ckkECEdwards:
+		params, err := token.module.getAttribute(session, pubObject, pkcs11.CKA_EC_PARAMS)
+		if err != nil {
+			return nil, err
+		}
+		point, err := token.module.getAttribute(session, pubObject, pkcs11.CKA_EC_POINT)
+		if err != nil {
+			return nil, err
+		}
+
+		var curve elliptic.Curve
+		switch {
+		case bytes.Equal(params, ecParamsP256):
+			curve = elliptic.P256()
+		case bytes.Equal(params, ecParamsP384):
+			curve = elliptic.P384()
+		case bytes.Equal(params, ecParamsP521):
+			curve = elliptic.P521()
+		case bytes.Equal(params, ecParamsEd25519), bytes.Equal(params, ecParamsEd25519Name):
+			point = unwrapECPoint(point, ed25519.PublicKeySize)
+			if len(point) != ed25519.PublicKeySize {
+				return nil, errPKCS11MalformedPubKey
+			}
+			return ed25519.PublicKey(point), nil
+		default:
+			return nil, errPKCS11UnsupportedKey
+		}
+
+		point = unwrapECPoint(point, 1+2*((curve.Params().BitSize+7)/8))
+		pub, err := ecdsa.ParseUncompressedPublicKey(curve, point)
+		if err != nil {
+			return nil, errPKCS11MalformedPubKey
+		}
+		return pub, nil
+	}
+
+	return nil, errPKCS11UnsupportedKey
+}
+
+// unwrapECPoint returns the raw point from a CKA_EC_POINT value; the spec says the value is
+// a DER OCTET STRING but some modules return the raw point
+func unwrapECPoint(point []byte, rawLen int) []byte {
+	if len(point) == rawLen {
+		return point
+	}
+
+	var raw []byte
+	rest, err := asn1.Unmarshal(point, &raw)
+	if err != nil || len(rest) > 0 {
+		return point
+	}
+	return raw
+}
+
+// Public implements crypto.Signer
+func (signer *pkcs11Signer) Public() crypto.PublicKey {
+	return signer.publicKey
+}
+
+// Sign implements crypto.Signer; the token generates any needed randomness so rand is
+// unused
+func (signer *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var mech p11Mechanism
+	data := digest
+
+	switch signer.publicKey.(type) {
+	case *rsa.PublicKey:
+		if pssOpts, ok := opts.(*rsa.PSSOptions); ok {
+			var hashMech, mgf uint
+			switch pssOpts.Hash {
+			case crypto.SHA256:
+				hashMech, mgf = pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256
+			case crypto.SHA384:
+				hashMech, mgf = pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384
+			case crypto.SHA512:
+				hashMech, mgf = pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512
+			default:
+				return nil, errPKCS11UnsupportedHash
+			}
+
+			saltLength := pssOpts.SaltLength
+			if saltLength == rsa.PSSSaltLengthAuto || saltLength == rsa.PSSSaltLengthEqualsHash {
+				saltLength = pssOpts.Hash.Size()
+			}
+
+			// CK_RSA_PKCS_PSS_PARAMS
+			param := append(p11Ulong(hashMech), p11Ulong(mgf)...)
+			param = append(param, p11Ulong(uint(saltLength))...)
+			mech = p11Mechanism{mechType: pkcs11.CKM_RSA_PKCS_PSS, param: param}
+		} else {
+			prefix, ok := rsaDigestInfoPrefixes[opts.HashFunc()]
+			if !ok {
+				return nil, errPKCS11UnsupportedHash
+			}
+			data = append(append([]byte{}, prefix...), digest...)
+			mech = p11Mechanism{mechType: pkcs11.CKM_RSA_PKCS}
+		}
+
+	case *ecdsa.PublicKey:
+		mech = p11Mechanism{mechType: pkcs11.CKM_ECDSA}
+
+	case ed25519.PublicKey:
+		// pure Ed25519 signs the message itself
+		if opts.HashFunc() != crypto.Hash(0) {
+			return nil, errPKCS11UnsupportedHash
+		}
+		mech = p11Mechanism{mechType: ckmEdDSA}
+
+	default:
+		return nil, errPKCS11UnsupportedKey
+	}
+
+	var signature []byte
+	err := signer.token.withSession(func(session uint) error {
+		privKey, err := signer.token.findObject(session, pkcs11.CKO_PRIVATE_KEY, signer.label)
+		if err != nil {
+			return err
+		}
+
+		signature, err = signer.token.module.sign(session, privKey, mech, data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// PKCS#11 returns ecdsa signatures as r || s, crypto.Signer returns asn.1
+	if mech.mechType == pkcs11.CKM_ECDSA {
+		if len(signature) == 0 || len(signature)%2 != 0 {
+			return nil, errPKCS11MalformedECDSASig
+		}
+		half := len(signature) / 2
+		return asn1.Marshal(struct {
+			R, S *big.Int
+		}{
+			R: new(big.Int).SetBytes(signature[:half]),
+			S: new(big.Int).SetBytes(signature[half:]),
+		})
+	}
+
+	return signature, nil
+}
+
+// GeneratePKCS11Key generates a new, non-extractable, key pair of the specified algorithm
+// on the referenced token. The private and public key objects both use the reference's
+// label.
+func GeneratePKCS11Key(ref PKCS11Reference, alg key_crypto.Algorithm) error {
+	var mech p11Mechanism
+	var algPubAttrs []p11Attribute
+
+	switch alg {
+	case key_crypto.AlgorithmRSA2048, key_crypto.AlgorithmRSA3072, key_crypto.AlgorithmRSA4096:
+		bits := map[key_crypto.Algorithm]uint{
+			key_crypto.AlgorithmRSA2048: 2048,
+			key_crypto.AlgorithmRSA3072: 3072,
+			key_crypto.AlgorithmRSA4096: 4096,
+		}[alg]
+		mech = p11Mechanism{mechType: pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN}
+		algPubAttrs = []p11Attribute{
+			{attrType: pkcs11.CKA_MODULUS_BITS, value: p11Ulong(bits)},
+			{attrType: pkcs11.CKA_PUBLIC_EXPONENT, value: []byte{0x01, 0x00, 0x01}},
+		}
+
+	case key_crypto.AlgorithmECDSAp256:
+		mech = p11Mechanism{mechType: pkcs11.CKM_EC_KEY_PAIR_GEN}
+		algPubAttrs = []p11Attribute{{attrType: pkcs11.CKA_EC_PARAMS, value: ecParamsP256}}
+
+	case key_crypto.AlgorithmECDSAp384:
+		mech = p11Mechanism{mechType: pkcs11.CKM_EC_KEY_PAIR_GEN}
+		algPubAttrs = []p11Attribute{{attrType: pkcs11.CKA_EC_PARAMS, value: ecParamsP384}}
+
+	case key_crypto.AlgorithmECDSAp521:
+		mech = p11Mechanism{mechType: pkcs11.CKM_EC_KEY_PAIR_GEN}
+		algPubAttrs = []p11Attribute{{attrType: pkcs11.CKA_EC_PARAMS, value: ecParamsP521}}
+
+	case key_crypto.AlgorithmEd25519:
+		mech = p11Mechanism{mechType: ckmECEdwardsKeyPairGen}
+		algPubAttrs = []p11Attribute{{attrType: pkcs11.CKA_EC_PARAMS, value: ecParamsEd25519}}
+
+	default:
+		return errPKCS11UnsupportedKey
+	}
+
+	token, err := getPKCS11Token(ref)
+	if err != nil {
+		return err
+	}
+
+	// random id links the private and public objects
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	if err != nil {
+		return err
+	}
+
+	pubTemplate := append([]p11Attribute{
+		{attrType: pkcs11.CKA_CLASS, value: p11Ulong(pkcs11.CKO_PUBLIC_KEY)},
+		{attrType: pkcs11.CKA_TOKEN, value: p11Bool(true)},
+		{attrType: pkcs11.CKA_LABEL, value: []byte(ref.Label)},
+		{attrType: pkcs11.CKA_ID, value: id},
+		{attrType: pkcs11.CKA_VERIFY, value: p11Bool(true)},
+	}, algPubAttrs...)
+
+	privTemplate := []p11Attribute{
+		{attrType: pkcs11.CKA_CLASS, value: p11Ulong(pkcs11.CKO_PRIVATE_KEY)},
+		{attrType: pkcs11.CKA_TOKEN, value: p11Bool(true)},
+		{attrType: pkcs11.CKA_PRIVATE, value: p11Bool(true)},
+		{attrType: pkcs11.CKA_LABEL, value: []byte(ref.Label)},
+		{attrType: pkcs11.CKA_ID, value: id},
+		{attrType: pkcs11.CKA_SENSITIVE, value: p11Bool(true)},
+		{attrType: pkcs11.CKA_EXTRACTABLE, value: p11Bool(false)},
+		{attrType: pkcs11.CKA_SIGN, value: p11Bool(true)},
+	}
+
+	return token.withSession(func(session uint) error {
+		// don't create a duplicate label
+		_, err := token.findObject(session, pkcs11.CKO_PRIVATE_KEY, ref.Label)
+		if err == nil || errors.Is(err, errPKCS11KeyNotUnique) {
+			return errPKCS11KeyExists
+		} else if !errors.Is(err, errPKCS11KeyNotFound) {
+			return err
+		}
+
+		return token.module.generateKeyPair(session, mech, pubTemplate, privTemplate)
+	})
+}
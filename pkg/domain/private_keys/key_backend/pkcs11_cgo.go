@@ -0,0 +1,195 @@
+//go:build cgo
+
+package key_backend
+
+import "C"
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/miekg/pkcs11"
+)
+
+// p11UlongSize is the size of CK_ULONG on this platform
+var p11UlongSize = len(pkcs11.NewAttribute(0, uint(0)).Value)
+
+// p11Module is a loaded and initialized PKCS#11 module
+type p11Module struct {
+	ctx *pkcs11.Ctx
+}
+
+// p11Err wraps an error returned by the pkcs11 package as a p11Error
+func p11Err(function string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	rv := pkcs11.Error(0)
+	if errors.As(err, &rv) {
+		return p11Error{function: function, rv: uint(rv)}
+	}
+	return fmt.Errorf("pkcs11: %s failed (%w)", function, err)
+}
+
+// p11Open loads and initializes the PKCS#11 module at path. initParams is passed to
+// the module as the (module specific) reserved initialize argument, if not blank.
+func p11Open(path string, initParams string) (*p11Module, error) {
+	ctx := pkcs11.New(path)
+	if ctx == nil {
+		return nil, fmt.Errorf("pkcs11: failed to load module %s", path)
+	}
+
+	opts := []pkcs11.InitializeOption{}
+	if initParams != "" {
+		// modules may keep a reference to the params, so they are never freed
+		opts = append(opts, pkcs11.InitializeWithReserved(unsafe.Pointer(C.CString(initParams))))
+	}
+
+	err := ctx.Initialize(opts...)
+	if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		ctx.Destroy()
+		return nil, p11Err("C_Initialize", err)
+	}
+
+	return &p11Module{ctx: ctx}, nil
+}
+
+// attributes converts attrs to the pkcs11 package's type
+func attributes(attrs []p11Attribute) []*pkcs11.Attribute {
+	pAttrs := make([]*pkcs11.Attribute, len(attrs))
+	for i := range attrs {
+		pAttrs[i] = pkcs11.NewAttribute(attrs[i].attrType, attrs[i].value)
+	}
+	return pAttrs
+}
+
+// mechanism converts mech to the pkcs11 package's type
+func mechanism(mech p11Mechanism) []*pkcs11.Mechanism {
+	if len(mech.param) == 0 {
+		return []*pkcs11.Mechanism{pkcs11.NewMechanism(mech.mechType, nil)}
+	}
+	return []*pkcs11.Mechanism{pkcs11.NewMechanism(mech.mechType, mech.param)}
+}
+
+// openSession opens a read/write session on slot
+func (module *p11Module) openSession(slot uint) (session uint, err error) {
+	sh, err := module.ctx.OpenSession(slot, pkcs11.CKF_RW_SESSION|pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return 0, p11Err("C_OpenSession", err)
+	}
+	return uint(sh), nil
+}
+
+// closeSession closes session
+func (module *p11Module) closeSession(session uint) error {
+	return p11Err("C_CloseSession", module.ctx.CloseSession(pkcs11.SessionHandle(session)))
+}
+
+// login logs the normal user in to session's token; it is not an error if the user is
+// already logged in
+func (module *p11Module) login(session uint, pin string) error {
+	err := module.ctx.Login(pkcs11.SessionHandle(session), pkcs11.CKU_USER, pin)
+	if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		return p11Err("C_Login", err)
+	}
+	return nil
+}
+
+// initUserPin logs in as the security officer and sets the normal user's pin (this is used
+// to prepare new tokens)
+func (module *p11Module) initUserPin(session uint, soPin string, userPin string) error {
+	sh := pkcs11.SessionHandle(session)
+
+	err := module.ctx.Login(sh, pkcs11.CKU_SO, soPin)
+	if err != nil {
+		return p11Err("C_Login", err)
+	}
+	defer func() { _ = module.ctx.Logout(sh) }()
+
+	return p11Err("C_InitPIN", module.ctx.InitPIN(sh, userPin))
+}
+
+// findObjects returns the handles of all objects that match template
+func (module *p11Module) findObjects(session uint, template []p11Attribute) ([]uint, error) {
+	sh := pkcs11.SessionHandle(session)
+
+	err := module.ctx.FindObjectsInit(sh, attributes(template))
+	if err != nil {
+		return nil, p11Err("C_FindObjectsInit", err)
+	}
+	defer func() { _ = module.ctx.FindObjectsFinal(sh) }()
+
+	const batchSize = 16
+	objects := []uint{}
+	for {
+		batch, _, err := module.ctx.FindObjects(sh, batchSize)
+		if err != nil {
+			return nil, p11Err("C_FindObjects", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, object := range batch {
+			objects = append(objects, uint(object))
+		}
+	}
+
+	return objects, nil
+}
+
+// getAttribute returns the value of the specified attribute of object
+func (module *p11Module) getAttribute(session uint, object uint, attrType uint) ([]byte, error) {
+	attrs, err := module.ctx.GetAttributeValue(pkcs11.SessionHandle(session), pkcs11.ObjectHandle(object),
+		[]*pkcs11.Attribute{pkcs11.NewAttribute(attrType, nil)})
+	if err != nil {
+		return nil, p11Err("C_GetAttributeValue", err)
+	}
+	if len(attrs) != 1 || attrs[0].Value == nil {
+		return []byte{}, nil
+	}
+
+	return attrs[0].Value, nil
+}
+
+// sign signs data with key using mech
+func (module *p11Module) sign(session uint, key uint, mech p11Mechanism, data []byte) ([]byte, error) {
+	sh := pkcs11.SessionHandle(session)
+
+	err := module.ctx.SignInit(sh, mechanism(mech), pkcs11.ObjectHandle(key))
+	if err != nil {
+		return nil, p11Err("C_SignInit", err)
+	}
+
+	signature, err := module.ctx.Sign(sh, data)
+	if err != nil {
+		return nil, p11Err("C_Sign", err)
+	}
+
+	return signature, nil
+}
+
+// generateKeyPair generates a key pair on the session's token
+func (module *p11Module) generateKeyPair(session uint, mech p11Mechanism, pubTemplate []p11Attribute, privTemplate []p11Attribute) error {
+	_, _, err := module.ctx.GenerateKeyPair(pkcs11.SessionHandle(session), mechanism(mech),
+		attributes(pubTemplate), attributes(privTemplate))
+	return p11Err("C_GenerateKeyPair", err)
+}
+
+// p11Ulong encodes v as a CK_ULONG attribute value
+func p11Ulong(v uint) []byte {
+	return pkcs11.NewAttribute(0, v).Value
+}
+
+// p11DecodeUlong decodes a CK_ULONG attribute value
+func p11DecodeUlong(b []byte) (uint, error) {
+	if len(b) != p11UlongSize {
+		return 0, fmt.Errorf("pkcs11: invalid CK_ULONG length %d", len(b))
+	}
+	if p11UlongSize == 4 {
+		return uint(binary.NativeEndian.Uint32(b)), nil
+	}
+	return uint(binary.NativeEndian.Uint64(b)), nil
+}
@@ -0,0 +1,100 @@
+package key_backend
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+)
+
+// PKCS11ModuleConfig is the config for a PKCS#11 module that keys may reside on
+type PKCS11ModuleConfig struct {
+	// Path of the module shared library
+	Path string `yaml:"path"`
+	// Pin is the user pin, used for all of the module's slots (blank to skip login)
+	Pin string `yaml:"pin"`
+	// InitParams is an optional, module specific, string passed to C_Initialize (e.g. NSS
+	// softoken requires `configdir='sql:/path/to/db' flags=`)
+	InitParams string `yaml:"init_params"`
+}
+
+// PKCS11Config lists the PKCS#11 modules that may be used; keys can only reference
+// modules in this list
+type PKCS11Config struct {
+	Modules []PKCS11ModuleConfig `yaml:"modules"`
+}
+
+var errPKCS11ModuleNotConfigured = errors.New("pkcs11: module is not in the app's pkcs11 config")
+
+// pkcs11Registry holds the configured modules and the tokens that have been opened
+var pkcs11Registry = struct {
+	sync.Mutex
+	modules map[string]PKCS11ModuleConfig
+	loaded  map[string]*p11Module
+	tokens  map[PKCS11Reference]*pkcs11Token // keyed with Label blank
+}{}
+
+// ConfigurePKCS11 validates and sets the PKCS#11 config. Modules are not loaded until a
+// key that resides on one is used.
+func ConfigurePKCS11(cfg PKCS11Config) error {
+	modules := make(map[string]PKCS11ModuleConfig)
+	for i, moduleCfg := range cfg.Modules {
+		if moduleCfg.Path == "" || !filepath.IsAbs(moduleCfg.Path) {
+			return fmt.Errorf("pkcs11: module %d config invalid (path must be absolute)", i)
+		}
+		if _, exists := modules[moduleCfg.Path]; exists {
+			return fmt.Errorf("pkcs11: module %d config invalid (path %s is duplicated)", i, moduleCfg.Path)
+		}
+		modules[moduleCfg.Path] = moduleCfg
+	}
+
+	pkcs11Registry.Lock()
+	defer pkcs11Registry.Unlock()
+
+	pkcs11Registry.modules = modules
+	if pkcs11Registry.loaded == nil {
+		pkcs11Registry.loaded = make(map[string]*p11Module)
+	}
+	// reset tokens so updated pins take effect (modules stay loaded as they can't be safely
+	// re-initialized)
+	pkcs11Registry.tokens = make(map[PKCS11Reference]*pkcs11Token)
+
+	return nil
+}
+
+// getPKCS11Token returns the token for the reference's module and slot, loading the
+// module if needed
+func getPKCS11Token(ref PKCS11Reference) (*pkcs11Token, error) {
+	pkcs11Registry.Lock()
+	defer pkcs11Registry.Unlock()
+
+	moduleCfg, ok := pkcs11Registry.modules[ref.ModulePath]
+	if !ok {
+		return nil, errPKCS11ModuleNotConfigured
+	}
+
+	tokenKey := PKCS11Reference{ModulePath: ref.ModulePath, SlotID: ref.SlotID}
+	token, ok := pkcs11Registry.tokens[tokenKey]
+	if ok {
+		return token, nil
+	}
+
+	module, ok := pkcs11Registry.loaded[ref.ModulePath]
+	if !ok {
+		var err error
+		module, err = p11Open(moduleCfg.Path, moduleCfg.InitParams)
+		if err != nil {
+			return nil, err
+		}
+		pkcs11Registry.loaded[ref.ModulePath] = module
+	}
+
+	token = &pkcs11Token{
+		module: module,
+		slotID: ref.SlotID,
+		pin:    moduleCfg.Pin,
+	}
+	pkcs11Registry.tokens[tokenKey] = token
+
+	return token, nil
+}
@@ -0,0 +1,66 @@
+//go:build !cgo
+
+package key_backend
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// pkcs11 modules can only be loaded in cgo builds
+
+// p11UlongSize is the size of CK_ULONG on 64-bit systems
+const p11UlongSize = 8
+
+// p11Module is a loaded and initialized PKCS#11 module
+type p11Module struct{}
+
+// p11Open always returns an error in this build
+func p11Open(path string, initParams string) (*p11Module, error) {
+	return nil, errPKCS11Unsupported
+}
+
+func (module *p11Module) openSession(slot uint) (session uint, err error) {
+	return 0, errPKCS11Unsupported
+}
+
+func (module *p11Module) closeSession(session uint) error {
+	return errPKCS11Unsupported
+}
+
+func (module *p11Module) login(session uint, pin string) error {
+	return errPKCS11Unsupported
+}
+
+func (module *p11Module) initUserPin(session uint, soPin string, userPin string) error {
+	return errPKCS11Unsupported
+}
+
+func (module *p11Module) findObjects(session uint, template []p11Attribute) ([]uint, error) {
+	return nil, errPKCS11Unsupported
+}
+
+func (module *p11Module) getAttribute(session uint, object uint, attrType uint) ([]byte, error) {
+	return nil, errPKCS11Unsupported
+}
+
+func (module *p11Module) sign(session uint, key uint, mech p11Mechanism, data []byte) ([]byte, error) {
+	return nil, errPKCS11Unsupported
+}
+
+func (module *p11Module) generateKeyPair(session uint, mech p11Mechanism, pubTemplate []p11Attribute, privTemplate []p11Attribute) error {
+	return errPKCS11Unsupported
+}
+
+// p11Ulong encodes v as a CK_ULONG attribute value
+func p11Ulong(v uint) []byte {
+	return binary.NativeEndian.AppendUint64(nil, uint64(v))
+}
+
+// p11DecodeUlong decodes a CK_ULONG attribute value
+func p11DecodeUlong(b []byte) (uint, error) {
+	if len(b) != p11UlongSize {
+		return 0, fmt.Errorf("pkcs11: invalid CK_ULONG length %d", len(b))
+	}
+	return uint(binary.NativeEndian.Uint64(b)), nil
+}
@@ -0,0 +1,108 @@
+package key_backend
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// keys that reside on a PKCS#11 token are saved in storage (in place of the key pem) as an
+// RFC 7512 PKCS#11 URI that identifies the token object
+// e.g. pkcs11:slot-id=0;object=my-key;type=private?module-path=/usr/lib/softhsm/libsofthsm2.so
+
+const pkcs11URIScheme = "pkcs11:"
+
+var errPKCS11URIInvalid = errors.New("pkcs11: invalid uri")
+
+// PKCS11Reference identifies a private key object on a PKCS#11 token
+type PKCS11Reference struct {
+	ModulePath string `json:"module_path"`
+	SlotID     uint   `json:"slot_id"`
+	Label      string `json:"label"`
+}
+
+// IsPKCS11URI returns true if the stored key value is a PKCS#11 URI (as opposed to a
+// pem)
+func IsPKCS11URI(keyPemOrURI string) bool {
+	return strings.HasPrefix(keyPemOrURI, pkcs11URIScheme)
+}
+
+// URI returns the PKCS#11 URI for the reference
+func (ref PKCS11Reference) URI() string {
+	return fmt.Sprintf("%sslot-id=%d;object=%s;type=private?module-path=%s", pkcs11URIScheme,
+		ref.SlotID, pkcs11URIEscape(ref.Label), pkcs11URIEscape(ref.ModulePath))
+}
+
+// pkcs11URIEscape percent-encodes everything except RFC 7512 unreserved chars and '/'
+func pkcs11URIEscape(s string) string {
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '.' || c == '_' || c == '~' || c == '/' {
+			_ = b.WriteByte(c)
+		} else {
+			_, _ = fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// ParsePKCS11URI parses a PKCS#11 URI (as produced by URI) into a reference
+func ParsePKCS11URI(uri string) (PKCS11Reference, error) {
+	if !IsPKCS11URI(uri) {
+		return PKCS11Reference{}, errPKCS11URIInvalid
+	}
+
+	pathPart, queryPart, _ := strings.Cut(strings.TrimPrefix(uri, pkcs11URIScheme), "?")
+
+	attrs := map[string]string{}
+	parseAttrs := func(s string, sep string) error {
+		if s == "" {
+			return nil
+		}
+		for _, attr := range strings.Split(s, sep) {
+			name, rawValue, found := strings.Cut(attr, "=")
+			if !found {
+				return errPKCS11URIInvalid
+			}
+			value, err := url.PathUnescape(rawValue)
+			if err != nil {
+				return errPKCS11URIInvalid
+			}
+			attrs[name] = value
+		}
+		return nil
+	}
+
+	err := parseAttrs(pathPart, ";")
+	if err != nil {
+		return PKCS11Reference{}, err
+	}
+	err = parseAttrs(queryPart, "&")
+	if err != nil {
+		return PKCS11Reference{}, err
+	}
+
+	if attrs["type"] != "" && attrs["type"] != "private" {
+		return PKCS11Reference{}, fmt.Errorf("%w (object type must be private)", errPKCS11URIInvalid)
+	}
+
+	slotID, err := strconv.ParseUint(attrs["slot-id"], 10, 0)
+	if err != nil {
+		return PKCS11Reference{}, fmt.Errorf("%w (slot-id missing or invalid)", errPKCS11URIInvalid)
+	}
+
+	ref := PKCS11Reference{
+		ModulePath: attrs["module-path"],
+		SlotID:     uint(slotID),
+		Label:      attrs["object"],
+	}
+	if ref.ModulePath == "" || ref.Label == "" {
+		return PKCS11Reference{}, fmt.Errorf("%w (module-path and object must be specified)", errPKCS11URIInvalid)
+	}
+
+	return ref, nil
+}
@@ -20,6 +20,8 @@ const (
 	AlgorithmRSA4096
 	AlgorithmECDSAp256
 	AlgorithmECDSAp384
+	AlgorithmECDSAp521
+	AlgorithmEd25519
 )
 
 // Algorithm custom JSON Marshal (turns the Algorithm into exportable AlgorithmDetails
@@ -1,7 +1,11 @@
 package key_crypto
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
+	"crypto/rsa"
 	"crypto/x509"
 )
 
@@ -11,7 +15,7 @@ type algorithmDetails struct {
 	storageValue          string
 	name                  string
 	csrSignatureAlgorithm x509.SignatureAlgorithm
-	keyType               string                // rsa or ecdsa
+	keyType               string                // rsa, ecdsa, or ed25519
 	bitLen                int                   // rsa
 	ellipticCurveName     string                // ecdsa
 	ellipticCurveFunc     func() elliptic.Curve // ecdsa
@@ -60,6 +64,22 @@ var keyAlgorithmDetails = []algorithmDetails{
 		ellipticCurveName:     "P-384",
 		ellipticCurveFunc:     elliptic.P384,
 	},
+	{
+		algorithm:             AlgorithmECDSAp521,
+		storageValue:          "ecdsap521",
+		name:                  "ECDSA P-521",
+		csrSignatureAlgorithm: x509.ECDSAWithSHA512,
+		keyType:               "EC",
+		ellipticCurveName:     "P-521",
+		ellipticCurveFunc:     elliptic.P521,
+	},
+	{
+		algorithm:             AlgorithmEd25519,
+		storageValue:          "ed25519",
+		name:                  "Ed25519",
+		csrSignatureAlgorithm: x509.PureEd25519,
+		keyType:               "Ed25519",
+	},
 }
 
 // ListOfAlgorithms() returns a slice of all Algorithms
@@ -106,3 +126,18 @@ func ecdsaAlgorithmByCurve(curveName string) Algorithm {
 
 	return UnknownAlgorithm
 }
+
+// AlgorithmByPublicKey returns the Algorithm corresponding to the specified public
+// key; this is used to identify keys that are not available in pem format
+func AlgorithmByPublicKey(publicKey crypto.PublicKey) Algorithm {
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		return rsaAlgorithmByBits(pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		return ecdsaAlgorithmByCurve(pub.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return AlgorithmEd25519
+	}
+
+	return UnknownAlgorithm
+}
@@ -2,6 +2,7 @@ package key_crypto
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -20,6 +21,8 @@ func (alg Algorithm) GeneratePrivateKeyPem() (pem string, err error) {
 		pem, err = generateRSAPrivateKeyPem(algDetails.bitLen)
 	case "EC":
 		pem, err = generateECDSAPrivateKeyPem(algDetails.ellipticCurveFunc())
+	case "Ed25519":
+		pem, err = generateEd25519PrivateKeyPem()
 	default:
 		// if key type is not supported
 		err = errUnsupportedAlgorithm
@@ -83,3 +86,26 @@ func generateECDSAPrivateKeyPem(curve elliptic.Curve) (string, error) {
 
 	return string(privateKeyPem), nil
 }
+
+// generateEd25519PrivateKeyPem generates an Ed25519 key and returns the key in
+// PKCS8/PEM format
+func generateEd25519PrivateKeyPem() (string, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	privateKeyBlock := &pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: privateKeyBytes,
+	}
+
+	privateKeyPem := pem.EncodeToMemory(privateKeyBlock)
+
+	return string(privateKeyPem), nil
+}
@@ -3,6 +3,7 @@ package key_crypto
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -191,6 +192,13 @@ func pemStringDecode(keyPem string, alg Algorithm) (privKey crypto.PrivateKey, i
 			// success!
 			privKey = pkcs8Key
 
+		case ed25519.PrivateKey:
+			// Ed25519 has no size or curve variants
+			identifiedAlg = AlgorithmEd25519
+
+			// success!
+			privKey = pkcs8Key
+
 		default:
 			return nil, UnknownAlgorithm, errUnsupportedPem
 		}
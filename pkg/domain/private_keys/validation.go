@@ -17,6 +17,10 @@ var (
 
 	ErrKeyOptionNone     = errors.New("no key option method specified")
 	ErrKeyOptionMultiple = errors.New("multiple key option methods specified")
+
+	ErrKeyPKCS11Bad         = errors.New("pkcs11 key module_path and label must be specified")
+	ErrKeyPKCS11Unsupported = errors.New("pkcs11 key algorithm is not supported")
+	ErrKeyNotExportable     = errors.New("private key resides on a hardware token and is not exportable")
 )
 
 // getKey returns the Key for the specified id or an
@@ -0,0 +1,290 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// family is a single metric family (e.g. a counter with all of its label combinations)
+// that knows how to write itself in the Prometheus text exposition format
+type family interface {
+	write(w io.Writer) error
+}
+
+// labelSeparator is used to join label values into a single map key
+const labelSeparator = "\xff"
+
+// escapeLabelValue escapes a label value per the text exposition format
+func escapeLabelValue(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+// formatLabels returns the `{name="value",...}` portion of a sample line. extra
+// may contain an additional pre-formatted label (e.g. `le="0.5"`).
+func formatLabels(names []string, values []string, extra string) string {
+	if len(names) == 0 && extra == "" {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(names)+1)
+	for i := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, names[i], escapeLabelValue(values[i])))
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat formats a sample value
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// writeHeader writes the HELP and TYPE lines for a family
+func writeHeader(w io.Writer, name, help, metricType string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	return err
+}
+
+// counterVec is a counter partitioned by label values
+type counterVec struct {
+	name       string
+	help       string
+	labelNames []string
+	values     map[string]float64
+	mu         sync.Mutex
+}
+
+// newCounterVec creates a counterVec and registers it with service
+func (service *Service) newCounterVec(name, help string, labelNames ...string) *counterVec {
+	cv := &counterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]float64),
+	}
+
+	service.register(cv)
+	return cv
+}
+
+// add adds v to the counter with the specified label values
+func (cv *counterVec) add(v float64, labelValues ...string) {
+	if cv == nil || len(labelValues) != len(cv.labelNames) {
+		return
+	}
+
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	cv.values[strings.Join(labelValues, labelSeparator)] += v
+}
+
+// inc increments the counter with the specified label values by one
+func (cv *counterVec) inc(labelValues ...string) {
+	cv.add(1, labelValues...)
+}
+
+func (cv *counterVec) write(w io.Writer) error {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	err := writeHeader(w, cv.name, cv.help, "counter")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(cv.values) {
+		_, err = fmt.Fprintf(w, "%s%s %s\n", cv.name, formatLabels(cv.labelNames, splitKey(key, len(cv.labelNames)), ""), formatFloat(cv.values[key]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// histogram holds the observations for one set of label values
+type histogram struct {
+	bucketCounts []uint64
+	count        uint64
+	sum          float64
+}
+
+// histogramVec is a histogram partitioned by label values
+type histogramVec struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+	values     map[string]*histogram
+	mu         sync.Mutex
+}
+
+// newHistogramVec creates a histogramVec with the specified upper bucket bounds and
+// registers it with service
+func (service *Service) newHistogramVec(name, help string, buckets []float64, labelNames ...string) *histogramVec {
+	sort.Float64s(buckets)
+
+	hv := &histogramVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    buckets,
+		values:     make(map[string]*histogram),
+	}
+
+	service.register(hv)
+	return hv
+}
+
+// observe records v in the histogram with the specified label values
+func (hv *histogramVec) observe(v float64, labelValues ...string) {
+	if hv == nil || len(labelValues) != len(hv.labelNames) {
+		return
+	}
+
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+
+	key := strings.Join(labelValues, labelSeparator)
+	h, exists := hv.values[key]
+	if !exists {
+		h = &histogram{bucketCounts: make([]uint64, len(hv.buckets))}
+		hv.values[key] = h
+	}
+
+	for i, upperBound := range hv.buckets {
+		if v <= upperBound {
+			h.bucketCounts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (hv *histogramVec) write(w io.Writer) error {
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+
+	err := writeHeader(w, hv.name, hv.help, "histogram")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(hv.values) {
+		h := hv.values[key]
+		labelValues := splitKey(key, len(hv.labelNames))
+
+		for i, upperBound := range hv.buckets {
+			_, err = fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, formatLabels(hv.labelNames, labelValues, `le="`+formatFloat(upperBound)+`"`), h.bucketCounts[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			hv.name, formatLabels(hv.labelNames, labelValues, `le="+Inf"`), h.count,
+			hv.name, formatLabels(hv.labelNames, labelValues, ""), formatFloat(h.sum),
+			hv.name, formatLabels(hv.labelNames, labelValues, ""), h.count)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GaugeSample is a single gauge value and its label values
+type GaugeSample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// gaugeFunc is a gauge whose samples are gathered by calling collect at scrape time
+type gaugeFunc struct {
+	name       string
+	help       string
+	labelNames []string
+	collect    func() []GaugeSample
+}
+
+// RegisterGaugeFunc registers a gauge whose samples are calculated by calling collect
+// each time the metrics are scraped. collect should be reasonably fast and must be
+// safe for concurrent use.
+func (service *Service) RegisterGaugeFunc(name, help string, collect func() []GaugeSample, labelNames ...string) {
+	if service == nil || collect == nil {
+		return
+	}
+
+	service.register(&gaugeFunc{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		collect:    collect,
+	})
+}
+
+func (gf *gaugeFunc) write(w io.Writer) error {
+	err := writeHeader(w, gf.name, gf.help, "gauge")
+	if err != nil {
+		return err
+	}
+
+	for _, sample := range gf.collect() {
+		if len(sample.LabelValues) != len(gf.labelNames) {
+			continue
+		}
+
+		_, err = fmt.Fprintf(w, "%s%s %s\n", gf.name, formatLabels(gf.labelNames, sample.LabelValues, ""), formatFloat(sample.Value))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// register adds a family to service
+func (service *Service) register(f family) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.families = append(service.families, f)
+}
+
+// sortedKeys returns the keys of m in sorted order (for stable output)
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// splitKey splits a map key back into its label values
+func splitKey(key string, labelCount int) []string {
+	if labelCount == 0 {
+		return nil
+	}
+	return strings.Split(key, labelSeparator)
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestExposition checks the text exposition output of each family type
+func TestExposition(t *testing.T) {
+	service := new(Service)
+
+	cv := service.newCounterVec("test_total", "Test counter.", "a", "b")
+	cv.inc("x", `quote"back\slash`)
+	cv.add(2, "x", `quote"back\slash`)
+	cv.inc("only one label value") // ignored, wrong label count
+
+	hv := service.newHistogramVec("test_seconds", "Test histogram.", []float64{1, 0.5}, "c")
+	hv.observe(0.25, "y")
+	hv.observe(0.75, "y")
+	hv.observe(5, "y")
+
+	service.RegisterGaugeFunc("test_gauge", "Test gauge.", func() []GaugeSample {
+		return []GaugeSample{{LabelValues: []string{"z"}, Value: 1.5}}
+	}, "d")
+
+	buf := new(bytes.Buffer)
+	for _, f := range service.families {
+		err := f.write(buf)
+		if err != nil {
+			t.Fatalf("write failed (%s)", err)
+		}
+	}
+
+	expected := `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{a="x",b="quote\"back\\slash"} 3
+# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{c="y",le="0.5"} 1
+test_seconds_bucket{c="y",le="1"} 2
+test_seconds_bucket{c="y",le="+Inf"} 3
+test_seconds_sum{c="y"} 6
+test_seconds_count{c="y"} 3
+# HELP test_gauge Test gauge.
+# TYPE test_gauge gauge
+test_gauge{d="z"} 1.5
+`
+
+	if buf.String() != expected {
+		t.Errorf("unexpected exposition output\n got:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+// TestNilService ensures recording metrics on a nil service is a no-op
+func TestNilService(t *testing.T) {
+	var service *Service
+
+	service.RecordAcmeRequest("https://acme.example.com/directory", "GET", errors.New("failed"))
+	service.RecordOrderOutcome("Example", OrderOutcomeValid)
+	service.ObserveChallengeAction("http-01-internal", "tag", ChallengeActionProvision, time.Second, nil)
+	service.RegisterGaugeFunc("test_gauge", "Test gauge.", func() []GaugeSample { return nil })
+
+	if service.Enabled() {
+		t.Error("nil service should not be enabled")
+	}
+}
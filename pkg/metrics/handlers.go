@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"bytes"
+	"certwarden-backend/pkg/output"
+	"net/http"
+)
+
+// contentType is the content type of the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// GetMetrics writes all of the app's metrics in the Prometheus text exposition format
+func (service *Service) GetMetrics(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// render to a buffer first so a failure doesn't result in partial output
+	buf := new(bytes.Buffer)
+
+	service.mu.RLock()
+	families := service.families
+	service.mu.RUnlock()
+
+	for _, f := range families {
+		err := f.write(buf)
+		if err != nil {
+			service.logger.Errorf("metrics: failed to render metrics (%s)", err)
+			return output.JsonErrInternal(err)
+		}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(buf.Bytes())
+	if err != nil {
+		service.logger.Errorf("metrics: failed to write metrics (%s)", err)
+		// header already written, don't return an error
+	}
+
+	return nil
+}
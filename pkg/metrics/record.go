@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"time"
+)
+
+// order outcomes
+const (
+	OrderOutcomeValid   = "valid"
+	OrderOutcomeInvalid = "invalid"
+	OrderOutcomeError   = "error"
+	OrderOutcomeTimeout = "timeout"
+)
+
+// challenge actions
+const (
+	ChallengeActionProvision   = "provision"
+	ChallengeActionDeprovision = "deprovision"
+)
+
+// resultLabel returns the result label value for err
+func resultLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// RecordAcmeRequest records an HTTP request made to the ACME server with the
+// specified directory URL; err should be the error (if any) the request returned
+func (service *Service) RecordAcmeRequest(acmeDirectory, method string, err error) {
+	if service == nil {
+		return
+	}
+
+	service.acmeRequests.inc(acmeDirectory, method)
+	if err != nil {
+		service.acmeRequestErrors.inc(acmeDirectory, method)
+	}
+}
+
+// RecordOrderOutcome records the final result of an order fulfillment attempt
+func (service *Service) RecordOrderOutcome(acmeServer, outcome string) {
+	if service == nil {
+		return
+	}
+
+	service.ordersCompleted.inc(acmeServer, outcome)
+}
+
+// ObserveChallengeAction records how long a challenge provider took to provision or
+// deprovision a resource
+func (service *Service) ObserveChallengeAction(providerType, providerTag, action string, duration time.Duration, err error) {
+	if service == nil {
+		return
+	}
+
+	service.challengeActionsSecs.observe(duration.Seconds(), providerType, providerTag, action, resultLabel(err))
+}
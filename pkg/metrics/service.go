@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"certwarden-backend/pkg/output"
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+var errServiceComponent = errors.New("necessary metrics service component is missing")
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetOutputter() *output.Service
+}
+
+// Config holds the config for the metrics endpoint
+type Config struct {
+	Enabled     *bool  `yaml:"enabled"`
+	BearerToken string `yaml:"bearer_token"`
+}
+
+// Service is the metrics service; it holds all of the metrics collected by the
+// app and renders them in the Prometheus text exposition format
+type Service struct {
+	logger      *zap.SugaredLogger
+	output      *output.Service
+	enabled     bool
+	bearerToken string
+
+	// metric families, in the order they were registered
+	families []family
+	mu       sync.RWMutex
+
+	// app metrics
+	acmeRequests         *counterVec
+	acmeRequestErrors    *counterVec
+	ordersCompleted      *counterVec
+	challengeActionsSecs *histogramVec
+}
+
+// NewService creates a new metrics service
+func NewService(app App, cfg *Config) (*Service, error) {
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// output service
+	service.output = app.GetOutputter()
+	if service.output == nil {
+		return nil, errServiceComponent
+	}
+
+	// config
+	if cfg != nil {
+		service.enabled = cfg.Enabled != nil && *cfg.Enabled
+		service.bearerToken = cfg.BearerToken
+	}
+
+	// register the app's static metrics
+	service.acmeRequests = service.newCounterVec("certwarden_acme_requests_total",
+		"Total number of HTTP requests sent to ACME servers.", "acme_directory", "method")
+	service.acmeRequestErrors = service.newCounterVec("certwarden_acme_request_errors_total",
+		"Total number of HTTP requests sent to ACME servers that returned an error.", "acme_directory", "method")
+	service.ordersCompleted = service.newCounterVec("certwarden_orders_completed_total",
+		"Total number of order fulfillment attempts, by ACME server and outcome.", "acme_server", "outcome")
+	service.challengeActionsSecs = service.newHistogramVec("certwarden_challenge_action_duration_seconds",
+		"Time taken by challenge providers to provision or deprovision challenge resources.",
+		[]float64{0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		"provider_type", "provider_tag", "action", "result")
+
+	return service, nil
+}
+
+// Enabled returns if the metrics endpoint should be served
+func (service *Service) Enabled() bool {
+	return service != nil && service.enabled
+}
+
+// BearerToken returns the configured token that scrapers may use to access the
+// metrics endpoint, if one is configured
+func (service *Service) BearerToken() string {
+	if service == nil {
+		return ""
+	}
+	return service.bearerToken
+}
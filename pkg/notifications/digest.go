@@ -0,0 +1,136 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// digest defaults
+const DefaultDigestHour = 8
+const DefaultDigestExpiringDays = 14
+
+// CertificateStatus is the state of a certificate's newest valid order
+type CertificateStatus struct {
+	CertificateID      int
+	CertificateName    string
+	OrderID            int
+	ValidTo            time.Time
+	RenewalWindowStart time.Time
+}
+
+// digest is the daily email digest of certificates that need attention
+type digest struct {
+	hour         int
+	expiringDays int
+
+	// source returns the status of every certificate that currently has a valid order
+	source func() []CertificateStatus
+	mu     sync.RWMutex
+}
+
+// RegisterCertificateStatusSource sets the function that the daily digest uses to get the
+// current status of all certificates
+func (service *Service) RegisterCertificateStatusSource(source func() []CertificateStatus) {
+	if service == nil || service.digest == nil {
+		return
+	}
+
+	service.digest.mu.Lock()
+	defer service.digest.mu.Unlock()
+
+	service.digest.source = source
+}
+
+// digestItems returns the certificates that should be included in the digest, sorted
+// by soonest expiration
+func (d *digest) digestItems(now time.Time) []CertificateStatus {
+	d.mu.RLock()
+	source := d.source
+	d.mu.RUnlock()
+
+	if source == nil {
+		return nil
+	}
+
+	items := []CertificateStatus{}
+	for _, status := range source() {
+		inRenewalWindow := !now.Before(status.RenewalWindowStart)
+		expiringSoon := status.ValidTo.Before(now.Add(time.Duration(d.expiringDays) * 24 * time.Hour))
+
+		if inRenewalWindow || expiringSoon {
+			items = append(items, status)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ValidTo.Before(items[j].ValidTo)
+	})
+
+	return items
+}
+
+// digestBody returns the plain text body of a digest email containing items
+func digestBody(items []CertificateStatus, now time.Time) string {
+	body := strings.Builder{}
+	fmt.Fprintf(&body, "%d certificate(s) are in their renewal window or expiring soon.\n", len(items))
+
+	for _, item := range items {
+		fmt.Fprintf(&body, "\n%s (id: %d)\n", item.CertificateName, item.CertificateID)
+		fmt.Fprintf(&body, "  Valid To: %s (%.1f days)\n", item.ValidTo.Format(time.RFC1123), item.ValidTo.Sub(now).Hours()/24)
+		fmt.Fprintf(&body, "  Renewal Window Start: %s\n", item.RenewalWindowStart.Format(time.RFC1123))
+		fmt.Fprintf(&body, "  Newest Valid Order: %d\n", item.OrderID)
+	}
+
+	return body.String()
+}
+
+// sendDigest sends the digest email, if there is anything to include in it
+func (service *Service) sendDigest(ctx context.Context) error {
+	now := time.Now()
+
+	items := service.digest.digestItems(now)
+	if len(items) == 0 {
+		service.logger.Debug("notifications: no certificates need attention, digest email not sent")
+		return nil
+	}
+
+	subject := fmt.Sprintf("Cert Warden: %d Certificate(s) Need Attention", len(items))
+	return service.email.sendMail(ctx, subject, digestBody(items, now))
+}
+
+// startDigestService starts a go routine that sends the digest email once per day
+func (service *Service) startDigestService() {
+	service.logger.Infof("notifications: starting daily digest email service (hour: %d, expiring days: %d)", service.digest.hour, service.digest.expiringDays)
+
+	service.shutdownWaitgroup.Add(1)
+	go func() {
+		defer service.shutdownWaitgroup.Done()
+
+		for {
+			// next run at the configured hour (local time)
+			now := time.Now()
+			nextRun := time.Date(now.Year(), now.Month(), now.Day(), service.digest.hour, 0, 0, 0, now.Location())
+			if !nextRun.After(now) {
+				nextRun = nextRun.AddDate(0, 0, 1)
+			}
+
+			select {
+			case <-service.shutdownContext.Done():
+				service.logger.Info("notifications: daily digest email service shutdown complete")
+				return
+
+			case <-time.After(time.Until(nextRun)):
+				// proceed
+			}
+
+			err := service.sendDigest(service.shutdownContext)
+			if err != nil {
+				service.logger.Errorf("notifications: failed to send digest email (%s)", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,282 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// SMTPSecurity is the method used to secure the connection to the SMTP server
+type SMTPSecurity string
+
+const (
+	SMTPSecurityStartTLS SMTPSecurity = "starttls"
+	SMTPSecurityTLS      SMTPSecurity = "tls"
+	SMTPSecurityNone     SMTPSecurity = "none"
+)
+
+// email timeouts
+const (
+	smtpDialTimeout    = 30 * time.Second
+	smtpSessionTimeout = 2 * time.Minute
+)
+
+// defaultEmailEvents are the events immediately emailed if events are not specified in config
+var defaultEmailEvents = []EventType{EventOrderFailed, EventPostProcessFailed}
+
+// EmailConfig is the configuration of the SMTP email target
+type EmailConfig struct {
+	Enabled  *bool             `yaml:"enabled"`
+	Host     string            `yaml:"host"`
+	Port     *int              `yaml:"port"`
+	Security *SMTPSecurity     `yaml:"security"`
+	Username string            `yaml:"username"`
+	Password string            `yaml:"password"`
+	From     string            `yaml:"from"`
+	To       []string          `yaml:"to"`
+	Events   []EventType       `yaml:"events"`
+	Digest   EmailDigestConfig `yaml:"digest"`
+}
+
+// EmailDigestConfig is the configuration of the daily digest email
+type EmailDigestConfig struct {
+	Enabled      *bool `yaml:"enabled"`
+	Hour         *int  `yaml:"hour"`
+	ExpiringDays *int  `yaml:"expiring_days"`
+}
+
+// email is a notification target that sends events via SMTP
+type email struct {
+	host     string
+	port     int
+	security SMTPSecurity
+	auth     smtp.Auth
+	from     *mail.Address
+	to       []*mail.Address
+	events   []EventType
+
+	// tlsConfig is used for both STARTTLS and implicit TLS
+	tlsConfig *tls.Config
+}
+
+// newEmail validates cfg and returns an email target
+func newEmail(cfg EmailConfig) (*email, error) {
+	e := &email{
+		host:   cfg.Host,
+		events: cfg.Events,
+	}
+
+	if e.host == "" {
+		return nil, errors.New("host must be specified")
+	}
+
+	// security & port
+	e.security = SMTPSecurityStartTLS
+	if cfg.Security != nil {
+		e.security = *cfg.Security
+	}
+
+	switch e.security {
+	case SMTPSecurityStartTLS, SMTPSecurityNone:
+		e.port = 587
+	case SMTPSecurityTLS:
+		e.port = 465
+	default:
+		return nil, fmt.Errorf("security '%s' is not valid", e.security)
+	}
+	if cfg.Port != nil {
+		e.port = *cfg.Port
+	}
+	if e.port < 1 || e.port > 65535 {
+		return nil, fmt.Errorf("port %d is not valid", e.port)
+	}
+
+	e.tlsConfig = &tls.Config{
+		ServerName: e.host,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	// auth
+	if cfg.Username != "" {
+		e.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, e.host)
+	}
+
+	// addresses
+	var err error
+	e.from, err = mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("from address '%s' is not valid (%s)", cfg.From, err)
+	}
+
+	if len(cfg.To) == 0 {
+		return nil, errors.New("at least one to address must be specified")
+	}
+	for _, to := range cfg.To {
+		toAddr, err := mail.ParseAddress(to)
+		if err != nil {
+			return nil, fmt.Errorf("to address '%s' is not valid (%s)", to, err)
+		}
+		e.to = append(e.to, toAddr)
+	}
+
+	// events
+	if len(e.events) == 0 {
+		e.events = defaultEmailEvents
+	}
+	for _, et := range e.events {
+		if !et.isValid() {
+			return nil, fmt.Errorf("event '%s' is not valid", et)
+		}
+	}
+
+	return e, nil
+}
+
+func (e *email) name() string {
+	return "email"
+}
+
+func (e *email) wants(eventType EventType) bool {
+	return eventType == EventTest || slices.Contains(e.events, eventType)
+}
+
+// send emails event to all recipients
+func (e *email) send(ctx context.Context, event Event) error {
+	return e.sendMail(ctx, event.title(), event.text())
+}
+
+// makeMessage returns the complete message (headers and body) for the email
+func (e *email) makeMessage(subject, body string) ([]byte, error) {
+	msgID := make([]byte, 16)
+	_, err := rand.Read(msgID)
+	if err != nil {
+		return nil, err
+	}
+
+	toHeader := []string{}
+	for _, to := range e.to {
+		toHeader = append(toHeader, to.String())
+	}
+
+	msg := new(bytes.Buffer)
+	fmt.Fprintf(msg, "From: %s\r\n", e.from.String())
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(toHeader, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(msgID), e.host)
+	fmt.Fprint(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprint(msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprint(msg, "Content-Transfer-Encoding: quoted-printable\r\n")
+	fmt.Fprint(msg, "\r\n")
+
+	qpWriter := quotedprintable.NewWriter(msg)
+	_, err = qpWriter.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	if err != nil {
+		return nil, err
+	}
+	err = qpWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return msg.Bytes(), nil
+}
+
+// sendMail sends an email with the specified subject and plain text body to all recipients
+func (e *email) sendMail(ctx context.Context, subject, body string) error {
+	msg, err := e.makeMessage(subject, body)
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+
+	// connect
+	addr := net.JoinHostPort(e.host, strconv.Itoa(e.port))
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+
+	var conn net.Conn
+	if e.security == SMTPSecurityTLS {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: e.tlsConfig}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("smtp: failed to connect to %s (%w)", addr, err)
+	}
+
+	err = conn.SetDeadline(time.Now().Add(smtpSessionTimeout))
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("smtp: failed to start session (%w)", err)
+	}
+	defer c.Close()
+
+	// STARTTLS
+	if e.security == SMTPSecurityStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return backoff.Permanent(errors.New("smtp: server does not support STARTTLS"))
+		}
+
+		err = c.StartTLS(e.tlsConfig)
+		if err != nil {
+			return fmt.Errorf("smtp: starttls failed (%w)", err)
+		}
+	}
+
+	// auth
+	if e.auth != nil {
+		err = c.Auth(e.auth)
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("smtp: auth failed (%w)", err))
+		}
+	}
+
+	// envelope
+	err = c.Mail(e.from.Address)
+	if err != nil {
+		return fmt.Errorf("smtp: mail from failed (%w)", err)
+	}
+	for _, to := range e.to {
+		err = c.Rcpt(to.Address)
+		if err != nil {
+			return fmt.Errorf("smtp: rcpt to %s failed (%w)", to.Address, err)
+		}
+	}
+
+	// message
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: data failed (%w)", err)
+	}
+	_, err = w.Write(msg)
+	if err != nil {
+		return fmt.Errorf("smtp: failed to write message (%w)", err)
+	}
+	err = w.Close()
+	if err != nil {
+		return fmt.Errorf("smtp: message not accepted (%w)", err)
+	}
+
+	return c.Quit()
+}
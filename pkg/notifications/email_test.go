@@ -0,0 +1,273 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStandIn is a minimal in-process SMTP server that records the messages it
+// receives
+type smtpStandIn struct {
+	listener net.Listener
+	messages chan smtpMessage
+}
+
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// newSMTPStandIn starts a new stand-in server on a random local port
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen (%s)", err)
+	}
+
+	s := &smtpStandIn{
+		listener: l,
+		messages: make(chan smtpMessage, 10),
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { _ = l.Close() })
+
+	return s
+}
+
+func (s *smtpStandIn) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// serve handles a single SMTP session
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	write := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+	msg := smtpMessage{}
+	write("220 localhost ESMTP stand-in")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			write("250-localhost")
+			write("250 8BITMIME")
+
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg.from = angleAddr(line)
+			write("250 OK")
+
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.to = append(msg.to, angleAddr(line))
+			write("250 OK")
+
+		case cmd == "DATA":
+			write("354 go ahead")
+			data := strings.Builder{}
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			msg.data = data.String()
+			s.messages <- msg
+			msg = smtpMessage{}
+			write("250 OK queued")
+
+		case cmd == "QUIT":
+			write("221 bye")
+			return
+
+		default:
+			write("502 not implemented")
+		}
+	}
+}
+
+// angleAddr returns the address inside the angle brackets of an SMTP command
+func angleAddr(line string) string {
+	_, addr, _ := strings.Cut(line, "<")
+	addr, _, _ = strings.Cut(addr, ">")
+	return addr
+}
+
+// testEmail makes an email target that sends to s
+func testEmail(t *testing.T, s *smtpStandIn) *email {
+	port := s.port()
+	security := SMTPSecurityNone
+
+	e, err := newEmail(EmailConfig{
+		Host:     "127.0.0.1",
+		Port:     &port,
+		Security: &security,
+		From:     "Cert Warden <certwarden@example.com>",
+		To:       []string{"ops@example.com", "Admin <admin@example.com>"},
+	})
+	if err != nil {
+		t.Fatalf("failed to make email target (%s)", err)
+	}
+
+	return e
+}
+
+// receive waits for the stand-in to receive a message and parses it
+func (s *smtpStandIn) receive(t *testing.T) (smtpMessage, *mail.Message, string) {
+	select {
+	case msg := <-s.messages:
+		parsed, err := mail.ReadMessage(strings.NewReader(msg.data))
+		if err != nil {
+			t.Fatalf("failed to parse message (%s)", err)
+		}
+
+		body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+		if err != nil {
+			t.Fatalf("failed to read message body (%s)", err)
+		}
+
+		return msg, parsed, strings.ReplaceAll(string(body), "\r\n", "\n")
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+
+	return smtpMessage{}, nil, ""
+}
+
+// TestEmailSend sends an event email to the stand-in and checks what was received
+func TestEmailSend(t *testing.T) {
+	s := newSMTPStandIn(t)
+	e := testEmail(t, s)
+
+	if !e.wants(EventOrderFailed) || !e.wants(EventPostProcessFailed) || e.wants(EventOrderValid) {
+		t.Error("default email events not applied correctly")
+	}
+
+	err := e.send(context.Background(), Event{
+		Type:            EventOrderFailed,
+		CertificateID:   7,
+		CertificateName: "my-cert",
+		OrderID:         99,
+		Message:         "Order for certificate my-cert failed (outcome: invalid).",
+	})
+	if err != nil {
+		t.Fatalf("send failed (%s)", err)
+	}
+
+	msg, parsed, body := s.receive(t)
+
+	if msg.from != "certwarden@example.com" {
+		t.Errorf("unexpected envelope from: %s", msg.from)
+	}
+	if strings.Join(msg.to, ",") != "ops@example.com,admin@example.com" {
+		t.Errorf("unexpected envelope to: %s", msg.to)
+	}
+	if subject := parsed.Header.Get("Subject"); subject != "Cert Warden: Order Failed" {
+		t.Errorf("unexpected subject: %s", subject)
+	}
+	if !strings.Contains(body, "Certificate: my-cert (id: 7)\nOrder: 99") {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
+
+// TestEmailDigest checks which certs are included in the digest and that it is sent
+func TestEmailDigest(t *testing.T) {
+	s := newSMTPStandIn(t)
+	now := time.Now()
+
+	service := &Service{
+		email: testEmail(t, s),
+		digest: &digest{
+			hour:         DefaultDigestHour,
+			expiringDays: 10,
+		},
+	}
+	service.RegisterCertificateStatusSource(func() []CertificateStatus {
+		return []CertificateStatus{
+			// not in window, not expiring
+			{CertificateID: 1, CertificateName: "fine", ValidTo: now.Add(60 * 24 * time.Hour), RenewalWindowStart: now.Add(30 * 24 * time.Hour)},
+			// in window
+			{CertificateID: 2, CertificateName: "in-window", ValidTo: now.Add(20 * 24 * time.Hour), RenewalWindowStart: now.Add(-time.Hour)},
+			// expiring within 10 days, not in window
+			{CertificateID: 3, CertificateName: "expiring", ValidTo: now.Add(5 * 24 * time.Hour), RenewalWindowStart: now.Add(time.Hour)},
+		}
+	})
+
+	items := service.digest.digestItems(now)
+	if len(items) != 2 || items[0].CertificateID != 3 || items[1].CertificateID != 2 {
+		t.Fatalf("unexpected digest items: %+v", items)
+	}
+
+	err := service.sendDigest(context.Background())
+	if err != nil {
+		t.Fatalf("send digest failed (%s)", err)
+	}
+
+	_, parsed, body := s.receive(t)
+	if subject := parsed.Header.Get("Subject"); subject != "Cert Warden: 2 Certificate(s) Need Attention" {
+		t.Errorf("unexpected subject: %s", subject)
+	}
+	if !strings.Contains(body, "expiring (id: 3)") || !strings.Contains(body, "in-window (id: 2)") || strings.Contains(body, "fine") {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
+
+// TestEmailConfig checks email config validation
+func TestEmailConfig(t *testing.T) {
+	badSecurity := SMTPSecurity("ssl3")
+	badPort := 0
+
+	testCases := []EmailConfig{
+		{From: "a@example.com", To: []string{"b@example.com"}},                                       // no host
+		{Host: "mail", From: "not an address", To: []string{"b@example.com"}},                        // bad from
+		{Host: "mail", From: "a@example.com"},                                                        // no to
+		{Host: "mail", From: "a@example.com", To: []string{"b@example.com"}, Security: &badSecurity}, // bad security
+		{Host: "mail", From: "a@example.com", To: []string{"b@example.com"}, Port: &badPort},         // bad port
+	}
+
+	for i, cfg := range testCases {
+		_, err := newEmail(cfg)
+		if err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+
+	implicitTLS := SMTPSecurityTLS
+	e, err := newEmail(EmailConfig{Host: "mail", From: "a@example.com", To: []string{"b@example.com"}, Security: &implicitTLS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.port != 465 {
+		t.Errorf("implicit tls port should default to 465, got %d", e.port)
+	}
+}
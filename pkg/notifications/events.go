@@ -0,0 +1,101 @@
+package notifications
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// EventType is the type of event a notification is being sent for
+type EventType string
+
+const (
+	EventOrderFailed             EventType = "order_failed"
+	EventOrderValid              EventType = "order_valid"
+	EventPostProcessFailed       EventType = "post_process_failed"
+	EventRenewalWindowNoNewOrder EventType = "renewal_window_no_new_order"
+	EventDeploymentUnverified    EventType = "deployment_unverified"
+
+	// EventTest is only sent by the test endpoint; it is always delivered regardless
+	// of a target's event filter
+	EventTest EventType = "test"
+)
+
+// allEventTypes are the event types that can be subscribed to
+var allEventTypes = []EventType{
+	EventOrderFailed,
+	EventOrderValid,
+	EventPostProcessFailed,
+	EventRenewalWindowNoNewOrder,
+	EventDeploymentUnverified,
+}
+
+// isValid returns true if the event type can be subscribed to
+func (et EventType) isValid() bool {
+	return slices.Contains(allEventTypes, et)
+}
+
+// title returns a short human readable description of the event type
+func (et EventType) title() string {
+	switch et {
+	case EventOrderFailed:
+		return "Order Failed"
+	case EventOrderValid:
+		return "Order Valid"
+	case EventPostProcessFailed:
+		return "Post Processing Failed"
+	case EventRenewalWindowNoNewOrder:
+		return "Renewal Window Without New Order"
+	case EventDeploymentUnverified:
+		return "Deployment Unverified"
+	case EventTest:
+		return "Test Notification"
+	default:
+		return string(et)
+	}
+}
+
+// Event is a single occurrence of something notification targets may want to know about
+type Event struct {
+	Type            EventType `json:"type"`
+	Time            time.Time `json:"-"`
+	CertificateID   int       `json:"certificate_id,omitempty"`
+	CertificateName string    `json:"certificate_name,omitempty"`
+	OrderID         int       `json:"order_id,omitempty"`
+	Message         string    `json:"message"`
+}
+
+// eventJSON is the generic JSON representation of an Event
+type eventJSON struct {
+	Event
+	Title     string `json:"title"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// toJSON returns the struct that is used for generic JSON output of Event
+func (e Event) toJSON() eventJSON {
+	return eventJSON{
+		Event:     e,
+		Title:     e.Type.title(),
+		Timestamp: e.Time.Unix(),
+	}
+}
+
+// title returns the title for the event
+func (e Event) title() string {
+	return "Cert Warden: " + e.Type.title()
+}
+
+// text returns a plain text description of the event
+func (e Event) text() string {
+	text := e.Message
+
+	if e.CertificateName != "" {
+		text += fmt.Sprintf("\nCertificate: %s (id: %d)", e.CertificateName, e.CertificateID)
+	}
+	if e.OrderID != 0 {
+		text += fmt.Sprintf("\nOrder: %d", e.OrderID)
+	}
+
+	return text
+}
@@ -0,0 +1,146 @@
+package notifications
+
+import (
+	"certwarden-backend/pkg/output"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// testPayload is the payload to send a test notification
+type testPayload struct {
+	Name *string `json:"name"`
+}
+
+// testResult is the result of sending the test notification to one target
+type testResult struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// testResponse is the response to sending test notifications
+type testResponse struct {
+	output.JsonResponse
+	Results []testResult `json:"results"`
+}
+
+// PostSendTest sends a test notification to the named target, or to all targets if
+// no name is specified. Test sends are done synchronously and are not retried so the
+// result can be returned to the client.
+func (service *Service) PostSendTest(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload testPayload
+
+	// decode body into payload (empty body is permitted)
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil && !errors.Is(err, io.EOF) {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// select target(s)
+	targets := []target{}
+	for _, t := range service.targets {
+		if payload.Name == nil || *payload.Name == t.name() {
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) == 0 {
+		err = errors.New("no matching notification target(s)")
+		service.logger.Debug(err)
+		return output.JsonErrNotFound(err)
+	}
+
+	event := Event{
+		Type:    EventTest,
+		Time:    time.Now(),
+		Message: "This is a test notification from Cert Warden.",
+	}
+
+	// send
+	response := &testResponse{}
+	for _, t := range targets {
+		result := testResult{Name: t.name(), Success: true}
+
+		err = t.send(r.Context(), event)
+		if err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			service.logger.Infof("notifications: test send to %s failed (%s)", t.name(), err)
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	// write response
+	response.StatusCode = http.StatusOK
+	response.Message = fmt.Sprintf("sent test notification to %d target(s)", len(targets))
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// testEmailPayload is the payload to send a test email
+type testEmailPayload struct {
+	Digest bool `json:"digest"`
+}
+
+// PostSendTestEmail sends a test email, or if digest is true, sends the digest email
+// immediately
+func (service *Service) PostSendTestEmail(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload testEmailPayload
+
+	// decode body into payload (empty body is permitted)
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil && !errors.Is(err, io.EOF) {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	if service.email == nil {
+		err = errors.New("email notifications are not enabled")
+		service.logger.Debug(err)
+		return output.JsonErrNotFound(err)
+	}
+
+	if payload.Digest {
+		if service.digest == nil {
+			err = errors.New("email digest is not enabled")
+			service.logger.Debug(err)
+			return output.JsonErrNotFound(err)
+		}
+
+		err = service.sendDigest(r.Context())
+	} else {
+		err = service.email.send(r.Context(), Event{
+			Type:    EventTest,
+			Time:    time.Now(),
+			Message: "This is a test email from Cert Warden.",
+		})
+	}
+	if err != nil {
+		service.logger.Infof("notifications: test email failed (%s)", err)
+		return output.JsonErrInternal(err)
+	}
+
+	// write response
+	response := &output.JsonResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "test email sent"
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package notifications
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/randomness"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"go.uber.org/zap"
+)
+
+var errServiceComponent = errors.New("necessary notifications service component is missing")
+
+// maxRetryTime is the maximum time to keep retrying a failed notification
+const maxRetryTime = 15 * time.Minute
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetOutputter() *output.Service
+	GetHttpClient() *http.Client
+	GetShutdownContext() context.Context
+	GetShutdownWaitGroup() *sync.WaitGroup
+}
+
+// Config holds the configuration of all notification targets
+type Config struct {
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+	Email    EmailConfig     `yaml:"email"`
+}
+
+// target is an interface for anything notifications can be sent to
+type target interface {
+	// name is the unique name of the target
+	name() string
+	// wants returns true if the target subscribes to eventType
+	wants(eventType EventType) bool
+	// send sends the event to the target; if the returned error is a *backoff.PermanentError
+	// the send will not be retried
+	send(ctx context.Context, event Event) error
+}
+
+// Service is the notifications service, it sends events to all subscribed targets
+type Service struct {
+	logger            *zap.SugaredLogger
+	output            *output.Service
+	httpClient        *http.Client
+	shutdownContext   context.Context
+	shutdownWaitgroup *sync.WaitGroup
+	targets           []target
+
+	// email is also in targets, but is needed directly for the digest and test
+	email  *email
+	digest *digest
+}
+
+// NewService creates a new notifications service
+func NewService(app App, cfg *Config) (*Service, error) {
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// output service
+	service.output = app.GetOutputter()
+	if service.output == nil {
+		return nil, errServiceComponent
+	}
+
+	// http client
+	service.httpClient = app.GetHttpClient()
+	if service.httpClient == nil {
+		return nil, errServiceComponent
+	}
+
+	// shutdown context & wg
+	service.shutdownContext = app.GetShutdownContext()
+	if service.shutdownContext == nil {
+		return nil, errServiceComponent
+	}
+	service.shutdownWaitgroup = app.GetShutdownWaitGroup()
+	if service.shutdownWaitgroup == nil {
+		return nil, errServiceComponent
+	}
+
+	// targets
+	var err error
+	if cfg != nil {
+		for i := range cfg.Webhooks {
+			var wh *webhook
+			wh, err = newWebhook(cfg.Webhooks[i], service.httpClient)
+			if err != nil {
+				return nil, fmt.Errorf("notifications: webhook %d config invalid (%s)", i, err)
+			}
+
+			err = service.addTarget(wh)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if cfg.Email.Enabled != nil && *cfg.Email.Enabled {
+			service.email, err = newEmail(cfg.Email)
+			if err != nil {
+				return nil, fmt.Errorf("notifications: email config invalid (%s)", err)
+			}
+
+			err = service.addTarget(service.email)
+			if err != nil {
+				return nil, err
+			}
+
+			// daily digest
+			if cfg.Email.Digest.Enabled != nil && *cfg.Email.Digest.Enabled {
+				service.digest = &digest{
+					hour:         DefaultDigestHour,
+					expiringDays: DefaultDigestExpiringDays,
+				}
+				if cfg.Email.Digest.Hour != nil {
+					service.digest.hour = *cfg.Email.Digest.Hour
+				}
+				if cfg.Email.Digest.ExpiringDays != nil {
+					service.digest.expiringDays = *cfg.Email.Digest.ExpiringDays
+				}
+				if service.digest.hour < 0 || service.digest.hour > 23 {
+					return nil, fmt.Errorf("notifications: email digest hour %d is not valid", service.digest.hour)
+				}
+
+				service.startDigestService()
+			}
+		}
+	}
+
+	service.logger.Infof("notifications: %d notification target(s) configured", len(service.targets))
+
+	return service, nil
+}
+
+// addTarget adds t to the service's targets, ensuring its name is unique
+func (service *Service) addTarget(t target) error {
+	for _, existing := range service.targets {
+		if existing.name() == t.name() {
+			return fmt.Errorf("notifications: target name '%s' is not unique", t.name())
+		}
+	}
+
+	service.targets = append(service.targets, t)
+	return nil
+}
+
+// Notify sends event to every target that subscribes to the event's type. Sending is
+// done asynchronously and failed sends are retried with backoff.
+func (service *Service) Notify(event Event) {
+	if service == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	for _, t := range service.targets {
+		if !t.wants(event.Type) {
+			continue
+		}
+
+		service.shutdownWaitgroup.Add(1)
+		go func() {
+			defer service.shutdownWaitgroup.Done()
+
+			sendFunc := func() error {
+				return t.send(service.shutdownContext, event)
+			}
+
+			notifyFunc := func(err error, dur time.Duration) {
+				service.logger.Warnf("notifications: failed to send %s event to %s (%s), will retry in %s", event.Type, t.name(), err, dur.Round(100*time.Millisecond))
+			}
+
+			bo := randomness.BackoffACME(maxRetryTime, service.shutdownContext)
+			err := backoff.RetryNotify(sendFunc, bo, notifyFunc)
+			if err != nil {
+				service.logger.Errorf("notifications: failed to send %s event to %s (%s)", event.Type, t.name(), err)
+				return
+			}
+
+			service.logger.Debugf("notifications: sent %s event to %s", event.Type, t.name())
+		}()
+	}
+}
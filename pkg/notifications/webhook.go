@@ -0,0 +1,176 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// WebhookType is the format of the payload sent to a webhook
+type WebhookType string
+
+const (
+	WebhookTypeJSON    WebhookType = "json"
+	WebhookTypeSlack   WebhookType = "slack"
+	WebhookTypeDiscord WebhookType = "discord"
+	WebhookTypeNtfy    WebhookType = "ntfy"
+)
+
+// discordMaxContentLength is the max length of a Discord message's content
+const discordMaxContentLength = 2000
+
+// WebhookConfig is the configuration of a single webhook target
+type WebhookConfig struct {
+	Name    string            `yaml:"name"`
+	Type    WebhookType       `yaml:"type"`
+	URL     string            `yaml:"url"`
+	Events  []EventType       `yaml:"events"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// webhook is a notification target that sends events via HTTP POST
+type webhook struct {
+	cfg        WebhookConfig
+	httpClient *http.Client
+}
+
+// newWebhook validates cfg and returns a webhook target
+func newWebhook(cfg WebhookConfig, httpClient *http.Client) (*webhook, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("name must be specified")
+	}
+
+	switch cfg.Type {
+	case WebhookTypeJSON, WebhookTypeSlack, WebhookTypeDiscord, WebhookTypeNtfy:
+		// no-op
+	case "":
+		cfg.Type = WebhookTypeJSON
+	default:
+		return nil, fmt.Errorf("type '%s' is not valid", cfg.Type)
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url '%s' is not valid", cfg.URL)
+	}
+
+	for _, et := range cfg.Events {
+		if !et.isValid() {
+			return nil, fmt.Errorf("event '%s' is not valid", et)
+		}
+	}
+
+	return &webhook{
+		cfg:        cfg,
+		httpClient: httpClient,
+	}, nil
+}
+
+func (wh *webhook) name() string {
+	return wh.cfg.Name
+}
+
+// wants returns true if the webhook is subscribed to eventType; if no events are
+// specified in the config, all events are sent
+func (wh *webhook) wants(eventType EventType) bool {
+	return eventType == EventTest || len(wh.cfg.Events) == 0 || slices.Contains(wh.cfg.Events, eventType)
+}
+
+// makeRequest makes the request to send event to the webhook
+func (wh *webhook) makeRequest(ctx context.Context, event Event) (*http.Request, error) {
+	var body []byte
+	var contentType string
+	var err error
+
+	switch wh.cfg.Type {
+	case WebhookTypeSlack:
+		contentType = "application/json"
+		body, err = json.Marshal(struct {
+			Text string `json:"text"`
+		}{
+			Text: "*" + event.title() + "*\n" + event.text(),
+		})
+
+	case WebhookTypeDiscord:
+		content := "**" + event.title() + "**\n" + event.text()
+		if len(content) > discordMaxContentLength {
+			content = content[:discordMaxContentLength]
+		}
+
+		contentType = "application/json"
+		body, err = json.Marshal(struct {
+			Content string `json:"content"`
+		}{
+			Content: content,
+		})
+
+	case WebhookTypeNtfy:
+		contentType = "text/plain; charset=utf-8"
+		body = []byte(event.text())
+
+	default:
+		contentType = "application/json"
+		body, err = json.Marshal(event.toJSON())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	// ntfy uses headers for the message metadata
+	if wh.cfg.Type == WebhookTypeNtfy {
+		req.Header.Set("Title", event.title())
+		req.Header.Set("Tags", string(event.Type))
+		if event.Type == EventOrderFailed || event.Type == EventPostProcessFailed {
+			req.Header.Set("Priority", "high")
+		}
+	}
+
+	// user specified headers (e.g. auth)
+	for k, v := range wh.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	return req, nil
+}
+
+// send sends event to the webhook
+func (wh *webhook) send(ctx context.Context, event Event) error {
+	req, err := wh.makeRequest(ctx, event)
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+
+	resp, err := wh.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("webhook returned status code %d", resp.StatusCode)
+
+	// client errors (other than rate limiting) won't be fixed by retrying
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return backoff.Permanent(err)
+	}
+
+	return err
+}
@@ -0,0 +1,167 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// TestWebhookPayloads sends an event to each webhook type and checks what the
+// server received
+func TestWebhookPayloads(t *testing.T) {
+	type received struct {
+		contentType string
+		title       string
+		auth        string
+		body        string
+	}
+	got := make(chan received, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{
+			contentType: r.Header.Get("Content-Type"),
+			title:       r.Header.Get("Title"),
+			auth:        r.Header.Get("Authorization"),
+			body:        string(body),
+		}
+	}))
+	defer server.Close()
+
+	event := Event{
+		Type:            EventOrderFailed,
+		Time:            time.Unix(1700000000, 0),
+		CertificateID:   3,
+		CertificateName: "my-cert",
+		OrderID:         12,
+		Message:         "Order failed.",
+	}
+
+	testCases := []struct {
+		whType      WebhookType
+		contentType string
+		checkBody   func(string) bool
+	}{
+		{WebhookTypeJSON, "application/json", func(b string) bool {
+			var decoded map[string]any
+			return json.Unmarshal([]byte(b), &decoded) == nil && decoded["type"] == "order_failed" &&
+				decoded["certificate_name"] == "my-cert" && decoded["timestamp"] == float64(1700000000)
+		}},
+		{WebhookTypeSlack, "application/json", func(b string) bool {
+			return strings.HasPrefix(b, `{"text":"*Cert Warden: Order Failed*\nOrder failed.`)
+		}},
+		{WebhookTypeDiscord, "application/json", func(b string) bool {
+			return strings.HasPrefix(b, `{"content":"**Cert Warden: Order Failed**\nOrder failed.`)
+		}},
+		{WebhookTypeNtfy, "text/plain; charset=utf-8", func(b string) bool {
+			return b == "Order failed.\nCertificate: my-cert (id: 3)\nOrder: 12"
+		}},
+	}
+
+	for _, tc := range testCases {
+		wh, err := newWebhook(WebhookConfig{
+			Name:    string(tc.whType),
+			Type:    tc.whType,
+			URL:     server.URL,
+			Headers: map[string]string{"Authorization": "Bearer abc"},
+		}, server.Client())
+		if err != nil {
+			t.Fatalf("%s: failed to make webhook (%s)", tc.whType, err)
+		}
+
+		err = wh.send(context.Background(), event)
+		if err != nil {
+			t.Fatalf("%s: send failed (%s)", tc.whType, err)
+		}
+
+		r := <-got
+		if r.contentType != tc.contentType {
+			t.Errorf("%s: content type %s, expected %s", tc.whType, r.contentType, tc.contentType)
+		}
+		if r.auth != "Bearer abc" {
+			t.Errorf("%s: custom header missing", tc.whType)
+		}
+		if !tc.checkBody(r.body) {
+			t.Errorf("%s: unexpected body: %s", tc.whType, r.body)
+		}
+		if tc.whType == WebhookTypeNtfy && r.title != "Cert Warden: Order Failed" {
+			t.Errorf("%s: unexpected title header: %s", tc.whType, r.title)
+		}
+	}
+}
+
+// TestWebhookErrors checks which failed sends are considered permanent
+func TestWebhookErrors(t *testing.T) {
+	statusCode := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+
+	wh, err := newWebhook(WebhookConfig{Name: "test", URL: server.URL}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		statusCode int
+		permanent  bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusNotFound, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusBadGateway, false},
+	}
+
+	for _, tc := range testCases {
+		statusCode = tc.statusCode
+
+		err = wh.send(context.Background(), Event{Type: EventTest})
+		if err == nil {
+			t.Fatalf("status %d: expected error", tc.statusCode)
+		}
+
+		permErr := new(backoff.PermanentError)
+		if errors.As(err, &permErr) != tc.permanent {
+			t.Errorf("status %d: permanent error is %t, expected %t", tc.statusCode, !tc.permanent, tc.permanent)
+		}
+	}
+}
+
+// TestWebhookConfig checks webhook config validation and event filtering
+func TestWebhookConfig(t *testing.T) {
+	_, err := newWebhook(WebhookConfig{Name: "a", Type: "carrier-pigeon", URL: "https://example.com"}, nil)
+	if err == nil {
+		t.Error("invalid type should fail")
+	}
+
+	_, err = newWebhook(WebhookConfig{Name: "a", URL: "ftp://example.com"}, nil)
+	if err == nil {
+		t.Error("invalid url should fail")
+	}
+
+	_, err = newWebhook(WebhookConfig{Name: "a", URL: "https://example.com", Events: []EventType{"order_exploded"}}, nil)
+	if err == nil {
+		t.Error("invalid event should fail")
+	}
+
+	wh, err := newWebhook(WebhookConfig{Name: "a", URL: "https://example.com", Events: []EventType{EventOrderFailed}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wh.cfg.Type != WebhookTypeJSON {
+		t.Errorf("type should default to json")
+	}
+	if !wh.wants(EventOrderFailed) || !wh.wants(EventTest) || wh.wants(EventOrderValid) {
+		t.Error("event filter not applied correctly")
+	}
+}
@@ -39,6 +39,8 @@ func JsonErrInternal(err error) *JsonError {
 
 var JsonErrUnauthorized = &JsonError{StatusCode: 401, Message: "unauthorized"}
 
+var JsonErrForbidden = &JsonError{StatusCode: 403, Message: "forbidden"}
+
 // storage
 func JsonErrStorageGeneric(err error) *JsonError {
 	return &JsonError{
@@ -0,0 +1,20 @@
+package output
+
+import (
+	"net/http"
+	"time"
+)
+
+// WriteBinary sends an opaque binary file with the specified filename (including
+// extension) using the supplied content
+func (service *Service) WriteBinary(w http.ResponseWriter, r *http.Request, filename string, content []byte) {
+	file := outFileObj{
+		filename:        filename,
+		content:         content,
+		httpContentType: "application/octet-stream",
+		modTime:         time.Time{},
+		// no eTag
+	}
+
+	service.writeFile(w, r, file)
+}
@@ -0,0 +1,19 @@
+package output
+
+import (
+	"net/http"
+	"time"
+)
+
+// WriteCSV sends a csv file with the specified filename using the supplied content
+func (service *Service) WriteCSV(w http.ResponseWriter, r *http.Request, filenameNoExt string, csvContent []byte) {
+	file := outFileObj{
+		filename:        filenameNoExt + ".csv",
+		content:         csvContent,
+		httpContentType: "text/csv",
+		modTime:         time.Time{},
+		// no eTag
+	}
+
+	service.writeFile(w, r, file)
+}
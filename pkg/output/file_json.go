@@ -0,0 +1,20 @@
+package output
+
+import (
+	"net/http"
+	"time"
+)
+
+// WriteJSONFile sends a json file with the specified filename using the supplied content;
+// unlike WriteJSON, the client is told to save the content as a file
+func (service *Service) WriteJSONFile(w http.ResponseWriter, r *http.Request, filenameNoExt string, jsonContent []byte) {
+	file := outFileObj{
+		filename:        filenameNoExt + ".json",
+		content:         jsonContent,
+		httpContentType: "application/json",
+		modTime:         time.Time{},
+		// no eTag
+	}
+
+	service.writeFile(w, r, file)
+}
@@ -13,9 +13,10 @@ type sorting struct {
 }
 
 type Query struct {
-	limit  int
-	offset int
-	sort   sorting
+	limit   int
+	offset  int
+	sort    sorting
+	filters map[string]string
 }
 
 // funcs to access Query members. These allow access to data but prevent changing
@@ -42,6 +43,19 @@ func (q Query) SortDirection() string {
 	return "asc"
 }
 
+// Filter returns the value of the named filter, or "" if the filter was not specified
+func (q Query) Filter(name string) string {
+	return q.filters[name]
+}
+
+// WithoutPagination returns a copy of q that will return all results (i.e. no limit or
+// offset); sorting and filters are retained
+func (q Query) WithoutPagination() Query {
+	q.limit = 0
+	q.offset = 0
+	return q
+}
+
 var validFieldNames = []string{
 	"accountname",
 	"algorithm",
@@ -57,6 +71,21 @@ var validFieldNames = []string{
 	"subject",
 	"valid_to",
 	"last_access",
+	"actor",
+	"action",
+	"target_type",
+}
+
+// validFilterNames are the query params that can be used to filter results; it is up to
+// the storage function to decide which filters it supports
+var validFilterNames = []string{
+	"actor",
+	"action",
+	"target_type",
+	"target_id",
+	"client_ip",
+	"since",
+	"until",
 }
 
 // ParseRequestToQuery returns pagination and sorting params
@@ -66,9 +95,10 @@ func ParseRequestToQuery(r *http.Request) Query {
 	v := r.URL.Query()
 
 	return Query{
-		limit:  limit(v),
-		offset: offset(v),
-		sort:   sort(v),
+		limit:   limit(v),
+		offset:  offset(v),
+		sort:    sort(v),
+		filters: filters(v),
 	}
 }
 
@@ -164,3 +194,18 @@ func sort(v url.Values) sorting {
 		descending: direction == "desc",
 	}
 }
+
+// filters parses and returns any valid filters from url.Values. Blank filters are
+// omitted.
+func filters(v url.Values) map[string]string {
+	filters := make(map[string]string)
+
+	for _, name := range validFilterNames {
+		val := v.Get(name)
+		if val != "" {
+			filters[name] = val
+		}
+	}
+
+	return filters
+}
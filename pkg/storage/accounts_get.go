@@ -105,7 +105,7 @@ func (store *Storage) GetAllAcmeAccounts(q pagination_sort.Query) (accounts []ac
 			&oneAccount.accountKeyDb.name,
 			&oneAccount.accountKeyDb.description,
 			&oneAccount.accountKeyDb.algorithmValue,
-			&oneAccount.accountKeyDb.pem,
+			store.keyPem(&oneAccount.accountKeyDb.pem),
 			&oneAccount.accountKeyDb.apiKey,
 			&oneAccount.accountKeyDb.apiKeyNew,
 			&oneAccount.accountKeyDb.apiKeyDisabled,
@@ -185,7 +185,7 @@ func (store *Storage) getOneAcmeAccount(id int, name string) (acme_accounts.Acco
 		&oneAccount.accountKeyDb.name,
 		&oneAccount.accountKeyDb.description,
 		&oneAccount.accountKeyDb.algorithmValue,
-		&oneAccount.accountKeyDb.pem,
+		store.keyPem(&oneAccount.accountKeyDb.pem),
 		&oneAccount.accountKeyDb.apiKey,
 		&oneAccount.accountKeyDb.apiKeyNew,
 		&oneAccount.accountKeyDb.apiKeyDisabled,
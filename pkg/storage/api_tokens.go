@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"context"
+	"encoding/json"
+)
+
+// apiTokenDb represents how api tokens are stored in the db
+type apiTokenDb struct {
+	id          int
+	name        string
+	tokenHash   string
+	tokenPrefix string
+	ownerType   string
+	ownerName   string
+	ownerRole   string
+	scopes      string // stored as json array
+	allowedIps  string // stored as json array
+	expiresAt   int
+	lastUsedAt  int
+	createdAt   int
+}
+
+// dbToApiToken converts the api token db object to app object
+func (tokenDb *apiTokenDb) dbToApiToken() (auth.ApiToken, error) {
+	scopes := []auth.Scope{}
+	err := json.Unmarshal([]byte(tokenDb.scopes), &scopes)
+	if err != nil {
+		return auth.ApiToken{}, err
+	}
+
+	allowedIps := []string{}
+	err = json.Unmarshal([]byte(tokenDb.allowedIps), &allowedIps)
+	if err != nil {
+		return auth.ApiToken{}, err
+	}
+
+	return auth.ApiToken{
+		ID:          tokenDb.id,
+		Name:        tokenDb.name,
+		TokenHash:   tokenDb.tokenHash,
+		TokenPrefix: tokenDb.tokenPrefix,
+		OwnerType:   tokenDb.ownerType,
+		OwnerName:   tokenDb.ownerName,
+		OwnerRole:   auth.Role(tokenDb.ownerRole),
+		Scopes:      scopes,
+		AllowedIPs:  allowedIps,
+		ExpiresAt:   tokenDb.expiresAt,
+		LastUsedAt:  tokenDb.lastUsedAt,
+		CreatedAt:   tokenDb.createdAt,
+	}, nil
+}
+
+// apiTokenSelect is the select clause shared by the api token get queries
+const apiTokenSelect = `
+	SELECT
+		id, name, token_hash, token_prefix, owner_type, owner_name, owner_role, scopes,
+		allowed_ips, expires_at, last_used_at, created_at
+	FROM
+		api_tokens
+	`
+
+// scanApiToken scans a single api token row
+func scanApiToken(scan func(dest ...any) error) (auth.ApiToken, error) {
+	var token apiTokenDb
+	err := scan(
+		&token.id,
+		&token.name,
+		&token.tokenHash,
+		&token.tokenPrefix,
+		&token.ownerType,
+		&token.ownerName,
+		&token.ownerRole,
+		&token.scopes,
+		&token.allowedIps,
+		&token.expiresAt,
+		&token.lastUsedAt,
+		&token.createdAt,
+	)
+	if err != nil {
+		return auth.ApiToken{}, err
+	}
+
+	return token.dbToApiToken()
+}
+
+// GetAllApiTokens returns all api tokens from the db, sorted by name
+func (store *Storage) GetAllApiTokens() ([]auth.ApiToken, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := apiTokenSelect + `
+	ORDER BY
+		name COLLATE NOCASE ASC
+	`
+
+	rows, err := store.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []auth.ApiToken{}
+	for rows.Next() {
+		token, err := scanApiToken(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// GetOneApiTokenById returns an api token from the db based on id
+func (store *Storage) GetOneApiTokenById(id int) (auth.ApiToken, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := apiTokenSelect + `
+	WHERE
+		id = $1
+	`
+
+	return scanApiToken(store.db.QueryRowContext(ctx, query, id).Scan)
+}
+
+// GetApiTokenByHash returns an api token from the db based on the token's hash
+func (store *Storage) GetApiTokenByHash(tokenHash string) (auth.ApiToken, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := apiTokenSelect + `
+	WHERE
+		token_hash = $1
+	`
+
+	return scanApiToken(store.db.QueryRowContext(ctx, query, tokenHash).Scan)
+}
+
+// PostNewApiToken saves a new api token to the db and returns the new token's id
+func (store *Storage) PostNewApiToken(payload auth.NewApiTokenPayload) (id int, err error) {
+	scopes, err := json.Marshal(payload.Scopes)
+	if err != nil {
+		return -2, err
+	}
+
+	allowedIps := payload.AllowedIPs
+	if allowedIps == nil {
+		allowedIps = []string{}
+	}
+	allowedIpsJson, err := json.Marshal(allowedIps)
+	if err != nil {
+		return -2, err
+	}
+
+	expiresAt := 0
+	if payload.ExpiresAt != nil {
+		expiresAt = *payload.ExpiresAt
+	}
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO
+		api_tokens (name, token_hash, token_prefix, owner_type, owner_name, owner_role, scopes,
+			allowed_ips, expires_at, created_at)
+	VALUES (
+		$1,
+		$2,
+		$3,
+		$4,
+		$5,
+		$6,
+		$7,
+		$8,
+		$9,
+		$10
+	)
+	RETURNING
+		id
+	`
+
+	err = store.db.QueryRowContext(ctx, query,
+		payload.Name,
+		payload.TokenHash,
+		payload.TokenPrefix,
+		payload.OwnerType,
+		payload.OwnerName,
+		payload.OwnerRole,
+		string(scopes),
+		string(allowedIpsJson),
+		expiresAt,
+		payload.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return -2, err
+	}
+
+	return id, nil
+}
+
+// PutApiTokenLastUsed updates the last used time of the specified api token
+func (store *Storage) PutApiTokenLastUsed(id int, lastUsedAt int) (err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		api_tokens
+	SET
+		last_used_at = $1
+	WHERE
+		id = $2
+	`
+
+	_, err = store.db.ExecContext(ctx, query, lastUsedAt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PutApiTokensOwnerRole updates the owner role of all of the specified owner's api tokens
+func (store *Storage) PutApiTokensOwnerRole(ownerType string, ownerName string, role auth.Role) (err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		api_tokens
+	SET
+		owner_role = $1
+	WHERE
+		owner_type = $2
+		AND
+		owner_name = $3
+	`
+
+	_, err = store.db.ExecContext(ctx, query, role, ownerType, ownerName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteApiToken deletes the specified api token from the db
+func (store *Storage) DeleteApiToken(id int) (err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		api_tokens
+	WHERE
+		id = $1
+	`
+
+	_, err = store.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
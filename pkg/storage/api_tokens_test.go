@@ -0,0 +1,117 @@
+package storage_test
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestApiTokens(t *testing.T) {
+	// create testing service
+	storage, err := openStorageWithTestData(t, "api_tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add a token
+	name := "ci-pipeline"
+	expiresAt := 1893456000
+	newId, err := storage.PostNewApiToken(auth.NewApiTokenPayload{
+		Name:        &name,
+		Scopes:      []auth.Scope{"certificates:view", "certificates:order"},
+		AllowedIPs:  []string{"192.0.2.0/24"},
+		ExpiresAt:   &expiresAt,
+		TokenHash:   "hash-1",
+		TokenPrefix: "cwt_abcdef",
+		OwnerType:   "local",
+		OwnerName:   "admin",
+		OwnerRole:   auth.RoleAdmin,
+		CreatedAt:   1780336479,
+	})
+	if err != nil {
+		t.Fatalf("failed to post api token (%s)", err)
+	}
+
+	// duplicate name (case insensitive) should fail
+	dupName := "CI-Pipeline"
+	_, err = storage.PostNewApiToken(auth.NewApiTokenPayload{
+		Name:      &dupName,
+		Scopes:    []auth.Scope{"app:view"},
+		TokenHash: "hash-2",
+	})
+	if err == nil {
+		t.Error("expected error posting api token with duplicate name")
+	}
+
+	// lookup by hash
+	token, err := storage.GetApiTokenByHash("hash-1")
+	if err != nil {
+		t.Fatalf("failed to get api token by hash (%s)", err)
+	}
+	if token.ID != newId || token.Name != name || token.OwnerRole != auth.RoleAdmin {
+		t.Errorf("api token by hash did not match posted token (got %+v)", token)
+	}
+	if len(token.Scopes) != 2 || token.Scopes[1] != "certificates:order" {
+		t.Errorf("expected 2 scopes but got %v", token.Scopes)
+	}
+	if len(token.AllowedIPs) != 1 || token.AllowedIPs[0] != "192.0.2.0/24" {
+		t.Errorf("expected allowed ips [192.0.2.0/24] but got %v", token.AllowedIPs)
+	}
+	if token.ExpiresAt != expiresAt || token.LastUsedAt != 0 {
+		t.Errorf("unexpected expires at (%d) or last used at (%d)", token.ExpiresAt, token.LastUsedAt)
+	}
+
+	_, err = storage.GetApiTokenByHash("hash-does-not-exist")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected no rows for unknown hash but got %v", err)
+	}
+
+	// last used
+	err = storage.PutApiTokenLastUsed(newId, 1780336500)
+	if err != nil {
+		t.Fatalf("failed to put api token last used (%s)", err)
+	}
+	token, err = storage.GetOneApiTokenById(newId)
+	if err != nil {
+		t.Fatalf("failed to get api token (%s)", err)
+	}
+	if token.LastUsedAt != 1780336500 {
+		t.Errorf("expected last used at 1780336500 but got %d", token.LastUsedAt)
+	}
+
+	// owner role
+	err = storage.PutApiTokensOwnerRole("local", "admin", auth.RoleViewer)
+	if err != nil {
+		t.Fatalf("failed to put api tokens owner role (%s)", err)
+	}
+	err = storage.PutApiTokensOwnerRole("oidc", "admin", auth.RoleAdmin)
+	if err != nil {
+		t.Fatalf("failed to put api tokens owner role (%s)", err)
+	}
+	token, err = storage.GetOneApiTokenById(newId)
+	if err != nil {
+		t.Fatalf("failed to get api token (%s)", err)
+	}
+	if token.OwnerRole != auth.RoleViewer {
+		t.Errorf("expected owner role '%s' but got '%s'", auth.RoleViewer, token.OwnerRole)
+	}
+
+	tokens, err := storage.GetAllApiTokens()
+	if err != nil {
+		t.Fatalf("failed to get api tokens (%s)", err)
+	}
+	if len(tokens) != 1 {
+		t.Fatalf("expected 1 api token but got %d", len(tokens))
+	}
+
+	// delete
+	err = storage.DeleteApiToken(newId)
+	if err != nil {
+		t.Fatalf("failed to delete api token (%s)", err)
+	}
+	_, err = storage.GetOneApiTokenById(newId)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected deleted api token to not be found but got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/pagination_sort"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// auditEntryDb is a single audit log entry, as database table fields
+// corresponds to audit.Entry
+type auditEntryDb struct {
+	id         int
+	createdAt  int64
+	actor      string
+	actorRole  string
+	action     string
+	targetType string
+	targetId   string
+	changes    string // stored as json array
+	clientIp   string
+	statusCode int
+}
+
+func (entry auditEntryDb) toAuditEntry() (audit.Entry, error) {
+	changes := []audit.Change{}
+	err := json.Unmarshal([]byte(entry.changes), &changes)
+	if err != nil {
+		return audit.Entry{}, err
+	}
+
+	return audit.Entry{
+		ID:         entry.id,
+		CreatedAt:  time.Unix(entry.createdAt, 0),
+		Actor:      entry.actor,
+		ActorRole:  entry.actorRole,
+		Action:     entry.action,
+		TargetType: entry.targetType,
+		TargetID:   entry.targetId,
+		Changes:    changes,
+		ClientIP:   entry.clientIp,
+		StatusCode: entry.statusCode,
+	}, nil
+}
+
+// PostAuditEntry saves a new entry to the audit log
+func (store *Storage) PostAuditEntry(payload audit.NewEntryPayload) (err error) {
+	changes := payload.Changes
+	if changes == nil {
+		changes = []audit.Change{}
+	}
+
+	changesJson, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO
+		audit_log
+			(
+				created_at,
+				actor,
+				actor_role,
+				action,
+				target_type,
+				target_id,
+				changes,
+				client_ip,
+				status_code
+			)
+	VALUES
+			(
+				$1,
+				$2,
+				$3,
+				$4,
+				$5,
+				$6,
+				$7,
+				$8,
+				$9
+			)
+	`
+
+	_, err = store.db.ExecContext(ctx, query,
+		payload.CreatedAt,
+		payload.Actor,
+		payload.ActorRole,
+		payload.Action,
+		payload.TargetType,
+		payload.TargetID,
+		string(changesJson),
+		payload.ClientIP,
+		payload.StatusCode,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAuditEntries returns the audit log entries that match the filters in q. Supported
+// filters are actor, action, target_type, target_id, client_ip (all exact matches), and
+// since and until (unix times, inclusive). By default, the newest entries are first.
+func (store *Storage) GetAuditEntries(q pagination_sort.Query) (entries []audit.Entry, totalRowCount int, err error) {
+	// validate and set sort
+	sortField := q.SortField()
+	switch sortField {
+	// allow these
+	case "id":
+	case "created_at":
+	case "actor":
+	case "action":
+	case "target_type":
+	// default if not in allowed list
+	default:
+		sortField = ""
+	}
+
+	sort := "created_at DESC, id DESC"
+	if sortField != "" {
+		sort = sortField + " " + q.SortDirection() + ", id " + q.SortDirection()
+	}
+
+	// time filters (invalid values are ignored)
+	since := int64(0)
+	if q.Filter("since") != "" {
+		sinceVal, err := strconv.ParseInt(q.Filter("since"), 10, 64)
+		if err == nil {
+			since = sinceVal
+		}
+	}
+	until := int64(math.MaxInt64)
+	if q.Filter("until") != "" {
+		untilVal, err := strconv.ParseInt(q.Filter("until"), 10, 64)
+		if err == nil {
+			until = untilVal
+		}
+	}
+
+	// do query
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// WARNING: SQL Injection is possible if the variables are not properly
+	// validated prior to this query being assembled!
+	query := fmt.Sprintf(`
+	SELECT
+		id, created_at, actor, actor_role, action, target_type, target_id, changes,
+		client_ip, status_code,
+
+		count(*) OVER() AS full_count
+	FROM
+		audit_log
+	WHERE
+		($1 = '' OR actor = $1)
+		AND
+		($2 = '' OR action = $2)
+		AND
+		($3 = '' OR target_type = $3)
+		AND
+		($4 = '' OR target_id = $4)
+		AND
+		($5 = '' OR client_ip = $5)
+		AND
+		created_at >= $6
+		AND
+		created_at <= $7
+	ORDER BY
+		%s
+	LIMIT
+		$8
+	OFFSET
+		$9
+	`, sort)
+
+	rows, err := store.db.QueryContext(ctx, query,
+		q.Filter("actor"),
+		q.Filter("action"),
+		q.Filter("target_type"),
+		q.Filter("target_id"),
+		q.Filter("client_ip"),
+		since,
+		until,
+		q.Limit(),
+		q.Offset(),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// for total row count
+	var totalRows int
+
+	entries = []audit.Entry{}
+	for rows.Next() {
+		var oneEntry auditEntryDb
+		err = rows.Scan(
+			&oneEntry.id,
+			&oneEntry.createdAt,
+			&oneEntry.actor,
+			&oneEntry.actorRole,
+			&oneEntry.action,
+			&oneEntry.targetType,
+			&oneEntry.targetId,
+			&oneEntry.changes,
+			&oneEntry.clientIp,
+			&oneEntry.statusCode,
+
+			&totalRows,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		convertedEntry, err := oneEntry.toAuditEntry()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		entries = append(entries, convertedEntry)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, totalRows, nil
+}
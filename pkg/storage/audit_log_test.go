@@ -0,0 +1,117 @@
+package storage_test
+
+import (
+	"certwarden-backend/pkg/domain/app/audit"
+	"certwarden-backend/pkg/pagination_sort"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	payloads := []audit.NewEntryPayload{
+		{
+			CreatedAt:  1780336400,
+			Actor:      "local|admin",
+			ActorRole:  "admin",
+			Action:     "PUT /v1/certificates/:certid",
+			TargetType: audit.TargetCertificate,
+			TargetID:   "4",
+			Changes:    []audit.Change{{Field: "post_processing_command", Before: "./old.sh", After: "./new.sh"}},
+			ClientIP:   "192.168.1.10",
+			StatusCode: 200,
+		},
+		{
+			CreatedAt:  1780336500,
+			Actor:      "oidc|jane",
+			ActorRole:  "operator",
+			Action:     "POST /v1/certificates/:certid/orders/:orderid/revoke",
+			TargetType: audit.TargetOrder,
+			TargetID:   "73",
+			ClientIP:   "192.168.1.11",
+			StatusCode: 200,
+		},
+		{
+			CreatedAt:  1780336600,
+			Actor:      "local|admin",
+			ActorRole:  "admin",
+			Action:     "DELETE /v1/acmeaccounts/:id",
+			TargetType: audit.TargetAcmeAccount,
+			TargetID:   "2",
+			ClientIP:   "192.168.1.10",
+			StatusCode: 400,
+		},
+	}
+
+	// create testing service
+	storage, err := openStorageWithTestData(t, "auditlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, payload := range payloads {
+		err = storage.PostAuditEntry(payload)
+		if err != nil {
+			t.Fatalf("failed to post audit entry (%s)", err)
+		}
+	}
+
+	query := func(rawQuery string) pagination_sort.Query {
+		return pagination_sort.ParseRequestToQuery(httptest.NewRequest("GET", "/?"+rawQuery, nil))
+	}
+
+	testCases := []struct {
+		query       string
+		expectedIDs []string
+		totalRows   int
+	}{
+		// default newest first
+		{"", []string{"2", "73", "4"}, 3},
+		{"sort=created_at.asc", []string{"4", "73", "2"}, 3},
+		{"actor=local|admin", []string{"2", "4"}, 2},
+		{"target_type=order", []string{"73"}, 1},
+		{"since=1780336500", []string{"2", "73"}, 2},
+		{"since=1780336450&until=1780336550", []string{"73"}, 1},
+		{"client_ip=192.168.1.10&limit=1", []string{"2"}, 2},
+		{"client_ip=192.168.1.10&limit=1&offset=1", []string{"4"}, 2},
+		{"action=nope", []string{}, 0},
+	}
+
+	for _, tc := range testCases {
+		entries, totalRows, err := storage.GetAuditEntries(query(tc.query))
+		if err != nil {
+			t.Fatalf("failed to get audit entries for '%s' (%s)", tc.query, err)
+		}
+
+		gotIDs := []string{}
+		for _, entry := range entries {
+			gotIDs = append(gotIDs, entry.TargetID)
+		}
+
+		if len(gotIDs) != len(tc.expectedIDs) || totalRows != tc.totalRows {
+			t.Errorf("query '%s': expected targets %v (total %d) but got %v (total %d)", tc.query, tc.expectedIDs, tc.totalRows, gotIDs, totalRows)
+			continue
+		}
+		for i := range gotIDs {
+			if gotIDs[i] != tc.expectedIDs[i] {
+				t.Errorf("query '%s': expected targets %v but got %v", tc.query, tc.expectedIDs, gotIDs)
+				break
+			}
+		}
+	}
+
+	// check saved details
+	entries, _, err := storage.GetAuditEntries(query("target_id=4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry but got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Actor != "local|admin" || entry.ActorRole != "admin" || entry.ClientIP != "192.168.1.10" || entry.StatusCode != 200 || entry.CreatedAt.Unix() != 1780336400 {
+		t.Errorf("entry not saved correctly (%+v)", entry)
+	}
+	if len(entry.Changes) != 1 || entry.Changes[0].Before != "./old.sh" || entry.Changes[0].After != "./new.sh" {
+		t.Errorf("entry changes not saved correctly (%+v)", entry.Changes)
+	}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"database/sql"
+	"time"
+)
+
+// certTemplateDb is a single certificate template, as database table fields
+// corresponds to certificates.Template
+type certTemplateDb struct {
+	id                          int
+	name                        string
+	description                 string
+	acmeAccountId               sql.NullInt32
+	keyAlgorithm                string
+	organization                string
+	organizationalUnit          string
+	country                     string
+	state                       string
+	city                        string
+	csrExtraExtensions          jsonCertExtensionSlice
+	preferredRootCN             string
+	profile                     string
+	postProcessingCommand       string
+	postProcessingEnvironment   jsonStringSlice // stored as json array
+	postProcessingClientAddress string
+	keyRotationPolicy           string
+	keyRotationIntervalDays     int
+	keyRotationAlgorithm        string
+	keyRotationGraceDays        int
+	createdAt                   int64
+	updatedAt                   int64
+}
+
+// certTemplateDbFields are the columns selected for a certTemplateDb, in the order
+// expected by scanFields
+const certTemplateDbFields = `
+		ct.id, ct.name, ct.description, ct.acme_account_id, ct.key_algorithm,
+		ct.csr_org, ct.csr_ou, ct.csr_country, ct.csr_state, ct.csr_city, ct.csr_extra_extensions,
+		ct.preferred_root_cn, ct.profile,
+		ct.post_processing_command, ct.post_processing_environment, ct.post_processing_client_address,
+		ct.key_rotation_policy, ct.key_rotation_interval_days, ct.key_rotation_algorithm, ct.key_rotation_grace_days,
+		ct.created_at, ct.updated_at`
+
+// scanFields returns pointers to the template's fields, for use with Scan
+func (tmpl *certTemplateDb) scanFields() []any {
+	return []any{
+		&tmpl.id,
+		&tmpl.name,
+		&tmpl.description,
+		&tmpl.acmeAccountId,
+		&tmpl.keyAlgorithm,
+		&tmpl.organization,
+		&tmpl.organizationalUnit,
+		&tmpl.country,
+		&tmpl.state,
+		&tmpl.city,
+		&tmpl.csrExtraExtensions,
+		&tmpl.preferredRootCN,
+		&tmpl.profile,
+		&tmpl.postProcessingCommand,
+		&tmpl.postProcessingEnvironment,
+		&tmpl.postProcessingClientAddress,
+		&tmpl.keyRotationPolicy,
+		&tmpl.keyRotationIntervalDays,
+		&tmpl.keyRotationAlgorithm,
+		&tmpl.keyRotationGraceDays,
+		&tmpl.createdAt,
+		&tmpl.updatedAt,
+	}
+}
+
+func (tmpl certTemplateDb) toTemplate() (certificates.Template, error) {
+	certExt, err := tmpl.csrExtraExtensions.toCertExtensionSlice()
+	if err != nil {
+		return certificates.Template{}, err
+	}
+
+	return certificates.Template{
+		ID:                          tmpl.id,
+		Name:                        tmpl.name,
+		Description:                 tmpl.description,
+		AcmeAccountID:               nullInt32ToInt(tmpl.acmeAccountId),
+		KeyAlgorithm:                key_crypto.AlgorithmByStorageValue(tmpl.keyAlgorithm),
+		Organization:                tmpl.organization,
+		OrganizationalUnit:          tmpl.organizationalUnit,
+		Country:                     tmpl.country,
+		State:                       tmpl.state,
+		City:                        tmpl.city,
+		CSRExtraExtensions:          certExt,
+		PreferredRootCN:             tmpl.preferredRootCN,
+		Profile:                     tmpl.profile,
+		PostProcessingCommand:       tmpl.postProcessingCommand,
+		PostProcessingEnvironment:   tmpl.postProcessingEnvironment.toSlice(),
+		PostProcessingClientAddress: tmpl.postProcessingClientAddress,
+		KeyRotation: certificates.KeyRotation{
+			Policy:       certificates.KeyRotationPolicy(tmpl.keyRotationPolicy),
+			IntervalDays: tmpl.keyRotationIntervalDays,
+			Algorithm:    key_crypto.AlgorithmByStorageValue(tmpl.keyRotationAlgorithm),
+			GraceDays:    tmpl.keyRotationGraceDays,
+		},
+		CreatedAt: time.Unix(tmpl.createdAt, 0),
+		UpdatedAt: time.Unix(tmpl.updatedAt, 0),
+	}, nil
+}
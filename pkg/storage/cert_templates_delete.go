@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+)
+
+// DeleteCertTemplate deletes a certificate template from the db; linked certificates
+// are unlinked (by the foreign key constraint)
+func (store *Storage) DeleteCertTemplate(id int) (err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		cert_templates
+	WHERE
+		id = $1
+	`
+
+	result, err := store.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return ErrWrongUpdateRowCount
+	}
+
+	return nil
+}
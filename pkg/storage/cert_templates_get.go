@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/pagination_sort"
+	"context"
+	"fmt"
+)
+
+// GetAllCertTemplates returns a slice of all of the certificate templates in the database
+func (store *Storage) GetAllCertTemplates(q pagination_sort.Query) (templates []certificates.Template, totalRowCount int, err error) {
+	// validate and set sort
+	sortField := q.SortField()
+
+	switch sortField {
+	// allow these
+	case "id":
+		sortField = "ct.id"
+	case "name":
+		sortField = "ct.name"
+	case "description":
+		sortField = "ct.description"
+	// default if not in allowed list
+	default:
+		sortField = "ct.name"
+	}
+
+	sort := sortField + " " + q.SortDirection()
+
+	// do query
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// WARNING: SQL Injection is possible if the variables are not properly
+	// validated prior to this query being assembled!
+	query := fmt.Sprintf(`
+	SELECT %s,
+
+		count(*) OVER() AS full_count
+	FROM
+		cert_templates ct
+	ORDER BY
+		%s
+	LIMIT
+		$1
+	OFFSET
+		$2
+	`, certTemplateDbFields, sort)
+
+	rows, err := store.db.QueryContext(ctx, query,
+		q.Limit(),
+		q.Offset(),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// for total row count
+	var totalRows int
+
+	allTemplates := []certificates.Template{}
+	for rows.Next() {
+		var oneTemplate certTemplateDb
+		err = rows.Scan(append(oneTemplate.scanFields(), &totalRows)...)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// convert to Template and append
+		tmpl, err := oneTemplate.toTemplate()
+		if err != nil {
+			return nil, 0, err
+		}
+		allTemplates = append(allTemplates, tmpl)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return allTemplates, totalRows, nil
+}
+
+// GetOneCertTemplateById returns a Template based on its unique id
+func (store *Storage) GetOneCertTemplateById(id int) (certificates.Template, error) {
+	return store.getOneCertTemplate(id, "")
+}
+
+// GetOneCertTemplateByName returns a Template based on its unique name
+func (store *Storage) GetOneCertTemplateByName(name string) (certificates.Template, error) {
+	return store.getOneCertTemplate(-1, name)
+}
+
+// getOneCertTemplate returns a Template based on unique id or unique name
+func (store *Storage) getOneCertTemplate(id int, name string) (certificates.Template, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM
+		cert_templates ct
+	WHERE
+		ct.id = $1
+		OR
+		ct.name = $2
+	`, certTemplateDbFields)
+
+	var oneTemplate certTemplateDb
+	err := store.db.QueryRowContext(ctx, query, id, name).Scan(oneTemplate.scanFields()...)
+	if err != nil {
+		return certificates.Template{}, err
+	}
+
+	return oneTemplate.toTemplate()
+}
+
+// GetCertIdsByTemplate returns the ids of all certificates linked to the specified template
+func (store *Storage) GetCertIdsByTemplate(templateId int) (certIds []int, err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id
+	FROM
+		certificates
+	WHERE
+		template_id = $1
+	ORDER BY
+		id
+	`
+
+	rows, err := store.db.QueryContext(ctx, query, templateId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	certIds = []int{}
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		certIds = append(certIds, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return certIds, nil
+}
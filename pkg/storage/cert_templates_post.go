@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"context"
+)
+
+// PostNewCertTemplate inserts a new certificate template into the db
+func (store *Storage) PostNewCertTemplate(payload certificates.NewTemplatePayload) (certificates.Template, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO cert_templates (name, description, acme_account_id, key_algorithm,
+		csr_org, csr_ou, csr_country, csr_state, csr_city, csr_extra_extensions, preferred_root_cn, profile,
+		post_processing_command, post_processing_environment, post_processing_client_address,
+		key_rotation_policy, key_rotation_interval_days, key_rotation_algorithm, key_rotation_grace_days,
+		created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+	RETURNING id
+	`
+
+	id := -1
+	err := store.db.QueryRowContext(ctx, query,
+		payload.Name,
+		payload.Description,
+		payload.AcmeAccountID,
+		payload.KeyAlgorithmValue,
+		payload.Organization,
+		payload.OrganizationalUnit,
+		payload.Country,
+		payload.State,
+		payload.City,
+		makeJsonCertExtensionSlice(payload.CSRExtraExtensions, false),
+		payload.PreferredRootCN,
+		payload.Profile,
+		payload.PostProcessingCommand,
+		makeJsonStringSlice(payload.PostProcessingEnvironment, false),
+		payload.PostProcessingClientAddress,
+		payload.KeyRotation.Policy,
+		payload.KeyRotation.IntervalDays,
+		payload.KeyRotation.Algorithm.StorageValue(),
+		payload.KeyRotation.GraceDays,
+		payload.CreatedAt,
+		payload.UpdatedAt,
+	).Scan(&id)
+	if err != nil {
+		return certificates.Template{}, err
+	}
+
+	// get new template to return
+	return store.GetOneCertTemplateById(id)
+}
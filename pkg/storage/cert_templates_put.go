@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"context"
+)
+
+// PutCertTemplate saves changes to a certificate template. It only updates the
+// details which are provided. Any certUpdates (the template changes propagated to its
+// linked certificates) are saved in the same transaction.
+func (store *Storage) PutCertTemplate(payload certificates.TemplateUpdatePayload, certUpdates []certificates.DetailsUpdatePayload) (certificates.Template, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return certificates.Template{}, err
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE
+		cert_templates
+	SET
+		name = case when $1 is null then name else $1 end,
+		description = case when $2 is null then description else $2 end,
+		acme_account_id = case when $3 is null then acme_account_id else nullif($3, 0) end,
+		key_algorithm = case when $4 is null then key_algorithm else $4 end,
+		csr_org = case when $5 is null then csr_org else $5 end,
+		csr_ou = case when $6 is null then csr_ou else $6 end,
+		csr_country = case when $7 is null then csr_country else $7 end,
+		csr_state = case when $8 is null then csr_state else $8 end,
+		csr_city = case when $9 is null then csr_city else $9 end,
+		csr_extra_extensions = case when $10 is null then csr_extra_extensions else $10 end,
+		preferred_root_cn = case when $11 is null then preferred_root_cn else $11 end,
+		profile = case when $12 is null then profile else $12 end,
+		post_processing_command = case when $13 is null then post_processing_command else $13 end,
+		post_processing_environment = case when $14 is null then post_processing_environment else $14 end,
+		post_processing_client_address = case when $15 is null then post_processing_client_address else $15 end,
+		key_rotation_policy = case when $16 is null then key_rotation_policy else $16 end,
+		key_rotation_interval_days = case when $17 is null then key_rotation_interval_days else $17 end,
+		key_rotation_algorithm = case when $18 is null then key_rotation_algorithm else $18 end,
+		key_rotation_grace_days = case when $19 is null then key_rotation_grace_days else $19 end,
+		updated_at = $20
+	WHERE
+		id = $21
+	`
+
+	// key rotation is updated as a whole (or not at all)
+	var keyRotationPolicy, keyRotationAlgorithm *string
+	var keyRotationIntervalDays, keyRotationGraceDays *int
+	if payload.KeyRotation != nil {
+		keyRotationPolicy = new(string(payload.KeyRotation.Policy))
+		keyRotationIntervalDays = &payload.KeyRotation.IntervalDays
+		keyRotationAlgorithm = new(payload.KeyRotation.Algorithm.StorageValue())
+		keyRotationGraceDays = &payload.KeyRotation.GraceDays
+	}
+
+	result, err := tx.ExecContext(ctx, query,
+		payload.Name,
+		payload.Description,
+		payload.AcmeAccountID,
+		payload.KeyAlgorithmValue,
+		payload.Organization,
+		payload.OrganizationalUnit,
+		payload.Country,
+		payload.State,
+		payload.City,
+		makeJsonCertExtensionSlice(payload.CSRExtraExtensions, true),
+		payload.PreferredRootCN,
+		payload.Profile,
+		payload.PostProcessingCommand,
+		makeJsonStringSlice(payload.PostProcessingEnvironment, true),
+		payload.PostProcessingClientAddress,
+		keyRotationPolicy,
+		keyRotationIntervalDays,
+		keyRotationAlgorithm,
+		keyRotationGraceDays,
+		payload.UpdatedAt,
+		payload.ID,
+	)
+	if err != nil {
+		return certificates.Template{}, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return certificates.Template{}, err
+	}
+	if rows != 1 {
+		return certificates.Template{}, ErrWrongUpdateRowCount
+	}
+
+	// propagate to linked certs
+	for i := range certUpdates {
+		err = txPutDetailsCert(ctx, tx, certUpdates[i])
+		if err != nil {
+			return certificates.Template{}, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return certificates.Template{}, err
+	}
+
+	// get updated to return
+	return store.GetOneCertTemplateById(payload.ID)
+}
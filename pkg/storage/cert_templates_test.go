@@ -0,0 +1,165 @@
+package storage_test
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/storage"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCertTemplates(t *testing.T) {
+	// create testing service
+	store, err := openStorageWithTestData(t, "certtemplates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create
+	newTmpl, err := store.PostNewCertTemplate(certificates.NewTemplatePayload{
+		Name:                        new("web-servers"),
+		Description:                 new("internal web servers"),
+		AcmeAccountID:               new(2),
+		KeyAlgorithmValue:           new("ecdsap384"),
+		Organization:                new("Example Org"),
+		OrganizationalUnit:          new(""),
+		Country:                     new("US"),
+		State:                       new(""),
+		City:                        new(""),
+		PreferredRootCN:             new(""),
+		Profile:                     new(""),
+		PostProcessingCommand:       new("/usr/local/bin/deploy.sh"),
+		PostProcessingEnvironment:   []string{"A=1"},
+		PostProcessingClientAddress: new(""),
+		KeyRotation: certificates.KeyRotation{
+			Policy:    certificates.KeyRotationEveryRenewal,
+			Algorithm: key_crypto.AlgorithmECDSAp256,
+			GraceDays: 3,
+		},
+		CreatedAt: 1000,
+		UpdatedAt: 1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newTmpl.Name != "web-servers" || newTmpl.AcmeAccountID == nil || *newTmpl.AcmeAccountID != 2 ||
+		newTmpl.KeyAlgorithm != key_crypto.AlgorithmECDSAp384 || !slices.Equal(newTmpl.PostProcessingEnvironment, []string{"A=1"}) ||
+		newTmpl.KeyRotation.Policy != certificates.KeyRotationEveryRenewal || newTmpl.KeyRotation.Algorithm != key_crypto.AlgorithmECDSAp256 ||
+		newTmpl.KeyRotation.GraceDays != 3 || len(newTmpl.CSRExtraExtensions) != 0 {
+		t.Errorf("unexpected new template %+v", newTmpl)
+	}
+
+	// get
+	tmpl, err := store.GetOneCertTemplateByName("WEB-servers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.ID != newTmpl.ID {
+		t.Errorf("expected template id %d but got %d", newTmpl.ID, tmpl.ID)
+	}
+
+	templates, total, err := store.GetAllCertTemplates(pagination_sort.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(templates) != 1 {
+		t.Errorf("expected 1 template but got %d (total %d)", len(templates), total)
+	}
+
+	// update (partial, clear account)
+	tmpl, err = store.PutCertTemplate(certificates.TemplateUpdatePayload{
+		ID:            newTmpl.ID,
+		AcmeAccountID: new(0),
+		Country:       new("CA"),
+		UpdatedAt:     2000,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.AcmeAccountID != nil || tmpl.Country != "CA" || tmpl.Organization != "Example Org" ||
+		tmpl.KeyRotation.Policy != certificates.KeyRotationEveryRenewal || tmpl.UpdatedAt.Unix() != 2000 {
+		t.Errorf("unexpected updated template %+v", tmpl)
+	}
+
+	_, err = store.PutCertTemplate(certificates.TemplateUpdatePayload{ID: 999, UpdatedAt: 2000}, nil)
+	if !errors.Is(err, storage.ErrWrongUpdateRowCount) {
+		t.Errorf("expected error '%s' but got '%v'", storage.ErrWrongUpdateRowCount, err)
+	}
+
+	// link a cert
+	cert, err := store.PutDetailsCert(certificates.DetailsUpdatePayload{
+		ID:         18,
+		TemplateID: &newTmpl.ID,
+		UpdatedAt:  3000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.TemplateID == nil || *cert.TemplateID != newTmpl.ID {
+		t.Errorf("expected cert to be linked to template %d but got %v", newTmpl.ID, cert.TemplateID)
+	}
+
+	// propagation to linked certs is saved in the same transaction
+	tmpl, err = store.PutCertTemplate(certificates.TemplateUpdatePayload{
+		ID:        newTmpl.ID,
+		Country:   new("DE"),
+		UpdatedAt: 4000,
+	}, []certificates.DetailsUpdatePayload{{ID: 18, Country: new("DE"), UpdatedAt: 4000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = store.GetOneCertById(18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Country != "DE" || cert.Country != "DE" {
+		t.Errorf("expected template and cert country 'DE' but got '%s' and '%s'", tmpl.Country, cert.Country)
+	}
+
+	// a failed cert update rolls back the template update (name collides with cert 26)
+	_, err = store.PutCertTemplate(certificates.TemplateUpdatePayload{
+		ID:        newTmpl.ID,
+		Country:   new("FR"),
+		UpdatedAt: 5000,
+	}, []certificates.DetailsUpdatePayload{{ID: 18, Name: new("test008.test.example.com"), UpdatedAt: 5000}})
+	if err == nil {
+		t.Error("expected error from colliding cert name but got nil")
+	}
+	tmpl, err = store.GetOneCertTemplateById(newTmpl.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Country != "DE" {
+		t.Errorf("expected template update to be rolled back but country is '%s'", tmpl.Country)
+	}
+
+	certIds, err := store.GetCertIdsByTemplate(newTmpl.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(certIds, []int{18}) {
+		t.Errorf("expected linked certs [18] but got %v", certIds)
+	}
+
+	// delete unlinks the cert
+	err = store.DeleteCertTemplate(newTmpl.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.GetOneCertTemplateById(newTmpl.ID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected error '%s' but got '%v'", sql.ErrNoRows, err)
+	}
+
+	cert, err = store.GetOneCertById(18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.TemplateID != nil {
+		t.Errorf("expected cert to be unlinked but got template id %d", *cert.TemplateID)
+	}
+}
@@ -2,6 +2,8 @@ package storage
 
 import (
 	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"database/sql"
 	"time"
 )
 
@@ -33,6 +35,13 @@ type certificateDb struct {
 	postProcessingClientAddress string
 	postProcessingClientKeyB64  string // base64 raw url encoded AES 256 key
 	profile                     string
+	keyRotationPolicy           string
+	keyRotationIntervalDays     int
+	keyRotationAlgorithm        string // storage value, blank = same as current key
+	keyRotationGraceDays        int
+	templateId                  sql.NullInt32
+	deployVerify                bool
+	deployVerifyWindowMinutes   int
 }
 
 func (cert certificateDb) toCertificate() (certificates.Certificate, error) {
@@ -67,5 +76,16 @@ func (cert certificateDb) toCertificate() (certificates.Certificate, error) {
 		PostProcessingClientAddress: cert.postProcessingClientAddress,
 		PostProcessingClientKeyB64:  cert.postProcessingClientKeyB64,
 		Profile:                     cert.profile,
+		KeyRotation: certificates.KeyRotation{
+			Policy:       certificates.KeyRotationPolicy(cert.keyRotationPolicy),
+			IntervalDays: cert.keyRotationIntervalDays,
+			Algorithm:    key_crypto.AlgorithmByStorageValue(cert.keyRotationAlgorithm),
+			GraceDays:    cert.keyRotationGraceDays,
+		},
+		DeployVerification: certificates.DeployVerification{
+			Enabled:       cert.deployVerify,
+			WindowMinutes: cert.deployVerifyWindowMinutes,
+		},
+		TemplateID: nullInt32ToInt(cert.templateId),
 	}, nil
 }
@@ -48,6 +48,8 @@ func (store *Storage) GetAllCerts(q pagination_sort.Query) (certs []certificates
 		c.last_access, c.created_at, c.updated_at,
 		c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, c.post_processing_environment,
 		c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.key_rotation_policy, c.key_rotation_interval_days, c.key_rotation_algorithm, c.key_rotation_grace_days, c.template_id,
+		c.deploy_verify, c.deploy_verify_window_minutes,
 		
 		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, pk.api_key, pk.api_key_new,
 		pk.api_key_disabled, pk.api_key_via_url, pk.last_access, pk.created_at, pk.updated_at,
@@ -115,12 +117,19 @@ func (store *Storage) GetAllCerts(q pagination_sort.Query) (certs []certificates
 			&oneCert.postProcessingClientAddress,
 			&oneCert.postProcessingClientKeyB64,
 			&oneCert.profile,
+			&oneCert.keyRotationPolicy,
+			&oneCert.keyRotationIntervalDays,
+			&oneCert.keyRotationAlgorithm,
+			&oneCert.keyRotationGraceDays,
+			&oneCert.templateId,
+			&oneCert.deployVerify,
+			&oneCert.deployVerifyWindowMinutes,
 
 			&oneCert.certificateKeyDb.id,
 			&oneCert.certificateKeyDb.name,
 			&oneCert.certificateKeyDb.description,
 			&oneCert.certificateKeyDb.algorithmValue,
-			&oneCert.certificateKeyDb.pem,
+			store.keyPem(&oneCert.certificateKeyDb.pem),
 			&oneCert.certificateKeyDb.apiKey,
 			&oneCert.certificateKeyDb.apiKeyNew,
 			&oneCert.certificateKeyDb.apiKeyDisabled,
@@ -151,7 +160,7 @@ func (store *Storage) GetAllCerts(q pagination_sort.Query) (certs []certificates
 			&oneCert.certificateAccountDb.accountKeyDb.name,
 			&oneCert.certificateAccountDb.accountKeyDb.description,
 			&oneCert.certificateAccountDb.accountKeyDb.algorithmValue,
-			&oneCert.certificateAccountDb.accountKeyDb.pem,
+			store.keyPem(&oneCert.certificateAccountDb.accountKeyDb.pem),
 			&oneCert.certificateAccountDb.accountKeyDb.apiKey,
 			&oneCert.certificateAccountDb.accountKeyDb.apiKeyNew,
 			&oneCert.certificateAccountDb.accountKeyDb.apiKeyDisabled,
@@ -200,6 +209,8 @@ func (store *Storage) getOneCert(id int, name string) (cert certificates.Certifi
 		c.last_access, c.created_at, c.updated_at,
 		c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, c.post_processing_environment,
 		c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.key_rotation_policy, c.key_rotation_interval_days, c.key_rotation_algorithm, c.key_rotation_grace_days, c.template_id,
+		c.deploy_verify, c.deploy_verify_window_minutes,
 		
 		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, pk.api_key, pk.api_key_new,
 		pk.api_key_disabled, pk.api_key_via_url, pk.last_access, pk.created_at, pk.updated_at,
@@ -251,12 +262,19 @@ func (store *Storage) getOneCert(id int, name string) (cert certificates.Certifi
 		&oneCert.postProcessingClientAddress,
 		&oneCert.postProcessingClientKeyB64,
 		&oneCert.profile,
+		&oneCert.keyRotationPolicy,
+		&oneCert.keyRotationIntervalDays,
+		&oneCert.keyRotationAlgorithm,
+		&oneCert.keyRotationGraceDays,
+		&oneCert.templateId,
+		&oneCert.deployVerify,
+		&oneCert.deployVerifyWindowMinutes,
 
 		&oneCert.certificateKeyDb.id,
 		&oneCert.certificateKeyDb.name,
 		&oneCert.certificateKeyDb.description,
 		&oneCert.certificateKeyDb.algorithmValue,
-		&oneCert.certificateKeyDb.pem,
+		store.keyPem(&oneCert.certificateKeyDb.pem),
 		&oneCert.certificateKeyDb.apiKey,
 		&oneCert.certificateKeyDb.apiKeyNew,
 		&oneCert.certificateKeyDb.apiKeyDisabled,
@@ -287,7 +305,7 @@ func (store *Storage) getOneCert(id int, name string) (cert certificates.Certifi
 		&oneCert.certificateAccountDb.accountKeyDb.name,
 		&oneCert.certificateAccountDb.accountKeyDb.description,
 		&oneCert.certificateAccountDb.accountKeyDb.algorithmValue,
-		&oneCert.certificateAccountDb.accountKeyDb.pem,
+		store.keyPem(&oneCert.certificateAccountDb.accountKeyDb.pem),
 		&oneCert.certificateAccountDb.accountKeyDb.apiKey,
 		&oneCert.certificateAccountDb.accountKeyDb.apiKeyNew,
 		&oneCert.certificateAccountDb.accountKeyDb.apiKeyDisabled,
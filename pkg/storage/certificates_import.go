@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// PostImportedCert saves an imported certificate: a new private key, a new certificate
+// using that key, and a valid order containing the imported certificate chain. All changes
+// are made in a single transaction. If the certificate was already imported, the error
+// certificates.ErrImportExists is returned.
+func (store *Storage) PostImportedCert(payload certificates.ImportPayload) (cert certificates.Certificate, orderId int, err error) {
+	// encrypt pem (if master key is configured)
+	keyPem, err := store.encryptKeyPem(payload.KeyPem)
+	if err != nil {
+		return certificates.Certificate{}, -1, err
+	}
+
+	// database action
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return certificates.Certificate{}, -1, err
+	}
+	defer tx.Rollback()
+
+	// check if already imported
+	query := `
+	SELECT
+		id
+	FROM
+		acme_orders
+	WHERE
+		acme_location = $1
+	`
+
+	err = tx.QueryRowContext(ctx, query, payload.OrderLocation).Scan(&orderId)
+	if err == nil {
+		return certificates.Certificate{}, -1, certificates.ErrImportExists
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return certificates.Certificate{}, -1, err
+	}
+
+	// insert the key
+	query = `
+	INSERT INTO private_keys (name, description, algorithm, pem, pem_fingerprint, api_key, api_key_disabled, api_key_via_url, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING id
+	`
+
+	keyId := -1
+	err = tx.QueryRowContext(ctx, query,
+		payload.Cert.Name,
+		payload.Cert.Description,
+		payload.KeyAlgorithm.StorageValue(),
+		keyPem,
+		keyPemFingerprint(payload.KeyPem),
+		payload.KeyApiKey,
+		false,
+		false,
+		payload.Cert.CreatedAt,
+		payload.Cert.UpdatedAt,
+	).Scan(&keyId)
+	if err != nil {
+		return certificates.Certificate{}, -1, err
+	}
+
+	// insert the cert
+	query = `
+	INSERT INTO certificates (name, description, private_key_id, acme_account_id, subject, subject_alts,
+		csr_org, csr_ou, csr_country, csr_state, csr_city, csr_extra_extensions, preferred_root_cn,
+		created_at, updated_at, api_key, api_key_via_url,
+		post_processing_command, post_processing_environment, post_processing_client_address,
+		post_processing_client_key, profile,
+		key_rotation_policy, key_rotation_interval_days, key_rotation_algorithm, key_rotation_grace_days, template_id,
+		deploy_verify, deploy_verify_window_minutes)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+		$23, $24, $25, $26, $27, $28, $29)
+	RETURNING id
+	`
+
+	certId := -1
+	err = tx.QueryRowContext(ctx, query,
+		payload.Cert.Name,
+		payload.Cert.Description,
+		keyId,
+		payload.Cert.AcmeAccountID,
+		payload.Cert.Subject,
+		makeJsonStringSlice(payload.Cert.SubjectAltNames, false),
+		payload.Cert.Organization,
+		payload.Cert.OrganizationalUnit,
+		payload.Cert.Country,
+		payload.Cert.State,
+		payload.Cert.City,
+		makeJsonCertExtensionSlice(payload.Cert.CSRExtraExtensions, false),
+		payload.Cert.PreferredRootCN,
+		payload.Cert.CreatedAt,
+		payload.Cert.UpdatedAt,
+		payload.Cert.ApiKey,
+		payload.Cert.ApiKeyViaUrl,
+		payload.Cert.PostProcessingCommand,
+		makeJsonStringSlice(payload.Cert.PostProcessingEnvironment, false),
+		payload.Cert.PostProcessingClientAddress,
+		payload.Cert.PostProcessingClientKeyB64,
+		payload.Cert.Profile,
+		payload.Cert.KeyRotation.Policy,
+		payload.Cert.KeyRotation.IntervalDays,
+		payload.Cert.KeyRotation.Algorithm.StorageValue(),
+		payload.Cert.KeyRotation.GraceDays,
+		payload.Cert.TemplateID,
+		payload.Cert.DeployVerification.Enabled,
+		payload.Cert.DeployVerification.WindowMinutes,
+	).Scan(&certId)
+	if err != nil {
+		return certificates.Certificate{}, -1, err
+	}
+
+	// insert the (already valid) order; renewal info is left null so the auto ordering
+	// service populates it (from ARI, if available) on its next run
+	query = `
+	INSERT INTO acme_orders (certificate_id, acme_account_id, acme_location, status, known_revoked,
+		dns_identifiers, ip_identifiers, authorizations, finalize, finalized_key_id, pem, valid_from,
+		valid_to, chain_root_cn, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	RETURNING id
+	`
+
+	err = tx.QueryRowContext(ctx, query,
+		certId,
+		payload.Cert.AcmeAccountID,
+		payload.OrderLocation,
+		"valid",
+		false,
+		makeJsonStringSlice(payload.DnsIds, false),
+		makeJsonStringSlice(payload.IpIds, false),
+		makeJsonStringSlice(nil, false),
+		"",
+		keyId,
+		payload.ChainPem,
+		payload.ValidFrom,
+		payload.ValidTo,
+		payload.ChainRootCN,
+		payload.Cert.CreatedAt,
+		payload.Cert.UpdatedAt,
+	).Scan(&orderId)
+	if err != nil {
+		return certificates.Certificate{}, -1, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return certificates.Certificate{}, -1, err
+	}
+
+	// get new cert to return
+	cert, err = store.GetOneCertById(certId)
+	if err != nil {
+		return certificates.Certificate{}, -1, err
+	}
+
+	return cert, orderId, nil
+}
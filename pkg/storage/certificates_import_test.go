@@ -0,0 +1,117 @@
+package storage_test
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeImportPem returns a pem containing a new self-signed certificate for the specified
+// names followed by its private key
+func makeImportPem(t *testing.T, names ...string) string {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-24 * time.Hour),
+		NotAfter:     time.Now().Add(60 * 24 * time.Hour),
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, privKey.Public(), privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+}
+
+func TestPostImportedCert(t *testing.T) {
+	// create testing service
+	store, err := openStorageWithTestData(t, "importcert")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	importPem := makeImportPem(t, "import.example.com", "www.import.example.com")
+	bundle, err := certificates.PemImportBundle("test", "*.import.example.com", []byte(importPem))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// unusable account
+	_, _, err = certificates.ImportCertificates(store, []certificates.ImportBundle{bundle}, certificates.ImportOptions{AcmeAccountID: 23})
+	if err == nil {
+		t.Error("expected import with unusable account to fail")
+	}
+
+	// import
+	results, certs, err := certificates.ImportCertificates(store, []certificates.ImportBundle{bundle}, certificates.ImportOptions{AcmeAccountID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Error != "" || len(certs) != 1 {
+		t.Fatalf("expected 1 successful import but got %+v", results)
+	}
+	if results[0].Name != "_.import.example.com" {
+		t.Errorf("expected sanitized name '_.import.example.com' but got '%s'", results[0].Name)
+	}
+
+	cert := certs[0]
+	if cert.Subject != "import.example.com" || !slices.Equal(cert.SubjectAltNames, []string{"www.import.example.com"}) ||
+		cert.CertificateAccount.ID != 2 || cert.CertificateKey.Name != cert.Name {
+		t.Errorf("unexpected imported cert %+v", cert)
+	}
+
+	// key
+	key, err := store.GetOneKeyById(cert.CertificateKey.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Pem == "" || !strings.Contains(importPem, key.Pem) {
+		t.Error("expected imported key pem to match")
+	}
+
+	// order
+	order, err := store.GetCertNewestValidOrderById(cert.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.ID != *results[0].OrderID || order.Status != "valid" || order.Pem == nil || !strings.Contains(importPem, *order.Pem) ||
+		order.FinalizedKey == nil || order.FinalizedKey.ID != key.ID || order.ValidTo == nil || order.RenewalInfo != nil ||
+		order.ChainRootCN == nil || *order.ChainRootCN != "import.example.com" {
+		t.Errorf("unexpected imported order %+v", order)
+	}
+
+	// importing the same certificate again fails
+	bundle.Name = "import-again"
+	results, _, err = certificates.ImportCertificates(store, []certificates.ImportBundle{bundle}, certificates.ImportOptions{AcmeAccountID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Error != certificates.ErrImportExists.Error() {
+		t.Errorf("expected error '%s' but got %+v", certificates.ErrImportExists, results)
+	}
+	_, err = store.GetOneKeyByName("import-again")
+	if err == nil {
+		t.Error("expected failed import to not save a key")
+	}
+}
@@ -20,8 +20,11 @@ func (store *Storage) PostNewCert(payload certificates.NewPayload) (certificates
 		csr_org, csr_ou, csr_country, csr_state, csr_city, csr_extra_extensions, preferred_root_cn, 
 		created_at, updated_at, api_key, api_key_via_url,
 		post_processing_command, post_processing_environment, post_processing_client_address, 
-		post_processing_client_key, profile)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		post_processing_client_key, profile,
+		key_rotation_policy, key_rotation_interval_days, key_rotation_algorithm, key_rotation_grace_days, template_id,
+		deploy_verify, deploy_verify_window_minutes)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+		$23, $24, $25, $26, $27, $28, $29)
 	RETURNING id
 	`
 
@@ -49,6 +52,13 @@ func (store *Storage) PostNewCert(payload certificates.NewPayload) (certificates
 		payload.PostProcessingClientAddress,
 		payload.PostProcessingClientKeyB64,
 		payload.Profile,
+		payload.KeyRotation.Policy,
+		payload.KeyRotation.IntervalDays,
+		payload.KeyRotation.Algorithm.StorageValue(),
+		payload.KeyRotation.GraceDays,
+		payload.TemplateID,
+		payload.DeployVerification.Enabled,
+		payload.DeployVerification.WindowMinutes,
 	).Scan(&id)
 
 	if err != nil {
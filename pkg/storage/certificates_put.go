@@ -3,6 +3,7 @@ package storage
 import (
 	"certwarden-backend/pkg/domain/certificates"
 	"context"
+	"database/sql"
 	"time"
 )
 
@@ -13,6 +14,33 @@ func (store *Storage) PutDetailsCert(payload certificates.DetailsUpdatePayload)
 	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
 	defer cancel()
 
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return certificates.Certificate{}, err
+	}
+	defer tx.Rollback()
+
+	err = txPutDetailsCert(ctx, tx, payload)
+	if err != nil {
+		return certificates.Certificate{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return certificates.Certificate{}, err
+	}
+
+	// get updated to return
+	updatedCert, err := store.GetOneCertById(payload.ID)
+	if err != nil {
+		return certificates.Certificate{}, err
+	}
+
+	return updatedCert, nil
+}
+
+// txPutDetailsCert does the update of PutDetailsCert using tx
+func txPutDetailsCert(ctx context.Context, tx *sql.Tx, payload certificates.DetailsUpdatePayload) error {
 	query := `
 		UPDATE
 			certificates
@@ -35,12 +63,37 @@ func (store *Storage) PutDetailsCert(payload certificates.DetailsUpdatePayload)
 			post_processing_environment = case when $16 is null then post_processing_environment else $16 end,
 			post_processing_client_address = case when $17 is null then post_processing_client_address else $17 end,
 			profile = case when $18 is null then profile else $18 end,
-			updated_at = $19
+			key_rotation_policy = case when $19 is null then key_rotation_policy else $19 end,
+			key_rotation_interval_days = case when $20 is null then key_rotation_interval_days else $20 end,
+			key_rotation_algorithm = case when $21 is null then key_rotation_algorithm else $21 end,
+			key_rotation_grace_days = case when $22 is null then key_rotation_grace_days else $22 end,
+			template_id = case when $23 is null then template_id else nullif($23, 0) end,
+			deploy_verify = case when $24 is null then deploy_verify else $24 end,
+			deploy_verify_window_minutes = case when $25 is null then deploy_verify_window_minutes else $25 end,
+			updated_at = $26
 		WHERE
-			id = $20
+			id = $27
 		`
 
-	_, err := store.db.ExecContext(ctx, query,
+	// key rotation is updated as a whole (or not at all)
+	var keyRotationPolicy, keyRotationAlgorithm *string
+	var keyRotationIntervalDays, keyRotationGraceDays *int
+	if payload.KeyRotation != nil {
+		keyRotationPolicy = new(string(payload.KeyRotation.Policy))
+		keyRotationIntervalDays = &payload.KeyRotation.IntervalDays
+		keyRotationAlgorithm = new(payload.KeyRotation.Algorithm.StorageValue())
+		keyRotationGraceDays = &payload.KeyRotation.GraceDays
+	}
+
+	// deployment verification is updated as a whole (or not at all)
+	var deployVerify *bool
+	var deployVerifyWindowMinutes *int
+	if payload.DeployVerification != nil {
+		deployVerify = &payload.DeployVerification.Enabled
+		deployVerifyWindowMinutes = &payload.DeployVerification.WindowMinutes
+	}
+
+	_, err := tx.ExecContext(ctx, query,
 		payload.Name,
 		payload.Description,
 		payload.PrivateKeyId,
@@ -59,21 +112,18 @@ func (store *Storage) PutDetailsCert(payload certificates.DetailsUpdatePayload)
 		makeJsonStringSlice(payload.PostProcessingEnvironment, true),
 		payload.PostProcessingClientAddress,
 		payload.Profile,
+		keyRotationPolicy,
+		keyRotationIntervalDays,
+		keyRotationAlgorithm,
+		keyRotationGraceDays,
+		payload.TemplateID,
+		deployVerify,
+		deployVerifyWindowMinutes,
 		payload.UpdatedAt,
 		payload.ID,
 	)
 
-	if err != nil {
-		return certificates.Certificate{}, err
-	}
-
-	// get updated to return
-	updatedCert, err := store.GetOneCertById(payload.ID)
-	if err != nil {
-		return certificates.Certificate{}, err
-	}
-
-	return updatedCert, nil
+	return err
 }
 
 // UpdateCertUpdatedTime sets the specified order's updated_at to now
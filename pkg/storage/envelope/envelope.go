@@ -0,0 +1,173 @@
+package envelope
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Encrypted values are stored as:
+//   cwenc:v1:<master key id>:<wrapped data key>:<ciphertext>
+// Each value has its own random data key (AES-256-GCM) which encrypts the value. The
+// data key is then wrapped (encrypted) with the master key. Rotating the master key
+// only requires re-wrapping the data keys.
+
+const (
+	prefix     = "cwenc:v1:"
+	partsCount = 3
+)
+
+var (
+	ErrNotEncrypted   = errors.New("envelope: value is not encrypted")
+	ErrNoMasterKey    = errors.New("envelope: value is encrypted but no master key is configured")
+	ErrWrongMasterKey = errors.New("envelope: value was encrypted with a different master key")
+	errMalformed      = errors.New("envelope: encrypted value is malformed")
+)
+
+// IsEncrypted returns true if value is an envelope encrypted value
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// MasterKeyID returns the id of the master key that was used to wrap the encrypted value.
+func MasterKeyID(value string) (string, error) {
+	parts, err := split(value)
+	if err != nil {
+		return "", err
+	}
+
+	return parts[0], nil
+}
+
+// split splits an encrypted value into master key id, wrapped data key, and ciphertext
+func split(value string) ([]string, error) {
+	if !IsEncrypted(value) {
+		return nil, ErrNotEncrypted
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != partsCount {
+		return nil, errMalformed
+	}
+
+	return parts, nil
+}
+
+// seal encrypts plaintext with aead and returns nonce|ciphertext, base64 encoded
+func seal(aead cipher.AEAD, plaintext []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("envelope: failed to generate nonce (%s)", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, []byte(prefix))), nil
+}
+
+// open decodes and decrypts a value created by seal
+func open(aead cipher.AEAD, encoded string) ([]byte, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, errMalformed
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(prefix))
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to decrypt (%s)", err)
+	}
+
+	return plaintext, nil
+}
+
+// Encrypt encrypts plaintext with a new random data key and wraps the data key with
+// the master key
+func (mk *MasterKey) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, masterKeyLength)
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("envelope: failed to generate data key (%s)", err)
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(dataAEAD, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := seal(mk.aead, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + mk.id + ":" + wrappedKey + ":" + ciphertext, nil
+}
+
+// unwrap returns the data key of the encrypted value parts
+func (mk *MasterKey) unwrap(parts []string) ([]byte, error) {
+	if parts[0] != mk.id {
+		return nil, fmt.Errorf("%w (value key id: %s, master key id: %s)", ErrWrongMasterKey, parts[0], mk.id)
+	}
+
+	return open(mk.aead, parts[1])
+}
+
+// Decrypt decrypts an encrypted value. If value is not encrypted, it is returned
+// unmodified. mk may be nil, in which case an error is returned for encrypted values.
+func (mk *MasterKey) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if mk == nil {
+		return "", ErrNoMasterKey
+	}
+
+	parts, err := split(value)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := mk.unwrap(parts)
+	if err != nil {
+		return "", err
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataAEAD, parts[2])
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// Rewrap re-wraps the data key of the encrypted value using newKey. The ciphertext
+// itself is not modified.
+func (mk *MasterKey) Rewrap(value string, newKey *MasterKey) (string, error) {
+	parts, err := split(value)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := mk.unwrap(parts)
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := seal(newKey.aead, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + newKey.id + ":" + wrappedKey + ":" + parts[2], nil
+}
@@ -0,0 +1,146 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// masterKeyLength is the required length of the decoded master key (AES-256)
+const masterKeyLength = 32
+
+// masterKeyCommandTimeout is the maximum time the master key command may run
+const masterKeyCommandTimeout = 30 * time.Second
+
+var errMultipleSources = errors.New("envelope: only one master key source (file, env, or command) may be specified")
+
+// Config is the configuration for the master key used to wrap data keys. At most one
+// source may be specified. If none is specified, envelope encryption is not used.
+type Config struct {
+	MasterKeyFile    *string  `yaml:"master_key_file"`
+	MasterKeyEnv     *string  `yaml:"master_key_env"`
+	MasterKeyCommand []string `yaml:"master_key_command"`
+}
+
+// MasterKey is a key encryption key used to wrap and unwrap per-value data keys
+type MasterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// ID returns a short, non-secret identifier for the master key. It is stored with each
+// encrypted value so the wrong master key can be detected.
+func (mk *MasterKey) ID() string {
+	return mk.id
+}
+
+// ParseMasterKey parses a base64 encoded 32 byte master key. Leading and trailing
+// whitespace is ignored.
+func ParseMasterKey(encoded []byte) (*MasterKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("envelope: master key is not valid base64 (%s)", err)
+	}
+	if len(raw) != masterKeyLength {
+		return nil, fmt.Errorf("envelope: master key must be %d bytes (got %d)", masterKeyLength, len(raw))
+	}
+
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	idHash := sha256.Sum256(raw)
+
+	return &MasterKey{
+		id:   hex.EncodeToString(idHash[:4]),
+		aead: aead,
+	}, nil
+}
+
+// LoadMasterKeyFile reads and parses the master key in the specified file
+func LoadMasterKeyFile(filename string) (*MasterKey, error) {
+	encoded, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to read master key file (%s)", err)
+	}
+
+	return ParseMasterKey(encoded)
+}
+
+// LoadMasterKey loads the master key from the source specified in cfg. If cfg does not
+// specify any source, nil is returned without an error.
+func LoadMasterKey(cfg *Config) (*MasterKey, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	sources := 0
+	if cfg.MasterKeyFile != nil {
+		sources++
+	}
+	if cfg.MasterKeyEnv != nil {
+		sources++
+	}
+	if len(cfg.MasterKeyCommand) > 0 {
+		sources++
+	}
+	if sources > 1 {
+		return nil, errMultipleSources
+	}
+
+	switch {
+	case cfg.MasterKeyFile != nil:
+		return LoadMasterKeyFile(*cfg.MasterKeyFile)
+
+	case cfg.MasterKeyEnv != nil:
+		encoded, exists := os.LookupEnv(*cfg.MasterKeyEnv)
+		if !exists {
+			return nil, fmt.Errorf("envelope: master key environment variable %s is not set", *cfg.MasterKeyEnv)
+		}
+		return ParseMasterKey([]byte(encoded))
+
+	case len(cfg.MasterKeyCommand) > 0:
+		// run command (e.g. a KMS client) and use its stdout as the key
+		ctx, cancel := context.WithTimeout(context.Background(), masterKeyCommandTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, cfg.MasterKeyCommand[0], cfg.MasterKeyCommand[1:]...)
+		stderr := &bytes.Buffer{}
+		cmd.Stderr = stderr
+		encoded, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("envelope: master key command failed (%s: %s)", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return ParseMasterKey(encoded)
+
+	default:
+		// no master key configured
+	}
+
+	return nil, nil
+}
+
+// newAEAD returns an AES-GCM AEAD for the specified key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to make cipher (%s)", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to make gcm (%s)", err)
+	}
+
+	return aead, nil
+}
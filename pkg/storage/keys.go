@@ -2,6 +2,7 @@ package storage
 
 import (
 	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/domain/private_keys/key_backend"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"time"
 )
@@ -26,12 +27,21 @@ type keyDb struct {
 // toKey maps the database key info to the private_keys Key
 // object
 func (key keyDb) toKey() private_keys.Key {
+	// the pem field holds the key's backend reference if it isn't a pem key
+	backend := key_backend.TypeOf(key.pem)
+	pem, backendRef := key.pem, ""
+	if backend != key_backend.TypePem {
+		pem, backendRef = "", key.pem
+	}
+
 	return private_keys.Key{
 		ID:             key.id,
 		Name:           key.name,
 		Description:    key.description,
 		Algorithm:      key_crypto.AlgorithmByStorageValue(key.algorithmValue),
-		Pem:            key.pem,
+		Backend:        backend,
+		BackendRef:     backendRef,
+		Pem:            pem,
 		ApiKey:         key.apiKey,
 		ApiKeyNew:      key.apiKeyNew,
 		ApiKeyDisabled: key.apiKeyDisabled,
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/storage/envelope"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// keyPemScanner is a sql.Scanner that scans a private key pem into dest, transparently
+// decrypting it if it is envelope encrypted
+type keyPemScanner struct {
+	dest      *string
+	masterKey *envelope.MasterKey
+}
+
+// Scan implements sql.Scanner
+func (s *keyPemScanner) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("storage: unsupported type %T for key pem", src)
+	}
+
+	pem, err := s.masterKey.Decrypt(value)
+	if err != nil {
+		return err
+	}
+
+	*s.dest = pem
+	return nil
+}
+
+// keyPem returns a scanner to use in place of dest when scanning a private key pem
+func (store *Storage) keyPem(dest *string) *keyPemScanner {
+	return &keyPemScanner{
+		dest:      dest,
+		masterKey: store.masterKey,
+	}
+}
+
+// encryptKeyPem encrypts a private key pem for storage. If no master key is configured,
+// the pem is returned unmodified.
+func (store *Storage) encryptKeyPem(pem string) (string, error) {
+	if store.masterKey == nil {
+		return pem, nil
+	}
+
+	return store.masterKey.Encrypt(pem)
+}
+
+// migrateKeyPemEncryption encrypts any plaintext private key pems if a master key is
+// configured. It also confirms any already encrypted pems were encrypted with the
+// configured master key (or that a master key is configured at all).
+func (store *Storage) migrateKeyPemEncryption(logger *zap.SugaredLogger) error {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	pems, err := txGetAllKeyPems(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	encryptedCount := 0
+	for id, pem := range pems {
+		if envelope.IsEncrypted(pem) {
+			// verify existing (fail now rather than on first use)
+			_, err = store.masterKey.Decrypt(pem)
+			if err != nil {
+				return fmt.Errorf("storage: failed to decrypt private key id %d (%w)", id, err)
+			}
+			continue
+		}
+
+		// plaintext, encrypt it if there is a master key
+		if store.masterKey == nil {
+			continue
+		}
+
+		encryptedPem, err := store.masterKey.Encrypt(pem)
+		if err != nil {
+			return err
+		}
+
+		err = txPutKeyPem(ctx, tx, id, encryptedPem)
+		if err != nil {
+			return err
+		}
+		encryptedCount++
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	if encryptedCount > 0 {
+		logger.Infof("storage: encrypted %d private key(s) with master key %s", encryptedCount, store.masterKey.ID())
+		logger.Warn("storage: backups made before private key encryption was enabled still contain plaintext private keys")
+	}
+
+	return nil
+}
+
+// RotateMasterKey re-wraps the data keys of all private keys using newKey. Any plaintext
+// private keys are also encrypted. On success, newKey becomes the storage master key and
+// the number of keys that were updated is returned.
+func (store *Storage) RotateMasterKey(newKey *envelope.MasterKey) (int, error) {
+	if newKey == nil {
+		return 0, errors.New("storage: new master key is missing")
+	}
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	pems, err := txGetAllKeyPems(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	for id, pem := range pems {
+		var newPem string
+		if envelope.IsEncrypted(pem) {
+			if store.masterKey == nil {
+				return 0, fmt.Errorf("storage: failed to re-wrap private key id %d (%w)", id, envelope.ErrNoMasterKey)
+			}
+			newPem, err = store.masterKey.Rewrap(pem, newKey)
+		} else {
+			newPem, err = newKey.Encrypt(pem)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("storage: failed to re-wrap private key id %d (%w)", id, err)
+		}
+
+		err = txPutKeyPem(ctx, tx, id, newPem)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	store.masterKey = newKey
+
+	return len(pems), nil
+}
+
+// txGetAllKeyPems returns the raw (possibly encrypted) pem of every private key, by id
+func txGetAllKeyPems(ctx context.Context, tx *sql.Tx) (map[int]string, error) {
+	query := `
+	SELECT
+		id, pem
+	FROM
+		private_keys
+	`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pems := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var pem string
+		err = rows.Scan(&id, &pem)
+		if err != nil {
+			return nil, err
+		}
+
+		pems[id] = pem
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return pems, nil
+}
+
+// txPutKeyPem updates the raw pem of the specified private key
+func txPutKeyPem(ctx context.Context, tx *sql.Tx, id int, pem string) error {
+	query := `
+	UPDATE
+		private_keys
+	SET
+		pem = $1
+	WHERE
+		id = $2
+	`
+
+	_, err := tx.ExecContext(ctx, query, pem, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
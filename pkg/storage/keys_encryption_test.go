@@ -0,0 +1,189 @@
+package storage_test
+
+import (
+	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/storage/envelope"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeTestMasterKeyConfig writes a new random master key to a file and returns a config
+// that uses it along with the parsed key
+func makeTestMasterKeyConfig(t *testing.T) (*envelope.Config, *envelope.MasterKey) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(raw)
+
+	keyFile := filepath.Join(t.TempDir(), "master.key")
+	err = os.WriteFile(keyFile, []byte(encoded), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mk, err := envelope.ParseMasterKey([]byte(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &envelope.Config{MasterKeyFile: &keyFile}, mk
+}
+
+// reopenStorage opens the test folder's existing db using cfg
+func reopenStorage(t *testing.T, testName string, cfg *envelope.Config) (*storage.Storage, error) {
+	store, err := storage.OpenStorage(makeFakeApp(t, tempFileStorage+testName), cfg)
+	if err != nil {
+		return nil, err
+	}
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+
+	return store, nil
+}
+
+func TestKeyEncryption(t *testing.T) {
+	const testName = "TestKeyEncryption"
+	cfg, _ := makeTestMasterKeyConfig(t)
+
+	// open encrypts existing keys
+	store, err := openStorageWithTestDataAndKeyConfig(t, testName, cfg)
+	if err != nil {
+		t.Fatalf("failed to open storage with master key (%s)", err)
+	}
+
+	key, err := store.GetOneKeyById(key1.ID)
+	if err != nil {
+		t.Fatalf("failed to get key (%s)", err)
+	}
+	if key.Pem != key1.Pem {
+		t.Errorf("expected decrypted pem %s, got %s", key1.Pem, key.Pem)
+	}
+
+	// new keys are encrypted and decrypted too
+	newKey, err := store.PostNewKey(private_keys.NewPayload{
+		Name:           new("encrypted_key"),
+		Description:    new(""),
+		AlgorithmValue: new(key_crypto.AlgorithmECDSAp256.StorageValue()),
+		PemContent:     new("-- new encrypted pem --"),
+		ApiKey:         "apikeyencrypted",
+		ApiKeyDisabled: new(false),
+		CreatedAt:      1780336479,
+		UpdatedAt:      1780337000,
+	})
+	if err != nil {
+		t.Fatalf("failed to post new key (%s)", err)
+	}
+	if newKey.Pem != "-- new encrypted pem --" {
+		t.Errorf("expected new key decrypted pem, got %s", newKey.Pem)
+	}
+
+	// duplicate keys are rejected even though the stored (encrypted) pems differ
+	_, err = store.PostNewKey(private_keys.NewPayload{
+		Name:           new("encrypted_key_dupe"),
+		Description:    new(""),
+		AlgorithmValue: new(key_crypto.AlgorithmECDSAp256.StorageValue()),
+		PemContent:     new("-- new encrypted pem --"),
+		ApiKey:         "apikeyencrypteddupe",
+		ApiKeyDisabled: new(false),
+		CreatedAt:      1780336479,
+		UpdatedAt:      1780337000,
+	})
+	if err == nil {
+		t.Error("expected error posting duplicate key")
+	}
+	_, err = store.PostNewKey(private_keys.NewPayload{
+		Name:           new("encrypted_key_existing_dupe"),
+		Description:    new(""),
+		AlgorithmValue: new(key1.Algorithm.StorageValue()),
+		PemContent:     new(key1.Pem),
+		ApiKey:         "apikeyexistingdupe",
+		ApiKeyDisabled: new(false),
+		CreatedAt:      1780336479,
+		UpdatedAt:      1780337000,
+	})
+	if err == nil {
+		t.Error("expected error posting duplicate of a key that existed before fingerprints")
+	}
+
+	// keys embedded in other objects are decrypted
+	cert, err := store.GetOneCertById(18)
+	if err != nil {
+		t.Fatalf("failed to get cert (%s)", err)
+	}
+	if envelope.IsEncrypted(cert.CertificateKey.Pem) || envelope.IsEncrypted(cert.CertificateAccount.AccountKey.Pem) {
+		t.Error("certificate or account key pem was not decrypted")
+	}
+
+	err = store.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without master key
+	_, err = reopenStorage(t, testName, nil)
+	if !errors.Is(err, envelope.ErrNoMasterKey) {
+		t.Errorf("expected no master key error, got %v", err)
+	}
+
+	// wrong master key
+	wrongCfg, _ := makeTestMasterKeyConfig(t)
+	_, err = reopenStorage(t, testName, wrongCfg)
+	if !errors.Is(err, envelope.ErrWrongMasterKey) {
+		t.Errorf("expected wrong master key error, got %v", err)
+	}
+}
+
+func TestKeyEncryption_RotateMasterKey(t *testing.T) {
+	const testName = "TestKeyEncryption_RotateMasterKey"
+	oldCfg, _ := makeTestMasterKeyConfig(t)
+	newCfg, newMasterKey := makeTestMasterKeyConfig(t)
+
+	store, err := openStorageWithTestDataAndKeyConfig(t, testName, oldCfg)
+	if err != nil {
+		t.Fatalf("failed to open storage with master key (%s)", err)
+	}
+
+	count, err := store.RotateMasterKey(newMasterKey)
+	if err != nil {
+		t.Fatalf("failed to rotate master key (%s)", err)
+	}
+	if count <= 0 {
+		t.Errorf("expected rotated key count > 0, got %d", count)
+	}
+
+	// still readable after rotation
+	key, err := store.GetOneKeyById(key4.ID)
+	if err != nil || key.Pem != key4.Pem {
+		t.Errorf("failed to read key after rotation (err: %v)", err)
+	}
+
+	err = store.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// old key no longer works
+	_, err = reopenStorage(t, testName, oldCfg)
+	if !errors.Is(err, envelope.ErrWrongMasterKey) {
+		t.Errorf("expected wrong master key error with old key, got %v", err)
+	}
+
+	// new key works
+	store, err = reopenStorage(t, testName, newCfg)
+	if err != nil {
+		t.Fatalf("failed to open storage with new master key (%s)", err)
+	}
+	key, err = store.GetOneKeyById(key4.ID)
+	if err != nil || key.Pem != key4.Pem {
+		t.Errorf("failed to read key with new master key (err: %v)", err)
+	}
+}
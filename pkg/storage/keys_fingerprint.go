@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/storage/envelope"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// keyPemFingerprint returns the fingerprint of a plaintext private key pem. It is saved
+// with the key (and is unique) so a duplicate key is rejected even if pems are encrypted.
+func keyPemFingerprint(pem string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(pem)))
+}
+
+// fingerprintKeyPems sets the pem fingerprint of any private keys that don't have one
+// (i.e. keys saved before fingerprints were added). A key that duplicates an already
+// fingerprinted key is left without a fingerprint and logged so it can be removed.
+func (store *Storage) fingerprintKeyPems(logger *zap.SugaredLogger) error {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	SELECT
+		id, pem
+	FROM
+		private_keys
+	WHERE
+		pem_fingerprint IS NULL
+	ORDER BY
+		id
+	`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	pems := make(map[int]string)
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		var pem string
+		err = rows.Scan(&id, &pem)
+		if err != nil {
+			return err
+		}
+		pems[id] = pem
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		pem := pems[id]
+		if envelope.IsEncrypted(pem) {
+			if store.masterKey == nil {
+				return fmt.Errorf("storage: private key id %d is encrypted but no master key is configured", id)
+			}
+			pem, err = store.masterKey.Decrypt(pem)
+			if err != nil {
+				return fmt.Errorf("storage: failed to decrypt private key id %d (%w)", id, err)
+			}
+		}
+		fingerprint := keyPemFingerprint(pem)
+
+		// check for a duplicate
+		query = `
+		SELECT
+			id
+		FROM
+			private_keys
+		WHERE
+			pem_fingerprint = $1
+		`
+
+		dupeId := -1
+		err = tx.QueryRowContext(ctx, query, fingerprint).Scan(&dupeId)
+		if err == nil {
+			logger.Warnf("storage: private key id %d is a duplicate of private key id %d and should be removed", id, dupeId)
+			continue
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		query = `
+		UPDATE
+			private_keys
+		SET
+			pem_fingerprint = $1
+		WHERE
+			id = $2
+		`
+
+		_, err = tx.ExecContext(ctx, query, fingerprint, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
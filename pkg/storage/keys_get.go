@@ -67,7 +67,7 @@ func (store Storage) GetAllKeys(q pagination_sort.Query) (keys []private_keys.Ke
 			&oneKeyDb.name,
 			&oneKeyDb.description,
 			&oneKeyDb.algorithmValue,
-			&oneKeyDb.pem,
+			store.keyPem(&oneKeyDb.pem),
 			&oneKeyDb.apiKey,
 			&oneKeyDb.apiKeyNew,
 			&oneKeyDb.apiKeyDisabled,
@@ -125,7 +125,7 @@ func (store Storage) getOneKey(id int, name string) (private_keys.Key, error) {
 		&oneKeyDb.name,
 		&oneKeyDb.description,
 		&oneKeyDb.algorithmValue,
-		&oneKeyDb.pem,
+		store.keyPem(&oneKeyDb.pem),
 		&oneKeyDb.apiKey,
 		&oneKeyDb.apiKeyNew,
 		&oneKeyDb.apiKeyDisabled,
@@ -194,7 +194,7 @@ func (store *Storage) GetAvailableKeys() ([]private_keys.Key, error) {
 			&oneKeyDb.name,
 			&oneKeyDb.description,
 			&oneKeyDb.algorithmValue,
-			&oneKeyDb.pem,
+			store.keyPem(&oneKeyDb.pem),
 			&oneKeyDb.apiKey,
 			&oneKeyDb.apiKeyNew,
 			&oneKeyDb.apiKeyDisabled,
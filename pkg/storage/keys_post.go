@@ -7,13 +7,25 @@ import (
 
 // PostNewKey saves the KeyExtended to the db as a new key
 func (store *Storage) PostNewKey(payload private_keys.NewPayload) (private_keys.Key, error) {
+	// encrypt pem (if master key is configured)
+	pem := payload.PemContent
+	var fingerprint *string
+	if pem != nil {
+		fingerprint = new(keyPemFingerprint(*pem))
+		encryptedPem, err := store.encryptKeyPem(*pem)
+		if err != nil {
+			return private_keys.Key{}, err
+		}
+		pem = &encryptedPem
+	}
+
 	// database action
 	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
 	defer cancel()
 
 	query := `
-	INSERT INTO private_keys (name, description, algorithm, pem, api_key, api_key_disabled, api_key_via_url, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	INSERT INTO private_keys (name, description, algorithm, pem, pem_fingerprint, api_key, api_key_disabled, api_key_via_url, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	RETURNING id
 	`
 
@@ -23,7 +35,8 @@ func (store *Storage) PostNewKey(payload private_keys.NewPayload) (private_keys.
 		payload.Name,
 		payload.Description,
 		payload.AlgorithmValue,
-		payload.PemContent,
+		pem,
+		fingerprint,
 		payload.ApiKey,
 		payload.ApiKeyDisabled,
 		payload.ApiKeyViaUrl,
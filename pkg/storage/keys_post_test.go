@@ -2,6 +2,7 @@ package storage_test
 
 import (
 	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/domain/private_keys/key_backend"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"certwarden-backend/pkg/test_helpers"
 	"database/sql"
@@ -47,6 +48,37 @@ func TestPostNewKey(t *testing.T) {
 			},
 			nil,
 		},
+		{ // key on a pkcs11 token
+			private_keys.NewPayload{
+				Name:           new("new_key_token"),
+				Description:    new("on a token"),
+				AlgorithmValue: new(key_crypto.AlgorithmECDSAp256.StorageValue()),
+				PemContent:     new("pkcs11:slot-id=0;object=new_key_token;type=private?module-path=/usr/lib/softhsm/libsofthsm2.so"),
+				ApiKey:         "apikeytoken",
+				ApiKeyDisabled: new(false),
+				ApiKeyViaUrl:   false,
+				CreatedAt:      1780336481,
+				UpdatedAt:      1780337002,
+			},
+			nil,
+			private_keys.Key{
+				ID:             73,
+				Name:           "new_key_token",
+				Description:    "on a token",
+				Algorithm:      key_crypto.AlgorithmECDSAp256,
+				Backend:        key_backend.TypePKCS11,
+				BackendRef:     "pkcs11:slot-id=0;object=new_key_token;type=private?module-path=/usr/lib/softhsm/libsofthsm2.so",
+				Pem:            "",
+				ApiKey:         "apikeytoken",
+				ApiKeyNew:      "",
+				ApiKeyDisabled: false,
+				ApiKeyViaUrl:   false,
+				LastAccess:     time.Unix(0, 0),
+				CreatedAt:      time.Unix(1780336481, 0),
+				UpdatedAt:      time.Unix(1780337002, 0),
+			},
+			nil,
+		},
 		{ // duplicate name (non-case sensitive)
 			private_keys.NewPayload{
 				Name:           new("le_staging"),
@@ -0,0 +1,433 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/orders"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// StageCertKeyRotation saves a newly generated key that will replace a certificate's private
+// key once the cert's next order is valid. The new key is not assigned to the cert and its api
+// key is disabled, so the cert's current key continues to be used for downloads until then.
+// All changes are made in a single transaction.
+func (store *Storage) StageCertKeyRotation(payload orders.StageKeyRotationPayload) (newKeyId int, err error) {
+	// encrypt pem (if master key is configured)
+	pem, err := store.encryptKeyPem(payload.NewKeyPem)
+	if err != nil {
+		return -1, err
+	}
+
+	// database action
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// confirm the cert is still using the old key
+	query := `
+	SELECT
+		EXISTS (SELECT 1 FROM certificates WHERE id = $1 AND private_key_id = $2)
+	`
+
+	usingOldKey := false
+	err = tx.QueryRowContext(ctx, query, payload.CertID, payload.OldKeyID).Scan(&usingOldKey)
+	if err != nil {
+		return -1, err
+	}
+	if !usingOldKey {
+		return -1, ErrWrongUpdateRowCount
+	}
+
+	// insert the new key
+	query = `
+	INSERT INTO private_keys (name, description, algorithm, pem, pem_fingerprint, api_key, api_key_disabled, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, 1, $7, $8)
+	RETURNING id
+	`
+
+	err = tx.QueryRowContext(ctx, query,
+		payload.NewKeyName,
+		payload.NewKeyDescription,
+		payload.NewKeyAlgorithm.StorageValue(),
+		pem,
+		keyPemFingerprint(payload.NewKeyPem),
+		payload.NewKeyApiKey,
+		payload.CreatedAt,
+		payload.CreatedAt,
+	).Scan(&newKeyId)
+	if err != nil {
+		return -1, err
+	}
+
+	// record the pending rotation
+	query = `
+	INSERT INTO private_key_rotations (certificate_id, old_key_id, new_key_id, grace_days, created_at)
+	VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		payload.CertID,
+		payload.OldKeyID,
+		newKeyId,
+		payload.GraceDays,
+		payload.CreatedAt,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newKeyId, nil
+}
+
+// GetCertKeyRotation returns the certificate's pending key rotation; sql.ErrNoRows is
+// returned if there isn't one
+func (store *Storage) GetCertKeyRotation(certId int) (orders.PendingKeyRotation, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		certificate_id, old_key_id, new_key_id, grace_days
+	FROM
+		private_key_rotations
+	WHERE
+		certificate_id = $1
+	`
+
+	var rotation orders.PendingKeyRotation
+	err := store.db.QueryRowContext(ctx, query, certId).Scan(
+		&rotation.CertID,
+		&rotation.OldKeyID,
+		&rotation.NewKeyID,
+		&rotation.GraceDays,
+	)
+	if err != nil {
+		return orders.PendingKeyRotation{}, err
+	}
+
+	return rotation, nil
+}
+
+// CompleteCertKeyRotation finishes a certificate's pending key rotation (after the new key's
+// order is valid). The new key takes over the old key's name, description, and api key
+// settings (so key clients do not need to be reconfigured) and is assigned to the cert, while
+// the old key is renamed and scheduled for retirement. All changes are made in a single
+// transaction.
+func (store *Storage) CompleteCertKeyRotation(payload orders.CompleteKeyRotationPayload) error {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// get the pending rotation
+	query := `
+	SELECT
+		old_key_id, new_key_id
+	FROM
+		private_key_rotations
+	WHERE
+		certificate_id = $1
+	`
+
+	var oldKeyId, newKeyId int
+	err = tx.QueryRowContext(ctx, query, payload.CertID).Scan(&oldKeyId, &newKeyId)
+	if err != nil {
+		return err
+	}
+
+	// get the old key's details to carry over to the new key
+	query = `
+	SELECT
+		name, description, api_key, api_key_new, api_key_disabled, api_key_via_url
+	FROM
+		private_keys
+	WHERE
+		id = $1
+	`
+
+	var name, description, apiKey, apiKeyNew string
+	var apiKeyDisabled, apiKeyViaUrl bool
+	err = tx.QueryRowContext(ctx, query, oldKeyId).Scan(
+		&name, &description, &apiKey, &apiKeyNew, &apiKeyDisabled, &apiKeyViaUrl,
+	)
+	if err != nil {
+		return err
+	}
+
+	// rename old key, freeing its name for the new key
+	query = `
+	UPDATE
+		private_keys
+	SET
+		name = $1,
+		updated_at = $2
+	WHERE
+		id = $3
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		name+payload.OldKeyRetiredSuffix,
+		payload.UpdatedAt,
+		oldKeyId,
+	)
+	if err != nil {
+		return err
+	}
+
+	// new key takes over
+	query = `
+	UPDATE
+		private_keys
+	SET
+		name = $1,
+		description = $2,
+		api_key = $3,
+		api_key_new = $4,
+		api_key_disabled = $5,
+		api_key_via_url = $6,
+		updated_at = $7
+	WHERE
+		id = $8
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		name,
+		description,
+		apiKey,
+		apiKeyNew,
+		apiKeyDisabled,
+		apiKeyViaUrl,
+		payload.UpdatedAt,
+		newKeyId,
+	)
+	if err != nil {
+		return err
+	}
+
+	// move the cert to the new key (only if it is still using the old key)
+	query = `
+	UPDATE
+		certificates
+	SET
+		private_key_id = $1,
+		updated_at = $2
+	WHERE
+		id = $3
+		AND
+		private_key_id = $4
+	`
+
+	result, err := tx.ExecContext(ctx, query,
+		newKeyId,
+		payload.UpdatedAt,
+		payload.CertID,
+		oldKeyId,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return ErrWrongUpdateRowCount
+	}
+
+	// schedule the old key's retirement
+	query = `
+	INSERT INTO private_key_retirements (private_key_id, retire_at)
+	VALUES ($1, $2)
+	ON CONFLICT (private_key_id) DO UPDATE SET retire_at = excluded.retire_at
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		oldKeyId,
+		payload.RetireAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	// rotation is done
+	query = `
+	DELETE FROM
+		private_key_rotations
+	WHERE
+		certificate_id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, query, payload.CertID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CancelCertKeyRotation discards a certificate's pending key rotation (e.g. the new key's
+// order failed) and deletes the new key, unless it has since been assigned to something.
+// The cert's current key is unchanged.
+func (store *Storage) CancelCertKeyRotation(certId int) error {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// get the pending rotation's key
+	query := `
+	SELECT
+		new_key_id
+	FROM
+		private_key_rotations
+	WHERE
+		certificate_id = $1
+	`
+
+	var newKeyId int
+	err = tx.QueryRowContext(ctx, query, certId).Scan(&newKeyId)
+	if err != nil {
+		return err
+	}
+
+	query = `
+	DELETE FROM
+		private_key_rotations
+	WHERE
+		certificate_id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, query, certId)
+	if err != nil {
+		return err
+	}
+
+	// delete the new key (orders it finalized keep their cert, their key is set to null)
+	query = `
+	DELETE FROM
+		private_keys
+	WHERE
+		id = $1
+		AND NOT EXISTS (SELECT 1 FROM acme_accounts WHERE private_key_id = $1)
+		AND NOT EXISTS (SELECT 1 FROM certificates WHERE private_key_id = $1)
+	`
+
+	_, err = tx.ExecContext(ctx, query, newKeyId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetDueKeyRetirements returns the ids of keys whose scheduled retirement time is at
+// or before the specified time
+func (store *Storage) GetDueKeyRetirements(unixTime int64) (keyIds []int, err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		private_key_id
+	FROM
+		private_key_retirements
+	WHERE
+		retire_at <= $1
+	ORDER BY
+		retire_at
+	`
+
+	rows, err := store.db.QueryContext(ctx, query, unixTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keyIds = []int{}
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		keyIds = append(keyIds, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return keyIds, nil
+}
+
+// RetireKey deletes a key that was scheduled for retirement by key rotation. If the key has
+// since been assigned to an account or certificate, the retirement is canceled instead. If
+// the key is still in use by a certificate's newest valid order (e.g. the renewal with the
+// new key has not completed), nothing is changed so retirement can be attempted again later.
+func (store *Storage) RetireKey(keyId int) (retired bool, err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// check if key was reassigned
+	query := `
+	SELECT
+		EXISTS (SELECT 1 FROM acme_accounts WHERE private_key_id = $1)
+		OR
+		EXISTS (SELECT 1 FROM certificates WHERE private_key_id = $1)
+	`
+
+	assigned := false
+	err = store.db.QueryRowContext(ctx, query, keyId).Scan(&assigned)
+	if err != nil {
+		return false, err
+	}
+
+	if assigned {
+		query = `
+		DELETE FROM
+			private_key_retirements
+		WHERE
+			private_key_id = $1
+		`
+
+		_, err = store.db.ExecContext(ctx, query, keyId)
+		if err != nil {
+			return false, err
+		}
+
+		return false, nil
+	}
+
+	// delete (retirement record is removed by cascade)
+	err = store.DeleteKey(keyId)
+	if err != nil {
+		if errors.Is(err, ErrInUse) {
+			return false, nil
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,219 @@
+package storage_test
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/orders"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"certwarden-backend/pkg/storage"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCertKeyRotation(t *testing.T) {
+	// create testing service
+	store, err := openStorageWithTestData(t, "rotatecertkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// default policy
+	cert, err := store.GetOneCertById(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.KeyRotation.Policy != certificates.KeyRotationReuse || cert.KeyRotation.GraceDays != 7 {
+		t.Errorf("expected default key rotation policy but got %+v", cert.KeyRotation)
+	}
+
+	// wrong old key id, nothing should change
+	_, err = store.StageCertKeyRotation(orders.StageKeyRotationPayload{
+		CertID:          32,
+		OldKeyID:        62,
+		NewKeyName:      "SomeKEy.next.1000",
+		NewKeyAlgorithm: key_crypto.AlgorithmECDSAp256,
+		NewKeyPem:       "new-pem-wrong",
+		NewKeyApiKey:    "new-api-key-wrong",
+		GraceDays:       7,
+		CreatedAt:       1000,
+	})
+	if !errors.Is(err, storage.ErrWrongUpdateRowCount) {
+		t.Errorf("expected error '%s' but got '%v'", storage.ErrWrongUpdateRowCount, err)
+	}
+	_, err = store.GetOneKeyByName("SomeKEy.next.1000")
+	if err == nil {
+		t.Error("expected failed staging to not add a key")
+	}
+
+	// stage rotation of cert with no orders
+	newKeyID, err := store.StageCertKeyRotation(orders.StageKeyRotationPayload{
+		CertID:          32,
+		OldKeyID:        68,
+		NewKeyName:      "asdasdsadasd.next.1000",
+		NewKeyAlgorithm: key_crypto.AlgorithmECDSAp384,
+		NewKeyPem:       "new-pem-32",
+		NewKeyApiKey:    "new-api-key-32",
+		GraceDays:       1,
+		CreatedAt:       1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing changes for the cert or its key until the rotation completes
+	cert, err = store.GetOneCertById(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.CertificateKey.ID != 68 || cert.CertificateKey.Name != "asdasdsadasd" {
+		t.Errorf("expected staged rotation to not change the cert's key (got %+v)", cert.CertificateKey)
+	}
+	newKey, err := store.GetOneKeyById(newKeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newKey.Name != "asdasdsadasd.next.1000" || !newKey.ApiKeyDisabled {
+		t.Errorf("expected staged key with disabled api key (got %+v)", newKey)
+	}
+
+	rotation, err := store.GetCertKeyRotation(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotation != (orders.PendingKeyRotation{CertID: 32, OldKeyID: 68, NewKeyID: newKeyID, GraceDays: 1}) {
+		t.Errorf("unexpected pending rotation %+v", rotation)
+	}
+
+	// complete
+	err = store.CompleteCertKeyRotation(orders.CompleteKeyRotationPayload{
+		CertID:              32,
+		OldKeyRetiredSuffix: ".retired.1000",
+		RetireAt:            2000,
+		UpdatedAt:           1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err = store.GetOneCertById(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.CertificateKey.ID != newKeyID {
+		t.Errorf("expected cert key id %d but got %d", newKeyID, cert.CertificateKey.ID)
+	}
+	if cert.CertificateKey.Name != "asdasdsadasd" || cert.CertificateKey.ApiKey != "key-api-key-68" || cert.CertificateKey.ApiKeyDisabled ||
+		cert.CertificateKey.Algorithm != key_crypto.AlgorithmECDSAp384 || cert.CertificateKey.Pem != "new-pem-32" {
+		t.Errorf("new key did not take over old key's details (got %+v)", cert.CertificateKey)
+	}
+
+	oldKey, err := store.GetOneKeyById(68)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oldKey.Name != "asdasdsadasd.retired.1000" {
+		t.Errorf("expected old key to be renamed but got name '%s'", oldKey.Name)
+	}
+	_, err = store.GetCertKeyRotation(32)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected completed rotation to be removed but got '%v'", err)
+	}
+
+	// cancel (e.g. order failed), new key is deleted and cert is unchanged
+	newKeyID, err = store.StageCertKeyRotation(orders.StageKeyRotationPayload{
+		CertID:          18,
+		OldKeyID:        31,
+		NewKeyName:      "certwarden.next.1000",
+		NewKeyAlgorithm: key_crypto.AlgorithmECDSAp256,
+		NewKeyPem:       "new-pem-18-canceled",
+		NewKeyApiKey:    "new-api-key-18",
+		GraceDays:       7,
+		CreatedAt:       1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.CancelCertKeyRotation(18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.GetOneKeyById(newKeyID)
+	if err == nil {
+		t.Error("expected canceled rotation's key to be deleted")
+	}
+	_, err = store.GetCertKeyRotation(18)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected canceled rotation to be removed but got '%v'", err)
+	}
+	cert, err = store.GetOneCertById(18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.CertificateKey.ID != 31 {
+		t.Errorf("expected canceled rotation to not change the cert's key (got %d)", cert.CertificateKey.ID)
+	}
+
+	// rotate cert whose old key is still in use by its newest valid order
+	_, err = store.StageCertKeyRotation(orders.StageKeyRotationPayload{
+		CertID:          18,
+		OldKeyID:        31,
+		NewKeyName:      "certwarden.next.1001",
+		NewKeyAlgorithm: key_crypto.AlgorithmECDSAp256,
+		NewKeyPem:       "new-pem-18",
+		NewKeyApiKey:    "new-api-key-18",
+		GraceDays:       7,
+		CreatedAt:       1001,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.CompleteCertKeyRotation(orders.CompleteKeyRotationPayload{
+		CertID:              18,
+		OldKeyRetiredSuffix: ".retired.1001",
+		RetireAt:            3000,
+		UpdatedAt:           1001,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// retirements
+	due, err := store.GetDueKeyRetirements(1999)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Errorf("expected no keys due for retirement but got %v", due)
+	}
+
+	due, err = store.GetDueKeyRetirements(3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(due, []int{68, 31}) {
+		t.Errorf("expected keys [68 31] due for retirement but got %v", due)
+	}
+
+	retired, err := store.RetireKey(68)
+	if err != nil || !retired {
+		t.Errorf("expected key 68 to be retired (retired: %t, err: %v)", retired, err)
+	}
+	_, err = store.GetOneKeyById(68)
+	if err == nil {
+		t.Error("expected key 68 to be deleted")
+	}
+
+	retired, err = store.RetireKey(31)
+	if err != nil || retired {
+		t.Errorf("expected in use key 31 to not be retired (retired: %t, err: %v)", retired, err)
+	}
+
+	due, err = store.GetDueKeyRetirements(3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(due, []int{31}) {
+		t.Errorf("expected key [31] still due for retirement but got %v", due)
+	}
+}
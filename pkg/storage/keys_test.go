@@ -23,6 +23,15 @@ func CompareKey(t *testing.T, key, expectedKey private_keys.Key) {
 		t.Errorf("key: algorithm expected '%s' but got '%s'", expectedKey.Algorithm.StorageValue(), key.Algorithm.StorageValue())
 	}
 
+	// fixtures only specify the backend if it isn't pem
+	if expectedKey.Backend != "" && key.Backend != expectedKey.Backend {
+		t.Errorf("key: backend expected '%s' but got '%s'", expectedKey.Backend, key.Backend)
+	}
+
+	if key.BackendRef != expectedKey.BackendRef {
+		t.Errorf("key: backend ref expected '%s' but got '%s'", expectedKey.BackendRef, key.BackendRef)
+	}
+
 	if key.Pem != expectedKey.Pem {
 		t.Errorf("key: pem expected '%s' but got '%s'", expectedKey.Pem, key.Pem)
 	}
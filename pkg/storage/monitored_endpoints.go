@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/monitored_endpoints"
+	"certwarden-backend/pkg/tlsprobe"
+	"database/sql"
+	"time"
+)
+
+// monitoredEndpointDb is a single monitored endpoint, as database table fields
+// corresponds to monitored_endpoints.Endpoint
+type monitoredEndpointDb struct {
+	id                   int
+	name                 string
+	description          string
+	host                 string
+	port                 int
+	serverName           string
+	startTLS             string
+	certificateId        sql.NullInt32
+	certificateName      sql.NullString
+	checkIntervalMinutes int
+	enabled              bool
+	createdAt            int64
+	updatedAt            int64
+	lastCheckedAt        sql.NullInt32
+	lastError            string
+	servedChain          string
+	leafSerial           string
+	leafSubject          string
+	leafIssuer           string
+	leafDNSNames         jsonStringSlice // stored as json array
+	leafNotBefore        sql.NullInt32
+	leafNotAfter         sql.NullInt32
+	matchesNewestOrder   sql.NullBool
+}
+
+// monitoredEndpointDbFields are the columns selected for a monitoredEndpointDb, in the
+// order expected by scanFields
+const monitoredEndpointDbFields = `
+		me.id, me.name, me.description, me.host, me.port, me.server_name, me.starttls,
+		me.certificate_id, c.name, me.check_interval_minutes, me.enabled, me.created_at, me.updated_at,
+		me.last_checked_at, me.last_error, me.served_chain, me.leaf_serial, me.leaf_subject, me.leaf_issuer,
+		me.leaf_dns_names, me.leaf_not_before, me.leaf_not_after, me.matches_newest_order`
+
+// monitoredEndpointDbFrom is the FROM clause for selecting monitoredEndpointDbFields
+const monitoredEndpointDbFrom = `
+		monitored_endpoints me
+		LEFT JOIN certificates c on (me.certificate_id = c.id)`
+
+// scanFields returns pointers to the endpoint's fields, for use with Scan
+func (ep *monitoredEndpointDb) scanFields() []any {
+	return []any{
+		&ep.id,
+		&ep.name,
+		&ep.description,
+		&ep.host,
+		&ep.port,
+		&ep.serverName,
+		&ep.startTLS,
+		&ep.certificateId,
+		&ep.certificateName,
+		&ep.checkIntervalMinutes,
+		&ep.enabled,
+		&ep.createdAt,
+		&ep.updatedAt,
+		&ep.lastCheckedAt,
+		&ep.lastError,
+		&ep.servedChain,
+		&ep.leafSerial,
+		&ep.leafSubject,
+		&ep.leafIssuer,
+		&ep.leafDNSNames,
+		&ep.leafNotBefore,
+		&ep.leafNotAfter,
+		&ep.matchesNewestOrder,
+	}
+}
+
+func (ep monitoredEndpointDb) toEndpoint() monitored_endpoints.Endpoint {
+	var cert *monitored_endpoints.EndpointCertificate
+	if ep.certificateId.Valid {
+		cert = &monitored_endpoints.EndpointCertificate{
+			ID:   int(ep.certificateId.Int32),
+			Name: ep.certificateName.String,
+		}
+	}
+
+	// no check if never checked
+	var lastCheck *monitored_endpoints.Check
+	if ep.lastCheckedAt.Valid {
+		var matches *bool
+		if ep.matchesNewestOrder.Valid {
+			matches = &ep.matchesNewestOrder.Bool
+		}
+
+		lastCheck = &monitored_endpoints.Check{
+			CheckedAt:          time.Unix(int64(ep.lastCheckedAt.Int32), 0),
+			Error:              ep.lastError,
+			ServedChain:        ep.servedChain,
+			LeafSerial:         ep.leafSerial,
+			LeafSubject:        ep.leafSubject,
+			LeafIssuer:         ep.leafIssuer,
+			LeafDNSNames:       ep.leafDNSNames.toSlice(),
+			LeafNotBefore:      nullInt32UnixToTime(ep.leafNotBefore),
+			LeafNotAfter:       nullInt32UnixToTime(ep.leafNotAfter),
+			MatchesNewestOrder: matches,
+		}
+	}
+
+	return monitored_endpoints.Endpoint{
+		ID:                   ep.id,
+		Name:                 ep.name,
+		Description:          ep.description,
+		Host:                 ep.host,
+		Port:                 ep.port,
+		ServerName:           ep.serverName,
+		StartTLS:             tlsprobe.StartTLS(ep.startTLS),
+		Certificate:          cert,
+		CheckIntervalMinutes: ep.checkIntervalMinutes,
+		Enabled:              ep.enabled,
+		CreatedAt:            time.Unix(ep.createdAt, 0),
+		UpdatedAt:            time.Unix(ep.updatedAt, 0),
+		LastCheck:            lastCheck,
+	}
+}
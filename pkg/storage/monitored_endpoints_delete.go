@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+)
+
+// DeleteMonitoredEndpoint deletes a monitored endpoint (including its last check) from the db
+func (store *Storage) DeleteMonitoredEndpoint(id int) (err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		monitored_endpoints
+	WHERE
+		id = $1
+	`
+
+	result, err := store.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return ErrWrongUpdateRowCount
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/monitored_endpoints"
+	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/tlsprobe"
+	"context"
+	"fmt"
+)
+
+// GetAllMonitoredEndpoints returns a slice of all of the monitored endpoints in the database
+func (store *Storage) GetAllMonitoredEndpoints(q pagination_sort.Query) (endpoints []monitored_endpoints.Endpoint, totalRowCount int, err error) {
+	// validate and set sort
+	sortField := q.SortField()
+
+	switch sortField {
+	// allow these
+	case "id":
+		sortField = "me.id"
+	case "name":
+		sortField = "me.name"
+	case "host":
+		sortField = "me.host"
+	case "enabled":
+		sortField = "me.enabled"
+	case "last_checked_at":
+		sortField = "me.last_checked_at"
+	case "leaf_not_after":
+		sortField = "me.leaf_not_after"
+	// default if not in allowed list
+	default:
+		sortField = "me.name"
+	}
+
+	sort := sortField + " " + q.SortDirection()
+
+	// do query
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// WARNING: SQL Injection is possible if the variables are not properly
+	// validated prior to this query being assembled!
+	query := fmt.Sprintf(`
+	SELECT %s,
+
+		count(*) OVER() AS full_count
+	FROM %s
+	ORDER BY
+		%s
+	LIMIT
+		$1
+	OFFSET
+		$2
+	`, monitoredEndpointDbFields, monitoredEndpointDbFrom, sort)
+
+	rows, err := store.db.QueryContext(ctx, query,
+		q.Limit(),
+		q.Offset(),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// for total row count
+	var totalRows int
+
+	allEndpoints := []monitored_endpoints.Endpoint{}
+	for rows.Next() {
+		var oneEndpoint monitoredEndpointDb
+		err = rows.Scan(append(oneEndpoint.scanFields(), &totalRows)...)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		allEndpoints = append(allEndpoints, oneEndpoint.toEndpoint())
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return allEndpoints, totalRows, nil
+}
+
+// GetOneMonitoredEndpointById returns an Endpoint based on its unique id
+func (store *Storage) GetOneMonitoredEndpointById(id int) (monitored_endpoints.Endpoint, error) {
+	return store.getOneMonitoredEndpoint(id, "")
+}
+
+// GetOneMonitoredEndpointByName returns an Endpoint based on its unique name
+func (store *Storage) GetOneMonitoredEndpointByName(name string) (monitored_endpoints.Endpoint, error) {
+	return store.getOneMonitoredEndpoint(-1, name)
+}
+
+// getOneMonitoredEndpoint returns an Endpoint based on unique id or unique name
+func (store *Storage) getOneMonitoredEndpoint(id int, name string) (monitored_endpoints.Endpoint, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM %s
+	WHERE
+		me.id = $1
+		OR
+		me.name = $2
+	`, monitoredEndpointDbFields, monitoredEndpointDbFrom)
+
+	var oneEndpoint monitoredEndpointDb
+	err := store.db.QueryRowContext(ctx, query, id, name).Scan(oneEndpoint.scanFields()...)
+	if err != nil {
+		return monitored_endpoints.Endpoint{}, err
+	}
+
+	return oneEndpoint.toEndpoint(), nil
+}
+
+// GetCertDeployTargets returns the targets of all of the monitored endpoints linked to
+// the specified certificate
+func (store *Storage) GetCertDeployTargets(certId int) (targets []tlsprobe.Target, err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		host, port, server_name, starttls
+	FROM
+		monitored_endpoints
+	WHERE
+		certificate_id = $1
+	ORDER BY
+		id
+	`
+
+	rows, err := store.db.QueryContext(ctx, query, certId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	targets = []tlsprobe.Target{}
+	for rows.Next() {
+		var target tlsprobe.Target
+		err = rows.Scan(&target.Host, &target.Port, &target.ServerName, &target.StartTLS)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/monitored_endpoints"
+	"context"
+)
+
+// PostNewMonitoredEndpoint inserts a new monitored endpoint into the db
+func (store *Storage) PostNewMonitoredEndpoint(payload monitored_endpoints.NewPayload) (monitored_endpoints.Endpoint, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO monitored_endpoints (name, description, host, port, server_name, starttls,
+		certificate_id, check_interval_minutes, enabled, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	RETURNING id
+	`
+
+	id := -1
+	err := store.db.QueryRowContext(ctx, query,
+		payload.Name,
+		payload.Description,
+		payload.Host,
+		payload.Port,
+		payload.ServerName,
+		payload.StartTLS,
+		payload.CertificateID,
+		payload.CheckIntervalMinutes,
+		payload.Enabled,
+		payload.CreatedAt,
+		payload.UpdatedAt,
+	).Scan(&id)
+	if err != nil {
+		return monitored_endpoints.Endpoint{}, err
+	}
+
+	// get new endpoint to return
+	return store.GetOneMonitoredEndpointById(id)
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/monitored_endpoints"
+	"context"
+)
+
+// PutMonitoredEndpoint saves changes to a monitored endpoint. It only updates the
+// details which are provided.
+func (store *Storage) PutMonitoredEndpoint(payload monitored_endpoints.UpdatePayload) (monitored_endpoints.Endpoint, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		monitored_endpoints
+	SET
+		name = case when $1 is null then name else $1 end,
+		description = case when $2 is null then description else $2 end,
+		host = case when $3 is null then host else $3 end,
+		port = case when $4 is null then port else $4 end,
+		server_name = case when $5 is null then server_name else $5 end,
+		starttls = case when $6 is null then starttls else $6 end,
+		certificate_id = case when $7 is null then certificate_id else nullif($7, 0) end,
+		check_interval_minutes = case when $8 is null then check_interval_minutes else $8 end,
+		enabled = case when $9 is null then enabled else $9 end,
+		updated_at = $10
+	WHERE
+		id = $11
+	`
+
+	result, err := store.db.ExecContext(ctx, query,
+		payload.Name,
+		payload.Description,
+		payload.Host,
+		payload.Port,
+		payload.ServerName,
+		payload.StartTLS,
+		payload.CertificateID,
+		payload.CheckIntervalMinutes,
+		payload.Enabled,
+		payload.UpdatedAt,
+		payload.ID,
+	)
+	if err != nil {
+		return monitored_endpoints.Endpoint{}, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return monitored_endpoints.Endpoint{}, err
+	}
+	if rows != 1 {
+		return monitored_endpoints.Endpoint{}, ErrWrongUpdateRowCount
+	}
+
+	// get updated to return
+	return store.GetOneMonitoredEndpointById(payload.ID)
+}
+
+// PutMonitoredEndpointCheck saves the result of checking an endpoint, replacing the
+// result of any previous check
+func (store *Storage) PutMonitoredEndpointCheck(endpointId int, check monitored_endpoints.Check) (err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		monitored_endpoints
+	SET
+		last_checked_at = $1,
+		last_error = $2,
+		served_chain = $3,
+		leaf_serial = $4,
+		leaf_subject = $5,
+		leaf_issuer = $6,
+		leaf_dns_names = $7,
+		leaf_not_before = $8,
+		leaf_not_after = $9,
+		matches_newest_order = $10
+	WHERE
+		id = $11
+	`
+
+	var notBefore, notAfter *int64
+	if check.LeafNotBefore != nil {
+		notBefore = new(check.LeafNotBefore.Unix())
+	}
+	if check.LeafNotAfter != nil {
+		notAfter = new(check.LeafNotAfter.Unix())
+	}
+
+	result, err := store.db.ExecContext(ctx, query,
+		check.CheckedAt.Unix(),
+		check.Error,
+		check.ServedChain,
+		check.LeafSerial,
+		check.LeafSubject,
+		check.LeafIssuer,
+		makeJsonStringSlice(check.LeafDNSNames, false),
+		notBefore,
+		notAfter,
+		check.MatchesNewestOrder,
+		endpointId,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return ErrWrongUpdateRowCount
+	}
+
+	return nil
+}
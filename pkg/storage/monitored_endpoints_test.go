@@ -0,0 +1,225 @@
+package storage_test
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/monitored_endpoints"
+	"certwarden-backend/pkg/domain/orders"
+	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/tlsprobe"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMonitoredEndpoints(t *testing.T) {
+	// create testing service
+	store, err := openStorageWithTestData(t, "monitoredendpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create
+	newEp, err := store.PostNewMonitoredEndpoint(monitored_endpoints.NewPayload{
+		Name:                 new("mail-relay"),
+		Description:          new("office smtp relay"),
+		Host:                 new("mail.example.com"),
+		Port:                 new(25),
+		ServerName:           new(""),
+		StartTLS:             new(tlsprobe.StartTLSSMTP),
+		CertificateID:        new(18),
+		CheckIntervalMinutes: new(30),
+		Enabled:              new(true),
+		CreatedAt:            1000,
+		UpdatedAt:            1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newEp.Name != "mail-relay" || newEp.Port != 25 || newEp.StartTLS != tlsprobe.StartTLSSMTP ||
+		newEp.Certificate == nil || newEp.Certificate.ID != 18 || newEp.Certificate.Name == "" ||
+		newEp.CheckIntervalMinutes != 30 || !newEp.Enabled || newEp.LastCheck != nil {
+		t.Errorf("unexpected new endpoint %+v", newEp)
+	}
+
+	// get
+	ep, err := store.GetOneMonitoredEndpointByName("MAIL-relay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.ID != newEp.ID {
+		t.Errorf("expected endpoint id %d, got %d", newEp.ID, ep.ID)
+	}
+
+	// save a check
+	notAfter := time.Unix(5000, 0)
+	err = store.PutMonitoredEndpointCheck(ep.ID, monitored_endpoints.Check{
+		CheckedAt:          time.Unix(2000, 0),
+		ServedChain:        "-----BEGIN CERTIFICATE-----",
+		LeafSerial:         "abc123",
+		LeafSubject:        "CN=mail.example.com",
+		LeafIssuer:         "CN=Test CA",
+		LeafDNSNames:       []string{"mail.example.com"},
+		LeafNotBefore:      new(time.Unix(1000, 0)),
+		LeafNotAfter:       &notAfter,
+		MatchesNewestOrder: new(false),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ep, err = store.GetOneMonitoredEndpointById(ep.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.LastCheck == nil || ep.LastCheck.CheckedAt.Unix() != 2000 || ep.LastCheck.LeafSerial != "abc123" ||
+		!slices.Equal(ep.LastCheck.LeafDNSNames, []string{"mail.example.com"}) || ep.LastCheck.LeafNotAfter == nil ||
+		!ep.LastCheck.LeafNotAfter.Equal(notAfter) || ep.LastCheck.MatchesNewestOrder == nil || *ep.LastCheck.MatchesNewestOrder {
+		t.Errorf("unexpected last check %+v", ep.LastCheck)
+	}
+
+	// a failed check replaces the previous result
+	err = store.PutMonitoredEndpointCheck(ep.ID, monitored_endpoints.Check{
+		CheckedAt:    time.Unix(3000, 0),
+		Error:        "connection refused",
+		LeafDNSNames: []string{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ep, err = store.GetOneMonitoredEndpointById(ep.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.LastCheck == nil || ep.LastCheck.Error != "connection refused" || ep.LastCheck.LeafSerial != "" ||
+		ep.LastCheck.LeafNotAfter != nil || ep.LastCheck.MatchesNewestOrder != nil {
+		t.Errorf("unexpected last check after failure %+v", ep.LastCheck)
+	}
+
+	// update (partial) and unlink cert
+	updatedEp, err := store.PutMonitoredEndpoint(monitored_endpoints.UpdatePayload{
+		ID:            ep.ID,
+		Port:          new(587),
+		CertificateID: new(0),
+		Enabled:       new(false),
+		UpdatedAt:     4000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updatedEp.Port != 587 || updatedEp.Host != "mail.example.com" || updatedEp.Certificate != nil ||
+		updatedEp.Enabled || updatedEp.LastCheck == nil {
+		t.Errorf("unexpected updated endpoint %+v", updatedEp)
+	}
+
+	// list
+	endpoints, total, err := store.GetAllMonitoredEndpoints(pagination_sort.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(endpoints) != 1 || endpoints[0].ID != ep.ID {
+		t.Errorf("unexpected endpoint list (total %d) %+v", total, endpoints)
+	}
+
+	// delete
+	err = store.DeleteMonitoredEndpoint(ep.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.GetOneMonitoredEndpointById(ep.ID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected no rows after delete, got %v", err)
+	}
+	err = store.DeleteMonitoredEndpoint(ep.ID)
+	if !errors.Is(err, storage.ErrWrongUpdateRowCount) {
+		t.Errorf("expected wrong row count deleting missing endpoint, got %v", err)
+	}
+}
+
+func TestDeployVerification(t *testing.T) {
+	// create testing service
+	store, err := openStorageWithTestData(t, "deployverification")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// enable on cert
+	cert, err := store.PutDetailsCert(certificates.DetailsUpdatePayload{
+		ID:                 18,
+		DeployVerification: &certificates.DeployVerification{Enabled: true, WindowMinutes: 10},
+		UpdatedAt:          1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cert.DeployVerification.Enabled || cert.DeployVerification.WindowMinutes != 10 {
+		t.Errorf("unexpected deploy verification %+v", cert.DeployVerification)
+	}
+
+	// targets come from linked endpoints
+	targets, err := store.GetCertDeployTargets(18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 0 {
+		t.Errorf("expected no targets, got %+v", targets)
+	}
+
+	for _, host := range []string{"a.example.com", "b.example.com"} {
+		_, err = store.PostNewMonitoredEndpoint(monitored_endpoints.NewPayload{
+			Name:                 new(host),
+			Description:          new(""),
+			Host:                 new(host),
+			Port:                 new(443),
+			ServerName:           new("www.example.com"),
+			StartTLS:             new(tlsprobe.StartTLSNone),
+			CertificateID:        new(18),
+			CheckIntervalMinutes: new(60),
+			Enabled:              new(false),
+			CreatedAt:            1000,
+			UpdatedAt:            1000,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	targets, err = store.GetCertDeployTargets(18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0] != (tlsprobe.Target{Host: "a.example.com", Port: 443, ServerName: "www.example.com"}) {
+		t.Errorf("unexpected targets %+v", targets)
+	}
+
+	// order status
+	order, err := store.GetOneOrder(84)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.DeployStatus != orders.DeployStatusNone || order.DeployCheckedAt != nil {
+		t.Errorf("unexpected initial deploy status %s", order.DeployStatus)
+	}
+
+	err = store.PutOrderDeployStatus(order.ID, orders.DeployStatusUnverified, new(2000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err = store.GetOneOrder(order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.DeployStatus != orders.DeployStatusUnverified || order.DeployCheckedAt == nil || order.DeployCheckedAt.Unix() != 2000 ||
+		!order.Certificate.DeployVerification.Enabled {
+		t.Errorf("unexpected deploy status %s (checked at %v)", order.DeployStatus, order.DeployCheckedAt)
+	}
+
+	err = store.PutOrderDeployStatus(-1, orders.DeployStatusVerified, nil)
+	if !errors.Is(err, storage.ErrWrongUpdateRowCount) {
+		t.Errorf("expected wrong row count for missing order, got %v", err)
+	}
+}
@@ -11,26 +11,29 @@ import (
 // orderDb is a single acme order, as database table fields
 // corresponds to orders.Order
 type orderDb struct {
-	id             int
-	certificate    certificateDb
-	location       string
-	status         string
-	knownRevoked   bool
-	err            sql.NullString // stored as json object
-	expires        sql.NullInt32
-	dnsIdentifiers jsonStringSlice // stored as json array
-	authorizations jsonStringSlice // stored as json array
-	finalize       string
-	finalizedKey   keyDb
-	certificateUrl sql.NullString
-	pem            sql.NullString
-	chainRootCN    sql.NullString
-	validFrom      sql.NullInt32
-	validTo        sql.NullInt32
-	createdAt      int64
-	updatedAt      int64
-	profile        sql.NullString
-	renewalInfo    sql.NullString
+	id              int
+	certificate     certificateDb
+	location        string
+	status          string
+	knownRevoked    bool
+	err             sql.NullString // stored as json object
+	expires         sql.NullInt32
+	dnsIdentifiers  jsonStringSlice // stored as json array
+	ipIdentifiers   jsonStringSlice // stored as json array
+	authorizations  jsonStringSlice // stored as json array
+	finalize        string
+	finalizedKey    keyDb
+	certificateUrl  sql.NullString
+	pem             sql.NullString
+	chainRootCN     sql.NullString
+	validFrom       sql.NullInt32
+	validTo         sql.NullInt32
+	createdAt       int64
+	updatedAt       int64
+	profile         sql.NullString
+	renewalInfo     sql.NullString
+	deployStatus    string
+	deployCheckedAt sql.NullInt32
 }
 
 func (order orderDb) toOrder() (orders.Order, error) {
@@ -60,25 +63,28 @@ func (order orderDb) toOrder() (orders.Order, error) {
 	}
 
 	return orders.Order{
-		ID:             order.id,
-		Certificate:    cert,
-		Location:       order.location,
-		Status:         order.status,
-		KnownRevoked:   order.knownRevoked,
-		Error:          acmeErr,
-		Expires:        nullInt32ToInt(order.expires),
-		DnsIdentifiers: order.dnsIdentifiers.toSlice(),
-		Authorizations: order.authorizations.toSlice(),
-		Finalize:       order.finalize,
-		FinalizedKey:   key,
-		CertificateUrl: nullStringToString(order.certificateUrl),
-		Pem:            nullStringToString(order.pem),
-		ValidFrom:      nullInt32UnixToTime(order.validFrom),
-		ValidTo:        nullInt32UnixToTime(order.validTo),
-		ChainRootCN:    nullStringToString(order.chainRootCN),
-		CreatedAt:      time.Unix(order.createdAt, 0),
-		UpdatedAt:      time.Unix(order.updatedAt, 0),
-		Profile:        nullStringToString(order.profile),
-		RenewalInfo:    ri,
+		ID:              order.id,
+		Certificate:     cert,
+		Location:        order.location,
+		Status:          order.status,
+		KnownRevoked:    order.knownRevoked,
+		Error:           acmeErr,
+		Expires:         nullInt32ToInt(order.expires),
+		DnsIdentifiers:  order.dnsIdentifiers.toSlice(),
+		IpIdentifiers:   order.ipIdentifiers.toSlice(),
+		Authorizations:  order.authorizations.toSlice(),
+		Finalize:        order.finalize,
+		FinalizedKey:    key,
+		CertificateUrl:  nullStringToString(order.certificateUrl),
+		Pem:             nullStringToString(order.pem),
+		ValidFrom:       nullInt32UnixToTime(order.validFrom),
+		ValidTo:         nullInt32UnixToTime(order.validTo),
+		ChainRootCN:     nullStringToString(order.chainRootCN),
+		CreatedAt:       time.Unix(order.createdAt, 0),
+		UpdatedAt:       time.Unix(order.updatedAt, 0),
+		Profile:         nullStringToString(order.profile),
+		RenewalInfo:     ri,
+		DeployStatus:    orders.DeployStatus(order.deployStatus),
+		DeployCheckedAt: nullInt32UnixToTime(order.deployCheckedAt),
 	}, nil
 }
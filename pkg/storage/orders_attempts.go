@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/datatypes/history"
+	"certwarden-backend/pkg/domain/orders"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// orderAttemptDb is a single order attempt, as database table fields
+// corresponds to orders.OrderAttempt
+type orderAttemptDb struct {
+	id        int
+	orderId   int
+	kind      string
+	outcome   string
+	steps     string // stored as json array
+	startedAt int64
+	endedAt   int64
+}
+
+func (attempt orderAttemptDb) toOrderAttempt() (orders.OrderAttempt, error) {
+	steps := []history.Step{}
+	err := json.Unmarshal([]byte(attempt.steps), &steps)
+	if err != nil {
+		return orders.OrderAttempt{}, err
+	}
+
+	return orders.OrderAttempt{
+		ID:        attempt.id,
+		OrderID:   attempt.orderId,
+		Kind:      attempt.kind,
+		Outcome:   attempt.outcome,
+		Steps:     steps,
+		StartedAt: time.Unix(attempt.startedAt, 0),
+		EndedAt:   time.Unix(attempt.endedAt, 0),
+	}, nil
+}
+
+// PostOrderAttempt saves the history of a single order fulfillment or post processing attempt
+func (store *Storage) PostOrderAttempt(payload orders.NewOrderAttemptPayload) (err error) {
+	steps := payload.Steps
+	if steps == nil {
+		steps = []history.Step{}
+	}
+
+	stepsJson, err := json.Marshal(steps)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO
+		order_attempts
+			(
+				order_id,
+				kind,
+				outcome,
+				steps,
+				started_at,
+				ended_at
+			)
+	VALUES
+			(
+				$1,
+				$2,
+				$3,
+				$4,
+				$5,
+				$6
+			)
+	`
+
+	_, err = store.db.ExecContext(ctx, query,
+		payload.OrderID,
+		payload.Kind,
+		payload.Outcome,
+		string(stepsJson),
+		payload.StartedAt,
+		payload.EndedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetOrderAttempts returns all of the attempts recorded for the specified order, newest first
+func (store *Storage) GetOrderAttempts(orderId int) (attempts []orders.OrderAttempt, err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, order_id, kind, outcome, steps, started_at, ended_at
+	FROM
+		order_attempts
+	WHERE
+		order_id = $1
+	ORDER BY
+		started_at DESC, id DESC
+	`
+
+	rows, err := store.db.QueryContext(ctx, query, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts = []orders.OrderAttempt{}
+	for rows.Next() {
+		var oneAttempt orderAttemptDb
+		err = rows.Scan(
+			&oneAttempt.id,
+			&oneAttempt.orderId,
+			&oneAttempt.kind,
+			&oneAttempt.outcome,
+			&oneAttempt.steps,
+			&oneAttempt.startedAt,
+			&oneAttempt.endedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		convertedAttempt, err := oneAttempt.toOrderAttempt()
+		if err != nil {
+			return nil, err
+		}
+
+		attempts = append(attempts, convertedAttempt)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,89 @@
+package storage_test
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/datatypes/history"
+	"certwarden-backend/pkg/domain/orders"
+	"testing"
+)
+
+func TestOrderAttempts(t *testing.T) {
+	exitCode := 1
+	payloads := []orders.NewOrderAttemptPayload{
+		{
+			OrderID: 73,
+			Kind:    orders.AttemptKindFulfill,
+			Outcome: "invalid",
+			Steps: []history.Step{
+				{Time: 1780336479, Action: history.ActionProvision, Identifier: "example.com", Provider: "dns01manual", ProviderTag: "abc"},
+				{Time: 1780336490, Action: history.ActionValidate, Identifier: "example.com", Status: "invalid", AcmeError: &acme.Error{Type: "urn:ietf:params:acme:error:dns", Detail: "no txt record"}},
+			},
+			StartedAt: 1780336470,
+			EndedAt:   1780336500,
+		},
+		{
+			OrderID: 73,
+			Kind:    orders.AttemptKindPostProcess,
+			Outcome: "failed",
+			Steps: []history.Step{
+				{Time: 1780337000, Action: history.ActionPostProcessScript, ExitCode: &exitCode, Stdout: "out", Stderr: "err", Error: "exit status 1"},
+			},
+			StartedAt: 1780337000,
+			EndedAt:   1780337001,
+		},
+	}
+
+	// create testing service
+	storage, err := openStorageWithTestData(t, "orderattempts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, payload := range payloads {
+		err = storage.PostOrderAttempt(payload)
+		if err != nil {
+			t.Fatalf("failed to post attempt (%s)", err)
+		}
+	}
+
+	// attempt for non-existent order should fail (foreign key)
+	err = storage.PostOrderAttempt(orders.NewOrderAttemptPayload{OrderID: 999999, Kind: orders.AttemptKindFulfill, Outcome: "error"})
+	if err == nil {
+		t.Error("expected error posting attempt for non-existent order")
+	}
+
+	attempts, err := storage.GetOrderAttempts(73)
+	if err != nil {
+		t.Fatalf("failed to get attempts (%s)", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("expected 2 attempts but got %d", len(attempts))
+	}
+
+	// newest first
+	if attempts[0].Kind != orders.AttemptKindPostProcess || attempts[1].Kind != orders.AttemptKindFulfill {
+		t.Errorf("attempts not in expected order (got %s, %s)", attempts[0].Kind, attempts[1].Kind)
+	}
+
+	postStep := attempts[0].Steps[0]
+	if postStep.ExitCode == nil || *postStep.ExitCode != 1 || postStep.Stdout != "out" || postStep.Stderr != "err" {
+		t.Errorf("post process step not saved correctly (%+v)", postStep)
+	}
+
+	validateStep := attempts[1].Steps[1]
+	if validateStep.AcmeError == nil || validateStep.AcmeError.Detail != "no txt record" {
+		t.Errorf("acme error not saved correctly (%+v)", validateStep)
+	}
+	if attempts[1].StartedAt.Unix() != 1780336470 || attempts[1].EndedAt.Unix() != 1780336500 {
+		t.Errorf("attempt times not saved correctly (%s, %s)", attempts[1].StartedAt, attempts[1].EndedAt)
+	}
+
+	// order with no attempts
+	attempts, err = storage.GetOrderAttempts(74)
+	if err != nil {
+		t.Fatalf("failed to get attempts (%s)", err)
+	}
+	if len(attempts) != 0 {
+		t.Errorf("expected 0 attempts but got %d", len(attempts))
+	}
+}
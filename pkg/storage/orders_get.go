@@ -42,15 +42,17 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 	query := fmt.Sprintf(`
 	SELECT
 		/* order */
-		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
+		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, ao.ip_identifiers,
 		ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.chain_root_cn,
-		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, 
+		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, ao.deploy_status, ao.deploy_checked_at,
 
 		/* order's cert */
 		c.id, c.name, c.description, c.subject, c.subject_alts,
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.csr_extra_extensions, c.preferred_root_cn,
 		c.last_access, c.created_at, c.updated_at, c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, 
 		c.post_processing_environment, c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.key_rotation_policy, c.key_rotation_interval_days, c.key_rotation_algorithm, c.key_rotation_grace_days, c.template_id,
+		c.deploy_verify, c.deploy_verify_window_minutes,
 		
 		/* cert's key */
 		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ck.api_key_new,
@@ -131,6 +133,7 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.err,
 			&oneOrder.expires,
 			&oneOrder.dnsIdentifiers,
+			&oneOrder.ipIdentifiers,
 			&oneOrder.authorizations,
 			&oneOrder.finalize,
 			&oneOrder.certificateUrl,
@@ -142,6 +145,8 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.renewalInfo,
 			&oneOrder.createdAt,
 			&oneOrder.updatedAt,
+			&oneOrder.deployStatus,
+			&oneOrder.deployCheckedAt,
 
 			&oneOrder.certificate.id,
 			&oneOrder.certificate.name,
@@ -166,12 +171,19 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.certificate.postProcessingClientAddress,
 			&oneOrder.certificate.postProcessingClientKeyB64,
 			&oneOrder.certificate.profile,
+			&oneOrder.certificate.keyRotationPolicy,
+			&oneOrder.certificate.keyRotationIntervalDays,
+			&oneOrder.certificate.keyRotationAlgorithm,
+			&oneOrder.certificate.keyRotationGraceDays,
+			&oneOrder.certificate.templateId,
+			&oneOrder.certificate.deployVerify,
+			&oneOrder.certificate.deployVerifyWindowMinutes,
 
 			&oneOrder.certificate.certificateKeyDb.id,
 			&oneOrder.certificate.certificateKeyDb.name,
 			&oneOrder.certificate.certificateKeyDb.description,
 			&oneOrder.certificate.certificateKeyDb.algorithmValue,
-			&oneOrder.certificate.certificateKeyDb.pem,
+			store.keyPem(&oneOrder.certificate.certificateKeyDb.pem),
 			&oneOrder.certificate.certificateKeyDb.apiKey,
 			&oneOrder.certificate.certificateKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateKeyDb.apiKeyDisabled,
@@ -202,7 +214,7 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.name,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.description,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.algorithmValue,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem,
+			store.keyPem(&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem),
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKey,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyDisabled,
@@ -215,7 +227,7 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.finalizedKey.name,
 			&oneOrder.finalizedKey.description,
 			&oneOrder.finalizedKey.algorithmValue,
-			&oneOrder.finalizedKey.pem,
+			store.keyPem(&oneOrder.finalizedKey.pem),
 			&oneOrder.finalizedKey.apiKey,
 			&oneOrder.finalizedKey.apiKeyNew,
 			&oneOrder.finalizedKey.apiKeyDisabled,
@@ -275,9 +287,9 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 	query := fmt.Sprintf(`
 	SELECT
 		/* order */
-		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
+		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, ao.ip_identifiers,
 		ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.chain_root_cn,
-		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, 
+		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, ao.deploy_status, ao.deploy_checked_at,
 
 		/* order's cert */
 		c.id, c.name, c.description, c.subject, c.subject_alts,
@@ -285,6 +297,8 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 		c.last_access, c.created_at, c.updated_at,
 		c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, c.post_processing_environment,
 		c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.key_rotation_policy, c.key_rotation_interval_days, c.key_rotation_algorithm, c.key_rotation_grace_days, c.template_id,
+		c.deploy_verify, c.deploy_verify_window_minutes,
 		
 		/* cert's key */
 		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ck.api_key_new, ck.api_key_disabled,
@@ -351,6 +365,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.err,
 			&oneOrder.expires,
 			&oneOrder.dnsIdentifiers,
+			&oneOrder.ipIdentifiers,
 			&oneOrder.authorizations,
 			&oneOrder.finalize,
 			&oneOrder.certificateUrl,
@@ -362,6 +377,8 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.renewalInfo,
 			&oneOrder.createdAt,
 			&oneOrder.updatedAt,
+			&oneOrder.deployStatus,
+			&oneOrder.deployCheckedAt,
 
 			&oneOrder.certificate.id,
 			&oneOrder.certificate.name,
@@ -386,12 +403,19 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.certificate.postProcessingClientAddress,
 			&oneOrder.certificate.postProcessingClientKeyB64,
 			&oneOrder.certificate.profile,
+			&oneOrder.certificate.keyRotationPolicy,
+			&oneOrder.certificate.keyRotationIntervalDays,
+			&oneOrder.certificate.keyRotationAlgorithm,
+			&oneOrder.certificate.keyRotationGraceDays,
+			&oneOrder.certificate.templateId,
+			&oneOrder.certificate.deployVerify,
+			&oneOrder.certificate.deployVerifyWindowMinutes,
 
 			&oneOrder.certificate.certificateKeyDb.id,
 			&oneOrder.certificate.certificateKeyDb.name,
 			&oneOrder.certificate.certificateKeyDb.description,
 			&oneOrder.certificate.certificateKeyDb.algorithmValue,
-			&oneOrder.certificate.certificateKeyDb.pem,
+			store.keyPem(&oneOrder.certificate.certificateKeyDb.pem),
 			&oneOrder.certificate.certificateKeyDb.apiKey,
 			&oneOrder.certificate.certificateKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateKeyDb.apiKeyDisabled,
@@ -422,7 +446,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.name,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.description,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.algorithmValue,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem,
+			store.keyPem(&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem),
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKey,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyDisabled,
@@ -435,7 +459,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.finalizedKey.name,
 			&oneOrder.finalizedKey.description,
 			&oneOrder.finalizedKey.algorithmValue,
-			&oneOrder.finalizedKey.pem,
+			store.keyPem(&oneOrder.finalizedKey.pem),
 			&oneOrder.finalizedKey.apiKey,
 			&oneOrder.finalizedKey.apiKeyNew,
 			&oneOrder.finalizedKey.apiKeyDisabled,
@@ -561,16 +585,18 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 	query := fmt.Sprintf(`
 	SELECT
 		/* order */
-		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
+		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, ao.ip_identifiers,
 		ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.chain_root_cn,
-		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, 
+		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, ao.deploy_status, ao.deploy_checked_at,
 
 		/* order's cert */
 		c.id, c.name, c.description, c.subject, c.subject_alts,
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.csr_extra_extensions, c.preferred_root_cn,
 		c.last_access, c.created_at, c.updated_at,
 		c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, c.post_processing_environment,
-		c.post_processing_client_address, c.post_processing_client_key, c.profile, 
+		c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.key_rotation_policy, c.key_rotation_interval_days, c.key_rotation_algorithm, c.key_rotation_grace_days, c.template_id,
+		c.deploy_verify, c.deploy_verify_window_minutes,
 		
 		/* cert's key */
 		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ak.api_key_new, ck.api_key_disabled,
@@ -623,6 +649,7 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 			&oneOrder.err,
 			&oneOrder.expires,
 			&oneOrder.dnsIdentifiers,
+			&oneOrder.ipIdentifiers,
 			&oneOrder.authorizations,
 			&oneOrder.finalize,
 			&oneOrder.certificateUrl,
@@ -634,6 +661,8 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 			&oneOrder.renewalInfo,
 			&oneOrder.createdAt,
 			&oneOrder.updatedAt,
+			&oneOrder.deployStatus,
+			&oneOrder.deployCheckedAt,
 
 			&oneOrder.certificate.id,
 			&oneOrder.certificate.name,
@@ -658,12 +687,19 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 			&oneOrder.certificate.postProcessingClientAddress,
 			&oneOrder.certificate.postProcessingClientKeyB64,
 			&oneOrder.certificate.profile,
+			&oneOrder.certificate.keyRotationPolicy,
+			&oneOrder.certificate.keyRotationIntervalDays,
+			&oneOrder.certificate.keyRotationAlgorithm,
+			&oneOrder.certificate.keyRotationGraceDays,
+			&oneOrder.certificate.templateId,
+			&oneOrder.certificate.deployVerify,
+			&oneOrder.certificate.deployVerifyWindowMinutes,
 
 			&oneOrder.certificate.certificateKeyDb.id,
 			&oneOrder.certificate.certificateKeyDb.name,
 			&oneOrder.certificate.certificateKeyDb.description,
 			&oneOrder.certificate.certificateKeyDb.algorithmValue,
-			&oneOrder.certificate.certificateKeyDb.pem,
+			store.keyPem(&oneOrder.certificate.certificateKeyDb.pem),
 			&oneOrder.certificate.certificateKeyDb.apiKey,
 			&oneOrder.certificate.certificateKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateKeyDb.apiKeyDisabled,
@@ -694,7 +730,7 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.name,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.description,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.algorithmValue,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem,
+			store.keyPem(&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem),
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKey,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyDisabled,
@@ -707,7 +743,7 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 			&oneOrder.finalizedKey.name,
 			&oneOrder.finalizedKey.description,
 			&oneOrder.finalizedKey.algorithmValue,
-			&oneOrder.finalizedKey.pem,
+			store.keyPem(&oneOrder.finalizedKey.pem),
 			&oneOrder.finalizedKey.apiKey,
 			&oneOrder.finalizedKey.apiKeyNew,
 			&oneOrder.finalizedKey.apiKeyDisabled,
@@ -765,9 +801,9 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 	query := `
 	SELECT
 		/* order */
-		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
+		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, ao.ip_identifiers,
 		ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.chain_root_cn,
-		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, 
+		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, ao.deploy_status, ao.deploy_checked_at,
 
 		/* order's cert */
 		c.id, c.name, c.description, c.subject, c.subject_alts,
@@ -775,6 +811,8 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		c.last_access, c.created_at, c.updated_at,
 		c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, c.post_processing_environment,
 		c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.key_rotation_policy, c.key_rotation_interval_days, c.key_rotation_algorithm, c.key_rotation_grace_days, c.template_id,
+		c.deploy_verify, c.deploy_verify_window_minutes,
 		
 		/* cert's key */
 		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ak.api_key_new, ck.api_key_disabled,
@@ -842,6 +880,7 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		&oneOrder.err,
 		&oneOrder.expires,
 		&oneOrder.dnsIdentifiers,
+		&oneOrder.ipIdentifiers,
 		&oneOrder.authorizations,
 		&oneOrder.finalize,
 		&oneOrder.certificateUrl,
@@ -853,6 +892,8 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		&oneOrder.renewalInfo,
 		&oneOrder.createdAt,
 		&oneOrder.updatedAt,
+		&oneOrder.deployStatus,
+		&oneOrder.deployCheckedAt,
 
 		&oneOrder.certificate.id,
 		&oneOrder.certificate.name,
@@ -877,12 +918,19 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		&oneOrder.certificate.postProcessingClientAddress,
 		&oneOrder.certificate.postProcessingClientKeyB64,
 		&oneOrder.certificate.profile,
+		&oneOrder.certificate.keyRotationPolicy,
+		&oneOrder.certificate.keyRotationIntervalDays,
+		&oneOrder.certificate.keyRotationAlgorithm,
+		&oneOrder.certificate.keyRotationGraceDays,
+		&oneOrder.certificate.templateId,
+		&oneOrder.certificate.deployVerify,
+		&oneOrder.certificate.deployVerifyWindowMinutes,
 
 		&oneOrder.certificate.certificateKeyDb.id,
 		&oneOrder.certificate.certificateKeyDb.name,
 		&oneOrder.certificate.certificateKeyDb.description,
 		&oneOrder.certificate.certificateKeyDb.algorithmValue,
-		&oneOrder.certificate.certificateKeyDb.pem,
+		store.keyPem(&oneOrder.certificate.certificateKeyDb.pem),
 		&oneOrder.certificate.certificateKeyDb.apiKey,
 		&oneOrder.certificate.certificateKeyDb.apiKeyNew,
 		&oneOrder.certificate.certificateKeyDb.apiKeyDisabled,
@@ -913,7 +961,7 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.name,
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.description,
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.algorithmValue,
-		&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem,
+		store.keyPem(&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem),
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKey,
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyNew,
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyDisabled,
@@ -926,7 +974,7 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		&oneOrder.finalizedKey.name,
 		&oneOrder.finalizedKey.description,
 		&oneOrder.finalizedKey.algorithmValue,
-		&oneOrder.finalizedKey.pem,
+		store.keyPem(&oneOrder.finalizedKey.pem),
 		&oneOrder.finalizedKey.apiKey,
 		&oneOrder.finalizedKey.apiKeyNew,
 		&oneOrder.finalizedKey.apiKeyDisabled,
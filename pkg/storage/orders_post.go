@@ -56,7 +56,8 @@ func (store *Storage) PostNewOrder(payload orders.NewOrderAcmePayload) (newId in
 				profile,
 				acme_location,
 				created_at,
-				updated_at
+				updated_at,
+				ip_identifiers
 			)
 	VALUES
 			(
@@ -72,7 +73,8 @@ func (store *Storage) PostNewOrder(payload orders.NewOrderAcmePayload) (newId in
 				$10,
 				$11,
 				$12,
-				$13
+				$13,
+				$14
 			)
 	RETURNING
 		id
@@ -92,6 +94,7 @@ func (store *Storage) PostNewOrder(payload orders.NewOrderAcmePayload) (newId in
 		payload.Location,
 		payload.CreatedAt,
 		payload.UpdatedAt,
+		makeJsonStringSlice(payload.IpIds, false),
 	).Scan(&newId)
 
 	err = tx.Commit()
@@ -26,9 +26,10 @@ func (store *Storage) PutOrderAcme(payload orders.UpdateAcmeOrderPayload) (err e
 			finalize = $6,
 			profile = $7,
 			certificate_url = $8,
-			updated_at = $9
+			updated_at = $9,
+			ip_identifiers = $10
 		WHERE
-			id = $10
+			id = $11
 		`
 
 	_, err = store.db.ExecContext(ctx, query,
@@ -41,6 +42,7 @@ func (store *Storage) PutOrderAcme(payload orders.UpdateAcmeOrderPayload) (err e
 		payload.Profile,
 		payload.CertificateUrl,
 		payload.UpdatedAt,
+		makeJsonStringSlice(payload.IpIds, false),
 		payload.OrderId,
 	)
 
@@ -119,6 +121,42 @@ func (store *Storage) PutOrderInvalid(orderId int) (err error) {
 	return nil
 }
 
+// PutOrderDeployStatus updates the specified order ID with the result of deployment
+// verification
+func (store *Storage) PutOrderDeployStatus(orderId int, status orders.DeployStatus, checkedAt *int) (err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE
+			acme_orders
+		SET
+			deploy_status = $1,
+			deploy_checked_at = $2
+		WHERE
+			id = $3
+		`
+
+	result, err := store.db.ExecContext(ctx, query,
+		status,
+		checkedAt,
+		orderId,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return ErrWrongUpdateRowCount
+	}
+
+	return nil
+}
+
 // UpdateFinalizedKey updates the specified order ID with key id
 func (store *Storage) UpdateFinalizedKey(orderId int, keyId int) (err error) {
 	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"certwarden-backend/pkg/storage/envelope"
 	"certwarden-backend/pkg/storage/sqlite3"
 	"context"
 	"database/sql"
@@ -13,7 +14,7 @@ import (
 
 // config for DB
 const dbTimeout = time.Duration(5 * time.Second)
-const DbCurrentUserVersion = 11
+const DbCurrentUserVersion = 22
 
 var errServiceComponent = errors.New("necessary storage service component is missing")
 
@@ -29,11 +30,13 @@ type Storage struct {
 	shutdownContext context.Context
 	db              *sql.DB
 	timeout         time.Duration
+	masterKey       *envelope.MasterKey
 }
 
 // OpenStorage opens an existing sqlite database or creates a new one if needed.
-// It also creates tables. It then returns Storage.
-func OpenStorage(app App) (*Storage, error) {
+// It also creates tables. It then returns Storage. If cfg specifies a master key,
+// private key pems are encrypted at rest.
+func OpenStorage(app App, cfg *envelope.Config) (*Storage, error) {
 	store := new(Storage)
 	var err error
 
@@ -43,6 +46,12 @@ func OpenStorage(app App) (*Storage, error) {
 	// set timeout
 	store.timeout = dbTimeout
 
+	// load master key for private key encryption (nil if not configured)
+	store.masterKey, err = envelope.LoadMasterKey(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to load master key (%w)", err)
+	}
+
 	db, isNewDb, cleanUpOnErr, err := sqlite3.OpenSqlite3Database(app)
 	store.db = db
 	if err != nil {
@@ -190,6 +199,87 @@ func OpenStorage(app App) (*Storage, error) {
 		}
 	}
 
+	// upgrade if schema 11
+	if fileUserVersion == 11 {
+		fileUserVersion, err = store.migrateV11toV12()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+
+	// upgrade if schema 12
+	if fileUserVersion == 12 {
+		fileUserVersion, err = store.migrateV12toV13()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+	if fileUserVersion == 13 {
+		fileUserVersion, err = store.migrateV13toV14()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+	if fileUserVersion == 14 {
+		fileUserVersion, err = store.migrateV14toV15()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+	if fileUserVersion == 15 {
+		fileUserVersion, err = store.migrateV15toV16()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+	if fileUserVersion == 16 {
+		fileUserVersion, err = store.migrateV16toV17()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+	if fileUserVersion == 17 {
+		fileUserVersion, err = store.migrateV17toV18()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+	if fileUserVersion == 18 {
+		fileUserVersion, err = store.migrateV18toV19()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+	if fileUserVersion == 19 {
+		fileUserVersion, err = store.migrateV19toV20()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+	if fileUserVersion == 20 {
+		fileUserVersion, err = store.migrateV20toV21()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+	if fileUserVersion == 21 {
+		fileUserVersion, err = store.migrateV21toV22()
+		if err != nil {
+			cleanUpOnErr()
+			return nil, fmt.Errorf("storage: failed to migrate to user_version %d (%w)", fileUserVersion+1, err)
+		}
+	}
+
 	// fail if still not correct
 	if fileUserVersion != DbCurrentUserVersion {
 		cleanUpOnErr()
@@ -199,6 +289,20 @@ func OpenStorage(app App) (*Storage, error) {
 		app.GetLogger().Infof("storage: database user_version successfully upgraded from %d to %d", fileOriginalUserVersion, fileUserVersion)
 	}
 
+	// encrypt plaintext private key pems (if master key) and verify existing encrypted pems
+	err = store.migrateKeyPemEncryption(app.GetLogger())
+	if err != nil {
+		cleanUpOnErr()
+		return nil, fmt.Errorf("storage: private key encryption check failed (%w)", err)
+	}
+
+	// fingerprint any private keys saved before fingerprints were added
+	err = store.fingerprintKeyPems(app.GetLogger())
+	if err != nil {
+		cleanUpOnErr()
+		return nil, fmt.Errorf("storage: private key fingerprinting failed (%w)", err)
+	}
+
 	return store, nil
 }
 
@@ -3,6 +3,7 @@ package storage_test
 import (
 	"certwarden-backend/pkg/pagination_sort"
 	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/storage/envelope"
 	"context"
 	"errors"
 	"io"
@@ -17,7 +18,7 @@ import (
 )
 
 const (
-	testDataDbFile  = "../../test_data/testdata_v11.db"
+	testDataDbFile  = "../../test_data/testdata_v22.db"
 	tempFileStorage = "../../test_data/tmp/"
 )
 
@@ -55,6 +56,12 @@ func makeFakeApp(t *testing.T, appDataPath string) *fakeApp {
 // openStorageWithTestData makes a copy of the testing data db and then returns a storage service
 // that uses the copy; Cleanup() should be called at the end of the test
 func openStorageWithTestData(t *testing.T, testName string) (_ *storage.Storage, _ error) {
+	return openStorageWithTestDataAndKeyConfig(t, testName, nil)
+}
+
+// openStorageWithTestDataAndKeyConfig is the same as openStorageWithTestData but also uses
+// the specified key encryption config
+func openStorageWithTestDataAndKeyConfig(t *testing.T, testName string, cfg *envelope.Config) (_ *storage.Storage, _ error) {
 	thisTestFolder := tempFileStorage + testName
 
 	// copy test data to temp appDataPath for tests to run
@@ -97,7 +104,7 @@ func openStorageWithTestData(t *testing.T, testName string) (_ *storage.Storage,
 
 	fakeApp := makeFakeApp(t, thisTestFolder)
 
-	storage, err := storage.OpenStorage(fakeApp)
+	storage, err := storage.OpenStorage(fakeApp, cfg)
 	if err != nil {
 		return nil, err
 	}
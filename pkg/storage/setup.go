@@ -32,7 +32,7 @@ func (store *Storage) populateNewDb() error {
 	}
 
 	// create tables
-	err = createDBTablesV11(tx)
+	err = createDBTablesV22(tx)
 	if err != nil {
 		return err
 	}
@@ -74,18 +74,20 @@ func insertDefaultUser(tx *sql.Tx) error {
 	// insert
 	query := `
 	INSERT INTO
-		users (username, password_hash, created_at, updated_at)
+		users (username, password_hash, role, created_at, updated_at)
 	VALUES (
 		$1,
 		$2,
 		$3,
-		$4
+		$4,
+		$5
 	)
 	`
 
 	_, err = tx.Exec(query,
 		defaultUsername,
 		defaultHashedPw,
+		auth.RoleAdmin,
 		time.Now().Unix(),
 		time.Now().Unix(),
 	)
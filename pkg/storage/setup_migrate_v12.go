@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v11 to v12:
+// - order_attempts:
+//		 - Add 'order_attempts' table to store history of order fulfillment and post processing
+
+// migrateV11toV12 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV11toV12() (int, error) {
+	oldSchemaVer := 11
+	newSchemaVer := 12
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add order_attempts table
+	query = `CREATE TABLE IF NOT EXISTS order_attempts (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		order_id integer NOT NULL,
+		kind text NOT NULL,
+		outcome text NOT NULL,
+		steps text NOT NULL,
+		started_at integer NOT NULL,
+		ended_at integer NOT NULL,
+		FOREIGN KEY (order_id)
+			REFERENCES acme_orders (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newSchemaVer, nil
+}
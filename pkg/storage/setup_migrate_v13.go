@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v12 to v13:
+// - users:
+//		 - Add 'role' field/column
+
+// migrateV12toV13 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV12toV13() (int, error) {
+	oldSchemaVer := 12
+	newSchemaVer := 13
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add role column to users (existing users are admins)
+	query = `
+		ALTER TABLE users ADD role text NOT NULL DEFAULT 'admin';
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newSchemaVer, nil
+}
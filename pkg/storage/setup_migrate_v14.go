@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v13 to v14:
+// - audit_log:
+//		 - New table to record changes made by users
+
+// migrateV13toV14 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV13toV14() (int, error) {
+	oldSchemaVer := 13
+	newSchemaVer := 14
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// create audit_log table
+	query = `CREATE TABLE IF NOT EXISTS audit_log (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		created_at integer NOT NULL,
+		actor text NOT NULL,
+		actor_role text NOT NULL,
+		action text NOT NULL,
+		target_type text NOT NULL,
+		target_id text NOT NULL,
+		changes text NOT NULL,
+		client_ip text NOT NULL,
+		status_code integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newSchemaVer, nil
+}
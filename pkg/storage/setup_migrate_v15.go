@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v14 to v15:
+// - api_tokens:
+//		 - New table to store hashed api tokens for automation
+
+// migrateV14toV15 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV14toV15() (int, error) {
+	oldSchemaVer := 14
+	newSchemaVer := 15
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// create api_tokens table
+	query = `CREATE TABLE IF NOT EXISTS api_tokens (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		token_hash text NOT NULL UNIQUE,
+		token_prefix text NOT NULL,
+		owner_type text NOT NULL,
+		owner_name text NOT NULL,
+		owner_role text NOT NULL,
+		scopes text NOT NULL,
+		allowed_ips text NOT NULL,
+		expires_at integer NOT NULL DEFAULT 0,
+		last_used_at integer NOT NULL DEFAULT 0,
+		created_at integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newSchemaVer, nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v16 to v17:
+// - certificates:
+//		 - Add key_rotation_policy, key_rotation_interval_days, key_rotation_algorithm, and
+//		   key_rotation_grace_days to configure automatic private key rotation on renewal
+// - private_key_retirements:
+//		 - New table to schedule deletion of private keys replaced by key rotation
+
+// migrateV16toV17 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV16toV17() (int, error) {
+	oldSchemaVer := 16
+	newSchemaVer := 17
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add key rotation policy to certificates
+	query = `ALTER TABLE certificates ADD COLUMN key_rotation_policy text NOT NULL DEFAULT 'reuse'`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	query = `ALTER TABLE certificates ADD COLUMN key_rotation_interval_days integer NOT NULL DEFAULT 0`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	query = `ALTER TABLE certificates ADD COLUMN key_rotation_algorithm text NOT NULL DEFAULT ''`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	query = `ALTER TABLE certificates ADD COLUMN key_rotation_grace_days integer NOT NULL DEFAULT 7`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// add private_key_retirements
+	query = `CREATE TABLE IF NOT EXISTS private_key_retirements (
+		private_key_id integer PRIMARY KEY NOT NULL UNIQUE,
+		retire_at integer NOT NULL,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newSchemaVer, nil
+}
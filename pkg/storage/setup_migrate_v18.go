@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v17 to v18:
+// - cert_templates:
+//		 - New table of certificate templates that new certificates can be created from
+// - certificates:
+//		 - Add template_id to link a certificate to the template it was created from
+
+// migrateV17toV18 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV17toV18() (int, error) {
+	oldSchemaVer := 17
+	newSchemaVer := 18
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add cert_templates
+	query = `CREATE TABLE IF NOT EXISTS cert_templates (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		acme_account_id integer DEFAULT NULL,
+		key_algorithm text NOT NULL DEFAULT '',
+		csr_org text NOT NULL DEFAULT '',
+		csr_ou text NOT NULL DEFAULT '',
+		csr_country text NOT NULL DEFAULT '',
+		csr_state text NOT NULL DEFAULT '',
+		csr_city text NOT NULL DEFAULT '',
+		csr_extra_extensions text NOT NULL DEFAULT '[]',
+		preferred_root_cn text NOT NULL DEFAULT '',
+		profile text NOT NULL DEFAULT '',
+		post_processing_command text NOT NULL DEFAULT '',
+		post_processing_environment text NOT NULL DEFAULT '[]',
+		post_processing_client_address text NOT NULL DEFAULT '',
+		key_rotation_policy text NOT NULL DEFAULT 'reuse',
+		key_rotation_interval_days integer NOT NULL DEFAULT 0,
+		key_rotation_algorithm text NOT NULL DEFAULT '',
+		key_rotation_grace_days integer NOT NULL DEFAULT 7,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		FOREIGN KEY (acme_account_id)
+			REFERENCES acme_accounts (id)
+				ON DELETE SET NULL
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// add template_id to certificates
+	query = `ALTER TABLE certificates ADD COLUMN template_id integer DEFAULT NULL REFERENCES cert_templates (id) ON DELETE SET NULL ON UPDATE NO ACTION`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newSchemaVer, nil
+}
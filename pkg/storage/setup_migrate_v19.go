@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v18 to v19:
+// - monitored_endpoints:
+//		 - New table of external tls endpoints whose served certificate is periodically
+//		   checked, along with the result of the most recent check
+
+// migrateV18toV19 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV18toV19() (int, error) {
+	oldSchemaVer := 18
+	newSchemaVer := 19
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add monitored_endpoints
+	query = `CREATE TABLE IF NOT EXISTS monitored_endpoints (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		host text NOT NULL,
+		port integer NOT NULL,
+		server_name text NOT NULL DEFAULT '',
+		starttls text NOT NULL DEFAULT '',
+		certificate_id integer DEFAULT NULL,
+		check_interval_minutes integer NOT NULL DEFAULT 60,
+		enabled integer NOT NULL DEFAULT 1 CHECK(enabled IN (0,1)),
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		last_checked_at integer DEFAULT NULL,
+		last_error text NOT NULL DEFAULT '',
+		served_chain text NOT NULL DEFAULT '',
+		leaf_serial text NOT NULL DEFAULT '',
+		leaf_subject text NOT NULL DEFAULT '',
+		leaf_issuer text NOT NULL DEFAULT '',
+		leaf_dns_names text NOT NULL DEFAULT '[]',
+		leaf_not_before integer DEFAULT NULL,
+		leaf_not_after integer DEFAULT NULL,
+		matches_newest_order integer DEFAULT NULL CHECK(matches_newest_order IN (0,1)),
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE SET NULL
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newSchemaVer, nil
+}
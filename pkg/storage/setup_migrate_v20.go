@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v19 to v20:
+// - certificates:
+//		 - Add deploy_verify and deploy_verify_window_minutes to optionally verify that
+//		   post processing deployed the new certificate to the cert's monitored endpoints
+// - acme_orders:
+//		 - Add deploy_status and deploy_checked_at to record the result of deployment
+//		   verification
+
+// migrateV19toV20 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV19toV20() (int, error) {
+	oldSchemaVer := 19
+	newSchemaVer := 20
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add deployment verification to certificates
+	query = `ALTER TABLE certificates ADD COLUMN deploy_verify integer NOT NULL DEFAULT 0 CHECK(deploy_verify IN (0,1))`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	query = `ALTER TABLE certificates ADD COLUMN deploy_verify_window_minutes integer NOT NULL DEFAULT 5`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// add deployment verification result to orders
+	query = `ALTER TABLE acme_orders ADD COLUMN deploy_status text NOT NULL DEFAULT ''`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	query = `ALTER TABLE acme_orders ADD COLUMN deploy_checked_at integer DEFAULT NULL`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newSchemaVer, nil
+}
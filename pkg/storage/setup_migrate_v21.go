@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v20 to v21:
+// - private_key_rotations:
+//		 - New table of pending key rotations; the new key is only used to finalize the
+//		   cert's next order and takes over the old key's name and api keys once that
+//		   order is valid
+
+// migrateV20toV21 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV20toV21() (int, error) {
+	oldSchemaVer := 20
+	newSchemaVer := 21
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add private_key_rotations (pending rotations, the new key replaces the old key once the
+	// cert's next order is valid)
+	query = `CREATE TABLE IF NOT EXISTS private_key_rotations (
+		certificate_id integer PRIMARY KEY NOT NULL UNIQUE,
+		old_key_id integer NOT NULL,
+		new_key_id integer NOT NULL UNIQUE,
+		grace_days integer NOT NULL,
+		created_at integer NOT NULL,
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (old_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (new_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newSchemaVer, nil
+}
@@ -0,0 +1,433 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// CHANGES v21 to v22:
+// - private_keys:
+//		 - Add pem_fingerprint (sha256 of the plaintext pem) with a unique index; the pem
+//		   column's unique constraint doesn't catch duplicates once pems are encrypted
+
+// createDBTablesV22 creates a fresh set of tables in the db using schema version specified
+func createDBTablesV22(tx *sql.Tx) error {
+	// acme_servers
+	query := `CREATE TABLE IF NOT EXISTS acme_servers (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		directory_url text NOT NULL UNIQUE,
+		is_staging integer NOT NULL DEFAULT 0 CHECK(is_staging IN (0,1)),
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	)`
+
+	_, err := tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// private_keys
+	query = `CREATE TABLE IF NOT EXISTS private_keys (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		algorithm text NOT NULL,
+		pem text NOT NULL UNIQUE,
+		pem_fingerprint text UNIQUE,
+		api_key text NOT NULL,
+		api_key_new text NOT NULL DEFAULT '',
+		api_key_disabled integer NOT NULL DEFAULT 0 CHECK(api_key_disabled IN (0,1)),
+		api_key_via_url integer NOT NULL DEFAULT 0 CHECK(api_key_via_url IN (0,1)),
+		last_access integer NOT NULL DEFAULT 0,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// private_key_retirements (keys replaced by rotation, pending deletion)
+	query = `CREATE TABLE IF NOT EXISTS private_key_retirements (
+		private_key_id integer PRIMARY KEY NOT NULL UNIQUE,
+		retire_at integer NOT NULL,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// acme_accounts
+	query = `CREATE TABLE IF NOT EXISTS acme_accounts (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		private_key_id integer NOT NULL UNIQUE,
+		description text NOT NULL,
+		status text NOT NULL DEFAULT 'unknown',
+		email text NOT NULL,
+		accepted_tos integer NOT NULL DEFAULT 0 CHECK(accepted_tos IN (0,1)),
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		kid text NOT NULL,
+		acme_server_id integer NOT NULL,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (acme_server_id)
+			REFERENCES acme_servers (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// cert_templates
+	query = `CREATE TABLE IF NOT EXISTS cert_templates (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		acme_account_id integer DEFAULT NULL,
+		key_algorithm text NOT NULL DEFAULT '',
+		csr_org text NOT NULL DEFAULT '',
+		csr_ou text NOT NULL DEFAULT '',
+		csr_country text NOT NULL DEFAULT '',
+		csr_state text NOT NULL DEFAULT '',
+		csr_city text NOT NULL DEFAULT '',
+		csr_extra_extensions text NOT NULL DEFAULT '[]',
+		preferred_root_cn text NOT NULL DEFAULT '',
+		profile text NOT NULL DEFAULT '',
+		post_processing_command text NOT NULL DEFAULT '',
+		post_processing_environment text NOT NULL DEFAULT '[]',
+		post_processing_client_address text NOT NULL DEFAULT '',
+		key_rotation_policy text NOT NULL DEFAULT 'reuse',
+		key_rotation_interval_days integer NOT NULL DEFAULT 0,
+		key_rotation_algorithm text NOT NULL DEFAULT '',
+		key_rotation_grace_days integer NOT NULL DEFAULT 7,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		FOREIGN KEY (acme_account_id)
+			REFERENCES acme_accounts (id)
+				ON DELETE SET NULL
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// certificates
+	query = `CREATE TABLE IF NOT EXISTS certificates (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		private_key_id integer NOT NULL UNIQUE,
+		acme_account_id integer NOT NULL,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		subject text NOT NULL,
+		subject_alts text NOT NULL,
+		csr_org text NOT NULL,
+		csr_ou text NOT NULL,
+		csr_country text NOT NULL,
+		csr_state text NOT NULL,
+		csr_city text NOT NULL,
+		csr_extra_extensions text NOT NULL DEFAULT "[]",
+		preferred_root_cn text NOT NULL DEFAULT "",
+		api_key text NOT NULL,
+		api_key_new text NOT NULL DEFAULT '',
+		api_key_via_url integer NOT NULL DEFAULT 0 CHECK(api_key_via_url IN (0,1)),
+		last_access integer NOT NULL DEFAULT 0,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		post_processing_command text NOT NULL DEFAULT "",
+		post_processing_environment text NOT NULL DEFAULT "[]",
+		post_processing_client_address text NOT NULL DEFAULT "",
+		post_processing_client_key text NOT NULL DEFAULT "",
+		profile text NOT NULL DEFAULT "",
+		key_rotation_policy text NOT NULL DEFAULT 'reuse',
+		key_rotation_interval_days integer NOT NULL DEFAULT 0,
+		key_rotation_algorithm text NOT NULL DEFAULT '',
+		key_rotation_grace_days integer NOT NULL DEFAULT 7,
+		template_id integer DEFAULT NULL,
+		deploy_verify integer NOT NULL DEFAULT 0 CHECK(deploy_verify IN (0,1)),
+		deploy_verify_window_minutes integer NOT NULL DEFAULT 5,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (acme_account_id)
+			REFERENCES acme_accounts (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (template_id)
+			REFERENCES cert_templates (id)
+				ON DELETE SET NULL
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// private_key_rotations (pending rotations, the new key replaces the old key once the
+	// cert's next order is valid)
+	query = `CREATE TABLE IF NOT EXISTS private_key_rotations (
+		certificate_id integer PRIMARY KEY NOT NULL UNIQUE,
+		old_key_id integer NOT NULL,
+		new_key_id integer NOT NULL UNIQUE,
+		grace_days integer NOT NULL,
+		created_at integer NOT NULL,
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (old_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (new_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// ACME orders
+	query = `CREATE TABLE IF NOT EXISTS acme_orders (
+			id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+			acme_account_id integer NOT NULL,
+			certificate_id integer NOT NULL,
+			acme_location text NOT NULL UNIQUE,
+			status text NOT NULL,
+			known_revoked integer NOT NULL DEFAULT 0 CHECK(known_revoked IN (0,1)),
+			error text,
+			expires integer,
+			dns_identifiers text NOT NULL,
+			authorizations text NOT NULL,
+			finalize text NOT NULL,
+			finalized_key_id integer,
+			certificate_url text,
+			pem text,
+			valid_from integer,
+			valid_to integer,
+			chain_root_cn text,
+			created_at integer NOT NULL,
+			updated_at integer NOT NULL,
+			profile text DEFAULT NULL,
+			renewal_info text DEFAULT NULL,
+			ip_identifiers text NOT NULL DEFAULT '[]',
+			deploy_status text NOT NULL DEFAULT '',
+			deploy_checked_at integer DEFAULT NULL,
+			FOREIGN KEY (acme_account_id)
+				REFERENCES acme_accounts (id)
+					ON DELETE CASCADE
+					ON UPDATE NO ACTION,
+			FOREIGN KEY (finalized_key_id)
+				REFERENCES private_keys (id)
+					ON DELETE SET NULL
+					ON UPDATE NO ACTION,
+			FOREIGN KEY (certificate_id)
+				REFERENCES certificates (id)
+					ON DELETE CASCADE
+					ON UPDATE NO ACTION
+		)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// order_attempts (history of order fulfillment and post processing)
+	query = `CREATE TABLE IF NOT EXISTS order_attempts (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		order_id integer NOT NULL,
+		kind text NOT NULL,
+		outcome text NOT NULL,
+		steps text NOT NULL,
+		started_at integer NOT NULL,
+		ended_at integer NOT NULL,
+		FOREIGN KEY (order_id)
+			REFERENCES acme_orders (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// users (for login to app)
+	query = `CREATE TABLE IF NOT EXISTS users (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		username text NOT NULL UNIQUE,
+		password_hash NOT NULL,
+		role text NOT NULL DEFAULT 'admin',
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// audit_log (record of changes made by users)
+	query = `CREATE TABLE IF NOT EXISTS audit_log (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		created_at integer NOT NULL,
+		actor text NOT NULL,
+		actor_role text NOT NULL,
+		action text NOT NULL,
+		target_type text NOT NULL,
+		target_id text NOT NULL,
+		changes text NOT NULL,
+		client_ip text NOT NULL,
+		status_code integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// api_tokens (long lived tokens for automation)
+	query = `CREATE TABLE IF NOT EXISTS api_tokens (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		token_hash text NOT NULL UNIQUE,
+		token_prefix text NOT NULL,
+		owner_type text NOT NULL,
+		owner_name text NOT NULL,
+		owner_role text NOT NULL,
+		scopes text NOT NULL,
+		allowed_ips text NOT NULL,
+		expires_at integer NOT NULL DEFAULT 0,
+		last_used_at integer NOT NULL DEFAULT 0,
+		created_at integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// monitored_endpoints (external endpoints whose served cert is checked)
+	query = `CREATE TABLE IF NOT EXISTS monitored_endpoints (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		host text NOT NULL,
+		port integer NOT NULL,
+		server_name text NOT NULL DEFAULT '',
+		starttls text NOT NULL DEFAULT '',
+		certificate_id integer DEFAULT NULL,
+		check_interval_minutes integer NOT NULL DEFAULT 60,
+		enabled integer NOT NULL DEFAULT 1 CHECK(enabled IN (0,1)),
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		last_checked_at integer DEFAULT NULL,
+		last_error text NOT NULL DEFAULT '',
+		served_chain text NOT NULL DEFAULT '',
+		leaf_serial text NOT NULL DEFAULT '',
+		leaf_subject text NOT NULL DEFAULT '',
+		leaf_issuer text NOT NULL DEFAULT '',
+		leaf_dns_names text NOT NULL DEFAULT '[]',
+		leaf_not_before integer DEFAULT NULL,
+		leaf_not_after integer DEFAULT NULL,
+		matches_newest_order integer DEFAULT NULL CHECK(matches_newest_order IN (0,1)),
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE SET NULL
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// migrateV21toV22 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV21toV22() (int, error) {
+	oldSchemaVer := 21
+	newSchemaVer := 22
+
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add private_keys pem_fingerprint (existing keys are fingerprinted on startup, once
+	// their pems can be decrypted)
+	query = `ALTER TABLE private_keys ADD COLUMN pem_fingerprint text`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	query = `CREATE UNIQUE INDEX IF NOT EXISTS private_keys_pem_fingerprint ON private_keys (pem_fingerprint)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	return newSchemaVer, nil
+}
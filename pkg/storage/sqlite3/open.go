@@ -12,7 +12,7 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-const dbFilename = "appdata.db"
+const DbFilename = "appdata.db"
 const dbFileMode = 0600
 
 var dbOptions = url.Values{
@@ -27,7 +27,7 @@ type App interface {
 // OpenSqlite3Database
 func OpenSqlite3Database(app App) (_ *sql.DB, isNewDb bool, onErrCleanup func(), _ error) {
 	// full path and append options to the Dsn for connString
-	dbWithPath := app.GetDataStorageAppDataPath() + "/" + dbFilename
+	dbWithPath := app.GetDataStorageAppDataPath() + "/" + DbFilename
 
 	// check if db file exists
 	dbExists := true
@@ -42,7 +42,7 @@ func OpenSqlite3Database(app App) (_ *sql.DB, isNewDb bool, onErrCleanup func(),
 
 	// db doesn't exist, check old path
 	if !dbExists {
-		didMigrate, err := migrateDbFileLocation(oldFilePath+"/"+dbFilename, dbWithPath)
+		didMigrate, err := migrateDbFileLocation(oldFilePath+"/"+DbFilename, dbWithPath)
 		if err != nil {
 			return nil, false, func() {}, fmt.Errorf("sqlite3: db migration failed (%w)", err)
 		}
@@ -5,6 +5,7 @@ type userDb struct {
 	id           int
 	username     string
 	passwordHash string
+	role         string
 	createdAt    int
 	updatedAt    int
 }
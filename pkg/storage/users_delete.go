@@ -0,0 +1,23 @@
+package storage
+
+import "context"
+
+// DeleteUser deletes the specified user from the db
+func (store *Storage) DeleteUser(id int) (err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		users
+	WHERE
+		id = $1
+	`
+
+	_, err = store.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -11,6 +11,7 @@ func (userDb *userDb) dbToUser() (user auth.User) {
 		ID:           userDb.id,
 		Username:     userDb.username,
 		PasswordHash: userDb.passwordHash,
+		Role:         auth.Role(userDb.role),
 		CreatedAt:    userDb.createdAt,
 		UpdatedAt:    userDb.updatedAt,
 	}
@@ -24,7 +25,7 @@ func (store Storage) GetOneUserByName(username string) (auth.User, error) {
 
 	query := `
 	SELECT
-		id, username, password_hash, created_at, updated_at
+		id, username, password_hash, role, created_at, updated_at
 	FROM
 		users
 	WHERE
@@ -38,6 +39,7 @@ func (store Storage) GetOneUserByName(username string) (auth.User, error) {
 		&user.id,
 		&user.username,
 		&user.passwordHash,
+		&user.role,
 		&user.createdAt,
 		&user.updatedAt,
 	)
@@ -50,3 +52,83 @@ func (store Storage) GetOneUserByName(username string) (auth.User, error) {
 
 	return convertedUser, nil
 }
+
+// GetOneUserById returns a user from the db based on id
+func (store Storage) GetOneUserById(id int) (auth.User, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, username, password_hash, role, created_at, updated_at
+	FROM
+		users
+	WHERE
+		id = $1
+	`
+
+	row := store.db.QueryRowContext(ctx, query, id)
+
+	var user userDb
+	err := row.Scan(
+		&user.id,
+		&user.username,
+		&user.passwordHash,
+		&user.role,
+		&user.createdAt,
+		&user.updatedAt,
+	)
+
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	convertedUser := user.dbToUser()
+
+	return convertedUser, nil
+}
+
+// GetAllUsers returns all users from the db, sorted by username
+func (store Storage) GetAllUsers() ([]auth.User, error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, username, password_hash, role, created_at, updated_at
+	FROM
+		users
+	ORDER BY
+		username COLLATE NOCASE ASC
+	`
+
+	rows, err := store.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []auth.User{}
+	for rows.Next() {
+		var user userDb
+		err = rows.Scan(
+			&user.id,
+			&user.username,
+			&user.passwordHash,
+			&user.role,
+			&user.createdAt,
+			&user.updatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user.dbToUser())
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
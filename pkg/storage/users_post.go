@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"context"
+)
+
+// PostNewUser saves a new user to the db and returns the new user's id
+func (store *Storage) PostNewUser(payload auth.NewUserPayload) (userId int, err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO
+		users (username, password_hash, role, created_at, updated_at)
+	VALUES (
+		$1,
+		$2,
+		$3,
+		$4,
+		$5
+	)
+	RETURNING
+		id
+	`
+
+	err = store.db.QueryRowContext(ctx, query,
+		payload.Username,
+		payload.PasswordHash,
+		payload.Role,
+		payload.CreatedAt,
+		payload.UpdatedAt,
+	).Scan(&userId)
+	if err != nil {
+		return -2, err
+	}
+
+	return userId, nil
+}
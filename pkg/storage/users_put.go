@@ -1,6 +1,9 @@
 package storage
 
-import "context"
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"context"
+)
 
 // UpdateUserPassword updates the specified user's password hash to the specified
 // hash.
@@ -34,3 +37,30 @@ func (store *Storage) UpdateUserPassword(username string, newPasswordHash string
 
 	return userId, nil
 }
+
+// PutUserRole updates the specified user's role
+func (store *Storage) PutUserRole(id int, role auth.Role) (err error) {
+	ctx, cancel := context.WithTimeout(store.shutdownContext, store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		users
+	SET
+		role = $1,
+		updated_at = $2
+	WHERE
+		id = $3
+	`
+
+	_, err = store.db.ExecContext(ctx, query,
+		role,
+		timeNow(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package storage_test
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"testing"
+)
+
+func TestUsers(t *testing.T) {
+	// create testing service
+	storage, err := openStorageWithTestData(t, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// migrated user should be an admin
+	admin, err := storage.GetOneUserByName("admin")
+	if err != nil {
+		t.Fatalf("failed to get admin user (%s)", err)
+	}
+	if admin.Role != auth.RoleAdmin {
+		t.Errorf("expected existing user to have role '%s' but got '%s'", auth.RoleAdmin, admin.Role)
+	}
+
+	// add a user
+	username := "viewer-user"
+	role := auth.RoleViewer
+	newId, err := storage.PostNewUser(auth.NewUserPayload{
+		Username:     &username,
+		Role:         &role,
+		PasswordHash: "not-a-real-hash",
+		CreatedAt:    1780336479,
+		UpdatedAt:    1780336479,
+	})
+	if err != nil {
+		t.Fatalf("failed to post user (%s)", err)
+	}
+
+	// duplicate username should fail
+	_, err = storage.PostNewUser(auth.NewUserPayload{
+		Username:     &username,
+		Role:         &role,
+		PasswordHash: "not-a-real-hash",
+	})
+	if err == nil {
+		t.Error("expected error posting user with duplicate username")
+	}
+
+	users, err := storage.GetAllUsers()
+	if err != nil {
+		t.Fatalf("failed to get users (%s)", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users but got %d", len(users))
+	}
+
+	// change role
+	err = storage.PutUserRole(newId, auth.RoleOperator)
+	if err != nil {
+		t.Fatalf("failed to put user role (%s)", err)
+	}
+
+	user, err := storage.GetOneUserById(newId)
+	if err != nil {
+		t.Fatalf("failed to get user (%s)", err)
+	}
+	if user.Username != username || user.Role != auth.RoleOperator {
+		t.Errorf("user not updated correctly (%s, %s)", user.Username, user.Role)
+	}
+
+	// delete
+	err = storage.DeleteUser(newId)
+	if err != nil {
+		t.Fatalf("failed to delete user (%s)", err)
+	}
+
+	_, err = storage.GetOneUserById(newId)
+	if err == nil {
+		t.Error("expected error getting deleted user")
+	}
+}
@@ -0,0 +1,192 @@
+package tlsprobe
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartTLS is the plaintext protocol to upgrade to TLS before the handshake (if any)
+type StartTLS string
+
+const (
+	StartTLSNone StartTLS = ""
+	StartTLSSMTP StartTLS = "smtp"
+	StartTLSIMAP StartTLS = "imap"
+)
+
+// Valid returns true if the StartTLS value is supported
+func (st StartTLS) Valid() bool {
+	switch st {
+	case StartTLSNone, StartTLSSMTP, StartTLSIMAP:
+		return true
+	}
+
+	return false
+}
+
+// defaultTimeout is used if the context does not have a deadline
+const defaultTimeout = 30 * time.Second
+
+// Target is an endpoint that serves a certificate
+type Target struct {
+	Host       string
+	Port       int
+	ServerName string // SNI; Host is used if blank
+	StartTLS   StartTLS
+}
+
+// Address returns the host:port of the target
+func (target Target) Address() string {
+	return net.JoinHostPort(target.Host, strconv.Itoa(target.Port))
+}
+
+// Probe connects to the target and returns the certificate chain it serves (leaf first).
+// The chain is NOT verified, as the point is to see what is being served (even if it is
+// expired or otherwise invalid).
+func Probe(ctx context.Context, target Target) ([]*x509.Certificate, error) {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", target.Address())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// bound the plaintext portion (tls handshake uses ctx)
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	// upgrade, if needed
+	switch target.StartTLS {
+	case StartTLSNone:
+		// no-op
+	case StartTLSSMTP:
+		err = startTLSSMTP(conn)
+	case StartTLSIMAP:
+		err = startTLSIMAP(conn)
+	default:
+		err = fmt.Errorf("starttls protocol '%s' is not supported", target.StartTLS)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serverName := target.ServerName
+	if serverName == "" {
+		serverName = target.Host
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	err = tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("tls handshake failed (%s)", err)
+	}
+	defer tlsConn.Close()
+
+	chain := tlsConn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, errors.New("endpoint did not serve a certificate")
+	}
+
+	return chain, nil
+}
+
+// startTLSSMTP sends the SMTP STARTTLS command (RFC 3207)
+func startTLSSMTP(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+
+	// greeting
+	err := readSMTPReply(r, "220")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(conn, "EHLO certwarden\r\n")
+	if err != nil {
+		return err
+	}
+	err = readSMTPReply(r, "250")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(conn, "STARTTLS\r\n")
+	if err != nil {
+		return err
+	}
+	return readSMTPReply(r, "220")
+}
+
+// readSMTPReply reads a (possibly multiline) SMTP reply and confirms it has the expected
+// code
+func readSMTPReply(r *bufio.Reader, code string) error {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("smtp read failed (%s)", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if !strings.HasPrefix(line, code) {
+			return fmt.Errorf("unexpected smtp reply '%s'", line)
+		}
+		// last line of the reply has a space after the code
+		if len(line) == len(code) || line[len(code)] == ' ' {
+			return nil
+		}
+	}
+}
+
+// startTLSIMAP sends the IMAP STARTTLS command (RFC 3501)
+func startTLSIMAP(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+
+	// greeting
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("imap read failed (%s)", err)
+	}
+	if !strings.HasPrefix(line, "* OK") {
+		return fmt.Errorf("unexpected imap greeting '%s'", strings.TrimSpace(line))
+	}
+
+	_, err = fmt.Fprintf(conn, "a1 STARTTLS\r\n")
+	if err != nil {
+		return err
+	}
+
+	// skip any untagged responses until the tagged one
+	for {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("imap read failed (%s)", err)
+		}
+		if strings.HasPrefix(line, "a1 ") {
+			break
+		}
+	}
+	if !strings.HasPrefix(line, "a1 OK") {
+		return fmt.Errorf("imap starttls refused '%s'", strings.TrimSpace(line))
+	}
+
+	return nil
+}
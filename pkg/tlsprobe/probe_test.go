@@ -0,0 +1,159 @@
+package tlsprobe
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeTestTLSCert returns a self-signed tls certificate for name
+func makeTestTLSCert(t *testing.T, name string) tls.Certificate {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1234),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, privKey.Public(), privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: privKey}
+}
+
+// startTestServer starts a server that runs the plaintext exchange (if any) and then
+// serves cert over tls; it returns the port
+func startTestServer(t *testing.T, cert tls.Certificate, plaintext func(conn net.Conn, r *bufio.Reader) bool) int {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				if plaintext != nil && !plaintext(conn, bufio.NewReader(conn)) {
+					return
+				}
+
+				tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}})
+				_ = tlsConn.Handshake()
+				_ = tlsConn.Close()
+			}()
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestProbe(t *testing.T) {
+	cert := makeTestTLSCert(t, "probe.example.com")
+
+	smtp := func(conn net.Conn, r *bufio.Reader) bool {
+		conn.Write([]byte("220 mail.example.com ESMTP\r\n"))
+		line, _ := r.ReadString('\n')
+		if !strings.HasPrefix(line, "EHLO") {
+			return false
+		}
+		conn.Write([]byte("250-mail.example.com\r\n250-PIPELINING\r\n250 STARTTLS\r\n"))
+		line, _ = r.ReadString('\n')
+		if !strings.HasPrefix(line, "STARTTLS") {
+			return false
+		}
+		conn.Write([]byte("220 go ahead\r\n"))
+		return true
+	}
+
+	imap := func(conn net.Conn, r *bufio.Reader) bool {
+		conn.Write([]byte("* OK IMAP4rev1 ready\r\n"))
+		line, _ := r.ReadString('\n')
+		tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if cmd != "STARTTLS" {
+			return false
+		}
+		conn.Write([]byte(tag + " OK begin tls\r\n"))
+		return true
+	}
+
+	imapRefused := func(conn net.Conn, r *bufio.Reader) bool {
+		conn.Write([]byte("* OK IMAP4rev1 ready\r\n"))
+		line, _ := r.ReadString('\n')
+		tag, _, _ := strings.Cut(line, " ")
+		conn.Write([]byte(tag + " BAD no tls\r\n"))
+		return false
+	}
+
+	tests := []struct {
+		name      string
+		startTLS  StartTLS
+		plaintext func(conn net.Conn, r *bufio.Reader) bool
+		expectErr bool
+	}{
+		{"direct tls", StartTLSNone, nil, false},
+		{"smtp starttls", StartTLSSMTP, smtp, false},
+		{"imap starttls", StartTLSIMAP, imap, false},
+		{"imap starttls refused", StartTLSIMAP, imapRefused, true},
+		{"starttls mismatch", StartTLSSMTP, imap, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			port := startTestServer(t, cert, test.plaintext)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			chain, err := Probe(ctx, Target{Host: "127.0.0.1", Port: port, ServerName: "probe.example.com", StartTLS: test.startTLS})
+			if test.expectErr {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chain) != 1 || chain[0].Subject.CommonName != "probe.example.com" || chain[0].SerialNumber.Int64() != 1234 {
+				t.Errorf("unexpected chain served %v", chain)
+			}
+		})
+	}
+
+	// nothing listening
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	_, err = Probe(context.Background(), Target{Host: "127.0.0.1", Port: port})
+	if err == nil {
+		t.Errorf("expected error connecting to closed port %s", strconv.Itoa(port))
+	}
+}
@@ -0,0 +1,16 @@
+package validation
+
+import "net/netip"
+
+// IPAddressValid returns true if the string is an IPv4 or IPv6 address in its
+// canonical text form (e.g. IPv6 must be lower case and compressed per RFC 5952).
+// Addresses with a zone are not valid.
+// This is the form required for ACME ip identifiers (RFC 8738 s 3).
+func IPAddressValid(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || addr.Zone() != "" || addr.Is4In6() {
+		return false
+	}
+
+	return addr.String() == ip
+}
@@ -0,0 +1,41 @@
+package validation
+
+import "testing"
+
+// valid ip addresses
+var validIPAddresses = []string{
+	"192.0.2.1",
+	"10.0.0.255",
+	"2001:db8::1",
+	"2001:db8:0:1:1:1:1:1",
+	"::1",
+}
+
+// invalid ip addresses
+var invalidIPAddresses = []string{
+	"",
+	"192.0.2",
+	"192.0.2.256",
+	"192.0.2.1/32",
+	"010.0.0.1",
+	"2001:DB8::1",
+	"2001:db8:0:0:0:0:0:1",
+	"fe80::1%eth0",
+	"::ffff:192.0.2.1",
+	"example.com",
+	" 192.0.2.1",
+}
+
+func TestValidation_IPAddressValid(t *testing.T) {
+	for _, ip := range validIPAddresses {
+		if !IPAddressValid(ip) {
+			t.Errorf("valid ip address test case '%s' returned invalid", ip)
+		}
+	}
+
+	for _, ip := range invalidIPAddresses {
+		if IPAddressValid(ip) {
+			t.Errorf("invalid ip address test case '%s' returned valid", ip)
+		}
+	}
+}